@@ -1,121 +1,188 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"go-template/internal/config"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
-	"github.com/spf13/viper"
-	boilingcore "github.com/volatiletech/sqlboiler/v4/boilingcore"
-	"github.com/volatiletech/sqlboiler/v4/drivers"
-	importers "github.com/volatiletech/sqlboiler/v4/importers"
+	"go-template/internal/config"
+	"go-template/internal/migrations"
+	"go-template/internal/modelgen"
+	"go-template/internal/postgres"
+	"go-template/internal/secrets"
 )
 
-func allKeys(prefix string) []string {
-	keys := make(map[string]bool)
+// Exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+	// ExitDrift is returned by --check when the generated files differ from the committed ones
+	ExitDrift = 3
+)
 
-	prefix += "."
+// modelTables are the tables with sqlboiler models, the other tables are queried with the
+// hand-written SQL of daos
+var modelTables = []string{"gorp_migrations", "roles", "users"}
 
-	for _, e := range os.Environ() {
-		splits := strings.SplitN(e, "=", 2)
-		key := strings.ReplaceAll(strings.ToLower(splits[0]), "_", ".")
+const usage = `Usage: gendbmdls [flags]
 
-		if strings.HasPrefix(key, prefix) {
-			keys[strings.ReplaceAll(key, prefix, "")] = true
-		}
-	}
+Applies the embedded migrations to a throwaway schema, generates the models of its tables and
+the graphql converters of the new ones, then drops the schema.
 
-	for _, key := range viper.AllKeys() {
-		if strings.HasPrefix(key, prefix) {
-			keys[strings.ReplaceAll(key, prefix, "")] = true
-		}
-	}
+Flags:
+`
 
-	keySlice := make([]string, 0, len(keys))
-	for k := range keys {
-		keySlice = append(keySlice, k)
-	}
-	return keySlice
+func main() {
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func main() {
-	err := config.LoadEnv()
+// Connect returns the credentials of the configuration and opens a pool whose search_path is
+// schema
+func Connect(schema string) (*sql.DB, map[string]string, error) {
+	if err := config.LoadEnv(); err != nil {
+		return nil, nil, err
+	}
+	provider, err := secrets.FromEnv()
 	if err != nil {
-		fmt.Println("failed while loading env")
+		return nil, nil, err
 	}
-	driverName, _, err := drivers.RegisterBinaryFromCmdArg("psql")
-
-	keys := allKeys(driverName)
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	viper.AutomaticEnv()
+	creds, err := secrets.Read(context.Background(), provider, postgres.CredentialKeys...)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := postgres.Open(postgres.DSN(creds), postgres.Options{MaxOpenConns: 1, SearchPath: schema})
+	return db, creds, err
+}
 
-	var cfg = drivers.Config{}
-	for _, key := range keys {
-		if key != "blacklist" && key != "whitelist" {
-			prefixedKey := fmt.Sprintf("%s.%s", driverName, key)
-			cfg[key] = viper.Get(prefixedKey)
+// errUsage is returned for invalid commands, their exit code is ExitUsage
+var errUsage = errors.New("invalid usage")
+
+// errDrift is returned by --check when the generated files differ, their exit code is ExitDrift
+var errDrift = errors.New("the generated files differ from the committed ones, run gendbmdls to update them")
+
+// Run generates the files of args and returns its exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gendbmdls", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	config.RegisterFlag(fs)
+	opts := options{}
+	fs.BoolVar(&opts.check, "check", false, "compare the generated files to the committed ones instead of writing them")
+	fs.StringVar(&opts.models, "models", "models", "directory of the models")
+	fs.StringVar(&opts.converters, "converters", filepath.Join("pkg", "utl", "cnvrttogql"),
+		"directory of the cnvrttogql package the converters are generated to")
+	fs.StringVar(&opts.gqlModels, "gqlmodels", filepath.Join("gqlmodels", "generatedmodels.go"),
+		"file of the graphql models the converters convert to")
+	fs.StringVar(&opts.tables, "tables", strings.Join(modelTables, ","), "comma separated tables to generate the models of")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
 		}
-	}
+		return ExitUsage
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintf(stderr, "%s: gendbmdls takes no arguments\n", errUsage)
+		fs.Usage()
+		return ExitUsage
+	}
+	err := run(context.Background(), opts, stdout)
+	switch {
+	case errors.Is(err, errDrift):
+		fmt.Fprintln(stderr, "gendbmdls:", err)
+		return ExitDrift
+	case err != nil:
+		fmt.Fprintln(stderr, "gendbmdls:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+type options struct {
+	check      bool
+	models     string
+	converters string
+	gqlModels  string
+	tables     string
+}
+
+func run(ctx context.Context, opts options, out io.Writer) (err error) {
+	gqlModels, err := os.ReadFile(opts.gqlModels)
 	if err != nil {
-		return
-	}
-
-	cmdState, err := boilingcore.New(&boilingcore.Config{
-		DriverName:      driverName,
-		DriverConfig:    cfg,
-		NoHooks:         true,
-		OutFolder:       "models",
-		PkgName:         "models",
-		StructTagCasing: "snake",
-		RelationTag:     "-",
-		Imports:         configureImports(),
-	})
+		return err
+	}
+	existing, err := modelgen.ReadExisting(opts.converters)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	err = cmdState.Run()
+
+	schema := modelgen.SchemaName(time.Now())
+	db, creds, err := Connect(schema)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("connecting to the database: %w", err)
 	}
-	err = cmdState.Cleanup()
+	defer db.Close()
+	drop, err := modelgen.BuildSchema(ctx, db, schema, migrations.Source())
 	if err != nil {
-		panic(err)
+		return err
 	}
-}
-
-func configureImports() importers.Collection {
-	imports := importers.NewDefaultImports()
-
-	mustMap := func(m importers.Map, err error) importers.Map {
-		if err != nil {
-			panic("failed to change viper interface into importers.Map: " + err.Error())
+	defer func() {
+		if dropErr := drop(); dropErr != nil && err == nil {
+			err = fmt.Errorf("dropping the schema %s: %w", schema, dropErr)
 		}
+	}()
 
-		return m
+	outFolder := opts.models
+	if opts.check {
+		if outFolder, err = os.MkdirTemp("", "gendbmdls"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(outFolder)
 	}
-
-	if viper.IsSet("imports.all.standard") {
-		imports.All.Standard = viper.GetStringSlice("imports.all.standard")
+	tables, err := modelgen.Generate(modelgen.Options{Creds: creds, Schema: schema, OutFolder: outFolder,
+		Tables: strings.Split(opts.tables, ",")})
+	if err != nil {
+		return fmt.Errorf("generating the models: %w", err)
+	}
+	converters, err := modelgen.Converters(tables, gqlModels, existing)
+	if err != nil {
+		return err
 	}
-	if viper.IsSet("imports.all.third_party") {
-		imports.All.ThirdParty = viper.GetStringSlice("imports.all.third_party")
+	if !opts.check {
+		if err := modelgen.WriteConverters(opts.converters, converters); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Generated the models of %d tables\n", len(tables))
+		return nil
 	}
-	if viper.IsSet("imports.test.standard") {
-		imports.Test.Standard = viper.GetStringSlice("imports.test.standard")
+
+	drift, err := modelgen.Drift(opts.models, outFolder)
+	if err != nil {
+		return err
 	}
-	if viper.IsSet("imports.test.third_party") {
-		imports.Test.ThirdParty = viper.GetStringSlice("imports.test.third_party")
+	convertersDrift, err := modelgen.FileDrift(filepath.Join(opts.converters, modelgen.ConvertersFile), converters)
+	if err != nil {
+		return err
 	}
-	if viper.IsSet("imports.singleton") {
-		imports.Singleton = mustMap(importers.MapFromInterface(viper.Get("imports.singleton")))
+	if convertersDrift != "" {
+		drift = append(drift, convertersDrift)
 	}
-	if viper.IsSet("imports.test_singleton") {
-		imports.TestSingleton = mustMap(importers.MapFromInterface(viper.Get("imports.test_singleton")))
+	for _, d := range drift {
+		fmt.Fprintln(out, d)
 	}
-	if viper.IsSet("imports.based_on_type") {
-		imports.BasedOnType = mustMap(importers.MapFromInterface(viper.Get("imports.based_on_type")))
+	if len(drift) > 0 {
+		return errDrift
 	}
-
-	return imports
+	fmt.Fprintln(out, "The generated files are up to date")
+	return nil
 }
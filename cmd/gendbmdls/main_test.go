@@ -0,0 +1,125 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "go-template/cmd/gendbmdls"
+	"go-template/internal/modelgen"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agiledragon/gomonkey/v2"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+)
+
+const gqlModels = `package gqlmodels
+
+type Invoice struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+`
+
+// project writes the graphql models, the converters package and the models of a project
+func project(t *testing.T) (dir string, args []string) {
+	t.Helper()
+	dir = t.TempDir()
+	for _, sub := range []string{"models", "cnvrttogql"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0o755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "generatedmodels.go"), []byte(gqlModels), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "cnvrttogql", "cnvrttogql.go"), []byte("package cnvrttogql\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "models", "roles.go"), []byte("roles"), 0o600))
+	return dir, []string{"--models", filepath.Join(dir, "models"), "--converters", filepath.Join(dir, "cnvrttogql"),
+		"--gqlmodels", filepath.Join(dir, "generatedmodels.go")}
+}
+
+// mockGenerate patches the database and the generation of the models, which writes the roles
+// and invoices models
+func mockGenerate(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	patches := gomonkey.ApplyFunc(main.Connect, func(schema string) (*sql.DB, map[string]string, error) {
+		assert.Regexp(t, `^gendbmdls_\d+$`, schema)
+		return db, map[string]string{}, nil
+	}).ApplyFunc(modelgen.BuildSchema, func(_ context.Context, _ *sql.DB, schema string, _ migrate.MigrationSource) (
+		func() error, error) {
+		return func() error {
+			_, err := db.Exec("DROP SCHEMA " + schema)
+			return err
+		}, nil
+	}).ApplyFunc(modelgen.Generate, func(opts modelgen.Options) ([]drivers.Table, error) {
+		assert.Equal(t, []string{"gorp_migrations", "roles", "users"}, opts.Tables)
+		assert.NoError(t, os.WriteFile(filepath.Join(opts.OutFolder, "roles.go"), []byte("roles"), 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(opts.OutFolder, "invoices.go"), []byte("invoices"), 0o600))
+		return []drivers.Table{{Name: "roles"}, {Name: "invoices", Columns: []drivers.Column{{Name: "id", Type: "int"}}}}, nil
+	})
+	t.Cleanup(patches.Reset)
+	mock.ExpectExec("DROP SCHEMA gendbmdls_").WillReturnResult(sqlmock.NewResult(0, 0))
+	return mock
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitUsage, main.Run([]string{"models"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "invalid usage: gendbmdls takes no arguments")
+	assert.Contains(t, stderr.String(), "Usage: gendbmdls [flags]")
+
+	stderr.Reset()
+	assert.Equal(t, main.ExitUsage, main.Run([]string{"--force"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "flag provided but not defined: -force")
+	assert.Equal(t, main.ExitOK, main.Run([]string{"--help"}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+}
+
+func TestRunConnectFails(t *testing.T) {
+	_, args := project(t)
+	patches := gomonkey.ApplyFunc(main.Connect, func(string) (*sql.DB, map[string]string, error) {
+		return nil, nil, errors.New("connection refused")
+	})
+	defer patches.Reset()
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitError, main.Run(args, &stdout, &stderr))
+	assert.Equal(t, "gendbmdls: connecting to the database: connection refused\n", stderr.String())
+}
+
+func TestRunGenerate(t *testing.T) {
+	dir, args := project(t)
+	mock := mockGenerate(t)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitOK, main.Run(args, &stdout, &stderr))
+	assert.Equal(t, "Generated the models of 2 tables\n", stdout.String())
+	assert.Empty(t, stderr.String())
+	assert.FileExists(t, filepath.Join(dir, "models", "invoices.go"))
+	converters, err := os.ReadFile(filepath.Join(dir, "cnvrttogql", modelgen.ConvertersFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(converters), "func InvoiceToGraphQlInvoice(m *models.Invoice) *graphql.Invoice {")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the committed files are up to date once generated
+	mockGenerate(t)
+	stdout.Reset()
+	assert.Equal(t, main.ExitOK, main.Run(append(args, "--check"), &stdout, &stderr))
+	assert.Equal(t, "The generated files are up to date\n", stdout.String())
+}
+
+func TestRunCheck(t *testing.T) {
+	dir, args := project(t)
+	mock := mockGenerate(t)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitDrift, main.Run(append(args, "--check"), &stdout, &stderr))
+	assert.Equal(t, "missing "+filepath.ToSlash(filepath.Join(dir, "models", "invoices.go"))+"\n"+
+		"missing "+filepath.ToSlash(filepath.Join(dir, "cnvrttogql", modelgen.ConvertersFile))+"\n", stdout.String())
+	assert.Equal(t, "gendbmdls: the generated files differ from the committed ones, run gendbmdls to update them\n",
+		stderr.String())
+	// the committed files are left untouched
+	assert.NoFileExists(t, filepath.Join(dir, "models", "invoices.go"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,46 +2,251 @@ package main
 
 import (
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
 	"go-template/internal/config"
+	"go-template/internal/migrations"
 	"go-template/internal/postgres"
-	"go-template/pkg/utl/zaplog"
-	"log"
-	"os"
 
 	migrate "github.com/rubenv/sql-migrate"
 )
 
+// Exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const usage = `Usage: migrations [flags] <command> [args]
+
+Commands:
+  up [n|all]    apply the next n pending migrations, all of them by default
+  down [n|all]  roll back the last n applied migrations, 1 by default
+  redo          roll back the last applied migration and apply it again
+  status        list the migrations and whether they are applied
+  new <name>    write an empty migration named name to --dir
+
+Flags:
+`
+
 func main() {
-	err := config.LoadEnv()
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Connect opens the database of the configuration with the credentials of the secrets provider
+func Connect() (*sql.DB, error) {
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	dbCfg, err := config.LoadDatabase()
+	if err != nil {
+		return nil, err
+	}
+	opts := postgres.OptionsFromConfig(dbCfg)
+	// migrations may run for longer than a query is allowed to
+	opts.QueryTimeout, opts.StatementTimeout = 0, 0
+	return postgres.ConnectWith(opts)
+}
+
+// errUsage is returned for invalid commands, their exit code is ExitUsage
+var errUsage = errors.New("invalid usage")
+
+// Run runs the command of args and returns its exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("migrations", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	config.RegisterFlag(fs)
+	dryRun := fs.Bool("dry-run", false, "print the migrations up, down and redo would run without running them")
+	dir := fs.String("dir", "internal/migrations", "directory new writes the migrations to")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	command := fs.Arg(0)
+	if command == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+	// flags are also accepted after the command
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return exitCode(err)
+	}
+	cmd := &cmd{out: stdout, dryRun: *dryRun, src: migrations.Source()}
+	err := cmd.run(command, fs.Args(), *dir)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return ExitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "migrations:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	return ExitUsage
+}
+
+type cmd struct {
+	out    io.Writer
+	dryRun bool
+	src    migrate.MigrationSource
+	db     *sql.DB
+}
+
+func (c *cmd) run(command string, args []string, dir string) error {
+	if command == "new" {
+		if len(args) != 1 {
+			return fmt.Errorf("%w: new takes the name of the migration", errUsage)
+		}
+		path, err := migrations.Create(dir, args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, "Created", path)
+		return nil
+	}
+
+	var (
+		n   int
+		err error
+	)
+	switch command {
+	case "up":
+		n, err = count(args, 0)
+	case "down":
+		n, err = count(args, 1)
+	case "redo", "status":
+		if len(args) != 0 {
+			err = fmt.Errorf("%w: %s takes no arguments", errUsage, command)
+		}
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
 	if err != nil {
-		log.Println("&&&&")
-		log.Println(err)
-		return
+		return err
+	}
+	if c.db, err = Connect(); err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
 	}
-	db, err := postgres.Connect()
+	defer c.db.Close()
+
+	switch command {
+	case "up":
+		return c.migrate(migrate.Up, n)
+	case "down":
+		return c.migrate(migrate.Down, n)
+	case "redo":
+		return c.redo()
+	}
+	return c.status()
+}
+
+// count parses the number of migrations of args, all meaning no limit
+func count(args []string, defaultCount int) (int, error) {
+	switch {
+	case len(args) == 0:
+		return defaultCount, nil
+	case len(args) > 1:
+		return 0, fmt.Errorf("%w: too many arguments", errUsage)
+	case args[0] == "all":
+		return 0, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: the number of migrations must be a positive integer or all, got %q", errUsage, args[0])
+	}
+	return n, nil
+}
+
+func (c *cmd) migrate(direction migrate.MigrationDirection, n int) error {
+	name := "up"
+	if direction == migrate.Down {
+		name = "down"
+	}
+	if c.dryRun {
+		planned, err := migrations.Plan(c.db, c.src, direction, n)
+		if err != nil {
+			return err
+		}
+		if len(planned) == 0 {
+			fmt.Fprintln(c.out, "No migrations to run")
+		}
+		for _, m := range planned {
+			printQueries(c.out, name, m.Id, m.Queries)
+		}
+		return nil
+	}
+	applied, err := migrations.Apply(c.db, c.src, direction, n)
 	if err != nil {
-		fmt.Println("failed while fetching db connection", err)
-		zaplog.Logger.Error(err)
+		return err
 	}
+	fmt.Fprintf(c.out, "Applied %d migrations %s\n", applied, name)
+	return nil
+}
 
-	for _, arg := range os.Args {
-		if arg == "down" {
-			runMigration(db, migrate.Down)
+func (c *cmd) redo() error {
+	if !c.dryRun {
+		if err := c.migrate(migrate.Down, 1); err != nil {
+			return err
 		}
+		return c.migrate(migrate.Up, 1)
 	}
-	runMigration(db, migrate.Up)
+	planned, err := migrations.Plan(c.db, c.src, migrate.Down, 1)
+	if err != nil {
+		return err
+	}
+	if len(planned) == 0 {
+		fmt.Fprintln(c.out, "No migrations to run")
+		return nil
+	}
+	// the migration rolled back is the one applied again
+	m := planned[0]
+	printQueries(c.out, "down", m.Id, m.Down)
+	printQueries(c.out, "up", m.Id, m.Up)
+	return nil
 }
 
-func runMigration(db *sql.DB, direction migrate.MigrationDirection) {
-	migrations := &migrate.FileMigrationSource{
-		Dir: "internal/migrations",
+func printQueries(w io.Writer, direction, id string, queries []string) {
+	fmt.Fprintf(w, "-- %s %s\n", direction, id)
+	for _, query := range queries {
+		fmt.Fprintln(w, strings.TrimSpace(query))
 	}
-	n, err := migrate.Exec(db, "postgres", migrations, direction)
+}
+
+func (c *cmd) status() error {
+	statuses, err := migrations.Statuses(c.db, c.src)
 	if err != nil {
-		fmt.Println("failed while executing migration")
-		zaplog.Logger.Error(err)
-		return
+		return err
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		switch {
+		case s.Unknown:
+			fmt.Fprintf(w, "%s\tunknown, missing from this binary\t%s\n", s.ID, s.AppliedAt.Format(time.RFC3339))
+		case s.Applied:
+			fmt.Fprintf(w, "%s\tapplied\t%s\n", s.ID, s.AppliedAt.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(w, "%s\tpending\t\n", s.ID)
+		}
 	}
-	fmt.Printf("Applied %d migrations!\n", n)
+	return w.Flush()
 }
@@ -0,0 +1,145 @@
+package main_test
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "go-template/cmd/migrations"
+	"go-template/internal/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDB patches main.Connect to return a mock database having applied the migrations ids
+func mockDB(t *testing.T, ids ...string) sqlmock.Sqlmock {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	patches := gomonkey.ApplyFunc(main.Connect, func() (*sql.DB, error) { return db, nil })
+	t.Cleanup(patches.Reset)
+	mock.ExpectExec(`create table if not exists "gorp_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"id", "applied_at"})
+	for _, id := range ids {
+		rows.AddRow(id, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	}
+	mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).WillReturnRows(rows)
+	return mock
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "No command", args: []string{}},
+		{name: "Unknown command", args: []string{"sideways"}, wantErr: `invalid usage: unknown command "sideways"`},
+		{name: "Invalid count", args: []string{"down", "0"},
+			wantErr: `invalid usage: the number of migrations must be a positive integer or all, got "0"`},
+		{name: "Too many arguments", args: []string{"up", "1", "2"}, wantErr: "invalid usage: too many arguments"},
+		{name: "Redo arguments", args: []string{"redo", "2"}, wantErr: "invalid usage: redo takes no arguments"},
+		{name: "New without a name", args: []string{"new"}, wantErr: "invalid usage: new takes the name of the migration"},
+		{name: "Unknown flag", args: []string{"up", "--force"}, wantErr: "flag provided but not defined: -force"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, main.ExitUsage, main.Run(tt.args, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), tt.wantErr)
+			assert.Contains(t, stderr.String(), "Usage: migrations [flags] <command> [args]")
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestRunNew(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitOK, main.Run([]string{"new", "--dir", dir, "add_avatars"}, &stdout, &stderr))
+	files, err := filepath.Glob(filepath.Join(dir, "*_add_avatars.sql"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "Created "+files[0]+"\n", stdout.String())
+
+	assert.Equal(t, main.ExitError, main.Run([]string{"new", "--dir", dir, "Add avatars"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `migrations: invalid migration name "Add avatars"`)
+}
+
+func TestRunStatus(t *testing.T) {
+	mock := mockDB(t, "1_create_roles.sql", "99_from_the_future.sql")
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitOK, main.Run([]string{"status"}, &stdout, &stderr))
+	assert.Contains(t, stdout.String(), "1_create_roles.sql")
+	assert.Regexp(t, `1_create_roles.sql\s+applied\s+2026-01-02T03:04:05Z`, stdout.String())
+	assert.Regexp(t, `2_create_users.sql\s+pending`, stdout.String())
+	assert.Regexp(t, `99_from_the_future.sql\s+unknown, missing from this binary`, stdout.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		applied []string
+		want    []string
+	}{
+		{name: "Up", args: []string{"--dry-run", "up", "1"}, want: []string{"-- up 1_create_roles.sql",
+			"CREATE TABLE public.roles"}},
+		{name: "Flag after the command", args: []string{"down", "--dry-run"}, applied: []string{"1_create_roles.sql"},
+			want: []string{"-- down 1_create_roles.sql", "DROP TABLE roles"}},
+		{name: "Redo", args: []string{"redo", "--dry-run"}, applied: []string{"1_create_roles.sql"},
+			want: []string{"-- down 1_create_roles.sql", "-- up 1_create_roles.sql"}},
+		{name: "Nothing to roll back", args: []string{"down", "--dry-run"}, want: []string{"No migrations to run"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockDB(t, tt.applied...)
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, main.ExitOK, main.Run(tt.args, &stdout, &stderr), stderr.String())
+			for _, want := range tt.want {
+				assert.Contains(t, stdout.String(), want)
+			}
+			// nothing but the migration records is queried
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRunConnectError(t *testing.T) {
+	patches := gomonkey.ApplyFunc(main.Connect, func() (*sql.DB, error) {
+		return nil, errors.New("connection refused")
+	})
+	defer patches.Reset()
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitError, main.Run([]string{"up"}, &stdout, &stderr))
+	assert.Equal(t, "migrations: connecting to the database: connection refused\n", stderr.String())
+}
+
+func TestConnect(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PSQL_USER"), []byte("migrator\n"), 0o600))
+	t.Setenv("SECRETS_PROVIDER", "file")
+	t.Setenv("SECRETS_DIR", dir)
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	patches := gomonkey.ApplyFunc(postgres.Open, func(dsn string, opts postgres.Options) (*sql.DB, error) {
+		// the credentials come from the secrets provider
+		assert.Contains(t, dsn, "user=migrator ")
+		// migrations may run for longer than a query is allowed to
+		assert.Zero(t, opts.QueryTimeout)
+		assert.Zero(t, opts.StatementTimeout)
+		return db, nil
+	})
+	defer patches.Reset()
+
+	got, err := main.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, db, got)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go-template/internal/scaffold"
+)
+
+// Exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const usage = `Usage: scaffold [flags] <entity> <field:kind[?]>...
+
+Writes the migration, the daos, the repository, the service, the graphql schema and the resolvers
+of a new entity along with their tests, and adds its table to the tables gendbmdls generates the
+models of. The kind of a field is one of string, int, float, bool and time, followed by ? when the
+field is nullable. The generated mutations are restricted to super admins.
+
+Example:
+  scaffold invoice number:string amount:float paid:bool due_at:time?
+
+Flags:
+`
+
+// nextSteps are printed once the files are written, along with the model and the package of the
+// service of the entity. The generated code compiles once they ran.
+const nextSteps = `
+Next steps:
+  add %[1]sService *%[2]s.Service to resolver.Resolver, %[2]s.New(repository.Postgres%[3]s{}, tx) in pkg/api
+  go run ./cmd/migrations up      apply the migration
+  gqlgen generate                 generate the graphql models, the converters are reported missing
+  go run ./cmd/gendbmdls          generate the model and the cnvrttogql converters
+`
+
+func main() {
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// errUsage is returned for invalid commands, their exit code is ExitUsage
+var errUsage = errors.New("invalid usage")
+
+// Run scaffolds the entity of args and returns its exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", ".", "root of the repository the files are written to")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	err := run(fs.Args(), *dir, stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return ExitUsage
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "scaffold:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func run(args []string, dir string, out io.Writer) error {
+	if len(args) < 2 {
+		return fmt.Errorf("%w: scaffold takes the entity and its fields", errUsage)
+	}
+	entity, err := scaffold.Parse(args[0], args[1:])
+	if err != nil {
+		return fmt.Errorf("%w: %s", errUsage, err)
+	}
+	files, err := scaffold.Files(entity, time.Now())
+	if err != nil {
+		return err
+	}
+	gendbmdls := filepath.Join(dir, "cmd", "gendbmdls", "main.go")
+	src, err := os.ReadFile(gendbmdls)
+	if err != nil {
+		return err
+	}
+	if src, err = scaffold.AddModelTable(src, entity.Table()); err != nil {
+		return fmt.Errorf("adding %s to the tables of gendbmdls: %w", entity.Table(), err)
+	}
+	if err := scaffold.Write(dir, files); err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Fprintln(out, "Created", filepath.ToSlash(f.Path))
+	}
+	if err := os.WriteFile(gendbmdls, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Updated cmd/gendbmdls/main.go")
+	fmt.Fprintf(out, nextSteps, entity.Model(), entity.Package(), entity.Models())
+	return nil
+}
@@ -0,0 +1,67 @@
+package main_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	main "go-template/cmd/scaffold"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// repository writes the directories and the gendbmdls source the scaffold writes to
+func repository(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, sub := range []string{"daos", "resolver", "schema", filepath.Join("internal", "migrations"),
+		filepath.Join("cmd", "gendbmdls")} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, sub), 0o755))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "cmd", "gendbmdls", "main.go"),
+		[]byte("package main\n\nvar modelTables = []string{\"roles\", \"users\"}\n"), 0o600))
+	return dir
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitUsage, main.Run([]string{"invoice"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "invalid usage: scaffold takes the entity and its fields")
+	assert.Contains(t, stderr.String(), "Usage: scaffold [flags] <entity> <field:kind[?]>...")
+
+	stderr.Reset()
+	assert.Equal(t, main.ExitUsage, main.Run([]string{"invoice", "amount:decimal"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `invalid usage: invalid field "amount:decimal"`)
+	assert.Equal(t, main.ExitOK, main.Run([]string{"--help"}, &stdout, &stderr))
+	assert.Empty(t, stdout.String())
+}
+
+func TestRun(t *testing.T) {
+	dir := repository(t)
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitOK, main.Run([]string{"--dir", dir, "invoice", "number:string", "due_at:time?"},
+		&stdout, &stderr))
+	assert.Empty(t, stderr.String())
+	assert.Contains(t, stdout.String(), "Created daos/invoices.go\n")
+	assert.Contains(t, stdout.String(), "Created resolver/invoice_mutations.resolvers_test.go\n")
+	assert.Contains(t, stdout.String(), "Updated cmd/gendbmdls/main.go\n")
+	assert.Contains(t, stdout.String(), "go run ./cmd/gendbmdls")
+	assert.Contains(t, stdout.String(),
+		"add InvoiceService *invoices.Service to resolver.Resolver, invoices.New(repository.PostgresInvoices{}, tx) in pkg/api")
+	assert.FileExists(t, filepath.Join(dir, "internal", "service", "invoices", "invoices.go"))
+	assert.FileExists(t, filepath.Join(dir, "schema", "invoice.graphql"))
+	migrations, err := filepath.Glob(filepath.Join(dir, "internal", "migrations", "*_create_invoices.sql"))
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 1)
+	gendbmdls, err := os.ReadFile(filepath.Join(dir, "cmd", "gendbmdls", "main.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(gendbmdls), `var modelTables = []string{"invoices", "roles", "users"}`)
+
+	// an entity is scaffolded once
+	stdout.Reset()
+	assert.Equal(t, main.ExitError, main.Run([]string{"--dir", dir, "invoice", "number:string"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "scaffold: ")
+	assert.Contains(t, stderr.String(), "already exists")
+	assert.Empty(t, stdout.String())
+}
@@ -1,57 +1,213 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"go-template/internal/config"
-	"go-template/pkg/utl/zaplog"
-	"log"
+	"io"
+	"io/fs"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
+
+	"go-template/internal/config"
+	"go-template/internal/postgres"
+	"go-template/internal/seeds"
+	"go-template/pkg/utl/secure"
 
-	"golang.org/x/exp/slices"
+	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
-func stripFileExtension(fileName string) string {
-	s := strings.TrimRight(fileName, ".go")
-	return s
-}
+// Exit codes
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+const usage = `Usage: seeder [flags] <command>
+
+Commands:
+  apply     upsert the fixtures of the environment that changed since they were last applied
+  generate  copy deterministic fake roles and users, for load tests and demos
+
+Flags:
+`
 
 func main() {
-	err := config.LoadEnv()
+	os.Exit(Run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// Connect opens the database of the configuration with the credentials of the secrets provider
+func Connect() (*sql.DB, error) {
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	dbCfg, err := config.LoadDatabase()
 	if err != nil {
-		fmt.Println("erorr while loading the env")
-		return
+		return nil, err
+	}
+	return postgres.ConnectWith(postgres.OptionsFromConfig(dbCfg))
+}
+
+// errUsage is returned for invalid commands, their exit code is ExitUsage
+var errUsage = errors.New("invalid usage")
+
+// Run runs the command of args and returns its exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("seeder", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	config.RegisterFlag(fs)
+	opts := options{}
+	fs.StringVar(&opts.env, "env", "", "environment of the fixtures, ENVIRONMENT_NAME or local by default")
+	fs.StringVar(&opts.dir, "dir", "", "directory holding the fixtures of each environment, the embedded ones by default")
+	fs.StringVar(&opts.only, "only", "", "comma separated fixtures to apply, "+strings.Join(seeds.Kinds, " and ")+
+		", all of them by default")
+	fs.BoolVar(&opts.reset, "reset", false, "empty the tables of the fixtures before seeding them, refused in production")
+	fs.IntVar(&opts.generate.Users, "users", 0, "number of users generate copies")
+	fs.IntVar(&opts.generate.Roles, "roles", 0, "number of roles generate copies, the users get the existing roles when 0")
+	fs.Int64Var(&opts.generate.Seed, "seed", 1, "seed of the fake data of generate, the same seed yields the same data")
+	fs.IntVar(&opts.generate.BatchSize, "batch-size", 5000, "number of users generate copies per transaction")
+	fs.StringVar(&opts.password, "password", "",
+		"password of the users generate copies, password by default and required outside the local and docker environments")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitCode(err)
+	}
+	command := fs.Arg(0)
+	if command == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+	// flags are also accepted after the command
+	if err := fs.Parse(fs.Args()[1:]); err != nil {
+		return exitCode(err)
+	}
+	err := run(command, fs.Args(), opts, stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(stderr, err)
+		fs.Usage()
+		return ExitUsage
 	}
-	base, _ := os.Getwd()
-	base += "/cmd/seeder"
-	fmt.Println(base)
-	files, err := os.ReadDir(base)
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(stderr, "seeder:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+func exitCode(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
 	}
+	return ExitUsage
+}
 
-	for _, file := range files {
-		if slices.Contains([]string{"main.go", "seed", "output", "exec", "build", "utls"}, file.Name()) {
-			continue
+type options struct {
+	env      string
+	dir      string
+	only     string
+	reset    bool
+	generate seeds.GenerateOptions
+	password string
+}
+
+func run(command string, args []string, opts options, out io.Writer) error {
+	var only []string
+	switch {
+	case command != "apply" && command != "generate":
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	case len(args) != 0:
+		return fmt.Errorf("%w: %s takes no arguments", errUsage, command)
+	case command == "generate" && (opts.generate.Users < 0 || opts.generate.Roles < 0):
+		return fmt.Errorf("%w: the number of users and roles can't be negative", errUsage)
+	case command == "generate" && opts.generate.BatchSize < 1:
+		return fmt.Errorf("%w: the batch size must be positive", errUsage)
+	case opts.only != "":
+		only = strings.Split(opts.only, ",")
+		if _, err := seeds.Select(nil, only); err != nil {
+			return fmt.Errorf("%w: %s", errUsage, err)
 		}
+	}
+
+	db, err := Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to the database: %w", err)
+	}
+	defer db.Close()
+	boil.SetDB(db)
+
+	// the environment is read once the .env files are loaded
+	env := opts.env
+	if env == "" {
+		env = os.Getenv("ENVIRONMENT_NAME")
+	}
+	if env == "" {
+		env = "local"
+	}
+	ctx := context.Background()
+	if command == "generate" {
+		return generate(ctx, env, opts, out)
+	}
 
-		filepath := base + "/" + file.Name()
-		// outputPath := base + "/output"
-		files, err := os.ReadDir(filepath)
-		if err != nil {
-			log.Fatal(err)
+	fixtures, err := seeds.Load(fixtureFiles(opts.dir), env)
+	if err != nil {
+		return err
+	}
+	if fixtures, err = seeds.Select(fixtures, only); err != nil {
+		return err
+	}
+
+	seeder := seeds.Seeder{Environment: env, Hash: secure.New(0, nil).Hash}
+	if opts.reset {
+		if err := seeder.Reset(ctx, fixtures); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Reset the %s fixtures\n", env)
+	}
+	results, err := seeder.Apply(ctx, fixtures)
+	for _, r := range results {
+		if r.Skipped {
+			fmt.Fprintf(out, "Skipped %s, unchanged since it was last applied\n", r.Name)
+		} else {
+			fmt.Fprintf(out, "Seeded %d %s\n", r.Records, r.Name)
 		}
-		for _, file := range files {
-			fmt.Println(filepath + "/" + file.Name())
-			cmd := exec.
-				Command("go", "build", "-o",
-					fmt.Sprintf("./cmd/seeder/exec/build/%s", stripFileExtension(file.Name())), filepath+"/"+file.Name())
-			data, err := cmd.CombinedOutput()
-			if err != nil {
-				zaplog.Logger.Error(string(data), err)
-			}
-			fmt.Println(err)
+	}
+	return err
+}
+
+func generate(ctx context.Context, env string, opts options, out io.Writer) error {
+	// the default password is only known to be harmless on the databases of the developers
+	if opts.password == "" {
+		if env != "local" && env != "docker" {
+			return fmt.Errorf("generating users in the %s environment requires --password", env)
 		}
+		opts.password = "password"
+	}
+	// every user gets the same hash, hashing the password of every user would take longer than
+	// copying them
+	opts.generate.PasswordHash = secure.New(0, nil).Hash(opts.password)
+	seeder := seeds.Seeder{Environment: env}
+	start := time.Now()
+	err := seeder.Generate(ctx, opts.generate, func(users int) {
+		fmt.Fprintf(out, "Copied %d/%d users\n", users, opts.generate.Users)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Generated %d roles and %d users in %s\n", opts.generate.Roles, opts.generate.Users,
+		time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func fixtureFiles(dir string) fs.FS {
+	if dir == "" {
+		return seeds.Files()
 	}
+	return os.DirFS(dir)
 }
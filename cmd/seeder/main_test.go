@@ -0,0 +1,174 @@
+package main_test
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	main "go-template/cmd/seeder"
+	"go-template/internal/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// mockDB patches main.Connect to return a mock database
+func mockDB(t *testing.T) sqlmock.Sqlmock {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	oldDB := boil.GetDB()
+	patches := gomonkey.ApplyFunc(main.Connect, func() (*sql.DB, error) { return db, nil })
+	t.Cleanup(func() {
+		patches.Reset()
+		boil.SetDB(oldDB)
+	})
+	return mock
+}
+
+// fixtures writes the roles fixture of the test environment to a directory
+func fixtures(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "test"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "test", "roles.yaml"),
+		[]byte("roles:\n  - name: USER\n    access_level: 200\n"), 0o600))
+	return dir
+}
+
+func TestRunUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "No command", args: []string{}},
+		{name: "Unknown command", args: []string{"plant"}, wantErr: `invalid usage: unknown command "plant"`},
+		{name: "Arguments", args: []string{"apply", "roles"}, wantErr: "invalid usage: apply takes no arguments"},
+		{name: "Unknown fixture", args: []string{"apply", "--only", "roles,jobs"},
+			wantErr: `invalid usage: unknown fixture "jobs", use roles, users`},
+		{name: "Unknown flag", args: []string{"apply", "--force"}, wantErr: "flag provided but not defined: -force"},
+		{name: "Negative count", args: []string{"generate", "--users", "-1"},
+			wantErr: "invalid usage: the number of users and roles can't be negative"},
+		{name: "Empty batches", args: []string{"generate", "--users", "10", "--batch-size", "0"},
+			wantErr: "invalid usage: the batch size must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, main.ExitUsage, main.Run(tt.args, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), tt.wantErr)
+			assert.Contains(t, stderr.String(), "Usage: seeder [flags] <command>")
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
+
+func TestRunApply(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM seeds WHERE name = $1 AND environment = $2`)).WithArgs("roles", "test").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "environment", "checksum", "applied_at"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "roles"`)).WithArgs(200, "USER", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(1, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO seeds`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	var stdout, stderr bytes.Buffer
+	code := main.Run([]string{"apply", "--env", "test", "--dir", fixtures(t)}, &stdout, &stderr)
+	assert.Equal(t, main.ExitOK, code, stderr.String())
+	assert.Equal(t, "Seeded 1 roles\n", stdout.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunApplyReset(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`TRUNCATE TABLE roles RESTART IDENTITY CASCADE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM seeds`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM seeds WHERE name = $1 AND environment = $2`)).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "environment", "checksum", "applied_at"}).
+			AddRow("roles", "test", "outdated", time.Now()))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "roles"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(1, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO seeds`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	var stdout, stderr bytes.Buffer
+	code := main.Run([]string{"--env", "test", "--dir", fixtures(t), "--reset", "--only", "roles", "apply"}, &stdout, &stderr)
+	assert.Equal(t, main.ExitOK, code, stderr.String())
+	assert.Equal(t, "Reset the test fixtures\nSeeded 1 roles\n", stdout.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunApplyFails(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectClose()
+
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, main.ExitError, main.Run([]string{"apply", "--env", "staging", "--dir", fixtures(t)}, &stdout, &stderr))
+	assert.Equal(t, "seeder: no fixtures for the environment \"staging\"\n", stderr.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunGenerate(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" ORDER BY id;`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_level", "name"}).
+			AddRow(1, 100, "SUPER_ADMIN").AddRow(2, 200, "USER"))
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(`COPY "users"`))
+	prepared.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectClose()
+
+	var stdout, stderr bytes.Buffer
+	code := main.Run([]string{"generate", "--env", "load", "--users", "1", "--seed", "7", "--password", "s3cret"},
+		&stdout, &stderr)
+	assert.Equal(t, main.ExitOK, code, stderr.String())
+	assert.Regexp(t, `^Copied 1/1 users\nGenerated 0 roles and 1 users in \S+\n$`, stdout.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunGenerateWithoutPassword(t *testing.T) {
+	mock := mockDB(t)
+	mock.ExpectClose()
+
+	var stdout, stderr bytes.Buffer
+	code := main.Run([]string{"generate", "--env", "load", "--users", "1"}, &stdout, &stderr)
+	assert.Equal(t, main.ExitError, code)
+	assert.Equal(t, "seeder: generating users in the load environment requires --password\n", stderr.String())
+	assert.Empty(t, stdout.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConnect(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PSQL_USER"), []byte("seeder\n"), 0o600))
+	t.Setenv("SECRETS_PROVIDER", "file")
+	t.Setenv("SECRETS_DIR", dir)
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+	patches := gomonkey.ApplyFunc(postgres.Open, func(dsn string, _ postgres.Options) (*sql.DB, error) {
+		// the credentials come from the secrets provider
+		assert.Contains(t, dsn, "user=seeder ")
+		return db, nil
+	})
+	defer patches.Reset()
+
+	got, err := main.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, db, got)
+}
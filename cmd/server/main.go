@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -13,6 +14,8 @@ import (
 )
 
 func main() {
+	config.RegisterFlag(flag.CommandLine)
+	flag.Parse()
 	Setup()
 }
 func Setup() {
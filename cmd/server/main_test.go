@@ -50,12 +50,14 @@ func TestSetup(t *testing.T) {
 	cases := map[string]struct {
 		error   string
 		isPanic bool
+		env     map[string]string
 		init    func(*TestArgs) *Patches
 		args    *TestArgs
 	}{
-		"Failure__envFileNotFound": {
-			error:   "error loading port from .env",
+		"Failure__invalidConfig": {
+			error:   `invalid configuration: SERVER_READ_TIMEOUT must be an integer, got "ten"`,
 			isPanic: true,
+			env:     map[string]string{"SERVER_READ_TIMEOUT": "ten"},
 			init:    initEnv,
 			args: &TestArgs{
 				patchDotEnv: true,
@@ -72,6 +74,9 @@ func TestSetup(t *testing.T) {
 	}
 	for name, tt := range cases {
 		t.Run(name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
 			if tt.init != nil {
 				patches := tt.init(tt.args)
 				if patches != nil {
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+
+	"go-template/internal/config"
+	"go-template/internal/postgres"
+	"go-template/internal/secrets"
+	"go-template/internal/service/jobs"
+	"go-template/internal/service/webhooks"
+	"go-template/pkg/utl/rediscache"
+	"go-template/pkg/utl/zaplog"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+func main() {
+	config.RegisterFlag(flag.CommandLine)
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	if err := config.LoadEnv(); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	opts, err := jobs.OptionsFromEnv()
+	if err != nil {
+		return err
+	}
+	webhookOpts, err := webhooks.OptionsFromEnv()
+	if err != nil {
+		return err
+	}
+	watcher, err := secrets.WatcherFromEnv()
+	if err != nil {
+		return err
+	}
+	db, err := postgres.ConnectWatched(context.Background(), watcher, postgres.OptionsFromConfig(cfg.DB))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	watcher.Start()
+	boil.SetDB(db)
+	defer rediscache.Close()
+
+	registry := jobs.NewRegistry()
+	jobs.RegisterBuiltins(registry)
+	webhooks.RegisterHandlers(registry, webhookOpts)
+	scheduler := jobs.NewScheduler()
+	if err := jobs.ScheduleBuiltins(scheduler, cfg.JWT.MaxRefresh); err != nil {
+		return err
+	}
+
+	worker := jobs.NewWorker(registry, opts)
+	worker.Start()
+	scheduler.Start()
+	zaplog.Logger.Infow("worker started", "kinds", registry.Kinds(), "concurrency", opts.Concurrency)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	timeout := defaultShutdownTimeout
+	if cfg.Server.ShutdownTimeout > 0 {
+		timeout = time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := watcher.Stop(ctx); err != nil {
+		zaplog.Logger.Error("failed to stop the secrets watcher: ", err)
+	}
+	if err := scheduler.Stop(ctx); err != nil {
+		zaplog.Logger.Error("failed to stop the scheduler: ", err)
+	}
+	return worker.Stop(ctx)
+}
@@ -0,0 +1,84 @@
+package daos
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+)
+
+// FeatureFlag is a row of the feature_flags table
+type FeatureFlag struct {
+	Name          string
+	Description   string
+	Type          string
+	Enabled       bool
+	Percentage    int
+	UserIDs       []int64
+	Roles         []string
+	Organizations []string
+	UpdatedBy     null.Int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+const featureFlagColumns = `name, description, type, enabled, percentage, user_ids, roles, organizations, updated_by,
+	created_at, updated_at`
+
+func scanFeatureFlag(row interface{ Scan(...interface{}) error }) (FeatureFlag, error) {
+	var f FeatureFlag
+	err := row.Scan(&f.Name, &f.Description, &f.Type, &f.Enabled, &f.Percentage, pq.Array(&f.UserIDs),
+		pq.Array(&f.Roles), pq.Array(&f.Organizations), &f.UpdatedBy, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+// FindAllFeatureFlags reads the feature flags from the primary, they are cached by the
+// instances which must see the latest updates
+func FindAllFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	contextExecutor := GetContextExecutor(nil)
+	rows, err := contextExecutor.QueryContext(ctx, `SELECT `+featureFlagColumns+` FROM feature_flags ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var flags []FeatureFlag
+	for rows.Next() {
+		f, err := scanFeatureFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
+// UpsertFeatureFlag creates the feature flag or replaces its rules
+func UpsertFeatureFlag(flag FeatureFlag, ctx context.Context) (*FeatureFlag, error) {
+	contextExecutor := GetContextExecutor(nil)
+	f, err := scanFeatureFlag(contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO feature_flags (name, description, type, enabled, percentage, user_ids, roles, organizations,
+			updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (name) DO UPDATE SET description = excluded.description, type = excluded.type,
+			enabled = excluded.enabled, percentage = excluded.percentage, user_ids = excluded.user_ids,
+			roles = excluded.roles, organizations = excluded.organizations, updated_by = excluded.updated_by,
+			updated_at = now()
+		RETURNING `+featureFlagColumns,
+		flag.Name, flag.Description, flag.Type, flag.Enabled, flag.Percentage, pq.Array(flag.UserIDs),
+		pq.Array(flag.Roles), pq.Array(flag.Organizations), flag.UpdatedBy))
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// DeleteFeatureFlag deletes a feature flag
+func DeleteFeatureFlag(name string, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx, `DELETE FROM feature_flags WHERE name = $1`, name)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,71 @@
+package daos_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+var featureFlagRowColumns = []string{"name", "description", "type", "enabled", "percentage", "user_ids", "roles",
+	"organizations", "updated_by", "created_at", "updated_at"}
+
+func TestFindAllFeatureFlags(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM feature_flags ORDER BY name`)).
+		WillReturnRows(sqlmock.NewRows(featureFlagRowColumns).
+			AddRow("new-dashboard", "", "percentage", true, 20, "{1,2}", "{SUPER_ADMIN}", "{}", 1, now, now).
+			AddRow("exports", "CSV exports", "boolean", false, 0, "{}", "{}", "{acme}", nil, now, now))
+
+	flags, err := daos.FindAllFeatureFlags(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []daos.FeatureFlag{
+		{Name: "new-dashboard", Type: "percentage", Enabled: true, Percentage: 20, UserIDs: []int64{1, 2},
+			Roles: []string{"SUPER_ADMIN"}, Organizations: []string{}, UpdatedBy: null.IntFrom(1),
+			CreatedAt: now, UpdatedAt: now},
+		{Name: "exports", Description: "CSV exports", Type: "boolean", UserIDs: []int64{}, Roles: []string{},
+			Organizations: []string{"acme"}, CreatedAt: now, UpdatedAt: now},
+	}, flags)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertFeatureFlag(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	flag := daos.FeatureFlag{Name: "exports", Type: "boolean", Enabled: true, UserIDs: []int64{3},
+		Roles: []string{}, Organizations: []string{}, UpdatedBy: null.IntFrom(1)}
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO feature_flags`)).
+		WithArgs("exports", "", "boolean", true, 0, pq.Array([]int64{3}), pq.Array([]string{}),
+			pq.Array([]string{}), null.IntFrom(1)).
+		WillReturnRows(sqlmock.NewRows(featureFlagRowColumns).
+			AddRow("exports", "", "boolean", true, 0, "{3}", "{}", "{}", 1, now, now))
+
+	got, err := daos.UpsertFeatureFlag(flag, context.Background())
+	assert.Nil(t, err)
+	flag.CreatedAt, flag.UpdatedAt = now, now
+	assert.Equal(t, &flag, got)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteFeatureFlag(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM feature_flags WHERE name = $1`)).WithArgs("exports").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := daos.DeleteFeatureFlag("exports", context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,211 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+)
+
+// Job statuses, a failed job goes back to pending until it runs out of attempts and is
+// moved to dead
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+// Job is a row of the jobs table
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	UniqueKey   null.String
+	LastError   null.String
+	FinishedAt  null.Time
+	CreatedAt   time.Time
+}
+
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, run_at, unique_key, last_error,
+	finished_at, created_at`
+
+func scanJob(row interface{ Scan(...interface{}) error }) (Job, error) {
+	var j Job
+	err := row.Scan(&j.ID, &j.Kind, &j.Payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.RunAt,
+		&j.UniqueKey, &j.LastError, &j.FinishedAt, &j.CreatedAt)
+	return j, err
+}
+
+func scanJobs(rows *sql.Rows, err error) ([]Job, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// CreateJobTx enqueues a job. A job whose unique key is already taken isn't enqueued again,
+// in which case the returned id is 0.
+func CreateJobTx(job Job, ctx context.Context, tx *sql.Tx) (int64, error) {
+	contextExecutor := GetContextExecutor(tx)
+	var id int64
+	err := contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO jobs (kind, payload, max_attempts, run_at, unique_key) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (unique_key) DO NOTHING RETURNING id`,
+		job.Kind, job.Payload, job.MaxAttempts, job.RunAt, job.UniqueKey,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// CreateJob ...
+func CreateJob(job Job, ctx context.Context) (int64, error) {
+	return CreateJobTx(job, ctx, nil)
+}
+
+// ClaimJobs moves up to limit due jobs of the given kinds to running on behalf of worker
+// and counts the attempt. Rows claimed concurrently by another worker are skipped.
+func ClaimJobs(worker string, kinds []string, limit int, ctx context.Context) ([]Job, error) {
+	contextExecutor := GetContextExecutor(nil)
+	return scanJobs(contextExecutor.QueryContext(ctx,
+		`UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_by = $1, locked_at = now(),
+		updated_at = now()
+		WHERE id IN (
+			SELECT id FROM jobs WHERE status = 'pending' AND run_at <= now() AND kind = ANY($2)
+			ORDER BY run_at, id LIMIT $3 FOR UPDATE SKIP LOCKED
+		) RETURNING `+jobColumns,
+		worker, pq.Array(kinds), limit,
+	))
+}
+
+// CompleteJob marks a running job as succeeded
+func CompleteJob(id int64, ctx context.Context) error {
+	contextExecutor := GetContextExecutor(nil)
+	_, err := contextExecutor.ExecContext(ctx,
+		`UPDATE jobs SET status = 'succeeded', last_error = NULL, locked_by = NULL, locked_at = NULL,
+		finished_at = now(), updated_at = now() WHERE id = $1`, id)
+	return err
+}
+
+// FailJob records the failure of a running job, it is retried after retryIn or, when dead
+// is set, moved to the dead-letter state
+func FailJob(id int64, cause error, retryIn time.Duration, dead bool, ctx context.Context) error {
+	contextExecutor := GetContextExecutor(nil)
+	status, finishedAt := JobPending, "NULL"
+	if dead {
+		status, finishedAt = JobDead, "now()"
+	}
+	_, err := contextExecutor.ExecContext(ctx,
+		`UPDATE jobs SET status = $2, last_error = $3, run_at = now() + $4 * interval '1 millisecond',
+		locked_by = NULL, locked_at = NULL, finished_at = `+finishedAt+`, updated_at = now() WHERE id = $1`,
+		id, status, cause.Error(), retryIn.Milliseconds())
+	return err
+}
+
+// RescueStaleJobs puts back to pending the jobs that have been running for longer than
+// lease, e.g. because their worker crashed
+func RescueStaleJobs(lease time.Duration, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx,
+		`UPDATE jobs SET status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+		last_error = 'lease expired', locked_by = NULL, locked_at = NULL, updated_at = now()
+		WHERE status = 'running' AND locked_at < now() - $1 * interval '1 millisecond'`,
+		lease.Milliseconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RetryJob puts a dead job back in the queue with a fresh set of attempts
+func RetryJob(id int64, ctx context.Context) (*Job, error) {
+	contextExecutor := GetContextExecutor(nil)
+	j, err := scanJob(contextExecutor.QueryRowContext(ctx,
+		`UPDATE jobs SET status = 'pending', attempts = 0, run_at = now(), finished_at = NULL,
+		updated_at = now() WHERE id = $1 AND status = 'dead' RETURNING `+jobColumns, id))
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// FindJobByID ...
+func FindJobByID(id int64, ctx context.Context) (*Job, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	j, err := scanJob(contextExecutor.QueryRowContext(ctx,
+		`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// JobFilter narrows FindAllJobsWithCount, zero values match every job
+type JobFilter struct {
+	Status string
+	Kind   string
+	Limit  int
+	Offset int
+}
+
+// FindAllJobsWithCount returns the jobs matching filter, most recent first, and their count
+func FindAllJobsWithCount(filter JobFilter, ctx context.Context) ([]Job, int64, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	var (
+		where []string
+		args  []interface{}
+	)
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Kind != "" {
+		args = append(args, filter.Kind)
+		where = append(where, fmt.Sprintf("kind = $%d", len(args)))
+	}
+	clause := ""
+	if len(where) > 0 {
+		clause = " WHERE " + strings.Join(where, " AND ")
+	}
+	var count int64
+	if err := contextExecutor.QueryRowContext(ctx, `SELECT count(*) FROM jobs`+clause, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+	query := `SELECT ` + jobColumns + ` FROM jobs` + clause + ` ORDER BY id DESC`
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+	}
+	jobs, err := scanJobs(contextExecutor.QueryContext(ctx, query, args...))
+	return jobs, count, err
+}
+
+// DeleteJobsFinishedBefore removes the succeeded jobs finished before t, dead jobs are
+// kept until they are retried or deleted by hand
+func DeleteJobsFinishedBefore(t time.Time, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx,
+		`DELETE FROM jobs WHERE status = 'succeeded' AND finished_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
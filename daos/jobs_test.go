@@ -0,0 +1,187 @@
+package daos_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+var jobRowColumns = []string{"id", "kind", "payload", "status", "attempts", "max_attempts", "run_at",
+	"unique_key", "last_error", "finished_at", "created_at"}
+
+func TestCreateJobTx(t *testing.T) {
+	runAt := time.Now()
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+		want int64
+	}{
+		{
+			name: "Success",
+			rows: sqlmock.NewRows([]string{"id"}).AddRow(7),
+			want: 7,
+		},
+		{
+			name: "Duplicate unique key",
+			rows: sqlmock.NewRows([]string{"id"}),
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT (unique_key) DO NOTHING`)).
+				WithArgs("email", []byte(`{}`), 3, runAt, null.StringFrom("key")).
+				WillReturnRows(tt.rows)
+
+			id, err := daos.CreateJob(daos.Job{
+				Kind: "email", Payload: []byte(`{}`), MaxAttempts: 3, RunAt: runAt, UniqueKey: null.StringFrom("key"),
+			}, context.Background())
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, id)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestClaimJobs(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`)).
+		WithArgs("worker-1", pq.Array([]string{"email"}), 2).
+		WillReturnRows(sqlmock.NewRows(jobRowColumns).
+			AddRow(1, "email", []byte(`{}`), daos.JobRunning, 1, 10, now, nil, nil, nil, now))
+
+	jobs, err := daos.ClaimJobs("worker-1", []string{"email"}, 2, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []daos.Job{{
+		ID: 1, Kind: "email", Payload: []byte(`{}`), Status: daos.JobRunning, Attempts: 1, MaxAttempts: 10,
+		RunAt: now, CreatedAt: now,
+	}}, jobs)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteAndFailJob(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE jobs SET status = 'succeeded'`)).WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`finished_at = NULL`)).
+		WithArgs(2, daos.JobPending, "timeout", int64(2000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`finished_at = now()`)).
+		WithArgs(3, daos.JobDead, "timeout", int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, daos.CompleteJob(1, context.Background()))
+	assert.Nil(t, daos.FailJob(2, errors.New("timeout"), 2*time.Second, false, context.Background()))
+	assert.Nil(t, daos.FailJob(3, errors.New("timeout"), 0, true, context.Background()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryJob(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		wantErr error
+	}{
+		{
+			name: "Success",
+			rows: sqlmock.NewRows(jobRowColumns).
+				AddRow(4, "email", []byte(`{}`), daos.JobPending, 0, 10, now, nil, "boom", nil, now),
+		},
+		{
+			name:    "Not a dead job",
+			rows:    sqlmock.NewRows(jobRowColumns),
+			wantErr: sql.ErrNoRows,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			mock.ExpectQuery(regexp.QuoteMeta(`WHERE id = $1 AND status = 'dead'`)).WithArgs(4).
+				WillReturnRows(tt.rows)
+
+			job, err := daos.RetryJob(4, context.Background())
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, daos.JobPending, job.Status)
+				assert.Equal(t, null.StringFrom("boom"), job.LastError)
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFindAllJobsWithCount(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM jobs WHERE status = $1 AND kind = $2`)).
+		WithArgs(daos.JobDead, "email").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE status = $1 AND kind = $2 ORDER BY id DESC LIMIT 1 OFFSET 2`)).
+		WithArgs(daos.JobDead, "email").
+		WillReturnRows(sqlmock.NewRows(jobRowColumns).
+			AddRow(1, "email", []byte(`{}`), daos.JobDead, 10, 10, now, nil, "boom", now, now))
+
+	jobs, count, err := daos.FindAllJobsWithCount(daos.JobFilter{
+		Status: daos.JobDead, Kind: "email", Limit: 1, Offset: 2,
+	}, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, null.TimeFrom(now), jobs[0].FinishedAt)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRescueStaleJobs(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE status = 'running' AND locked_at < now()`)).
+		WithArgs(int64(60000)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM jobs WHERE status = 'succeeded'`)).
+		WithArgs(fixedTime()).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+
+	n, err := daos.RescueStaleJobs(time.Minute, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	n, err = daos.DeleteJobsFinishedBefore(fixedTime(), context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func fixedTime() time.Time {
+	return time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestClearTokensIssuedBefore(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET token = NULL, token_issued_at = NULL WHERE token IS NOT NULL AND token_issued_at < $1`)).
+		WithArgs(fixedTime()).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := daos.ClearTokensIssuedBefore(fixedTime(), context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
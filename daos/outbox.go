@@ -0,0 +1,98 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// OutboxEvent is an event recorded in the outbox_events table, in the transaction of the
+// change it describes, until the relay publishes it
+type OutboxEvent struct {
+	ID       int64
+	Topic    string
+	Payload  []byte
+	Attempts int
+	// DeliveredTo names the sinks that already received the event
+	DeliveredTo []string
+	CreatedAt   time.Time
+}
+
+// CreateOutboxEventTx records an event to be published once tx commits
+func CreateOutboxEventTx(topic string, payload []byte, ctx context.Context, tx *sql.Tx) (int64, error) {
+	contextExecutor := GetContextExecutor(tx)
+	var id int64
+	err := contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO outbox_events (topic, payload) VALUES ($1, $2) RETURNING id`,
+		topic, payload,
+	).Scan(&id)
+	return id, err
+}
+
+// ClaimOutboxEvents claims up to limit events that are due for publishing by pushing their
+// next attempt lease away. Rows claimed concurrently by another relay are skipped, and the
+// events of a relay that dies while publishing are claimed again once the lease expired.
+func ClaimOutboxEvents(limit int, lease time.Duration, ctx context.Context) ([]OutboxEvent, error) {
+	contextExecutor := GetContextExecutor(nil)
+	rows, err := contextExecutor.QueryContext(ctx,
+		`UPDATE outbox_events SET next_attempt_at = now() + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox_events WHERE published_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		) RETURNING id, topic, payload, attempts, delivered_to, created_at`,
+		limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		err := rows.Scan(&e.ID, &e.Topic, &e.Payload, &e.Attempts, pq.Array(&e.DeliveredTo), &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// MarkOutboxEventPublished ...
+func MarkOutboxEventPublished(id int64, ctx context.Context) error {
+	contextExecutor := GetContextExecutor(nil)
+	_, err := contextExecutor.ExecContext(ctx,
+		`UPDATE outbox_events SET published_at = now(), last_error = NULL WHERE id = $1`, id)
+	return err
+}
+
+// MarkOutboxEventFailed records a failed attempt along with the sinks the event was delivered
+// to, and schedules the next one
+func MarkOutboxEventFailed(id int64, cause error, deliveredTo []string, retryIn time.Duration,
+	ctx context.Context) error {
+	contextExecutor := GetContextExecutor(nil)
+	_, err := contextExecutor.ExecContext(ctx,
+		`UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, delivered_to = $3,
+		next_attempt_at = now() + $4 * interval '1 millisecond' WHERE id = $1`,
+		id, cause.Error(), pq.Array(deliveredTo), retryIn.Milliseconds())
+	return err
+}
+
+// DeleteOutboxEventsPublishedBefore removes the events published before t
+func DeleteOutboxEventsPublishedBefore(t time.Time, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx,
+		`DELETE FROM outbox_events WHERE published_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package daos_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimOutboxEvents(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	createdAt := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`)).WithArgs(5, int64(60000)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "topic", "payload", "attempts", "delivered_to", "created_at"}).
+			AddRow(2, "role", []byte(`{}`), 0, "{}", createdAt).
+			AddRow(1, "user", []byte(`{}`), 2, "{subscriptions}", createdAt))
+
+	events, err := daos.ClaimOutboxEvents(5, time.Minute, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []daos.OutboxEvent{
+		{ID: 1, Topic: "user", Payload: []byte(`{}`), Attempts: 2, DeliveredTo: []string{"subscriptions"},
+			CreatedAt: createdAt},
+		{ID: 2, Topic: "role", Payload: []byte(`{}`), Attempts: 0, DeliveredTo: []string{}, CreatedAt: createdAt},
+	}, events)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkOutboxEvent(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE outbox_events SET published_at = now()`)).WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE outbox_events SET attempts = attempts + 1`)).
+		WithArgs(2, "sink is down", `{"webhooks"}`, int64(1500)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.Nil(t, daos.MarkOutboxEventPublished(1, context.Background()))
+	assert.Nil(t, daos.MarkOutboxEventFailed(2, errors.New("sink is down"), []string{"webhooks"},
+		1500*time.Millisecond, context.Background()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOutboxEventsPublishedBefore(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	before := time.Now()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM outbox_events WHERE published_at < $1`)).WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := daos.DeleteOutboxEventsPublishedBefore(before, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,167 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// Replica is a read replica of the primary database
+type Replica struct {
+	Name string
+	DB   *sql.DB
+
+	healthy atomic.Bool
+}
+
+// Healthy reports whether the last health check of the replica succeeded
+func (r *Replica) Healthy() bool {
+	return r.healthy.Load()
+}
+
+// Router spreads the reads over the healthy replicas in turn, reads go to the primary when
+// no replica is healthy
+type Router struct {
+	replicas []*Replica
+	next     atomic.Uint64
+	timeout  time.Duration
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewRouter returns a router over replicas, each is pinged with timeout by the health checks
+func NewRouter(replicas []*Replica, timeout time.Duration) *Router {
+	return &Router{
+		replicas: replicas,
+		timeout:  timeout,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Check pings every replica and records whether it is healthy
+func (r *Router) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, replica := range r.replicas {
+		wg.Add(1)
+		go func(replica *Replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+			err := replica.DB.PingContext(ctx)
+			if healthy := err == nil; replica.healthy.Swap(healthy) != healthy {
+				if healthy {
+					zaplog.Logger.Infow("replica is healthy", "replica", replica.Name)
+				} else {
+					zaplog.Logger.Warnw("replica is unhealthy", "replica", replica.Name, "error", err.Error())
+				}
+			}
+		}(replica)
+	}
+	wg.Wait()
+}
+
+// Start checks the replicas right away, then every interval until Stop is called
+func (r *Router) Start(interval time.Duration) {
+	r.started.Store(true)
+	r.Check(context.Background())
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.Check(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop stops the health checks and closes the replicas
+func (r *Router) Stop(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	if r.started.Load() {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	var err error
+	for _, replica := range r.replicas {
+		if closeErr := replica.DB.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// pick returns the next healthy replica, or nil when none is
+func (r *Router) pick() *Replica {
+	healthy := make([]*Replica, 0, len(r.replicas))
+	for _, replica := range r.replicas {
+		if replica.Healthy() {
+			healthy = append(healthy, replica)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	return healthy[r.next.Add(1)%uint64(len(healthy))]
+}
+
+var router atomic.Pointer[Router]
+
+// SetRouter routes the reads of the daos through r, nil sends them all to the primary
+func SetRouter(r *Router) {
+	router.Store(r)
+}
+
+type sessionKey struct{}
+
+// session tracks whether the request wrote to the primary
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession returns a ctx whose reads stick to the primary once MarkWrite was called on
+// it, so that a request reads its own writes despite the replication lag
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// MarkWrite sends the following reads of the session of ctx to the primary
+func MarkWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// GetReadExecutor returns the executor of a read: tx when set, otherwise a healthy replica
+// unless the session of ctx wrote to the primary, and the primary as a fallback
+func GetReadExecutor(ctx context.Context, tx *sql.Tx) boil.ContextExecutor {
+	if tx != nil {
+		return tx
+	}
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok && s.wrote.Load() {
+		return boil.GetContextDB()
+	}
+	if r := router.Load(); r != nil {
+		if replica := r.pick(); replica != nil {
+			return replica.DB
+		}
+	}
+	return boil.GetContextDB()
+}
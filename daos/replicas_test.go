@@ -0,0 +1,73 @@
+package daos_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func newReplica(t *testing.T, name string, pingErr error) (*daos.Replica, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.Nil(t, err)
+	mock.ExpectPing().WillReturnError(pingErr)
+	return &daos.Replica{Name: name, DB: db}, mock
+}
+
+func TestGetReadExecutor(t *testing.T) {
+	_, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	primary := boil.GetContextDB()
+
+	first, _ := newReplica(t, "first", nil)
+	second, _ := newReplica(t, "second", nil)
+	down, _ := newReplica(t, "down", errors.New("connection refused"))
+	router := daos.NewRouter([]*daos.Replica{first, down, second}, time.Second)
+	router.Check(context.Background())
+	assert.True(t, first.Healthy())
+	assert.False(t, down.Healthy())
+
+	ctx := context.Background()
+	assert.Equal(t, primary, daos.GetReadExecutor(ctx, nil), "no router")
+
+	daos.SetRouter(router)
+	defer daos.SetRouter(nil)
+	// round robin over the healthy replicas
+	picked := map[boil.ContextExecutor]int{}
+	for i := 0; i < 4; i++ {
+		picked[daos.GetReadExecutor(ctx, nil)]++
+	}
+	assert.Equal(t, map[boil.ContextExecutor]int{first.DB: 2, second.DB: 2}, picked)
+
+	tx := &sql.Tx{}
+	assert.Equal(t, tx, daos.GetReadExecutor(ctx, tx), "transaction")
+
+	session := daos.WithSession(ctx)
+	assert.NotEqual(t, primary, daos.GetReadExecutor(session, nil), "session before a write")
+	daos.MarkWrite(session)
+	assert.Equal(t, primary, daos.GetReadExecutor(session, nil), "session after a write")
+	assert.NotEqual(t, primary, daos.GetReadExecutor(ctx, nil), "other request")
+}
+
+func TestRouterFallsBackToPrimary(t *testing.T) {
+	_, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	replica, mock := newReplica(t, "replica", errors.New("connection refused"))
+	mock.ExpectClose()
+	router := daos.NewRouter([]*daos.Replica{replica}, time.Second)
+	router.Start(time.Hour)
+	daos.SetRouter(router)
+	defer daos.SetRouter(nil)
+
+	assert.Equal(t, boil.GetContextDB(), daos.GetReadExecutor(context.Background(), nil))
+	assert.Nil(t, router.Stop(context.Background()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
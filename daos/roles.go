@@ -6,7 +6,9 @@ import (
 
 	"go-template/models"
 
+	"github.com/lib/pq"
 	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 // CreateRoleTx ...
@@ -22,8 +24,54 @@ func CreateRole(role models.Role, ctx context.Context) (models.Role, error) {
 	return CreateRoleTx(role, ctx, nil)
 }
 
+// UpsertRoleTx creates the role or updates the access level of the role of the same name
+func UpsertRoleTx(role models.Role, ctx context.Context, tx *sql.Tx) (models.Role, error) {
+	contextExecutor := GetContextExecutor(tx)
+
+	err := role.Upsert(ctx, contextExecutor, true, []string{models.RoleColumns.Name},
+		boil.Whitelist(models.RoleColumns.AccessLevel, models.RoleColumns.UpdatedAt), boil.Infer())
+	return role, err
+}
+
+// FindRoleByNameTx ...
+func FindRoleByNameTx(name string, ctx context.Context, tx *sql.Tx) (*models.Role, error) {
+	contextExecutor := GetContextExecutor(tx)
+	return models.Roles(models.RoleWhere.Name.EQ(name)).One(ctx, contextExecutor)
+}
+
+// CopyRolesTx inserts roles with a COPY, which is faster than inserts for large batches but
+// doesn't return the ids of the roles
+func CopyRolesTx(roles []models.Role, ctx context.Context, tx *sql.Tx) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(models.TableNames.Roles, models.RoleColumns.AccessLevel,
+		models.RoleColumns.Name, models.RoleColumns.CreatedAt, models.RoleColumns.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, r := range roles {
+		if _, err := stmt.ExecContext(ctx, r.AccessLevel, r.Name, r.CreatedAt, r.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	// flushes the rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// FindRolesByNamesTx ...
+func FindRolesByNamesTx(names []string, ctx context.Context, tx *sql.Tx) (models.RoleSlice, error) {
+	contextExecutor := GetContextExecutor(tx)
+	return models.Roles(models.RoleWhere.Name.IN(names), qm.OrderBy(models.RoleColumns.ID)).All(ctx, contextExecutor)
+}
+
 // FindRoleByID ...
 func FindRoleByID(roleID int, ctx context.Context) (*models.Role, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	return models.FindRole(ctx, contextExecutor, roleID)
 }
+
+// FindAllRoles returns the roles ordered by id
+func FindAllRoles(ctx context.Context) (models.RoleSlice, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	return models.Roles(qm.OrderBy(models.RoleColumns.ID)).All(ctx, contextExecutor)
+}
@@ -2,10 +2,12 @@ package daos_test
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"go-template/daos"
 	"go-template/internal/config"
 	"go-template/models"
+	"go-template/testutls"
 	"regexp"
 	"testing"
 
@@ -105,3 +107,27 @@ func TestFindRoleByID(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyRolesTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(
+		`COPY "roles" ("access_level", "name", "created_at", "updated_at") FROM STDIN`))
+	prepared.ExpectExec().WithArgs(300, "GENERATED_ROLE_1", nil, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" WHERE ("roles"."name" IN ($1)) ORDER BY id;`)).
+		WithArgs("GENERATED_ROLE_1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_level", "name"}).AddRow(6, 300, "GENERATED_ROLE_1"))
+	mock.ExpectCommit()
+
+	tx, err := boil.GetDB().(*sql.DB).Begin()
+	assert.Nil(t, err)
+	assert.Nil(t, daos.CopyRolesTx([]models.Role{{Name: "GENERATED_ROLE_1", AccessLevel: 300}}, context.Background(), tx))
+	roles, err := daos.FindRolesByNamesTx([]string{"GENERATED_ROLE_1"}, context.Background(), tx)
+	assert.Nil(t, err)
+	assert.Len(t, roles, 1)
+	assert.Equal(t, 6, roles[0].ID)
+	assert.Nil(t, tx.Commit())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
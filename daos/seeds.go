@@ -0,0 +1,49 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Seed is a row of the seeds table, it records a fixture applied by the seeder
+type Seed struct {
+	Name        string
+	Environment string
+	Checksum    string
+	AppliedAt   time.Time
+}
+
+// FindSeedTx returns the record of the fixture name of environment, sql.ErrNoRows when it
+// was never applied
+func FindSeedTx(name, environment string, ctx context.Context, tx *sql.Tx) (*Seed, error) {
+	contextExecutor := GetContextExecutor(tx)
+	var s Seed
+	err := contextExecutor.QueryRowContext(ctx,
+		`SELECT name, environment, checksum, applied_at FROM seeds WHERE name = $1 AND environment = $2`,
+		name, environment).Scan(&s.Name, &s.Environment, &s.Checksum, &s.AppliedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpsertSeedTx records that the fixture of seed was applied
+func UpsertSeedTx(seed Seed, ctx context.Context, tx *sql.Tx) error {
+	contextExecutor := GetContextExecutor(tx)
+	_, err := contextExecutor.ExecContext(ctx,
+		`INSERT INTO seeds (name, environment, checksum) VALUES ($1, $2, $3)
+		ON CONFLICT (name, environment) DO UPDATE SET checksum = excluded.checksum, applied_at = now()`,
+		seed.Name, seed.Environment, seed.Checksum)
+	return err
+}
+
+// DeleteSeedsTx forgets every applied fixture so that they are all applied again
+func DeleteSeedsTx(ctx context.Context, tx *sql.Tx) (int64, error) {
+	contextExecutor := GetContextExecutor(tx)
+	res, err := contextExecutor.ExecContext(ctx, `DELETE FROM seeds`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
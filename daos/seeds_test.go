@@ -0,0 +1,106 @@
+package daos_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/models"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestFindSeedTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	query := regexp.QuoteMeta(`SELECT name, environment, checksum, applied_at FROM seeds WHERE name = $1 AND environment = $2`)
+	mock.ExpectQuery(query).WithArgs("roles", "local").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "environment", "checksum", "applied_at"}).
+			AddRow("roles", "local", "abc", now))
+	mock.ExpectQuery(query).WithArgs("users", "local").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "environment", "checksum", "applied_at"}))
+
+	seed, err := daos.FindSeedTx("roles", "local", context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &daos.Seed{Name: "roles", Environment: "local", Checksum: "abc", AppliedAt: now}, seed)
+
+	_, err = daos.FindSeedTx("users", "local", context.Background(), nil)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSeedTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`ON CONFLICT (name, environment) DO UPDATE SET checksum = excluded.checksum`)).
+		WithArgs("roles", "local", "abc").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := daos.UpsertSeedTx(daos.Seed{Name: "roles", Environment: "local", Checksum: "abc"}, context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSeedsTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM seeds`)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := daos.DeleteSeedsTx(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertRoleTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT ("name") DO UPDATE SET "access_level" = EXCLUDED."access_level"`)).
+		WithArgs(100, "SUPER_ADMIN", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(3, null.Time{}))
+
+	role, err := daos.UpsertRoleTx(models.Role{Name: "SUPER_ADMIN", AccessLevel: 100}, context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, role.ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestFindRoleByNameTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" WHERE ("roles"."name" = $1) LIMIT 1;`)).
+		WithArgs("USER").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_level", "name"}).AddRow(5, 200, "USER"))
+
+	role, err := daos.FindRoleByNameTx("USER", context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, role.ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertUserTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	// the password is only inserted, it isn't updated on conflict
+	mock.ExpectQuery(`ON CONFLICT \("username"\) DO UPDATE SET "first_name" = EXCLUDED."first_name",` +
+		`"last_name" = EXCLUDED."last_name","email" = EXCLUDED."email","mobile" = EXCLUDED."mobile",` +
+		`"address" = EXCLUDED."address","active" = EXCLUDED."active","role_id" = EXCLUDED."role_id",` +
+		`"updated_at" = EXCLUDED."updated_at" RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	user, err := daos.UpsertUserTx(models.User{Username: null.StringFrom("admin"), Password: null.StringFrom("hash"),
+		Email: null.StringFrom("admin@mail.com"), FirstName: null.StringFrom("Admin"), LastName: null.StringFrom("Admin"),
+		Mobile: null.StringFrom("1"), Address: null.StringFrom("Pune"), Active: null.BoolFrom(true),
+		RoleID: null.IntFrom(1), LastLogin: null.TimeFrom(time.Now()), LastPasswordChange: null.TimeFrom(time.Now()),
+		Token: null.StringFrom("t"), DeletedAt: null.TimeFrom(time.Now()),
+		TokenIssuedAt: null.TimeFrom(time.Now())}, context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, user.ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
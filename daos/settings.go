@@ -0,0 +1,68 @@
+package daos
+
+import (
+	"context"
+	"time"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Setting is a row of the settings table, it overrides the default of a runtime setting
+type Setting struct {
+	Key       string
+	Value     string
+	UpdatedBy null.Int
+	UpdatedAt time.Time
+}
+
+const settingColumns = `key, value, updated_by, updated_at`
+
+func scanSetting(row interface{ Scan(...interface{}) error }) (Setting, error) {
+	var s Setting
+	err := row.Scan(&s.Key, &s.Value, &s.UpdatedBy, &s.UpdatedAt)
+	return s, err
+}
+
+// FindAllSettings reads the settings from the primary, they are cached by the instances
+// which must see the latest updates
+func FindAllSettings(ctx context.Context) ([]Setting, error) {
+	contextExecutor := GetContextExecutor(nil)
+	rows, err := contextExecutor.QueryContext(ctx, `SELECT `+settingColumns+` FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var settings []Setting
+	for rows.Next() {
+		s, err := scanSetting(rows)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, s)
+	}
+	return settings, rows.Err()
+}
+
+// UpsertSetting sets the value of a setting
+func UpsertSetting(setting Setting, ctx context.Context) (*Setting, error) {
+	contextExecutor := GetContextExecutor(nil)
+	s, err := scanSetting(contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO settings (key, value, updated_by) VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_by = excluded.updated_by, updated_at = now()
+		RETURNING `+settingColumns,
+		setting.Key, setting.Value, setting.UpdatedBy))
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteSetting deletes the value of a setting, which falls back to its default
+func DeleteSetting(key string, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx, `DELETE FROM settings WHERE key = $1`, key)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package daos_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+var settingRowColumns = []string{"key", "value", "updated_by", "updated_at"}
+
+func TestFindAllSettings(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT key, value, updated_by, updated_at FROM settings ORDER BY key`)).
+		WillReturnRows(sqlmock.NewRows(settingRowColumns).
+			AddRow("log.level", "warn", 1, now).
+			AddRow("throttle.limit", "10", nil, now))
+
+	settings, err := daos.FindAllSettings(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []daos.Setting{
+		{Key: "log.level", Value: "warn", UpdatedBy: null.IntFrom(1), UpdatedAt: now},
+		{Key: "throttle.limit", Value: "10", UpdatedAt: now},
+	}, settings)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertSetting(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO settings (key, value, updated_by)`)).
+		WithArgs("throttle.limit", "10", null.IntFrom(1)).
+		WillReturnRows(sqlmock.NewRows(settingRowColumns).AddRow("throttle.limit", "10", 1, now))
+
+	setting, err := daos.UpsertSetting(daos.Setting{Key: "throttle.limit", Value: "10", UpdatedBy: null.IntFrom(1)},
+		context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &daos.Setting{Key: "throttle.limit", Value: "10", UpdatedBy: null.IntFrom(1), UpdatedAt: now}, setting)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSetting(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM settings WHERE key = $1`)).WithArgs("log.level").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := daos.DeleteSetting("log.level", context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
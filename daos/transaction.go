@@ -0,0 +1,38 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// WithTx runs fn as a unit of work: the transaction it is given is committed when fn
+// succeeds and rolled back when it returns an error or panics. Pass the transaction to the
+// *Tx variants of the daos so that their writes are all or nothing. The following reads of
+// the session of ctx go to the primary.
+func WithTx(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
+	MarkWrite(ctx)
+	beginner, ok := boil.GetContextDB().(boil.ContextBeginner)
+	if !ok {
+		return fmt.Errorf("database does not support transactions")
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+package daos_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTx(t *testing.T) {
+	cases := []struct {
+		name      string
+		fn        func(tx *sql.Tx) error
+		expect    func(mock sqlmock.Sqlmock)
+		wantErr   bool
+		wantPanic bool
+	}{
+		{
+			name: "Commits on success",
+			fn: func(tx *sql.Tx) error {
+				_, err := tx.Exec("DELETE FROM users")
+				return err
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Rolls back on error",
+			fn: func(tx *sql.Tx) error {
+				return errors.New("error")
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+		{
+			name: "Rolls back on panic",
+			fn: func(tx *sql.Tx) error {
+				panic("boom")
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectRollback()
+			},
+			wantPanic: true,
+		},
+		{
+			name: "Fails to begin",
+			fn: func(tx *sql.Tx) error {
+				return nil
+			},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin().WillReturnError(errors.New("error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			tt.expect(mock)
+
+			run := func() {
+				err := daos.WithTx(context.Background(), tt.fn)
+				assert.Equal(t, tt.wantErr, err != nil)
+			}
+			if tt.wantPanic {
+				assert.Panics(t, run)
+			} else {
+				run()
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -4,37 +4,39 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"go-template/models"
 
+	"github.com/lib/pq"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 // FindUserByUserName finds user by username
 func FindUserByUserName(username string, ctx context.Context) (*models.User, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	return models.Users(qm.Where(fmt.Sprintf("%s=?", models.UserColumns.Username), username)).
 		One(ctx, contextExecutor)
 }
 
 // FindUserByEmail ...
 func FindUserByEmail(email string, ctx context.Context) (*models.User, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	return models.Users(qm.Where(fmt.Sprintf("%s=?", models.UserColumns.Email), email)).
 		One(ctx, contextExecutor)
 }
 
 // FindUserByToken ...
 func FindUserByToken(token string, ctx context.Context) (*models.User, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	return models.Users(qm.Where(fmt.Sprintf("%s=?", models.UserColumns.Token), token)).
 		One(ctx, contextExecutor)
 }
 
 // FindUserByID ...
 func FindUserByID(userID int, ctx context.Context) (*models.User, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	return models.FindUser(ctx, contextExecutor, userID)
 }
 
@@ -51,6 +53,40 @@ func CreateUser(user models.User, ctx context.Context) (models.User, error) {
 	return CreateUserTx(user, ctx, nil)
 }
 
+// UpsertUserTx creates the user or updates the profile and the role of the user of the same
+// username, the password of an existing user is kept
+func UpsertUserTx(user models.User, ctx context.Context, tx *sql.Tx) (models.User, error) {
+	contextExecutor := GetContextExecutor(tx)
+
+	err := user.Upsert(ctx, contextExecutor, true, []string{models.UserColumns.Username},
+		boil.Whitelist(models.UserColumns.FirstName, models.UserColumns.LastName, models.UserColumns.Email,
+			models.UserColumns.Mobile, models.UserColumns.Address, models.UserColumns.Active,
+			models.UserColumns.RoleID, models.UserColumns.UpdatedAt), boil.Infer())
+	return user, err
+}
+
+// CopyUsersTx inserts users with a COPY, which is faster than inserts for large batches but
+// doesn't return the ids of the users
+func CopyUsersTx(users []models.User, ctx context.Context, tx *sql.Tx) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(models.TableNames.Users, models.UserColumns.FirstName,
+		models.UserColumns.LastName, models.UserColumns.Username, models.UserColumns.Password,
+		models.UserColumns.Email, models.UserColumns.Mobile, models.UserColumns.Address, models.UserColumns.Active,
+		models.UserColumns.RoleID, models.UserColumns.CreatedAt, models.UserColumns.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, u := range users {
+		if _, err := stmt.ExecContext(ctx, u.FirstName, u.LastName, u.Username, u.Password, u.Email, u.Mobile,
+			u.Address, u.Active, u.RoleID, u.CreatedAt, u.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	// flushes the rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
 // UpdateUserTx ...
 func UpdateUserTx(user models.User, ctx context.Context, tx *sql.Tx) (models.User, error) {
 	contextExecutor := GetContextExecutor(tx)
@@ -63,16 +99,21 @@ func UpdateUser(user models.User, ctx context.Context) (models.User, error) {
 	return UpdateUserTx(user, ctx, nil)
 }
 
-// DeleteUser ...
-func DeleteUser(user models.User, ctx context.Context) (int64, error) {
-	contextExecutor := GetContextExecutor(nil)
+// DeleteUserTx ...
+func DeleteUserTx(user models.User, ctx context.Context, tx *sql.Tx) (int64, error) {
+	contextExecutor := GetContextExecutor(tx)
 	rowsAffected, err := user.Delete(ctx, contextExecutor)
 	return rowsAffected, err
 }
 
+// DeleteUser ...
+func DeleteUser(user models.User, ctx context.Context) (int64, error) {
+	return DeleteUserTx(user, ctx, nil)
+}
+
 // FindAllUsersWithCount ... This will get all the users that match the queryMod filter and also return the count
 func FindAllUsersWithCount(queryMods []qm.QueryMod, ctx context.Context) (models.UserSlice, int64, error) {
-	contextExecutor := GetContextExecutor(nil)
+	contextExecutor := GetReadExecutor(ctx, nil)
 	users, err := models.Users(queryMods...).All(ctx, contextExecutor)
 	if err != nil {
 		return models.UserSlice{}, 0, err
@@ -81,3 +122,16 @@ func FindAllUsersWithCount(queryMods []qm.QueryMod, ctx context.Context) (models
 	count, err := models.Users(queryMods...).Count(ctx, contextExecutor)
 	return users, count, err
 }
+
+// ClearTokensIssuedBefore removes the refresh tokens issued before t
+func ClearTokensIssuedBefore(t time.Time, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx,
+		fmt.Sprintf(`UPDATE users SET %s = NULL, %s = NULL WHERE %s IS NOT NULL AND %s < $1`,
+			models.UserColumns.Token, models.UserColumns.TokenIssuedAt, models.UserColumns.Token,
+			models.UserColumns.TokenIssuedAt), t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
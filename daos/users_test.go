@@ -2,6 +2,7 @@ package daos_test
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"regexp"
@@ -14,6 +15,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
@@ -70,6 +72,7 @@ func testCreateUser(t *testing.T, tt struct {
 		"token",
 		"role_id",
 		"deleted_at",
+		"token_issued_at",
 	}).AddRow(
 		testutls.MockUser().FirstName,
 		testutls.MockUser().LastName,
@@ -82,6 +85,7 @@ func testCreateUser(t *testing.T, tt struct {
 		testutls.MockUser().Token,
 		testutls.MockUser().RoleID,
 		testutls.MockUser().DeletedAt,
+		testutls.MockUser().TokenIssuedAt,
 	)
 	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "users"`)).
 		WithArgs().
@@ -368,3 +372,25 @@ func TestFindUserByToken(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyUsersTx(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(`COPY "users" ("first_name", "last_name", "username", "password", ` +
+		`"email", "mobile", "address", "active", "role_id", "created_at", "updated_at") FROM STDIN`))
+	prepared.ExpectExec().WithArgs("Jade", "Khan", "jade.khan.1", "hash", "jade.khan.1@example.com", nil, nil, true, 2,
+		nil, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	prepared.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	tx, err := boil.GetDB().(*sql.DB).Begin()
+	assert.Nil(t, err)
+	err = daos.CopyUsersTx([]models.User{{FirstName: null.StringFrom("Jade"), LastName: null.StringFrom("Khan"),
+		Username: null.StringFrom("jade.khan.1"), Password: null.StringFrom("hash"),
+		Email: null.StringFrom("jade.khan.1@example.com"), Active: null.BoolFrom(true), RoleID: null.IntFrom(2)}},
+		context.Background(), tx)
+	assert.Nil(t, err)
+	assert.Nil(t, tx.Commit())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
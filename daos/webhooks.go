@@ -0,0 +1,227 @@
+package daos
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+)
+
+// Webhook delivery statuses, a failed delivery is retried until its job runs out of attempts
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is a row of the webhooks table
+type Webhook struct {
+	ID         int
+	URL        string
+	Secret     string
+	EventTypes []string
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+const webhookColumns = `id, url, secret, event_types, active, created_at, updated_at`
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (Webhook, error) {
+	var w Webhook
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, pq.Array(&w.EventTypes), &w.Active, &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
+
+func scanWebhooks(rows *sql.Rows, err error) ([]Webhook, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var webhooks []Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// CreateWebhook ...
+func CreateWebhook(webhook Webhook, ctx context.Context) (*Webhook, error) {
+	contextExecutor := GetContextExecutor(nil)
+	w, err := scanWebhook(contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO webhooks (url, secret, event_types, active) VALUES ($1, $2, $3, $4) RETURNING `+webhookColumns,
+		webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.Active))
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// UpdateWebhook overwrites the url, secret, event types and active flag of the webhook
+func UpdateWebhook(webhook Webhook, ctx context.Context) (*Webhook, error) {
+	contextExecutor := GetContextExecutor(nil)
+	w, err := scanWebhook(contextExecutor.QueryRowContext(ctx,
+		`UPDATE webhooks SET url = $2, secret = $3, event_types = $4, active = $5, updated_at = now()
+		WHERE id = $1 RETURNING `+webhookColumns,
+		webhook.ID, webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.Active))
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries
+func DeleteWebhook(id int, ctx context.Context) (int64, error) {
+	contextExecutor := GetContextExecutor(nil)
+	res, err := contextExecutor.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// FindWebhookByID ...
+func FindWebhookByID(id int, ctx context.Context) (*Webhook, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	w, err := scanWebhook(contextExecutor.QueryRowContext(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// FindAllWebhooks ...
+func FindAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	return scanWebhooks(contextExecutor.QueryContext(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks ORDER BY id`))
+}
+
+// FindActiveWebhooksForEvent returns the active webhooks subscribed to eventType
+func FindActiveWebhooksForEvent(eventType string, ctx context.Context) ([]Webhook, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	return scanWebhooks(contextExecutor.QueryContext(ctx,
+		`SELECT `+webhookColumns+` FROM webhooks WHERE active AND $1 = ANY(event_types) ORDER BY id`,
+		eventType))
+}
+
+// WebhookDelivery is a row of the webhook_deliveries table, Payload is the body sent to the
+// webhook
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int
+	EventID        null.Int64
+	RedeliveryOf   null.Int64
+	EventType      string
+	Payload        []byte
+	Status         string
+	Attempts       int
+	ResponseStatus null.Int
+	ResponseBody   null.String
+	LastError      null.String
+	DurationMs     null.Int
+	DeliveredAt    null.Time
+	CreatedAt      time.Time
+}
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, redelivery_of, event_type, payload, status, attempts,
+	response_status, response_body, last_error, duration_ms, delivered_at, created_at`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.RedeliveryOf, &d.EventType, &d.Payload, &d.Status,
+		&d.Attempts, &d.ResponseStatus, &d.ResponseBody, &d.LastError, &d.DurationMs, &d.DeliveredAt, &d.CreatedAt)
+	return d, err
+}
+
+func scanWebhookDeliveries(rows *sql.Rows, err error) ([]WebhookDelivery, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// CreateWebhookDeliveryTx adds a pending delivery. An event is delivered once per webhook,
+// when the delivery of the event already exists the returned id is 0.
+func CreateWebhookDeliveryTx(delivery WebhookDelivery, ctx context.Context, tx *sql.Tx) (int64, error) {
+	contextExecutor := GetContextExecutor(tx)
+	var id int64
+	err := contextExecutor.QueryRowContext(ctx,
+		`INSERT INTO webhook_deliveries (webhook_id, event_id, redelivery_of, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING RETURNING id`,
+		delivery.WebhookID, delivery.EventID, delivery.RedeliveryOf, delivery.EventType, delivery.Payload,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// FindWebhookDeliveryByID ...
+func FindWebhookDeliveryByID(id int64, ctx context.Context) (*WebhookDelivery, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	d, err := scanWebhookDelivery(contextExecutor.QueryRowContext(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// FindWebhookDeliveriesWithCount returns the deliveries of a webhook, most recent first, and
+// their count. A zero limit returns all of them.
+func FindWebhookDeliveriesWithCount(webhookID int, limit int, offset int,
+	ctx context.Context) ([]WebhookDelivery, int64, error) {
+	contextExecutor := GetReadExecutor(ctx, nil)
+	var count int64
+	if err := contextExecutor.QueryRowContext(ctx,
+		`SELECT count(*) FROM webhook_deliveries WHERE webhook_id = $1`, webhookID).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+	var lim interface{}
+	if limit > 0 {
+		lim = limit
+	}
+	deliveries, err := scanWebhookDeliveries(contextExecutor.QueryContext(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE webhook_id = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3`, webhookID, lim, offset))
+	return deliveries, count, err
+}
+
+// WebhookAttempt is the outcome of an attempt to deliver a webhook
+type WebhookAttempt struct {
+	Status         string
+	ResponseStatus null.Int
+	ResponseBody   null.String
+	Error          null.String
+	Duration       time.Duration
+}
+
+// RecordWebhookAttempt counts an attempt of the delivery and stores its outcome
+func RecordWebhookAttempt(id int64, attempt WebhookAttempt, ctx context.Context) error {
+	contextExecutor := GetContextExecutor(nil)
+	_, err := contextExecutor.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1, response_status = $3,
+		response_body = $4, last_error = $5, duration_ms = $6,
+		delivered_at = CASE WHEN $2 = 'succeeded' THEN now() END, updated_at = now() WHERE id = $1`,
+		id, attempt.Status, attempt.ResponseStatus, attempt.ResponseBody, attempt.Error,
+		attempt.Duration.Milliseconds())
+	return err
+}
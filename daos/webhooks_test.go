@@ -0,0 +1,127 @@
+package daos_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+var (
+	webhookRowColumns  = []string{"id", "url", "secret", "event_types", "active", "created_at", "updated_at"}
+	deliveryRowColumns = []string{"id", "webhook_id", "event_id", "redelivery_of", "event_type", "payload", "status",
+		"attempts", "response_status", "response_body", "last_error", "duration_ms", "delivered_at", "created_at"}
+)
+
+func TestCreateWebhook(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO webhooks (url, secret, event_types, active)`)).
+		WithArgs("https://example.com/hook", "secret", pq.Array([]string{"user.created"}), true).
+		WillReturnRows(sqlmock.NewRows(webhookRowColumns).
+			AddRow(1, "https://example.com/hook", "secret", "{user.created}", true, now, now))
+
+	webhook, err := daos.CreateWebhook(daos.Webhook{
+		URL: "https://example.com/hook", Secret: "secret", EventTypes: []string{"user.created"}, Active: true,
+	}, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, &daos.Webhook{
+		ID: 1, URL: "https://example.com/hook", Secret: "secret", EventTypes: []string{"user.created"}, Active: true,
+		CreatedAt: now, UpdatedAt: now,
+	}, webhook)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestFindActiveWebhooksForEvent(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`WHERE active AND $1 = ANY(event_types)`)).WithArgs("role.deleted").
+		WillReturnRows(sqlmock.NewRows(webhookRowColumns).
+			AddRow(1, "https://a.example.com", "s1", "{role.deleted}", true, now, now).
+			AddRow(2, "https://b.example.com", "s2", "{role.created,role.deleted}", true, now, now))
+
+	webhooks, err := daos.FindActiveWebhooksForEvent("role.deleted", context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, webhooks, 2)
+	assert.Equal(t, []string{"role.created", "role.deleted"}, webhooks[1].EventTypes)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateWebhookDeliveryTx(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+		want int64
+	}{
+		{name: "Success", rows: sqlmock.NewRows([]string{"id"}).AddRow(5), want: 5},
+		{name: "Already delivered", rows: sqlmock.NewRows([]string{"id"}), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING`)).
+				WithArgs(1, null.Int64From(9), null.Int64{}, "user.created", []byte(`{}`)).
+				WillReturnRows(tt.rows)
+
+			id, err := daos.CreateWebhookDeliveryTx(daos.WebhookDelivery{
+				WebhookID: 1, EventID: null.Int64From(9), EventType: "user.created", Payload: []byte(`{}`),
+			}, context.Background(), nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, id)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFindWebhookDeliveriesWithCount(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM webhook_deliveries WHERE webhook_id = $1`)).
+		WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	mock.ExpectQuery(regexp.QuoteMeta(`ORDER BY id DESC LIMIT $2 OFFSET $3`)).WithArgs(1, 10, 10).
+		WillReturnRows(sqlmock.NewRows(deliveryRowColumns).
+			AddRow(3, 1, 9, nil, "user.created", []byte(`{}`), daos.WebhookDeliverySucceeded, 1, 200, "ok", nil,
+				35, now, now))
+
+	deliveries, count, err := daos.FindWebhookDeliveriesWithCount(1, 10, 10, context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), count)
+	assert.Equal(t, []daos.WebhookDelivery{{
+		ID: 3, WebhookID: 1, EventID: null.Int64From(9), EventType: "user.created", Payload: []byte(`{}`),
+		Status: daos.WebhookDeliverySucceeded, Attempts: 1, ResponseStatus: null.IntFrom(200),
+		ResponseBody: null.StringFrom("ok"), DurationMs: null.IntFrom(35), DeliveredAt: null.TimeFrom(now),
+		CreatedAt: now,
+	}}, deliveries)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordWebhookAttempt(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1`)).
+		WithArgs(3, daos.WebhookDeliveryFailed, null.IntFrom(500), null.StringFrom("oops"),
+			null.StringFrom("webhook responded with 500"), int64(120)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := daos.RecordWebhookAttempt(3, daos.WebhookAttempt{
+		Status:         daos.WebhookDeliveryFailed,
+		ResponseStatus: null.IntFrom(500),
+		ResponseBody:   null.StringFrom("oops"),
+		Error:          null.StringFrom("webhook responded with 500"),
+		Duration:       120 * time.Millisecond,
+	}, context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,12 @@
 
 package gqlmodels
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
 type BooleanFilter struct {
 	IsTrue  *bool `json:"isTrue"`
 	IsFalse *bool `json:"isFalse"`
@@ -12,6 +18,37 @@ type ChangePasswordResponse struct {
 	Ok bool `json:"ok"`
 }
 
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// BOOLEAN flags are on for everyone, or only for their targets when they have some.
+	// PERCENTAGE flags are on for their targets and for a stable percentage of the other users.
+	Type          FeatureFlagType `json:"type"`
+	Enabled       bool            `json:"enabled"`
+	Percentage    int             `json:"percentage"`
+	UserIds       []string        `json:"userIds"`
+	Roles         []string        `json:"roles"`
+	Organizations []string        `json:"organizations"`
+	UpdatedBy     *string         `json:"updatedBy"`
+	CreatedAt     int             `json:"createdAt"`
+	UpdatedAt     int             `json:"updatedAt"`
+}
+
+type FeatureFlagDeletePayload struct {
+	Name string `json:"name"`
+}
+
+type FeatureFlagInput struct {
+	Name          string          `json:"name"`
+	Description   *string         `json:"description"`
+	Type          FeatureFlagType `json:"type"`
+	Enabled       bool            `json:"enabled"`
+	Percentage    *int            `json:"percentage"`
+	UserIds       []string        `json:"userIds"`
+	Roles         []string        `json:"roles"`
+	Organizations []string        `json:"organizations"`
+}
+
 type FloatFilter struct {
 	EqualTo           *float64  `json:"equalTo"`
 	NotEqualTo        *float64  `json:"notEqualTo"`
@@ -41,6 +78,34 @@ type IntFilter struct {
 	NotIn             []int `json:"notIn"`
 }
 
+type Job struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	Payload     string    `json:"payload"`
+	Status      JobStatus `json:"status"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"maxAttempts"`
+	RunAt       int       `json:"runAt"`
+	LastError   *string   `json:"lastError"`
+	FinishedAt  *int      `json:"finishedAt"`
+	CreatedAt   int       `json:"createdAt"`
+}
+
+type JobFilter struct {
+	Status *JobStatus `json:"status"`
+	Kind   *string    `json:"kind"`
+}
+
+type JobPagination struct {
+	Limit int `json:"limit"`
+	Page  int `json:"page"`
+}
+
+type JobsPayload struct {
+	Jobs  []*Job `json:"jobs"`
+	Total int    `json:"total"`
+}
+
 type LoginResponse struct {
 	Token        string `json:"token"`
 	RefreshToken string `json:"refreshToken"`
@@ -69,6 +134,14 @@ type RoleDeletePayload struct {
 	ID string `json:"id"`
 }
 
+type RoleEvent struct {
+	Type  EventType `json:"type"`
+	Role  *Role     `json:"role"`
+	Actor *User     `json:"actor"`
+	// Unix time of the change in milliseconds
+	Timestamp int `json:"timestamp"`
+}
+
 type RoleFilter struct {
 	Search *string    `json:"search"`
 	Where  *RoleWhere `json:"where"`
@@ -119,6 +192,27 @@ type RolesUpdatePayload struct {
 	Ok bool `json:"ok"`
 }
 
+type Setting struct {
+	Key         string      `json:"key"`
+	Type        SettingType `json:"type"`
+	Value       string      `json:"value"`
+	Default     string      `json:"default"`
+	Description string      `json:"description"`
+	// The values allowed for ENUM settings
+	AllowedValues []string `json:"allowedValues"`
+	Min           *int     `json:"min"`
+	Max           *int     `json:"max"`
+	// FILE settings are pinned by the settings file and can't be updated
+	Source    SettingSource `json:"source"`
+	UpdatedBy *string       `json:"updatedBy"`
+	UpdatedAt *int          `json:"updatedAt"`
+}
+
+type SettingUpdateInput struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 type StringFilter struct {
 	EqualTo            *string  `json:"equalTo"`
 	NotEqualTo         *string  `json:"notEqualTo"`
@@ -173,6 +267,14 @@ type UserDeletePayload struct {
 	ID string `json:"id"`
 }
 
+type UserEvent struct {
+	Type  EventType `json:"type"`
+	User  *User     `json:"user"`
+	Actor *User     `json:"actor"`
+	// Unix time of the change in milliseconds
+	Timestamp int `json:"timestamp"`
+}
+
 type UserFilter struct {
 	Search *string    `json:"search"`
 	Where  *UserWhere `json:"where"`
@@ -224,3 +326,373 @@ type UsersPayload struct {
 	Users []*User `json:"users"`
 	Total int     `json:"total"`
 }
+
+type Webhook struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+	Active     bool               `json:"active"`
+	CreatedAt  int                `json:"createdAt"`
+	UpdatedAt  int                `json:"updatedAt"`
+}
+
+type WebhookCreateInput struct {
+	URL        string             `json:"url"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+	Secret     *string            `json:"secret"`
+	Active     *bool              `json:"active"`
+}
+
+type WebhookDeletePayload struct {
+	ID string `json:"id"`
+}
+
+type WebhookDeliveriesPayload struct {
+	Deliveries []*WebhookDelivery `json:"deliveries"`
+	Total      int                `json:"total"`
+}
+
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	WebhookID      string                `json:"webhookId"`
+	EventID        *string               `json:"eventId"`
+	RedeliveryOf   *string               `json:"redeliveryOf"`
+	EventType      WebhookEventType      `json:"eventType"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus"`
+	ResponseBody   *string               `json:"responseBody"`
+	LastError      *string               `json:"lastError"`
+	DurationMs     *int                  `json:"durationMs"`
+	DeliveredAt    *int                  `json:"deliveredAt"`
+	CreatedAt      int                   `json:"createdAt"`
+}
+
+type WebhookDeliveryPagination struct {
+	Limit int `json:"limit"`
+	Page  int `json:"page"`
+}
+
+type WebhookPayload struct {
+	Webhook *Webhook `json:"webhook"`
+	// The signing secret, only returned when it is set or generated
+	Secret *string `json:"secret"`
+}
+
+type WebhookUpdateInput struct {
+	ID         string             `json:"id"`
+	URL        *string            `json:"url"`
+	EventTypes []WebhookEventType `json:"eventTypes"`
+	Secret     *string            `json:"secret"`
+	Active     *bool              `json:"active"`
+}
+
+type EventType string
+
+const (
+	EventTypeCreated EventType = "CREATED"
+	EventTypeUpdated EventType = "UPDATED"
+	EventTypeDeleted EventType = "DELETED"
+)
+
+var AllEventType = []EventType{
+	EventTypeCreated,
+	EventTypeUpdated,
+	EventTypeDeleted,
+}
+
+func (e EventType) IsValid() bool {
+	switch e {
+	case EventTypeCreated, EventTypeUpdated, EventTypeDeleted:
+		return true
+	}
+	return false
+}
+
+func (e EventType) String() string {
+	return string(e)
+}
+
+func (e *EventType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EventType", str)
+	}
+	return nil
+}
+
+func (e EventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type FeatureFlagType string
+
+const (
+	FeatureFlagTypeBoolean    FeatureFlagType = "BOOLEAN"
+	FeatureFlagTypePercentage FeatureFlagType = "PERCENTAGE"
+)
+
+var AllFeatureFlagType = []FeatureFlagType{
+	FeatureFlagTypeBoolean,
+	FeatureFlagTypePercentage,
+}
+
+func (e FeatureFlagType) IsValid() bool {
+	switch e {
+	case FeatureFlagTypeBoolean, FeatureFlagTypePercentage:
+		return true
+	}
+	return false
+}
+
+func (e FeatureFlagType) String() string {
+	return string(e)
+}
+
+func (e *FeatureFlagType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FeatureFlagType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FeatureFlagType", str)
+	}
+	return nil
+}
+
+func (e FeatureFlagType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "PENDING"
+	JobStatusRunning   JobStatus = "RUNNING"
+	JobStatusSucceeded JobStatus = "SUCCEEDED"
+	JobStatusDead      JobStatus = "DEAD"
+)
+
+var AllJobStatus = []JobStatus{
+	JobStatusPending,
+	JobStatusRunning,
+	JobStatusSucceeded,
+	JobStatusDead,
+}
+
+func (e JobStatus) IsValid() bool {
+	switch e {
+	case JobStatusPending, JobStatusRunning, JobStatusSucceeded, JobStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e JobStatus) String() string {
+	return string(e)
+}
+
+func (e *JobStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = JobStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid JobStatus", str)
+	}
+	return nil
+}
+
+func (e JobStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettingSource string
+
+const (
+	SettingSourceDefault  SettingSource = "DEFAULT"
+	SettingSourceDatabase SettingSource = "DATABASE"
+	SettingSourceFile     SettingSource = "FILE"
+)
+
+var AllSettingSource = []SettingSource{
+	SettingSourceDefault,
+	SettingSourceDatabase,
+	SettingSourceFile,
+}
+
+func (e SettingSource) IsValid() bool {
+	switch e {
+	case SettingSourceDefault, SettingSourceDatabase, SettingSourceFile:
+		return true
+	}
+	return false
+}
+
+func (e SettingSource) String() string {
+	return string(e)
+}
+
+func (e *SettingSource) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettingSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettingSource", str)
+	}
+	return nil
+}
+
+func (e SettingSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettingType string
+
+const (
+	SettingTypeInt    SettingType = "INT"
+	SettingTypeBool   SettingType = "BOOL"
+	SettingTypeString SettingType = "STRING"
+	SettingTypeEnum   SettingType = "ENUM"
+)
+
+var AllSettingType = []SettingType{
+	SettingTypeInt,
+	SettingTypeBool,
+	SettingTypeString,
+	SettingTypeEnum,
+}
+
+func (e SettingType) IsValid() bool {
+	switch e {
+	case SettingTypeInt, SettingTypeBool, SettingTypeString, SettingTypeEnum:
+		return true
+	}
+	return false
+}
+
+func (e SettingType) String() string {
+	return string(e)
+}
+
+func (e *SettingType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettingType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettingType", str)
+	}
+	return nil
+}
+
+func (e SettingType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusSucceeded,
+	WebhookDeliveryStatusFailed,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusSucceeded, WebhookDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookEventType string
+
+const (
+	WebhookEventTypeUserCreated WebhookEventType = "USER_CREATED"
+	WebhookEventTypeUserUpdated WebhookEventType = "USER_UPDATED"
+	WebhookEventTypeUserDeleted WebhookEventType = "USER_DELETED"
+	WebhookEventTypeRoleCreated WebhookEventType = "ROLE_CREATED"
+	WebhookEventTypeRoleUpdated WebhookEventType = "ROLE_UPDATED"
+	WebhookEventTypeRoleDeleted WebhookEventType = "ROLE_DELETED"
+)
+
+var AllWebhookEventType = []WebhookEventType{
+	WebhookEventTypeUserCreated,
+	WebhookEventTypeUserUpdated,
+	WebhookEventTypeUserDeleted,
+	WebhookEventTypeRoleCreated,
+	WebhookEventTypeRoleUpdated,
+	WebhookEventTypeRoleDeleted,
+}
+
+func (e WebhookEventType) IsValid() bool {
+	switch e {
+	case WebhookEventTypeUserCreated, WebhookEventTypeUserUpdated, WebhookEventTypeUserDeleted, WebhookEventTypeRoleCreated, WebhookEventTypeRoleUpdated, WebhookEventTypeRoleDeleted:
+		return true
+	}
+	return false
+}
+
+func (e WebhookEventType) String() string {
+	return string(e)
+}
+
+func (e *WebhookEventType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookEventType", str)
+	}
+	return nil
+}
+
+func (e WebhookEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
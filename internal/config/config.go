@@ -1,55 +1,8 @@
 package config
 
-import (
-	"fmt"
-	"os"
-
-	"go-template/pkg/utl/convert"
-)
-
-// Load returns Configuration struct
-func Load() (*Configuration, error) {
-	cfg := &Configuration{
-		Server: &Server{
-			Port:         fmt.Sprintf(":%d", convert.StringToInt(os.Getenv("SERVER_PORT"))),
-			Debug:        convert.StringToBool(os.Getenv("SERVER_DEBUG")),
-			ReadTimeout:  convert.StringToInt(os.Getenv("SERVER_READ_TIMEOUT")),
-			WriteTimeout: convert.StringToInt(os.Getenv("SERVER_WRITE_TIMEOUT")),
-		},
-		DB: &Database{
-			LogQueries: convert.StringToBool(os.Getenv("DB_LOG_QUERIES")),
-			Timeout:    convert.StringToInt(os.Getenv("DB_TIMEOUT_SECONDS")),
-		},
-		JWT: &JWT{
-			MinSecretLength:  convert.StringToInt(os.Getenv("JWT_MIN_SECRET_LENGTH")),
-			DurationMinutes:  convert.StringToInt(os.Getenv("JWT_DURATION_MINUTES")),
-			RefreshDuration:  convert.StringToInt(os.Getenv("JWT_REFRESH_DURATION")),
-			MaxRefresh:       convert.StringToInt(os.Getenv("JWT_MAX_REFRESH")),
-			SigningAlgorithm: os.Getenv("JWT_SIGNING_ALGORITHM"),
-		},
-		App: &Application{
-			MinPasswordStr: convert.StringToInt(os.Getenv("APP_MIN_PASSWORD_STR")),
-		},
-	}
-	if len(os.Getenv("SERVER_PORT")) == 0 {
-		return nil, fmt.Errorf("error loading port from .env")
-	}
-	if len(os.Getenv("DB_TIMEOUT_SECONDS")) == 0 {
-		return nil, fmt.Errorf("error loading db timeout from .env")
-	}
-	if len(os.Getenv("JWT_MIN_SECRET_LENGTH")) == 0 {
-		return nil, fmt.Errorf("error loading jwt min secret length from .env")
-	}
-	if len(os.Getenv("APP_MIN_PASSWORD_STR")) == 0 {
-		return nil, fmt.Errorf("error loading application password string from .env")
-	}
-	if len(os.Getenv("SERVER_READ_TIMEOUT")) == 0 || len(os.Getenv("SERVER_WRITE_TIMEOUT")) == 0 {
-		return nil, fmt.Errorf("error loading server timeout from .env")
-	}
-	return cfg, nil
-}
-
-// Configuration holds data necessary for configuring application
+// Configuration holds data necessary for configuring application. Each setting is read
+// from the environment variable of its env tag, falling back to the configuration file and
+// then to its default tag, see Load. The settings tagged secret are redacted when printed.
 type Configuration struct {
 	Server *Server      `json:"server,omitempty"`
 	DB     *Database    `json:"database,omitempty"`
@@ -59,28 +12,43 @@ type Configuration struct {
 
 // Database holds data necessary for database configuration
 type Database struct {
-	LogQueries bool `json:"log_queries,omitempty"`
-	Timeout    int  `json:"timeout_seconds,omitempty"`
+	LogQueries         bool `json:"log_queries,omitempty"                env:"DB_LOG_QUERIES"`
+	Timeout            int  `json:"timeout_seconds,omitempty"            env:"DB_TIMEOUT_SECONDS"             default:"5" validate:"min=1"`
+	MaxOpenConns       int  `json:"max_open_conns,omitempty"             env:"DB_MAX_OPEN_CONNS"              validate:"min=0"`
+	MaxIdleConns       int  `json:"max_idle_conns,omitempty"             env:"DB_MAX_IDLE_CONNS"              validate:"min=0"`
+	ConnMaxLifetime    int  `json:"conn_max_lifetime_seconds,omitempty"  env:"DB_CONN_MAX_LIFETIME_SECONDS"   validate:"min=0"`
+	ConnMaxIdleTime    int  `json:"conn_max_idle_time_seconds,omitempty" env:"DB_CONN_MAX_IDLE_TIME_SECONDS"  validate:"min=0"`
+	StatementTimeout   int  `json:"statement_timeout_ms,omitempty"       env:"DB_STATEMENT_TIMEOUT_MS"        validate:"min=0"`
+	SlowQueryThreshold int  `json:"slow_query_ms,omitempty"              env:"DB_SLOW_QUERY_MS"               validate:"min=0"`
+	// ReplicaDSNs are the connection strings of the read replicas, they hold credentials
+	ReplicaDSNs        []string `json:"replica_dsns,omitempty"                 env:"DB_REPLICA_DSNS"                 secret:"true"`
+	ReplicaHealthCheck int      `json:"replica_health_check_seconds,omitempty" env:"DB_REPLICA_HEALTH_CHECK_SECONDS" validate:"min=0"`
 }
 
 // Server holds data necessary for server configuration
 type Server struct {
-	Port         string `json:"port"                  validate:"required"`
-	Debug        bool   `json:"debug"                 validate:"required"`
-	ReadTimeout  int    `json:"read_timeout_seconds"  validate:"required"`
-	WriteTimeout int    `json:"write_timeout_seconds" validate:"required"`
+	Port            string `json:"port"                     env:"SERVER_PORT"             default:":9000" validate:"port"`
+	Debug           bool   `json:"debug"                    env:"SERVER_DEBUG"`
+	ReadTimeout     int    `json:"read_timeout_seconds"     env:"SERVER_READ_TIMEOUT"     default:"10"    validate:"min=1"`
+	WriteTimeout    int    `json:"write_timeout_seconds"    env:"SERVER_WRITE_TIMEOUT"    default:"5"     validate:"min=1"`
+	ShutdownDelay   int    `json:"shutdown_delay_seconds,omitempty"   env:"SERVER_SHUTDOWN_DELAY"   validate:"min=0"`
+	ShutdownTimeout int    `json:"shutdown_timeout_seconds,omitempty" env:"SERVER_SHUTDOWN_TIMEOUT" validate:"min=0"`
+	// MigrateOnStart applies the embedded migrations before serving, see migrations.Migrate
+	MigrateOnStart bool `json:"migrate_on_start,omitempty" env:"MIGRATE_ON_START"`
 }
 
 // JWT holds data necessary for JWT configuration
 type JWT struct {
-	MinSecretLength  int    `json:"min_secret_length"                  validate:"required"`
-	DurationMinutes  int    `json:"duration_minutes,omitempty"`
-	RefreshDuration  int    `json:"refresh_duration_minutes,omitempty"`
-	MaxRefresh       int    `json:"max_refresh_minutes,omitempty"`
-	SigningAlgorithm string `json:"signing_algorithm"                  validate:"required"`
+	// Secret may be left out when it is read from the secrets provider, see secrets.FromEnv
+	Secret           string `json:"secret,omitempty"                   env:"JWT_SECRET"            secret:"true"`
+	MinSecretLength  int    `json:"min_secret_length"                  env:"JWT_MIN_SECRET_LENGTH" default:"64"    validate:"min=1"`
+	DurationMinutes  int    `json:"duration_minutes,omitempty"         env:"JWT_DURATION_MINUTES"  default:"1440"  validate:"min=1"`
+	RefreshDuration  int    `json:"refresh_duration_minutes,omitempty" env:"JWT_REFRESH_DURATION"  validate:"min=0"`
+	MaxRefresh       int    `json:"max_refresh_minutes,omitempty"      env:"JWT_MAX_REFRESH"       default:"1440"  validate:"min=1"`
+	SigningAlgorithm string `json:"signing_algorithm"                  env:"JWT_SIGNING_ALGORITHM" default:"HS256" validate:"oneof=HS256 HS384 HS512"`
 }
 
 // Application holds application configuration details
 type Application struct {
-	MinPasswordStr int `json:"min_password_strength" validate:"required"`
+	MinPasswordStr int `json:"min_password_strength" env:"APP_MIN_PASSWORD_STR" default:"1" validate:"min=0,max=4"`
 }
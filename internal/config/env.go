@@ -1,13 +1,11 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"go-template/pkg/utl/convert"
 	"log"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
 
 	"github.com/joho/godotenv"
@@ -40,6 +38,20 @@ func GetBool(key string) bool {
 	return convert.StringToBool(value)
 }
 
+// GetPositiveInt returns the positive integer of the env var key, 0 when it is unset and an error
+// when it is not a positive integer
+func GetPositiveInt(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", key, value)
+	}
+	return n, nil
+}
+
 func keyNotFound(key string) {
 	fmt.Printf("Key %s not found in %s Returning default value.", key, FileName())
 }
@@ -56,14 +68,23 @@ func FileName() string {
 	return envFileName
 }
 
+// LoadEnv loads .env.base and the .env file of ENVIRONMENT_NAME in the environment, without
+// overriding the variables already set. The files are looked up in the working directory
+// and then in its parents rather than next to the sources, which a binary doesn't ship with.
 func LoadEnv() error {
-	_, filename, _, ok := runtime.Caller(0)
-	if !ok {
-		return fmt.Errorf("Error getting current file path")
+	// the variables of a .env file given with --config take precedence over the project ones
+	if file := os.Getenv(FileEnv); isEnvFile(file) {
+		if err := loadEnvFile(file); err != nil {
+			return err
+		}
+	}
+	dir, err := envDir()
+	if err != nil {
+		return err
 	}
 
-	prefix := fmt.Sprintf("%s/", filepath.Join(filepath.Dir(filename), "../../"))
-	err := godotenv.Load(fmt.Sprintf("%s.env.base", prefix))
+	prefix := dir + string(filepath.Separator)
+	err = godotenv.Load(fmt.Sprintf("%s.env.base", prefix))
 	if err != nil {
 		return err
 	}
@@ -88,28 +109,24 @@ func LoadEnv() error {
 		return nil
 	}
 
-	dbCredsInjected := GetBool("COPILOT_DB_CREDS_VIA_SECRETS_MANAGER")
+	// the variables are injected in the environment, the credentials may come from the
+	// secrets provider, see secrets.FromEnv
+	return nil
+}
 
-	if dbCredsInjected {
-		type copilotSecrets struct {
-			Username string `json:"username"`
-			Host     string `json:"host"`
-			DBName   string `json:"dbname"`
-			Password string `json:"password"`
-			Port     int    `json:"port"`
+// envDir returns the closest directory holding .env.base, starting from the working
+// directory so that the commands and the tests of every package find the project files
+func envDir() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".env.base")); err == nil {
+			return dir, nil
 		}
-		secrets := &copilotSecrets{}
-
-		err := json.Unmarshal([]byte(os.Getenv("DB_SECRET")), secrets)
-		if err != nil {
-			return err
+		if filepath.Dir(dir) == dir {
+			return "", fmt.Errorf(".env.base not found in %s or its parents", wd)
 		}
-
-		os.Setenv("PSQL_DBNAME", secrets.DBName)
-		os.Setenv("PSQL_HOST", secrets.Host)
-		os.Setenv("PSQL_PASS", secrets.Password)
-		os.Setenv("PSQL_PORT", strconv.Itoa(secrets.Port))
-		os.Setenv("PSQL_USER", secrets.Username)
 	}
-	return fmt.Errorf("COPILOT_DB_CREDS_VIA_SECRETS_MANAGER should have had a value")
 }
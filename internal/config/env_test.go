@@ -88,6 +88,32 @@ func TestGetInt(t *testing.T) {
 	}
 }
 
+func TestGetPositiveInt(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr string
+	}{
+		{name: "Unset"},
+		{name: "Success", value: "12", want: 12},
+		{name: "Zero", value: "0", wantErr: `positive_arg must be a positive integer, got "0"`},
+		{name: "NotInteger", value: "ten", wantErr: `positive_arg must be a positive integer, got "ten"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("positive_arg", tt.value)
+			got, err := GetPositiveInt("positive_arg")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestGetBool(t *testing.T) {
 	type args struct {
 		key string
@@ -169,12 +195,7 @@ type args struct {
 }
 
 func TestLoadEnv(t *testing.T) {
-	username := "go_template_role"
-	host := "localhost"
-	dbname := "go_template"
-	password := "go_template_role456"
-	port := "5432"
-	tests := getTestCases(username, host, dbname, password, port)
+	tests := getTestCases()
 	for _, tt := range tests {
 		setEnvironmentVariables(tt.args)
 		defer clearEnvironmentVariables(tt.args)
@@ -231,33 +252,10 @@ func loadLocalEnv() envTestCaseArgs {
 		},
 	}
 }
-func errorOnEnvInjectionAndCopilotFalse() envTestCaseArgs {
-	return envTestCaseArgs{
-		name:    "Error when ENV_INJECTION and COPILOT_DB_CREDS_VIA_SECRETS_MANAGER false",
-		wantErr: true,
-		args: args{
-			setEnv: []keyValueArgs{
-				{
-					key:   "ENV_INJECTION",
-					value: "true",
-				},
-				{
-					key:   "ENVIRONMENT_NAME",
-					value: "develop",
-				},
-				{
-					key:   "COPILOT_DB_CREDS_VIA_SECRETS_MANAGER",
-					value: "false",
-				},
-			},
-		},
-	}
-}
-
-func loadOnDbCredsInjected(username string, host string, dbname string, password string, port string) envTestCaseArgs {
+func loadInjectedEnv() envTestCaseArgs {
 	return envTestCaseArgs{
-		name:    "dbCredsInjected True",
-		wantErr: true,
+		name:    "Successfully keep the injected env",
+		wantErr: false,
 		args: args{
 			setEnv: []keyValueArgs{
 				{
@@ -269,39 +267,14 @@ func loadOnDbCredsInjected(username string, host string, dbname string, password
 					value: "develop",
 				},
 				{
-					key:   "COPILOT_DB_CREDS_VIA_SECRETS_MANAGER",
-					value: "true",
-				},
-				{
-					key: "DB_SECRET",
-					value: fmt.Sprintf(`{"username": "%s", "password": "%s", "port": "%s", "dbname": "%s", "host": "%s"}`,
-						username,
-						password,
-						port,
-						host,
-						dbname),
+					key:   "PSQL_USER",
+					value: "injected_role",
 				},
 			},
 			expectedKeyValues: []keyValueArgs{
-				{
-					key:   "PSQL_PASS",
-					value: password,
-				},
-				{
-					key:   "PSQL_DBNAME",
-					value: dbname,
-				},
-				{
-					key:   "PSQL_HOST",
-					value: host,
-				},
 				{
 					key:   "PSQL_USER",
-					value: username,
-				},
-				{
-					key:   "PSQL_PORT",
-					value: port,
+					value: "injected_role",
 				},
 			},
 		},
@@ -314,6 +287,10 @@ func errorOnWrongEnvName() envTestCaseArgs {
 		wantErr: true,
 		args: args{
 			setEnv: []keyValueArgs{
+				{
+					key:   "ENV_INJECTION",
+					value: "false",
+				},
 				{
 					key:   "ENVIRONMENT_NAME",
 					value: "local1",
@@ -322,12 +299,11 @@ func errorOnWrongEnvName() envTestCaseArgs {
 		},
 	}
 }
-func getTestCases(username string, host string, dbname string, password string, port string) []envTestCaseArgs {
+func getTestCases() []envTestCaseArgs {
 	return []envTestCaseArgs{
 		loadLocalEnvIfNoEnvName(),
 		loadLocalEnv(),
-		errorOnEnvInjectionAndCopilotFalse(),
-		loadOnDbCredsInjected(username, host, dbname, password, port),
+		loadInjectedEnv(),
 		errorOnWrongEnvName(),
 	}
 }
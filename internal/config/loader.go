@@ -0,0 +1,311 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the configuration file when the --config flag isn't given
+const FileEnv = "CONFIG_FILE"
+
+// RegisterFlag adds the --config flag naming the configuration file to fs, it overrides
+// CONFIG_FILE so that every loader of the command reads the file
+func RegisterFlag(fs *flag.FlagSet) {
+	fs.Func("config", "configuration file, a YAML, JSON or .env file", func(file string) error {
+		return os.Setenv(FileEnv, file)
+	})
+}
+
+// redacted replaces the secrets when the configuration is printed
+const redacted = "[REDACTED]"
+
+// Load returns the configuration read from the environment and from the file named by
+// CONFIG_FILE, see LoadFile
+func Load() (*Configuration, error) {
+	return LoadFile(os.Getenv(FileEnv))
+}
+
+// LoadFile returns the configuration read, in decreasing priority, from the environment,
+// from file and from the defaults. file is optional, it is a YAML or JSON file holding the
+// configuration, or a .env file loaded in the environment without overriding it. Every
+// invalid setting is reported in the returned *Error.
+func LoadFile(file string) (*Configuration, error) {
+	cfg := &Configuration{}
+	if err := load(cfg, cfg, file); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadDatabase returns the database configuration, it is loaded on its own by the commands
+// that only need a connection
+func LoadDatabase() (*Database, error) {
+	db := &Database{}
+	if err := load(db, &Configuration{DB: db}, os.Getenv(FileEnv)); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// load fills the structs of v, a *Configuration or one of its sections, then validates it.
+// file is decoded into doc, the *Configuration holding v.
+func load(v interface{}, doc *Configuration, file string) error {
+	var errs []FieldError
+	fields(reflect.ValueOf(v), func(f reflect.StructField, value reflect.Value) {
+		if def, ok := f.Tag.Lookup("default"); ok {
+			_ = set(value, def)
+		}
+	})
+	if file != "" {
+		if err := loadFile(doc, file); err != nil {
+			return err
+		}
+	}
+	fields(reflect.ValueOf(v), func(f reflect.StructField, value reflect.Value) {
+		key := f.Tag.Get("env")
+		raw, ok := os.LookupEnv(key)
+		if key == "" || !ok || raw == "" {
+			return
+		}
+		if err := set(value, raw); err != nil {
+			errs = append(errs, FieldError{Key: key, Message: err.Error()})
+		}
+	})
+	if cfg, ok := v.(*Configuration); ok && cfg.Server != nil {
+		cfg.Server.Port = normalizePort(cfg.Server.Port)
+	}
+	errs = append(errs, validate(v)...)
+	if len(errs) != 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Key < errs[j].Key })
+		return &Error{Fields: errs}
+	}
+	return nil
+}
+
+// loadFile decodes file into cfg according to its extension
+func loadFile(cfg *Configuration, file string) error {
+	if isEnvFile(file) {
+		return loadEnvFile(file)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+	case ".yaml", ".yml":
+		// the structs are tagged for JSON, YAML documents are converted to it
+		var doc interface{}
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if b, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported configuration file, expected YAML, JSON or .env", file)
+	}
+	if err := json.Unmarshal(b, cfg); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// isEnvFile reports whether file is a .env file rather than a YAML or JSON document
+func isEnvFile(file string) bool {
+	return strings.HasPrefix(filepath.Base(file), ".env") || filepath.Ext(file) == ".env"
+}
+
+// loadEnvFile sets the variables of the .env file that are unset or empty in the environment
+func loadEnvFile(file string) error {
+	env, err := godotenv.Read(file)
+	if err != nil {
+		return err
+	}
+	for key, value := range env {
+		if os.Getenv(key) == "" {
+			if err := os.Setenv(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fields calls fn with the settings of the struct v points to, allocating its sections
+func fields(v reflect.Value, fn func(reflect.StructField, reflect.Value)) {
+	v = v.Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f, value := v.Type().Field(i), v.Field(i)
+		if f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct {
+			if value.IsNil() {
+				value.Set(reflect.New(f.Type.Elem()))
+			}
+			fields(value, fn)
+			continue
+		}
+		fn(f, value)
+	}
+}
+
+// set parses raw into value
+func set(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be an integer, got %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("must be a boolean, got %q", raw)
+		}
+		value.SetBool(b)
+	case reflect.Slice:
+		value.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping the empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizePort turns a bare port number into the address the server listens on
+func normalizePort(port string) string {
+	if _, err := strconv.Atoi(port); err == nil {
+		return ":" + port
+	}
+	return port
+}
+
+var validate = newValidator()
+
+// newValidator returns a function validating the settings of a struct, the invalid
+// settings being named after their environment variable
+func newValidator() func(v interface{}) []FieldError {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		return f.Tag.Get("env")
+	})
+	_ = v.RegisterValidation("port", func(fl validator.FieldLevel) bool {
+		_, port, err := net.SplitHostPort(fl.Field().String())
+		if err != nil {
+			return false
+		}
+		n, err := strconv.Atoi(port)
+		return err == nil && n > 0 && n <= 65535
+	})
+	return func(s interface{}) []FieldError {
+		err := v.Struct(s)
+		validationErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			if err != nil {
+				return []FieldError{{Message: err.Error()}}
+			}
+			return nil
+		}
+		var errs []FieldError
+		for _, e := range validationErrs {
+			errs = append(errs, FieldError{Key: e.Field(), Message: message(e)})
+		}
+		return errs
+	}
+}
+
+// message describes the rule e broke
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + e.Param()
+	case "max":
+		return "must be at most " + e.Param()
+	case "oneof":
+		return "must be one of " + e.Param()
+	case "port":
+		return fmt.Sprintf("must be a port between 1 and 65535, got %q", e.Value())
+	}
+	return fmt.Sprintf("failed the %s rule", e.Tag())
+}
+
+// FieldError is an invalid setting
+type FieldError struct {
+	// Key is the environment variable of the setting
+	Key     string
+	Message string
+}
+
+// Error lists every invalid setting of a configuration
+type Error struct {
+	Fields []FieldError
+}
+
+// Error ...
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s %s", f.Key, f.Message))
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+// Redacted returns a copy of c whose secrets are replaced
+func (c *Configuration) Redacted() *Configuration {
+	out := &Configuration{}
+	b, _ := json.Marshal(c)
+	_ = json.Unmarshal(b, out)
+	fields(reflect.ValueOf(out), func(f reflect.StructField, value reflect.Value) {
+		if f.Tag.Get("secret") != "true" {
+			return
+		}
+		switch value.Kind() {
+		case reflect.String:
+			if value.Len() != 0 {
+				value.SetString(redacted)
+			}
+		case reflect.Slice:
+			for i := 0; i < value.Len(); i++ {
+				value.Index(i).SetString(redacted)
+			}
+		}
+	})
+	return out
+}
+
+// String prints c as JSON with its secrets redacted
+func (c *Configuration) String() string {
+	b, _ := json.MarshalIndent(c.Redacted(), "", "  ")
+	return string(b)
+}
+
+// GoString redacts the secrets of c printed with %#v
+func (c *Configuration) GoString() string {
+	return c.String()
+}
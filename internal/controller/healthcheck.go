@@ -3,6 +3,8 @@ package controller
 import (
 	"net/http"
 
+	"go-template/internal/health"
+
 	"github.com/labstack/echo/v4"
 )
 
@@ -13,3 +15,23 @@ type response struct {
 func HealthCheckHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, response{Data: "Go template at your service!🍲"})
 }
+
+// LivenessHandler reports that the process is alive, it doesn't check any dependency
+func LivenessHandler(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, checker.Liveness())
+	}
+}
+
+// ReadinessHandler reports whether the service can serve traffic. It answers 503 when
+// a dependency is down or when the server is shutting down.
+func ReadinessHandler(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := checker.Readiness(c.Request().Context())
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSON(status, report)
+	}
+}
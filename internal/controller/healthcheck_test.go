@@ -0,0 +1,69 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-template/internal/controller"
+	"go-template/internal/health"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		check        health.Check
+		shuttingDown bool
+		wantCode     int
+		wantStatus   health.Status
+	}{
+		{
+			name:       "Ready",
+			check:      func(context.Context) error { return nil },
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusUp,
+		},
+		{
+			name:       "Dependency down",
+			check:      func(context.Context) error { return errors.New("connection refused") },
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+		},
+		{
+			name:         "Shutting down",
+			check:        func(context.Context) error { return nil },
+			shuttingDown: true,
+			wantCode:     http.StatusServiceUnavailable,
+			wantStatus:   health.StatusShuttingDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.New(0)
+			checker.Register("postgres", tt.check)
+			if tt.shuttingDown {
+				checker.SetShuttingDown()
+			}
+			e := echo.New()
+			e.GET("/healthz", controller.LivenessHandler(checker))
+			e.GET("/readyz", controller.ReadinessHandler(checker))
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.wantCode, rec.Code)
+			var report health.Report
+			assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tt.wantStatus, report.Status)
+
+			rec = httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
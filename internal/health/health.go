@@ -0,0 +1,192 @@
+// Package health reports whether the service and the dependencies it needs to serve
+// traffic are available.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-template/internal/version"
+	"go-template/pkg/utl/zaplog"
+)
+
+// DefaultTimeout bounds every dependency check
+const DefaultTimeout = 2 * time.Second
+
+// Status of the service or of one of its dependencies
+type Status string
+
+// Statuses
+const (
+	StatusUp           Status = "up"
+	StatusDown         Status = "down"
+	StatusShuttingDown Status = "shutting_down"
+)
+
+// Check returns an error when the dependency is unavailable
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check. The errors of the failed checks are logged rather
+// than reported, they would leak the hosts and the messages of the dependencies to anyone able
+// to reach the health endpoints.
+type Result struct {
+	Status    Status  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+// Schema is the version of the database schema
+type Schema struct {
+	Version string `json:"version"`
+	// Pending is the number of migrations of the binary not applied yet
+	Pending int `json:"pending"`
+	// Unknown are the migrations applied to the database but missing from the binary
+	Unknown []string `json:"unknown,omitempty"`
+	// Unavailable is set when the version couldn't be read, the error is logged
+	Unavailable bool `json:"unavailable,omitempty"`
+}
+
+// SchemaFunc reads the version of the database schema
+type SchemaFunc func(ctx context.Context) (Schema, error)
+
+// Report is the outcome of all the checks
+type Report struct {
+	Status  Status            `json:"status"`
+	Checks  map[string]Result `json:"checks,omitempty"`
+	Schema  *Schema           `json:"schema,omitempty"`
+	Version version.Info      `json:"version"`
+}
+
+// Checker runs the registered dependency checks
+type Checker struct {
+	timeout      time.Duration
+	mu           sync.RWMutex
+	checks       map[string]Check
+	schema       SchemaFunc
+	shuttingDown int32
+}
+
+// New returns a checker bounding every check by timeout
+func New(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Register adds the check of the dependency name
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// SetSchema makes the readiness report the schema version read by fn. The version is only
+// informative, failing to read it doesn't make the service not ready.
+func (c *Checker) SetSchema(fn SchemaFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schema = fn
+}
+
+// SetShuttingDown marks the service as not ready anymore so that load balancers stop
+// routing new requests to it while in-flight ones are drained
+func (c *Checker) SetShuttingDown() {
+	atomic.StoreInt32(&c.shuttingDown, 1)
+}
+
+// ShuttingDown ...
+func (c *Checker) ShuttingDown() bool {
+	return atomic.LoadInt32(&c.shuttingDown) == 1
+}
+
+// Liveness reports that the process is up, it never checks the dependencies
+func (c *Checker) Liveness() Report {
+	return Report{Status: StatusUp, Version: version.Get()}
+}
+
+// Readiness runs all the checks concurrently and reports the service as up only when
+// every dependency is up
+func (c *Checker) Readiness(ctx context.Context) Report {
+	if c.ShuttingDown() {
+		return Report{Status: StatusShuttingDown, Version: version.Get()}
+	}
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	schema := c.schema
+	c.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: map[string]Result{}, Version: version.Get()}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := c.run(ctx, name, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	if schema != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Schema = c.readSchema(ctx, schema)
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) readSchema(ctx context.Context, fn SchemaFunc) *Schema {
+	// the version is sent before the check returns, it is there whenever the check succeeds
+	versions := make(chan Schema, 1)
+	result := c.run(ctx, "schema", func(ctx context.Context) error {
+		s, err := fn(ctx)
+		if err == nil {
+			versions <- s
+		}
+		return err
+	})
+	if result.Status != StatusUp {
+		return &Schema{Unavailable: true}
+	}
+	s := <-versions
+	return &s
+}
+
+func (c *Checker) run(ctx context.Context, name string, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := Result{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		zaplog.Logger.Warnw("health check failed", "dependency", name, "error", err.Error())
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go-template/internal/health"
+	"go-template/internal/version"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name         string
+		checks       map[string]health.Check
+		shuttingDown bool
+		want         health.Status
+		wantChecks   map[string]health.Status
+	}{
+		{
+			name:       "All dependencies up",
+			checks:     map[string]health.Check{"postgres": up, "redis": up},
+			want:       health.StatusUp,
+			wantChecks: map[string]health.Status{"postgres": health.StatusUp, "redis": health.StatusUp},
+		},
+		{
+			name:       "Dependency down",
+			checks:     map[string]health.Check{"postgres": up, "redis": down},
+			want:       health.StatusDown,
+			wantChecks: map[string]health.Status{"postgres": health.StatusUp, "redis": health.StatusDown},
+		},
+		{
+			name:       "Dependency times out",
+			checks:     map[string]health.Check{"postgres": slow},
+			want:       health.StatusDown,
+			wantChecks: map[string]health.Status{"postgres": health.StatusDown},
+		},
+		{
+			name:         "Shutting down",
+			checks:       map[string]health.Check{"postgres": up},
+			shuttingDown: true,
+			want:         health.StatusShuttingDown,
+			wantChecks:   map[string]health.Status{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.New(50 * time.Millisecond)
+			for name, check := range tt.checks {
+				checker.Register(name, check)
+			}
+			if tt.shuttingDown {
+				checker.SetShuttingDown()
+			}
+			report := checker.Readiness(context.Background())
+			assert.Equal(t, tt.want, report.Status)
+			assert.Equal(t, version.Version, report.Version.Version)
+			got := map[string]health.Status{}
+			for name, result := range report.Checks {
+				got[name] = result.Status
+			}
+			assert.Equal(t, tt.wantChecks, got)
+		})
+	}
+}
+
+func TestReadinessHidesErrors(t *testing.T) {
+	checker := health.New(50 * time.Millisecond)
+	checker.Register("postgres", func(context.Context) error { return errors.New("dial tcp 10.0.3.7:5432: refused") })
+	checker.SetSchema(func(context.Context) (health.Schema, error) {
+		return health.Schema{}, errors.New("dial tcp 10.0.3.7:5432: refused")
+	})
+	body, err := json.Marshal(checker.Readiness(context.Background()))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "10.0.3.7")
+	assert.Contains(t, string(body), `"postgres":{"status":"down"`)
+	assert.Contains(t, string(body), `"schema":{"version":"","pending":0,"unavailable":true}`)
+}
+
+func TestLiveness(t *testing.T) {
+	checker := health.New(0)
+	checker.Register("postgres", func(context.Context) error { return errors.New("connection refused") })
+	report := checker.Liveness()
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Empty(t, report.Checks)
+}
+
+func TestReadinessSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema health.SchemaFunc
+		want   *health.Schema
+	}{
+		{name: "No schema"},
+		{
+			name: "Version",
+			schema: func(context.Context) (health.Schema, error) {
+				return health.Schema{Version: "7_create_feature_flags.sql", Pending: 1}, nil
+			},
+			want: &health.Schema{Version: "7_create_feature_flags.sql", Pending: 1},
+		},
+		{
+			name: "Failure",
+			schema: func(context.Context) (health.Schema, error) {
+				return health.Schema{}, errors.New("connection refused")
+			},
+			want: &health.Schema{Unavailable: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.New(50 * time.Millisecond)
+			if tt.schema != nil {
+				checker.SetSchema(tt.schema)
+			}
+			report := checker.Readiness(context.Background())
+			// the schema is informative, it never makes the service not ready
+			assert.Equal(t, health.StatusUp, report.Status)
+			assert.Equal(t, tt.want, report.Schema)
+		})
+	}
+}
@@ -2,8 +2,10 @@ package jwt
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"go-template/models"
@@ -19,41 +21,86 @@ func New(algo, secret string, ttlMinutes, minSecretLength int) (Service, error)
 	if minSecretLength > 0 {
 		minSecretLen = minSecretLength
 	}
-	if len(secret) < minSecretLen {
-		return Service{}, fmt.Errorf("jwt secret length is %v, which is less than required %v", len(secret), minSecretLen)
+	if err := checkSecret(secret, minSecretLen); err != nil {
+		return Service{}, err
 	}
 	signingMethod := jwt.GetSigningMethod(algo)
 	if signingMethod == nil {
 		return Service{}, fmt.Errorf("invalid jwt signing method: %s", algo)
 	}
 	return Service{
-		key:  []byte(secret),
+		keys: &keys{current: []byte(secret), minLength: minSecretLen},
 		algo: signingMethod,
 		ttl:  time.Duration(ttlMinutes) * time.Minute,
 	}, nil
 }
 
+func checkSecret(secret string, minLength int) error {
+	if len(secret) < minLength {
+		return fmt.Errorf("jwt secret length is %v, which is less than required %v", len(secret), minLength)
+	}
+	return nil
+}
+
 // Service provides a Json-Web-Token authentication implementation
 type Service struct {
-	// Secret key used for signing.
-	key []byte
+	// Secret keys used for signing, shared by the copies of the service.
+	keys *keys
 	// Duration for which the jwt token is valid.
 	ttl time.Duration
 	// JWT signing algorithm
 	algo jwt.SigningMethod
 }
 
+// keys holds the signing key and the one it replaced
+type keys struct {
+	mu        sync.RWMutex
+	current   []byte
+	previous  []byte
+	minLength int
+}
+
+func (k *keys) get() (current, previous []byte) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current, k.previous
+}
+
+// SetSecret rotates the signing key, the tokens signed with the previous one are accepted
+// until they expire or the key is rotated again
+func (s Service) SetSecret(secret string) error {
+	if err := checkSecret(secret, s.keys.minLength); err != nil {
+		return err
+	}
+	s.keys.mu.Lock()
+	defer s.keys.mu.Unlock()
+	if string(s.keys.current) != secret {
+		s.keys.previous, s.keys.current = s.keys.current, []byte(secret)
+	}
+	return nil
+}
+
 // ParseToken parses token from Authorization header
 func (s Service) ParseToken(authHeader string) (*jwt.Token, error) {
 	parts := strings.SplitN(authHeader, " ", 2)
 	if !(len(parts) == 2 && strings.ToLower(parts[0]) == "bearer") {
 		return nil, resultwrapper.ErrGeneric
 	}
-	return jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
+	current, previous := s.keys.get()
+	token, err := s.parse(parts[1], current)
+	var validationErr *jwt.ValidationError
+	if previous != nil && errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorSignatureInvalid != 0 {
+		return s.parse(parts[1], previous)
+	}
+	return token, err
+}
+
+func (s Service) parse(token string, key []byte) (*jwt.Token, error) {
+	return jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
 		if s.algo != token.Method {
 			return nil, resultwrapper.ErrGeneric
 		}
-		return s.key, nil
+		return key, nil
 	})
 }
 
@@ -63,11 +110,17 @@ func (s Service) GenerateToken(u *models.User) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return s.GenerateTokenWithRole(u, role.Name)
+}
+
+// GenerateTokenWithRole generates new JWT token for u having the role named role
+func (s Service) GenerateTokenWithRole(u *models.User, role string) (string, error) {
+	current, _ := s.keys.get()
 	return jwt.NewWithClaims(s.algo, jwt.MapClaims{
 		"id":   u.ID,
 		"u":    u.Username,
 		"e":    u.Email,
 		"exp":  time.Now().Add(s.ttl).Unix(),
-		"role": role.Name,
-	}).SignedString(s.key)
+		"role": role,
+	}).SignedString(current)
 }
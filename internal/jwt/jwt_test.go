@@ -211,3 +211,32 @@ func TestParseToken(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSecret(t *testing.T) {
+	oldSecret, newSecret := strings.Repeat("o", 64), strings.Repeat("n", 64)
+	s, err := jwt.New("HS256", oldSecret, 60, 64)
+	assert.Nil(t, err)
+	signedWithOld, err := s.GenerateTokenWithRole(&models.User{ID: 1}, "USER")
+	assert.Nil(t, err)
+
+	assert.EqualError(t, s.SetSecret("short"), "jwt secret length is 5, which is less than required 64")
+	// copies of the service share the rotated key
+	copied := s
+	assert.Nil(t, copied.SetSecret(newSecret))
+	signedWithNew, err := s.GenerateTokenWithRole(&models.User{ID: 1}, "USER")
+	assert.Nil(t, err)
+	assert.NotEqual(t, signedWithOld, signedWithNew)
+
+	for _, token := range []string{signedWithOld, signedWithNew} {
+		parsed, err := s.ParseToken("Bearer " + token)
+		assert.Nil(t, err)
+		assert.True(t, parsed.Valid)
+	}
+
+	// the tokens signed with the key before the previous one are rejected
+	assert.Nil(t, s.SetSecret(strings.Repeat("x", 64)))
+	_, err = s.ParseToken("Bearer " + signedWithOld)
+	assert.NotNil(t, err)
+	_, err = s.ParseToken("Bearer " + signedWithNew)
+	assert.Nil(t, err)
+}
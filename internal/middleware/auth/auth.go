@@ -2,13 +2,15 @@ package auth
 
 import (
 	"context"
-	"reflect"
 
 	"go-template/daos"
 	"go-template/models"
+	"go-template/pkg/utl/apperror"
 	resultwrapper "go-template/pkg/utl/resultwrapper"
+	"go-template/pkg/utl/zaplog"
 
 	graphql2 "github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo/v4"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -20,6 +22,11 @@ const (
 	authorization key = "Authorization"
 )
 
+// SuperAdminRole is the role claim of the tokens of super admins
+const SuperAdminRole = "SUPER_ADMIN"
+
+const adminOnly = "Unauthorized! \n Only admins are authorized to make this request."
+
 // TokenParser represents JWT token parser
 type TokenParser interface {
 	ParseToken(string) (*jwt.Token, error)
@@ -33,6 +40,13 @@ type CustomContext struct {
 
 var UserCtxKey = &ContextKey{"user"}
 
+// RoleCtxKey holds the role name of the token of the authenticated user
+var RoleCtxKey = &ContextKey{"role"}
+
+// OrganizationCtxKey holds the organization of the token of the authenticated user, tokens
+// carry one in their optional org claim
+var OrganizationCtxKey = &ContextKey{"organization"}
+
 type ContextKey struct {
 	Name string
 }
@@ -52,6 +66,18 @@ func UserIDFromContext(ctx context.Context) int {
 	return 0
 }
 
+// RoleFromContext returns the role name of the authenticated user
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(RoleCtxKey).(string)
+	return role
+}
+
+// OrganizationFromContext returns the organization of the authenticated user, if any
+func OrganizationFromContext(ctx context.Context) string {
+	org, _ := ctx.Value(OrganizationCtxKey).(string)
+	return org
+}
+
 // GqlMiddleware ...
 func GqlMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -68,16 +94,36 @@ func GqlMiddleware() echo.MiddlewareFunc {
 	}
 }
 
+// WebsocketInitFunc authenticates websocket connections with the token of the
+// connection_init payload, since browsers can't set headers on websocket requests. A
+// connection with an invalid token is rejected, one without a token falls back to the
+// Authorization header of the upgrade request.
+func WebsocketInitFunc(tokenParser TokenParser) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, error) {
+		tokenStr := payload.Authorization()
+		if tokenStr == "" {
+			return ctx, nil
+		}
+		token, err := tokenParser.ParseToken(tokenStr)
+		if err != nil || !token.Valid {
+			return nil, apperror.NewUnauthenticated("Invalid authorization token")
+		}
+		return context.WithValue(ctx, authorization, tokenStr), nil
+	}
+}
+
 // WhiteListedOperations...
 var WhiteListedOperations = map[string][]string{
-	"query":        {"__schema", "introspectionquery", "userNotification"},
-	"mutation":     {"login"},
-	"subscription": {"userNotification"},
+	"query":    {"__schema", "introspectionquery"},
+	"mutation": {"login"},
 }
 
 // AdminOperations...
 var AdminOperations = map[string][]string{
-	"query": {"users"},
+	"query": {"users", "jobs", "webhooks", "webhookDeliveries", "settings", "setting", "featureFlags",
+		"featureFlag"},
+	"mutation": {"retryJob", "createWebhook", "updateWebhook", "deleteWebhook", "redeliver", "updateSetting",
+		"resetSetting", "saveFeatureFlag", "deleteFeatureFlag"},
 }
 
 func contains(s []string, e string) bool {
@@ -88,20 +134,53 @@ func contains(s []string, e string) bool {
 	}
 	return false
 }
+
+// getAccessNeeds reports whether the fields operation selects at its top need an authenticated
+// user and a super admin. The fields of the fragments spread at the top are selected at the top
+// as well, so they are checked like the others.
 func getAccessNeeds(operation *ast.OperationDefinition) (needsAuthAccess bool, needsSuperAdminAccess bool) {
 	operationName := string(operation.Operation)
-	for _, selectionSet := range operation.SelectionSet {
-		selection := reflect.ValueOf(selectionSet).Elem()
-		if !contains(WhiteListedOperations[operationName], selection.FieldByName("Name").Interface().(string)) {
-			needsAuthAccess = true
-		}
-		if contains(AdminOperations[operationName], selection.FieldByName("Name").Interface().(string)) {
-			needsSuperAdminAccess = true
+	spread := map[string]bool{}
+	var check func(selections ast.SelectionSet)
+	check = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if !contains(WhiteListedOperations[operationName], s.Name) {
+					needsAuthAccess = true
+				}
+				if contains(AdminOperations[operationName], s.Name) {
+					needsSuperAdminAccess = true
+				}
+			case *ast.InlineFragment:
+				check(s.SelectionSet)
+			case *ast.FragmentSpread:
+				if spread[s.Name] {
+					continue
+				}
+				spread[s.Name] = true
+				if s.Definition == nil {
+					// the fields of an unknown fragment can't be checked
+					needsAuthAccess, needsSuperAdminAccess = true, true
+					continue
+				}
+				check(s.Definition.SelectionSet)
+			}
 		}
 	}
+	check(operation.SelectionSet)
 	return needsAuthAccess, needsSuperAdminAccess
 }
 
+// RequireSuperAdmin returns a forbidden error unless the authenticated user is a super admin, the
+// resolvers of AdminOperations check it on top of the middleware
+func RequireSuperAdmin(ctx context.Context) error {
+	if RoleFromContext(ctx) != SuperAdminRole {
+		return apperror.NewForbidden(adminOnly)
+	}
+	return nil
+}
+
 // GraphQLMiddleware ...
 func GraphQLMiddleware(
 	ctx context.Context,
@@ -115,23 +194,30 @@ func GraphQLMiddleware(
 	// strip token
 	var tokenStr = ctx.Value(authorization).(string)
 	if len(tokenStr) == 0 {
-		return resultwrapper.HandleGraphQLError("Authorization header is missing")
+		return resultwrapper.HandleAppError(ctx, apperror.NewUnauthenticated("Authorization header is missing"))
 	}
 	token, err := tokenParser.ParseToken(tokenStr)
 	if err != nil || !token.Valid {
-		return resultwrapper.HandleGraphQLError("Invalid authorization token")
+		return resultwrapper.HandleAppError(ctx, apperror.NewUnauthenticated("Invalid authorization token"))
 	}
-	claims := token.Claims.(jwt.MapClaims)
-	if needsSuperAdminAccess && claims["role"].(string) != "SUPER_ADMIN" {
-		return resultwrapper.HandleGraphQLError(
-			"Unauthorized! \n Only admins are authorized to make this request.",
-		)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return resultwrapper.HandleAppError(ctx, apperror.NewUnauthenticated("Invalid authorization token"))
 	}
-	email := claims["e"].(string)
+	role, _ := claims["role"].(string)
+	if needsSuperAdminAccess && role != SuperAdminRole {
+		return resultwrapper.HandleAppError(ctx, apperror.NewForbidden(adminOnly))
+	}
+	email, _ := claims["e"].(string)
 	user, err := daos.FindUserByEmail(email, ctx)
 	if err != nil {
-		return resultwrapper.HandleGraphQLError("No user found for this email address")
+		return resultwrapper.HandleAppError(ctx, apperror.NewUnauthenticated("No user found for this email address"))
 	}
 	ctx = context.WithValue(ctx, UserCtxKey, user)
+	ctx = context.WithValue(ctx, RoleCtxKey, role)
+	if org, _ := claims["org"].(string); org != "" {
+		ctx = context.WithValue(ctx, OrganizationCtxKey, org)
+	}
+	ctx = context.WithValue(ctx, zaplog.UserIDCtxKey, user.ID)
 	return next(ctx)
 }
@@ -16,6 +16,7 @@ import (
 	graphql "go-template/gqlmodels"
 	"go-template/internal/middleware/auth"
 	"go-template/models"
+	"go-template/pkg/utl/apperror"
 	"go-template/resolver"
 	testutls "go-template/testutls"
 
@@ -80,6 +81,7 @@ func TestGraphQLMiddleware(t *testing.T) {
 func defineTestCases(t *testing.T) map[string]testGraphQLMiddlewareType {
 	return map[string]testGraphQLMiddlewareType{
 		"SuccessCase":                        defineSuccessCase(t),
+		"Success__Organization":              defineSuccessOrganization(t),
 		"Success__WhitelistedQuery":          defineSuccessWhitelistedQuery(),
 		"Failure__NoAuthorizationToken":      defineFailureNoAuthorizationToken(),
 		"Failure__InvalidAuthorizationToken": defineFailureInvalidAuthorizationToken(),
@@ -123,6 +125,21 @@ func defineSuccessCase(t *testing.T) testGraphQLMiddlewareType {
 	}
 }
 
+// defineSuccessOrganization authenticates a token carrying the optional org claim
+func defineSuccessOrganization(t *testing.T) testGraphQLMiddlewareType {
+	tt := defineSuccessCase(t)
+	tt.tokenParser = func(token string) (*jwt.Token, error) {
+		jwtToken := testutls.MockJwt("SUPER_ADMIN")
+		jwtToken.Claims.(jwt.MapClaims)["org"] = "acme"
+		return jwtToken, nil
+	}
+	tt.operationHandler = func(ctx context.Context) graphql2.ResponseHandler {
+		assert.Equal(t, "acme", auth.OrganizationFromContext(ctx))
+		return defineOperationHandlerSuccessCase(t)(ctx)
+	}
+	return tt
+}
+
 func defineSuccessWhitelistedQuery() testGraphQLMiddlewareType {
 	return testGraphQLMiddlewareType{
 		whiteListedQuery: true,
@@ -280,9 +297,8 @@ func makeRequest(t *testing.T, requestQuery string, tt testGraphQLMiddlewareType
 	operationHandlerMock = tt.operationHandler
 	tokenParser := tokenParserMock{}
 	client := &http.Client{}
-	observers := map[string]chan *graphql.User{}
 	graphqlHandler := handler.New(graphql.NewExecutableSchema(graphql.Config{
-		Resolvers: &resolver.Resolver{Observers: observers},
+		Resolvers: &resolver.Resolver{},
 	}))
 	graphqlHandler.
 		AroundOperations(func(ctx context.Context, next graphql2.OperationHandler) graphql2.ResponseHandler {
@@ -328,6 +344,65 @@ func makeRequest(t *testing.T, requestQuery string, tt testGraphQLMiddlewareType
 	}
 	assert.Equal(t, tt.wantStatus, res.StatusCode)
 }
+func TestGraphQLMiddlewareFragments(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		header string
+		err    string
+	}{
+		{
+			name:   "FragmentSpread",
+			query:  `query { ...F } fragment F on Query { webhooks { id } }`,
+			header: "bearer 123",
+			err:    "Unauthorized! \n Only admins are authorized to make this request.",
+		},
+		{
+			name:   "InlineFragment",
+			query:  `query { ... on Query { webhooks { id } } }`,
+			header: "bearer 123",
+			err:    "Unauthorized! \n Only admins are authorized to make this request.",
+		},
+		{
+			name:   "NestedFragments",
+			query:  `mutation { ...M } fragment M on Mutation { ... on Mutation { ...D } } fragment D on Mutation { deleteWebhook(id: "1") { id } }`,
+			header: "bearer 123",
+			err:    "Unauthorized! \n Only admins are authorized to make this request.",
+		},
+		{
+			// a fragment named after a whitelisted field doesn't make its fields public
+			name:  "WhitelistedFragmentName",
+			query: `mutation { ...login } fragment login on Mutation { deleteWebhook(id: "1") { id } }`,
+			err:   "Authorization header is missing",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(map[string]interface{}{"query": tt.query, "variables": map[string]interface{}{}})
+			assert.NoError(t, err)
+			makeRequest(t, string(body), testGraphQLMiddlewareType{
+				header:     tt.header,
+				wantStatus: http.StatusOK,
+				err:        tt.err,
+				tokenParser: func(token string) (*jwt.Token, error) {
+					return testutls.MockJwt("USER"), nil
+				},
+				operationHandler: func(ctx context.Context) graphql2.ResponseHandler {
+					t.Error("the operation ran")
+					return nil
+				},
+			})
+		})
+	}
+}
+
+func TestRequireSuperAdmin(t *testing.T) {
+	assert.NoError(t, auth.RequireSuperAdmin(context.WithValue(testutls.MockCtx{}, auth.RoleCtxKey, auth.SuperAdminRole)))
+	err := auth.RequireSuperAdmin(context.WithValue(testutls.MockCtx{}, auth.RoleCtxKey, "USER"))
+	assert.Equal(t, apperror.Forbidden, apperror.CodeOf(err))
+	assert.Equal(t, apperror.Forbidden, apperror.CodeOf(auth.RequireSuperAdmin(testutls.MockCtx{})))
+}
+
 func TestUserIDFromContext(t *testing.T) {
 	cases := map[string]struct {
 		user   *models.User
@@ -355,3 +430,53 @@ func TestFromContext(t *testing.T) {
 	assert.Equal(t, user, u)
 	assert.Equal(t, user.ID, testutls.MockID)
 }
+func TestWebsocketInitFunc(t *testing.T) {
+	cases := map[string]struct {
+		payload     transport.InitPayload
+		tokenParser func(token string) (*jwt.Token, error)
+		wantToken   string
+		wantErr     bool
+	}{
+		SuccessCase: {
+			payload: transport.InitPayload{"authorization": "Bearer 123"},
+			tokenParser: func(token string) (*jwt.Token, error) {
+				return testutls.MockJwt("USER"), nil
+			},
+			wantToken: "Bearer 123",
+		},
+		"Success__NoToken": {
+			payload: transport.InitPayload{},
+		},
+		"Failure__InvalidAuthorizationToken": {
+			payload: transport.InitPayload{"Authorization": "Bearer 123"},
+			tokenParser: func(token string) (*jwt.Token, error) {
+				return nil, fmt.Errorf("token is invalid")
+			},
+			wantToken: "Bearer 123",
+			wantErr:   true,
+		},
+	}
+	for name, tt := range cases {
+		t.Run(name, func(t *testing.T) {
+			var parsed string
+			parseTokenMock = func(token string) (*jwt.Token, error) {
+				parsed = token
+				return tt.tokenParser(token)
+			}
+			ctx, err := auth.WebsocketInitFunc(tokenParserMock{})(context.Background(), tt.payload)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantToken, parsed)
+			if !tt.wantErr {
+				assert.NotNil(t, ctx)
+			}
+		})
+	}
+}
+func TestRoleFromContext(t *testing.T) {
+	assert.Equal(t, "SUPER_ADMIN", auth.RoleFromContext(context.WithValue(testutls.MockCtx{}, auth.RoleCtxKey, "SUPER_ADMIN")))
+	assert.Equal(t, "", auth.RoleFromContext(testutls.MockCtx{}))
+}
+func TestOrganizationFromContext(t *testing.T) {
+	assert.Equal(t, "acme", auth.OrganizationFromContext(context.WithValue(testutls.MockCtx{}, auth.OrganizationCtxKey, "acme")))
+	assert.Equal(t, "", auth.OrganizationFromContext(testutls.MockCtx{}))
+}
@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE public.outbox_events (
+				id BIGSERIAL UNIQUE PRIMARY KEY,
+				topic TEXT NOT NULL,
+				payload JSONB NOT NULL,
+				attempts INT NOT NULL DEFAULT 0,
+				last_error TEXT,
+				next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				published_at TIMESTAMP WITH TIME ZONE,
+				-- the sinks the event was delivered to, its retries skip them
+				delivered_to TEXT[] NOT NULL DEFAULT '{}',
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+CREATE INDEX outbox_events_pending_idx ON outbox_events(next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX outbox_events_published_at_idx ON outbox_events(published_at) WHERE published_at IS NOT NULL;
+
+-- +migrate Down
+DROP TABLE outbox_events;
@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE public.jobs (
+				id BIGSERIAL UNIQUE PRIMARY KEY,
+				kind TEXT NOT NULL,
+				payload JSONB NOT NULL DEFAULT '{}',
+				status TEXT NOT NULL DEFAULT 'pending',
+				attempts INT NOT NULL DEFAULT 0,
+				max_attempts INT NOT NULL DEFAULT 10,
+				run_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				unique_key TEXT,
+				locked_by TEXT,
+				locked_at TIMESTAMP WITH TIME ZONE,
+				last_error TEXT,
+				finished_at TIMESTAMP WITH TIME ZONE,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+CREATE INDEX jobs_pending_idx ON jobs(run_at, id) WHERE status = 'pending';
+CREATE INDEX jobs_status_kind_idx ON jobs(status, kind);
+CREATE UNIQUE INDEX jobs_unique_key_idx ON jobs(unique_key);
+
+-- +migrate Down
+DROP TABLE jobs;
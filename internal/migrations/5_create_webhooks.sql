@@ -0,0 +1,33 @@
+-- +migrate Up
+CREATE TABLE public.webhooks (
+				id SERIAL UNIQUE PRIMARY KEY,
+				url TEXT NOT NULL,
+				secret TEXT NOT NULL,
+				event_types TEXT[] NOT NULL,
+				active BOOLEAN NOT NULL DEFAULT true,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+CREATE TABLE public.webhook_deliveries (
+				id BIGSERIAL UNIQUE PRIMARY KEY,
+				webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+				event_id BIGINT,
+				redelivery_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+				event_type TEXT NOT NULL,
+				payload JSONB NOT NULL,
+				status TEXT NOT NULL DEFAULT 'pending',
+				attempts INT NOT NULL DEFAULT 0,
+				response_status INT,
+				response_body TEXT,
+				last_error TEXT,
+				duration_ms INT,
+				delivered_at TIMESTAMP WITH TIME ZONE,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries(webhook_id, id);
+CREATE UNIQUE INDEX webhook_deliveries_event_idx ON webhook_deliveries(webhook_id, event_id) WHERE redelivery_of IS NULL;
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhooks;
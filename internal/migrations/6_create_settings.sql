@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE public.settings (
+				key TEXT PRIMARY KEY,
+				value TEXT NOT NULL,
+				updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+
+-- +migrate Down
+DROP TABLE settings;
@@ -0,0 +1,17 @@
+-- +migrate Up
+CREATE TABLE public.feature_flags (
+				name TEXT PRIMARY KEY,
+				description TEXT NOT NULL DEFAULT '',
+				type TEXT NOT NULL DEFAULT 'boolean',
+				enabled BOOLEAN NOT NULL DEFAULT false,
+				percentage INT NOT NULL DEFAULT 0 CHECK (percentage BETWEEN 0 AND 100),
+				user_ids INT[] NOT NULL DEFAULT '{}',
+				roles TEXT[] NOT NULL DEFAULT '{}',
+				organizations TEXT[] NOT NULL DEFAULT '{}',
+				updated_by INT REFERENCES users(id) ON DELETE SET NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+
+-- +migrate Down
+DROP TABLE feature_flags;
@@ -0,0 +1,21 @@
+-- +migrate Up
+CREATE TABLE public.seeds (
+				name TEXT NOT NULL,
+				environment TEXT NOT NULL,
+				checksum TEXT NOT NULL,
+				applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				PRIMARY KEY (name, environment)
+			);
+-- the seeder upserts the roles by name. The previous seeder inserted the roles on every start of
+-- the docker containers, so the users are moved to the first role of each name and the duplicates
+-- are deleted before the names are made unique.
+UPDATE users SET role_id = kept.id
+FROM roles duplicate
+JOIN (SELECT name, min(id) AS id FROM roles GROUP BY name) kept ON kept.name = duplicate.name
+WHERE users.role_id = duplicate.id AND duplicate.id <> kept.id;
+DELETE FROM roles WHERE id NOT IN (SELECT min(id) FROM roles GROUP BY name);
+ALTER TABLE roles ADD CONSTRAINT roles_name_key UNIQUE (name);
+
+-- +migrate Down
+ALTER TABLE roles DROP CONSTRAINT roles_name_key;
+DROP TABLE seeds;
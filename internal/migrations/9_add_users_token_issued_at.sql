@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN token_issued_at TIMESTAMP WITH TIME ZONE;
+-- the refresh tokens issued so far are aged by the last update of their user
+UPDATE users SET token_issued_at = updated_at WHERE token IS NOT NULL;
+
+-- +migrate Down
+ALTER TABLE users DROP COLUMN token_issued_at;
@@ -0,0 +1,246 @@
+// Package migrations embeds the SQL migrations so that the binaries apply them from any
+// directory, and reports which of them are applied to a database.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Dialect is the dialect of the migrations
+const Dialect = "postgres"
+
+//go:embed *.sql
+var files embed.FS
+
+// Source returns the embedded migrations
+func Source() migrate.MigrationSource {
+	return migrate.HttpFileSystemMigrationSource{FileSystem: http.FS(files)}
+}
+
+// publicTable matches the tables the migrations qualify with the public schema
+var publicTable = regexp.MustCompile(`\bpublic\.`)
+
+// InSchema returns the migrations of src creating their tables in schema rather than public,
+// for building a throwaway copy of the schema. The unqualified names resolve to the search_path
+// of the database they are applied to, which should be schema.
+func InSchema(src migrate.MigrationSource, schema string) migrate.MigrationSource {
+	return inSchema{src: src, schema: pq.QuoteIdentifier(schema) + "."}
+}
+
+type inSchema struct {
+	src    migrate.MigrationSource
+	schema string
+}
+
+func (s inSchema) FindMigrations() ([]*migrate.Migration, error) {
+	found, err := s.src.FindMigrations()
+	if err != nil {
+		return nil, err
+	}
+	rewritten := make([]*migrate.Migration, 0, len(found))
+	for _, m := range found {
+		copied := *m
+		copied.Up = s.rewrite(m.Up)
+		copied.Down = s.rewrite(m.Down)
+		rewritten = append(rewritten, &copied)
+	}
+	return rewritten, nil
+}
+
+func (s inSchema) rewrite(queries []string) []string {
+	rewritten := make([]string, 0, len(queries))
+	for _, query := range queries {
+		rewritten = append(rewritten, publicTable.ReplaceAllLiteralString(query, s.schema))
+	}
+	return rewritten
+}
+
+// Status tells whether a migration is applied
+type Status struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown migrations are applied to the database but missing from the binary, which is
+	// older than the schema
+	Unknown bool
+}
+
+// Statuses returns the status of the migrations of src and of the unknown migrations applied
+// to db, in the order they are applied in
+func Statuses(db *sql.DB, src migrate.MigrationSource) ([]Status, error) {
+	migrations, err := src.FindMigrations()
+	if err != nil {
+		return nil, err
+	}
+	records, err := migrate.GetMigrationRecords(db, Dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		applied[record.Id] = record.AppliedAt
+	}
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.Id]
+		statuses = append(statuses, Status{ID: m.Id, Applied: ok, AppliedAt: at})
+		delete(applied, m.Id)
+	}
+	for _, record := range records {
+		if _, ok := applied[record.Id]; ok {
+			statuses = append(statuses, Status{ID: record.Id, Applied: true, AppliedAt: record.AppliedAt, Unknown: true})
+		}
+	}
+	return statuses, nil
+}
+
+// Plan returns the migrations that applying at most max migrations of src to db in direction
+// would run, 0 meaning all of them
+func Plan(db *sql.DB, src migrate.MigrationSource, direction migrate.MigrationDirection, max int) (
+	[]*migrate.PlannedMigration, error) {
+	planned, _, err := migrate.PlanMigration(db, Dialect, src, direction, max)
+	return planned, err
+}
+
+// Apply applies at most max migrations of src to db in direction, 0 meaning all of them, and
+// returns the number of migrations applied
+func Apply(db *sql.DB, src migrate.MigrationSource, direction migrate.MigrationDirection, max int) (int, error) {
+	return migrate.ExecMax(db, Dialect, src, direction, max)
+}
+
+// LockID is the key of the Postgres advisory lock taken by Migrate, so that the instances
+// starting together apply the migrations one at a time
+const LockID int64 = 7_102_541_864_380_223_601
+
+// ErrAhead is returned by Migrate when the database has migrations the binary doesn't know,
+// i.e. the binary is older than the schema
+var ErrAhead = errors.New("the database is ahead of the migrations of this binary")
+
+// Migrate applies the pending migrations of src to db under the advisory lock LockID, waiting
+// for the other instances holding it, and returns the number of migrations applied. It
+// applies nothing and returns ErrAhead when db has migrations src doesn't know.
+func Migrate(ctx context.Context, db *sql.DB, src migrate.MigrationSource) (int, error) {
+	// advisory locks belong to the session, the lock and the unlock use the same connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", LockID); err != nil {
+		return 0, fmt.Errorf("locking the migrations: %w", err)
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", LockID)
+	}()
+
+	statuses, err := Statuses(db, src)
+	if err != nil {
+		return 0, err
+	}
+	var unknown []string
+	for _, s := range statuses {
+		if s.Unknown {
+			unknown = append(unknown, s.ID)
+		}
+	}
+	if len(unknown) > 0 {
+		return 0, fmt.Errorf("%w, unknown migrations: %s", ErrAhead, strings.Join(unknown, ", "))
+	}
+	return Apply(db, src, migrate.Up, 0)
+}
+
+// Version is the version of the schema of a database
+type Version struct {
+	// Current is the last migration of the binary applied to the database, empty when none is
+	Current string
+	// Pending is the number of migrations of the binary not applied yet
+	Pending int
+	// Unknown are the migrations applied to the database but missing from the binary
+	Unknown []string
+}
+
+// undefinedTable is the Postgres error code of a missing table
+const undefinedTable = "42P01"
+
+// ReadVersion returns the version of the schema of db against the migrations of src. Unlike
+// Statuses it only reads, the migrations table of a database never migrated is treated as empty.
+func ReadVersion(ctx context.Context, db *sql.DB, src migrate.MigrationSource) (Version, error) {
+	migrations, err := src.FindMigrations()
+	if err != nil {
+		return Version{}, err
+	}
+	applied := map[string]bool{}
+	rows, err := db.QueryContext(ctx, `SELECT id FROM gorp_migrations`)
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == undefinedTable:
+	case err != nil:
+		return Version{}, err
+	default:
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return Version{}, err
+			}
+			applied[id] = true
+		}
+		if err := rows.Err(); err != nil {
+			return Version{}, err
+		}
+	}
+
+	var v Version
+	for _, m := range migrations {
+		if applied[m.Id] {
+			v.Current = m.Id
+			delete(applied, m.Id)
+		} else {
+			v.Pending++
+		}
+	}
+	for id := range applied {
+		v.Unknown = append(v.Unknown, id)
+	}
+	sort.Strings(v.Unknown)
+	return v, nil
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+const migrationTemplate = `-- +migrate Up
+
+-- +migrate Down
+`
+
+// Create writes an empty migration named name to dir, its id is prefixed with the timestamp
+// of now so that it runs after the existing migrations. It returns the path of the file.
+func Create(dir, name string, now time.Time) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid migration name %q, use lowercase letters, digits and underscores", name)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.sql", now.UTC().Format("20060102150405"), name))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(migrationTemplate); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
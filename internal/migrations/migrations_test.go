@@ -0,0 +1,246 @@
+package migrations_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-template/internal/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockRecords expects sql-migrate to read the applied migrations of db
+func mockRecords(t *testing.T, ids ...string) (*sql.DB, sqlmock.Sqlmock, time.Time) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	now := time.Now().UTC().Truncate(time.Second)
+	mock.ExpectExec(`create table if not exists "gorp_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"id", "applied_at"})
+	for _, id := range ids {
+		rows.AddRow(id, now)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).WillReturnRows(rows)
+	return db, mock, now
+}
+
+func TestSource(t *testing.T) {
+	found, err := migrations.Source().FindMigrations()
+	assert.NoError(t, err)
+	files, err := filepath.Glob("*.sql")
+	assert.NoError(t, err)
+	assert.Len(t, found, len(files))
+	assert.Equal(t, "1_create_roles.sql", found[0].Id)
+	for _, m := range found {
+		assert.NotEmpty(t, m.Up, m.Id)
+		assert.NotEmpty(t, m.Down, m.Id)
+	}
+}
+
+func TestInSchema(t *testing.T) {
+	src := &migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{{
+		Id:   "1_create_roles.sql",
+		Up:   []string{"CREATE TABLE public.roles (id SERIAL);", "ALTER TABLE roles ADD COLUMN republic.x TEXT;"},
+		Down: []string{"DROP TABLE public.roles;"},
+	}}}
+	found, err := migrations.InSchema(src, "gendbmdls_1").FindMigrations()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`CREATE TABLE "gendbmdls_1".roles (id SERIAL);`, "ALTER TABLE roles ADD COLUMN republic.x TEXT;"},
+		found[0].Up)
+	assert.Equal(t, []string{`DROP TABLE "gendbmdls_1".roles;`}, found[0].Down)
+	// the source is left untouched
+	assert.Equal(t, "DROP TABLE public.roles;", src.Migrations[0].Down[0])
+
+	embedded, err := migrations.InSchema(migrations.Source(), "gendbmdls_1").FindMigrations()
+	assert.NoError(t, err)
+	for _, m := range embedded {
+		for _, query := range append(m.Up, m.Down...) {
+			assert.NotContains(t, query, "public.", m.Id)
+		}
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	src := migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{
+		{Id: "1_create_roles.sql", Up: []string{"CREATE TABLE roles ();"}},
+		{Id: "2_create_users.sql", Up: []string{"CREATE TABLE users ();"}},
+	}}
+	db, mock, now := mockRecords(t, "1_create_roles.sql", "3_create_jobs.sql")
+
+	got, err := migrations.Statuses(db, src)
+	assert.NoError(t, err)
+	assert.Equal(t, []migrations.Status{
+		{ID: "1_create_roles.sql", Applied: true, AppliedAt: now},
+		{ID: "2_create_users.sql"},
+		{ID: "3_create_jobs.sql", Applied: true, AppliedAt: now, Unknown: true},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlan(t *testing.T) {
+	src := migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{
+		{Id: "1_create_roles.sql", Up: []string{"CREATE TABLE roles ();"}, Down: []string{"DROP TABLE roles;"}},
+		{Id: "2_create_users.sql", Up: []string{"CREATE TABLE users ();"}, Down: []string{"DROP TABLE users;"}},
+		{Id: "3_create_jobs.sql", Up: []string{"CREATE TABLE jobs ();"}, Down: []string{"DROP TABLE jobs;"}},
+	}}
+	tests := []struct {
+		name      string
+		direction migrate.MigrationDirection
+		max       int
+		want      []string
+	}{
+		{name: "Up", direction: migrate.Up, want: []string{"2_create_users.sql", "3_create_jobs.sql"}},
+		{name: "Up 1", direction: migrate.Up, max: 1, want: []string{"2_create_users.sql"}},
+		{name: "Down", direction: migrate.Down, max: 1, want: []string{"1_create_roles.sql"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, _ := mockRecords(t, "1_create_roles.sql")
+			planned, err := migrations.Plan(db, src, tt.direction, tt.max)
+			assert.NoError(t, err)
+			var ids []string
+			for _, m := range planned {
+				ids = append(ids, m.Id)
+			}
+			assert.Equal(t, tt.want, ids)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 7, 15, 0, 0, time.UTC)
+
+	path, err := migrations.Create(dir, "add_user_avatars", now)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20261019071500_add_user_avatars.sql"), path)
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	m, err := migrate.ParseMigration(filepath.Base(path), bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(20261019071500), m.VersionInt())
+
+	_, err = migrations.Create(dir, "add_user_avatars", now)
+	assert.ErrorIs(t, err, os.ErrExist)
+	_, err = migrations.Create(dir, "Add avatars", now)
+	assert.EqualError(t, err, `invalid migration name "Add avatars", use lowercase letters, digits and underscores`)
+}
+
+func TestMigrate(t *testing.T) {
+	src := migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{
+		{Id: "1_create_roles.sql", Up: []string{"CREATE TABLE roles ();"}},
+	}}
+	tests := []struct {
+		name    string
+		applied []string
+		wantErr string
+	}{
+		{name: "Up to date", applied: []string{"1_create_roles.sql"}},
+		{name: "Ahead", applied: []string{"1_create_roles.sql", "2_create_users.sql"},
+			wantErr: "the database is ahead of the migrations of this binary, unknown migrations: 2_create_users.sql"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(migrations.LockID).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			// the records are read again to plan the migrations when the database isn't ahead
+			reads := 2
+			if tt.wantErr != "" {
+				reads = 1
+			}
+			for i := 0; i < reads; i++ {
+				mock.ExpectExec(`create table if not exists "gorp_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+				rows := sqlmock.NewRows([]string{"id", "applied_at"})
+				for _, id := range tt.applied {
+					rows.AddRow(id, time.Now())
+				}
+				mock.ExpectQuery(`SELECT \* FROM "gorp_migrations"`).WillReturnRows(rows)
+			}
+			mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(migrations.LockID).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			applied, err := migrations.Migrate(context.Background(), db, src)
+			assert.Equal(t, 0, applied)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, migrations.ErrAhead)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigrateLockFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WillReturnError(errors.New("connection refused"))
+
+	_, err = migrations.Migrate(context.Background(), db, migrations.Source())
+	assert.EqualError(t, err, "locking the migrations: connection refused")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReadVersion(t *testing.T) {
+	src := migrate.MemoryMigrationSource{Migrations: []*migrate.Migration{
+		{Id: "1_create_roles.sql", Up: []string{"CREATE TABLE roles ();"}},
+		{Id: "2_create_users.sql", Up: []string{"CREATE TABLE users ();"}},
+		{Id: "3_create_jobs.sql", Up: []string{"CREATE TABLE jobs ();"}},
+	}}
+	tests := []struct {
+		name    string
+		applied []string
+		err     error
+		want    migrations.Version
+		wantErr string
+	}{
+		{name: "Pending", applied: []string{"1_create_roles.sql", "2_create_users.sql"},
+			want: migrations.Version{Current: "2_create_users.sql", Pending: 1}},
+		{name: "Ahead", applied: []string{"4_create_teams.sql", "1_create_roles.sql", "2_create_users.sql", "3_create_jobs.sql"},
+			want: migrations.Version{Current: "3_create_jobs.sql", Unknown: []string{"4_create_teams.sql"}}},
+		{name: "Never migrated", err: &pq.Error{Code: "42P01"}, want: migrations.Version{Pending: 3}},
+		{name: "Failure", err: errors.New("connection refused"), wantErr: "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			query := mock.ExpectQuery(`SELECT id FROM gorp_migrations`)
+			if tt.err != nil {
+				query.WillReturnError(tt.err)
+			} else {
+				rows := sqlmock.NewRows([]string{"id"})
+				for _, id := range tt.applied {
+					rows.AddRow(id)
+				}
+				query.WillReturnRows(rows)
+			}
+
+			got, err := migrations.ReadVersion(context.Background(), db, src)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
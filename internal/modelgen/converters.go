@@ -0,0 +1,253 @@
+package modelgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	"github.com/volatiletech/strmangle"
+)
+
+// ConvertersFile is the file of the cnvrttogql package the generated converters are written to
+const ConvertersFile = "cnvrttogql_gen.go"
+
+// conversion converts a model field of a Go type into a graphql field of another, %s being the
+// model field
+type conversion struct {
+	expr string
+	pkg  string
+}
+
+var conversions = map[[2]string]conversion{
+	{"int", "string"}:                 {expr: "strconv.Itoa(%s)", pkg: "strconv"},
+	{"int64", "string"}:               {expr: "strconv.FormatInt(%s, 10)", pkg: "strconv"},
+	{"time.Time", "int"}:              {expr: "int(%s.UnixMilli())"},
+	{"null.String", "*string"}:        {expr: "convert.NullDotStringToPointerString(%s)", pkg: "go-template/pkg/utl/convert"},
+	{"null.Bool", "*bool"}:            {expr: "convert.NullDotBoolToPointerBool(%s)", pkg: "go-template/pkg/utl/convert"},
+	{"null.Time", "*int"}:             {expr: "convert.NullDotTimeToPointerInt(%s)", pkg: "go-template/pkg/utl/convert"},
+	{"null.Int", "*int"}:              {expr: "%s.Ptr()"},
+	{"null.Float64", "*float64"}:      {expr: "%s.Ptr()"},
+	{"types.StringArray", "[]string"}: {expr: "[]string(%s)"},
+}
+
+// builtins are the types assigned as is when the model and the graphql fields share them
+var builtins = map[string]bool{"string": true, "int": true, "bool": true, "float64": true}
+
+// Existing are the converters written by hand in the cnvrttogql package
+type Existing struct {
+	// Funcs are the names of the funcs
+	Funcs map[string]bool
+	// Models are the models the funcs convert
+	Models map[string]bool
+}
+
+// ReadExisting returns the converters of the package in dir, ignoring ConvertersFile
+func ReadExisting(dir string) (Existing, error) {
+	existing := Existing{Funcs: map[string]bool{}, Models: map[string]bool{}}
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return existing, err
+	}
+	fset := token.NewFileSet()
+	for _, name := range names {
+		if filepath.Base(name) == ConvertersFile || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return existing, err
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			existing.Funcs[fn.Name.Name] = true
+			if params := fn.Type.Params.List; len(params) > 0 {
+				if model, ok := modelOf(params[0].Type); ok {
+					existing.Models[model] = true
+				}
+			}
+		}
+	}
+	return existing, nil
+}
+
+// modelOf returns the model of a *models.X or models.XSlice parameter
+func modelOf(expr ast.Expr) (string, bool) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "models" {
+		return "", false
+	}
+	return strings.TrimSuffix(sel.Sel.Name, "Slice"), true
+}
+
+// gqlType is a struct of the graphql models
+type gqlType struct {
+	fields []gqlField
+}
+
+type gqlField struct {
+	name string
+	typ  string
+}
+
+// parseGraphQL returns the structs and the enums of the source of the graphql models
+func parseGraphQL(src []byte) (map[string]gqlType, map[string]bool, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, err
+	}
+	structs := map[string]gqlType{}
+	enums := map[string]bool{}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			switch t := spec.Type.(type) {
+			case *ast.Ident:
+				if t.Name == "string" {
+					enums[spec.Name.Name] = true
+				}
+			case *ast.StructType:
+				var typ gqlType
+				for _, field := range t.Fields.List {
+					for _, name := range field.Names {
+						typ.fields = append(typ.fields, gqlField{name: name.Name, typ: exprString(field.Type)})
+					}
+				}
+				structs[spec.Name.Name] = typ
+			}
+		}
+	}
+	return structs, enums, nil
+}
+
+// exprString returns the source of a type expression
+func exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = format.Node(&buf, token.NewFileSet(), expr)
+	return buf.String()
+}
+
+// Converters returns the source of the converters from the models of tables to the graphql
+// types named after them, gqlModels being the source of the graphql models. The tables without
+// a graphql type or with an existing converter are skipped, it returns nil when every table is.
+func Converters(tables []drivers.Table, gqlModels []byte, existing Existing) ([]byte, error) {
+	structs, enums, err := parseGraphQL(gqlModels)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the graphql models: %w", err)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+
+	imports := map[string]bool{"go-template/models": true, "go-template/gqlmodels": true}
+	var body bytes.Buffer
+	for _, table := range tables {
+		if table.IsView || table.IsJoinTable {
+			continue
+		}
+		model := strmangle.TitleCase(strmangle.Singular(table.Name))
+		plural := strmangle.TitleCase(strmangle.Plural(table.Name))
+		one, many := model+"ToGraphQl"+model, plural+"ToGraphQl"+plural
+		gql, ok := structs[model]
+		if !ok || existing.Models[model] || existing.Funcs[one] || existing.Funcs[many] {
+			continue
+		}
+		writeConverters(&body, table, model, one, many, gql, enums, imports)
+	}
+	if body.Len() == 0 {
+		return nil, nil
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by gendbmdls, DO NOT EDIT.\n\npackage cnvrttogql\n\nimport (\n")
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if path == "go-template/gqlmodels" {
+			fmt.Fprintf(&src, "\tgraphql %q\n", path)
+		} else {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+	}
+	src.WriteString(")\n")
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+func writeConverters(w *bytes.Buffer, table drivers.Table, model, one, many string, gql gqlType,
+	enums, imports map[string]bool) {
+	columns := map[string]drivers.Column{}
+	for _, column := range table.Columns {
+		columns[strings.ToLower(strmangle.TitleCase(column.Name))] = column
+	}
+	var assignments, skipped []string
+	for _, field := range gql.fields {
+		column, ok := columns[strings.ToLower(field.name)]
+		if !ok {
+			skipped = append(skipped, field.name)
+			continue
+		}
+		value := "m." + strmangle.TitleCase(column.Name)
+		c, ok := conversions[[2]string{column.Type, field.typ}]
+		switch {
+		case ok:
+			value = fmt.Sprintf(c.expr, value)
+			if c.pkg != "" {
+				imports[c.pkg] = true
+			}
+		case column.Type == field.typ && builtins[field.typ]:
+		case column.Type == "string" && enums[field.typ]:
+			value = fmt.Sprintf("graphql.%s(strings.ToUpper(%s))", field.typ, value)
+			imports["strings"] = true
+		default:
+			skipped = append(skipped, field.name)
+			continue
+		}
+		assignments = append(assignments, fmt.Sprintf("\t\t%s: %s,\n", field.name, value))
+	}
+
+	fmt.Fprintf(w, "\n// %s converts models.%sSlice into array of pointer type graphql.%s\n", many, model, model)
+	fmt.Fprintf(w, "func %s(m models.%sSlice) []*graphql.%s {\n", many, model, model)
+	fmt.Fprintf(w, "\tvar r []*graphql.%s\n\tfor _, e := range m {\n\t\tr = append(r, %s(e))\n\t}\n\treturn r\n}\n",
+		model, one)
+	fmt.Fprintf(w, "\n// %s converts type models.%s into pointer type graphql.%s\n", one, model, model)
+	if len(skipped) > 0 {
+		fmt.Fprintf(w, "// %s are left empty, they have no column or no known conversion\n",
+			strings.Join(skipped, ", "))
+	}
+	fmt.Fprintf(w, "func %s(m *models.%s) *graphql.%s {\n\tif m == nil {\n\t\treturn nil\n\t}\n", one, model, model)
+	fmt.Fprintf(w, "\treturn &graphql.%s{\n%s\t}\n}\n", model, strings.Join(assignments, ""))
+}
+
+// WriteConverters writes src to the ConvertersFile of dir, or removes it when src is nil
+func WriteConverters(dir string, src []byte) error {
+	name := filepath.Join(dir, ConvertersFile)
+	if src == nil {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return os.WriteFile(name, src, 0o644)
+}
@@ -0,0 +1,153 @@
+package modelgen_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-template/internal/modelgen"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+)
+
+const gqlModels = `package gqlmodels
+
+type Invoice struct {
+	ID       string        ` + "`json:\"id\"`" + `
+	Number   int64         ` + "`json:\"number\"`" + `
+	Status   InvoiceStatus ` + "`json:\"status\"`" + `
+	Note     *string       ` + "`json:\"note\"`" + `
+	Paid     *bool         ` + "`json:\"paid\"`" + `
+	Tags     []string      ` + "`json:\"tags\"`" + `
+	IssuedAt int           ` + "`json:\"issuedAt\"`" + `
+	PaidAt   *int          ` + "`json:\"paidAt\"`" + `
+	UserID   *int          ` + "`json:\"userId\"`" + `
+	Amount   float64       ` + "`json:\"amount\"`" + `
+	Discount *float64      ` + "`json:\"discount\"`" + `
+	User     *User         ` + "`json:\"user\"`" + `
+}
+
+type Role struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+
+type User struct {
+	ID string ` + "`json:\"id\"`" + `
+}
+
+type InvoiceStatus string
+`
+
+const wantConverters = `// Code generated by gendbmdls, DO NOT EDIT.
+
+package cnvrttogql
+
+import (
+	graphql "go-template/gqlmodels"
+	"go-template/models"
+	"go-template/pkg/utl/convert"
+	"strconv"
+	"strings"
+)
+
+// InvoicesToGraphQlInvoices converts models.InvoiceSlice into array of pointer type graphql.Invoice
+func InvoicesToGraphQlInvoices(m models.InvoiceSlice) []*graphql.Invoice {
+	var r []*graphql.Invoice
+	for _, e := range m {
+		r = append(r, InvoiceToGraphQlInvoice(e))
+	}
+	return r
+}
+
+// InvoiceToGraphQlInvoice converts type models.Invoice into pointer type graphql.Invoice
+// Number, User are left empty, they have no column or no known conversion
+func InvoiceToGraphQlInvoice(m *models.Invoice) *graphql.Invoice {
+	if m == nil {
+		return nil
+	}
+	return &graphql.Invoice{
+		ID:       strconv.Itoa(m.ID),
+		Status:   graphql.InvoiceStatus(strings.ToUpper(m.Status)),
+		Note:     convert.NullDotStringToPointerString(m.Note),
+		Paid:     convert.NullDotBoolToPointerBool(m.Paid),
+		Tags:     []string(m.Tags),
+		IssuedAt: int(m.IssuedAt.UnixMilli()),
+		PaidAt:   convert.NullDotTimeToPointerInt(m.PaidAt),
+		UserID:   m.UserID.Ptr(),
+		Amount:   m.Amount,
+		Discount: m.Discount.Ptr(),
+	}
+}
+`
+
+func invoices() drivers.Table {
+	return drivers.Table{Name: "invoices", Columns: []drivers.Column{
+		{Name: "id", Type: "int"},
+		{Name: "number", Type: "string"},
+		{Name: "status", Type: "string"},
+		{Name: "note", Type: "null.String"},
+		{Name: "paid", Type: "null.Bool"},
+		{Name: "tags", Type: "types.StringArray"},
+		{Name: "issued_at", Type: "time.Time"},
+		{Name: "paid_at", Type: "null.Time"},
+		{Name: "user_id", Type: "null.Int"},
+		{Name: "amount", Type: "float64"},
+		{Name: "discount", Type: "null.Float64"},
+	}}
+}
+
+func TestConverters(t *testing.T) {
+	existing := modelgen.Existing{Funcs: map[string]bool{"UsersToGraphQlUsers": true},
+		Models: map[string]bool{"Role": true}}
+	tests := []struct {
+		name   string
+		tables []drivers.Table
+		want   string
+	}{
+		{name: "NewTable", tables: []drivers.Table{{Name: "roles"}, invoices(), {Name: "users"}}, want: wantConverters},
+		{name: "NoGraphQLType", tables: []drivers.Table{{Name: "seeds"}}},
+		{name: "Existing", tables: []drivers.Table{{Name: "roles"}, {Name: "users"}}},
+		{name: "View", tables: []drivers.Table{{Name: "invoices", IsView: true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := modelgen.Converters(tt.tables, []byte(gqlModels), existing)
+			assert.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, src)
+				return
+			}
+			assert.Equal(t, tt.want, string(src))
+		})
+	}
+}
+
+func TestConvertersInvalidGraphQL(t *testing.T) {
+	_, err := modelgen.Converters(nil, []byte("package"), modelgen.Existing{})
+	assert.ErrorContains(t, err, "parsing the graphql models")
+}
+
+func TestReadExisting(t *testing.T) {
+	existing, err := modelgen.ReadExisting(filepath.Join("..", "..", "pkg", "utl", "cnvrttogql"))
+	assert.NoError(t, err)
+	assert.True(t, existing.Funcs["UserToGraphQlUser"])
+	assert.True(t, existing.Funcs["RoleToGraphqlRole"])
+	assert.True(t, existing.Models["User"])
+	assert.True(t, existing.Models["Role"])
+	assert.False(t, existing.Models["Job"])
+}
+
+func TestWriteConverters(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, modelgen.ConvertersFile)
+	assert.NoError(t, modelgen.WriteConverters(dir, []byte(wantConverters)))
+	content, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, wantConverters, string(content))
+
+	assert.NoError(t, modelgen.WriteConverters(dir, nil))
+	assert.NoFileExists(t, name)
+	// removing a missing file is a no-op
+	assert.NoError(t, modelgen.WriteConverters(dir, nil))
+}
@@ -0,0 +1,204 @@
+// Package modelgen generates the sqlboiler models from a throwaway schema built from the
+// embedded migrations, so that the models never depend on the state of a live database, and
+// tells which generated files drifted from the committed ones.
+package modelgen
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"go-template/internal/migrations"
+
+	"github.com/lib/pq"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/volatiletech/sqlboiler/v4/boilingcore"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+	psql "github.com/volatiletech/sqlboiler/v4/drivers/sqlboiler-psql/driver"
+	"github.com/volatiletech/sqlboiler/v4/importers"
+)
+
+// DriverName is the sqlboiler driver generating the models of a throwaway schema as if its
+// tables were in public
+const DriverName = "psql-schema"
+
+func init() {
+	drivers.RegisterFromInit(DriverName, &driver{})
+}
+
+type driver struct {
+	psql.PostgresDriver
+}
+
+func (d *driver) Assemble(cfg drivers.Config) (*drivers.DBInfo, error) {
+	info, err := d.PostgresDriver.Assemble(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// the models query the tables of the search_path, like the models generated from public
+	info.Schema = "public"
+	info.Dialect.UseSchema = false
+	return info, nil
+}
+
+// SchemaName returns the name of the throwaway schema of a run started at now
+func SchemaName(now time.Time) string {
+	return fmt.Sprintf("gendbmdls_%d", now.UnixNano())
+}
+
+// BuildSchema creates schema and applies the migrations of src to it, then returns a func
+// dropping it. The search_path of db must be schema so that the unqualified names of the
+// migrations, and the migrations table, resolve to it.
+func BuildSchema(ctx context.Context, db *sql.DB, schema string, src migrate.MigrationSource) (func() error, error) {
+	quoted := pq.QuoteIdentifier(schema)
+	if _, err := db.ExecContext(ctx, "CREATE SCHEMA "+quoted); err != nil {
+		return nil, fmt.Errorf("creating the schema %s: %w", schema, err)
+	}
+	drop := func() error {
+		_, err := db.ExecContext(context.Background(), "DROP SCHEMA "+quoted+" CASCADE")
+		return err
+	}
+	if _, err := migrations.Apply(db, migrations.InSchema(src, schema), migrate.Up, 0); err != nil {
+		_ = drop()
+		return nil, fmt.Errorf("applying the migrations to %s: %w", schema, err)
+	}
+	return drop, nil
+}
+
+// Options configure Generate
+type Options struct {
+	// Creds are the PSQL_* credentials of the database holding Schema
+	Creds  map[string]string
+	Schema string
+	// Tables are the tables to generate the models of, all of them when empty
+	Tables    []string
+	OutFolder string
+}
+
+// DriverConfig returns the configuration of the driver reading the tables of opts
+func DriverConfig(opts Options) drivers.Config {
+	cfg := drivers.Config{
+		drivers.ConfigUser:   opts.Creds["PSQL_USER"],
+		drivers.ConfigPass:   opts.Creds["PSQL_PASS"],
+		drivers.ConfigDBName: opts.Creds["PSQL_DBNAME"],
+		drivers.ConfigHost:   opts.Creds["PSQL_HOST"],
+		drivers.ConfigSchema: opts.Schema,
+	}
+	if port, err := strconv.Atoi(opts.Creds["PSQL_PORT"]); err == nil {
+		cfg[drivers.ConfigPort] = port
+	}
+	if sslmode := opts.Creds["PSQL_SSLMODE"]; sslmode != "" {
+		cfg[drivers.ConfigSSLMode] = sslmode
+	}
+	if len(opts.Tables) > 0 {
+		cfg[drivers.ConfigWhitelist] = opts.Tables
+	}
+	return cfg
+}
+
+// Generate writes the models of the tables of opts to opts.OutFolder and returns the tables
+func Generate(opts Options) ([]drivers.Table, error) {
+	state, err := boilingcore.New(&boilingcore.Config{
+		DriverName:      DriverName,
+		DriverConfig:    DriverConfig(opts),
+		NoHooks:         true,
+		OutFolder:       opts.OutFolder,
+		PkgName:         "models",
+		StructTagCasing: "snake",
+		RelationTag:     "-",
+		Imports:         importers.NewDefaultImports(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := state.Run(); err != nil {
+		_ = state.Cleanup()
+		return nil, err
+	}
+	return state.Tables, state.Cleanup()
+}
+
+// Drift returns the differences between the files of dir and the files generated to
+// generated, as the paths relative to dir prefixed with changed, missing when only generated
+// has the file, or stale when only dir has it
+func Drift(dir, generated string) ([]string, error) {
+	committed, err := readFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := readFiles(generated)
+	if err != nil {
+		return nil, err
+	}
+	reasons := map[string]string{}
+	for name, content := range fresh {
+		old, ok := committed[name]
+		switch {
+		case !ok:
+			reasons[name] = "missing"
+		case !bytes.Equal(old, content):
+			reasons[name] = "changed"
+		}
+	}
+	for name := range committed {
+		if _, ok := fresh[name]; !ok {
+			reasons[name] = "stale"
+		}
+	}
+	names := make([]string, 0, len(reasons))
+	for name := range reasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	drift := make([]string, 0, len(names))
+	for _, name := range names {
+		drift = append(drift, reasons[name]+" "+path.Join(filepath.ToSlash(dir), name))
+	}
+	return drift, nil
+}
+
+// FileDrift returns the difference between the file at name and content, empty when there is
+// none. A nil content means the file should not exist.
+func FileDrift(name string, content []byte) (string, error) {
+	old, err := os.ReadFile(name)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && content == nil:
+		return "", nil
+	case errors.Is(err, fs.ErrNotExist):
+		return "missing " + filepath.ToSlash(name), nil
+	case err != nil:
+		return "", err
+	case content == nil:
+		return "stale " + filepath.ToSlash(name), nil
+	case !bytes.Equal(old, content):
+		return "changed " + filepath.ToSlash(name), nil
+	}
+	return "", nil
+}
+
+// readFiles returns the content of the regular files of dir by their path relative to it
+func readFiles(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		files[filepath.ToSlash(rel)] = content
+		return err
+	})
+	return files, err
+}
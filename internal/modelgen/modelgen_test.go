@@ -0,0 +1,146 @@
+package modelgen_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-template/internal/migrations"
+	"go-template/internal/modelgen"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agiledragon/gomonkey/v2"
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/drivers"
+)
+
+func TestSchemaName(t *testing.T) {
+	assert.Equal(t, "gendbmdls_1700000000000000001", modelgen.SchemaName(time.Unix(1_700_000_000, 1)))
+}
+
+func TestBuildSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{name: "Success"},
+		{name: "MigrationFails", err: errors.New("syntax error"),
+			wantErr: "applying the migrations to gendbmdls_1: syntax error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+			mock.ExpectExec(`CREATE SCHEMA "gendbmdls_1"`).WillReturnResult(sqlmock.NewResult(0, 0))
+			var applied []*migrate.Migration
+			patches := gomonkey.ApplyFunc(migrations.Apply, func(_ *sql.DB, src migrate.MigrationSource,
+				_ migrate.MigrationDirection, _ int) (int, error) {
+				applied, err = src.FindMigrations()
+				assert.NoError(t, err)
+				return len(applied), tt.err
+			})
+			defer patches.Reset()
+			// the schema is dropped by the caller once the models are generated, or right away when
+			// the migrations fail
+			mock.ExpectExec(`DROP SCHEMA "gendbmdls_1" CASCADE`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			drop, err := modelgen.BuildSchema(context.Background(), db, "gendbmdls_1", migrations.Source())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.NoError(t, mock.ExpectationsWereMet())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, applied[0].Up[0], `CREATE TABLE "gendbmdls_1".roles`)
+			assert.NoError(t, drop())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestBuildSchemaCreateFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+	mock.ExpectExec(`CREATE SCHEMA "gendbmdls_1"`).WillReturnError(errors.New("permission denied"))
+	_, err = modelgen.BuildSchema(context.Background(), db, "gendbmdls_1", migrations.Source())
+	assert.EqualError(t, err, "creating the schema gendbmdls_1: permission denied")
+}
+
+func TestDriverConfig(t *testing.T) {
+	creds := map[string]string{"PSQL_USER": "go_template_role", "PSQL_PASS": "go_template_role456",
+		"PSQL_DBNAME": "go_template", "PSQL_HOST": "localhost", "PSQL_PORT": "5432", "PSQL_SSLMODE": "disable"}
+	assert.Equal(t, drivers.Config{
+		drivers.ConfigUser:      "go_template_role",
+		drivers.ConfigPass:      "go_template_role456",
+		drivers.ConfigDBName:    "go_template",
+		drivers.ConfigHost:      "localhost",
+		drivers.ConfigPort:      5432,
+		drivers.ConfigSSLMode:   "disable",
+		drivers.ConfigSchema:    "gendbmdls_1",
+		drivers.ConfigWhitelist: []string{"roles", "users"},
+	}, modelgen.DriverConfig(modelgen.Options{Creds: creds, Schema: "gendbmdls_1", Tables: []string{"roles", "users"}}))
+
+	cfg := modelgen.DriverConfig(modelgen.Options{Creds: map[string]string{}, Schema: "gendbmdls_1"})
+	assert.NotContains(t, cfg, drivers.ConfigPort)
+	assert.NotContains(t, cfg, drivers.ConfigSSLMode)
+	assert.NotContains(t, cfg, drivers.ConfigWhitelist)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+}
+
+func TestDrift(t *testing.T) {
+	committed, generated := t.TempDir(), t.TempDir()
+	writeFiles(t, committed, map[string]string{"roles.go": "roles", "users.go": "users", "old.go": "old"})
+	writeFiles(t, generated, map[string]string{"roles.go": "roles", "users.go": "users v2", "jobs.go": "jobs"})
+
+	drift, err := modelgen.Drift(committed, generated)
+	assert.NoError(t, err)
+	dir := filepath.ToSlash(committed)
+	assert.Equal(t, []string{"missing " + dir + "/jobs.go", "stale " + dir + "/old.go", "changed " + dir + "/users.go"},
+		drift)
+
+	drift, err = modelgen.Drift(committed, committed)
+	assert.NoError(t, err)
+	assert.Empty(t, drift)
+
+	_, err = modelgen.Drift(filepath.Join(committed, "missing"), generated)
+	assert.Error(t, err)
+}
+
+func TestFileDrift(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"gen.go": "gen"})
+	name, missing := filepath.Join(dir, "gen.go"), filepath.Join(dir, "missing.go")
+	tests := []struct {
+		name    string
+		file    string
+		content []byte
+		want    string
+	}{
+		{name: "Same", file: name, content: []byte("gen"), want: ""},
+		{name: "Changed", file: name, content: []byte("gen v2"), want: "changed " + filepath.ToSlash(name)},
+		{name: "Stale", file: name, want: "stale " + filepath.ToSlash(name)},
+		{name: "Missing", file: missing, content: []byte("gen"), want: "missing " + filepath.ToSlash(missing)},
+		{name: "NotGenerated", file: missing, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift, err := modelgen.FileDrift(tt.file, tt.content)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, drift)
+		})
+	}
+}
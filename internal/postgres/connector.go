@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"time"
+
+	"go-template/pkg/utl/zaplog"
+)
+
+// WrapConnector returns a connector whose connections apply the query timeout of opts and
+// log the queries, it sits below database/sql so that the deadline of a query lasts until
+// its rows are closed
+func WrapConnector(c driver.Connector, opts Options) driver.Connector {
+	return &connector{Connector: c, opts: opts}
+}
+
+type connector struct {
+	driver.Connector
+	opts Options
+}
+
+// Connect ...
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	var stale func() bool
+	if r, ok := c.Connector.(*Rotator); ok {
+		stale = r.staleFunc()
+	}
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, opts: c.opts, stale: stale}, nil
+}
+
+type conn struct {
+	driver.Conn
+	opts Options
+	// stale reports whether the credentials of the connection were rotated
+	stale func() bool
+}
+
+// IsValid retires the connections opened with rotated credentials as they are returned to
+// the pool
+func (c *conn) IsValid() bool {
+	if c.stale != nil && c.stale() {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// ResetSession ...
+func (c *conn) ResetSession(ctx context.Context) error {
+	if c.stale != nil && c.stale() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.QueryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opts.QueryTimeout)
+}
+
+// observe logs the query when queries are logged or when it was slow, arguments are left
+// out since they may hold credentials
+func (c *conn) observe(ctx context.Context, query string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	slow := c.opts.SlowQueryThreshold > 0 && elapsed >= c.opts.SlowQueryThreshold
+	if !slow && !c.opts.LogQueries {
+		return
+	}
+	fields := []interface{}{"query", query, "duration_ms", elapsed.Milliseconds()}
+	if err != nil {
+		fields = append(fields, "error", err.Error())
+	}
+	if slow {
+		zaplog.For(ctx).Warnw("slow query", fields...)
+		return
+	}
+	zaplog.For(ctx).Debugw("query", fields...)
+}
+
+// QueryContext ...
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	queryCtx, cancel := c.withTimeout(ctx)
+	start := time.Now()
+	r, err := queryer.QueryContext(queryCtx, query, args)
+	c.observe(ctx, query, start, err)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &rows{Rows: r, cancel: cancel}, nil
+}
+
+// ExecContext ...
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	execCtx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	start := time.Now()
+	res, err := execer.ExecContext(execCtx, query, args)
+	c.observe(ctx, query, start, err)
+	return res, err
+}
+
+// PrepareContext ...
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx ...
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	//nolint:staticcheck // fallback of drivers without BeginTx
+	return c.Conn.Begin()
+}
+
+// Ping ...
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// rows cancels the deadline of its query once closed
+type rows struct {
+	driver.Rows
+	cancel context.CancelFunc
+}
+
+// Close ...
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// HasNextResultSet ...
+func (r *rows) HasNextResultSet() bool {
+	next, ok := r.Rows.(driver.RowsNextResultSet)
+	return ok && next.HasNextResultSet()
+}
+
+// NextResultSet ...
+func (r *rows) NextResultSet() error {
+	if next, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return next.NextResultSet()
+	}
+	return io.EOF
+}
+
+// ColumnTypeDatabaseTypeName ...
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+// ColumnTypeScanType ...
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if typed, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return typed.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
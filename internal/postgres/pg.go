@@ -1,36 +1,196 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"go-template/internal/config"
+	"go-template/internal/secrets"
 	"go-template/pkg/utl/zaplog"
 	"go-template/testutls"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	otelsql "github.com/uptrace/opentelemetry-go-extra/otelsql"
 	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
 
-	// DB adapter
-	_ "github.com/lib/pq"
+const (
+	defaultMaxOpenConns       = 25
+	defaultConnMaxLifetime    = 30 * time.Minute
+	defaultConnMaxIdleTime    = 5 * time.Minute
+	defaultSlowQueryThreshold = 500 * time.Millisecond
 )
 
-// Connect ...
+// Options configure the connection pool and the queries
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// QueryTimeout is the deadline of every query, 0 disables it
+	QueryTimeout time.Duration
+	// StatementTimeout is set as the statement_timeout of the sessions, 0 disables it
+	StatementTimeout time.Duration
+	// SearchPath is set as the search_path of the sessions when not empty
+	SearchPath string
+	// LogQueries logs every query at debug level
+	LogQueries bool
+	// SlowQueryThreshold is the duration above which queries are logged as slow
+	SlowQueryThreshold time.Duration
+}
+
+// OptionsFromConfig applies the defaults to the unset settings of cfg: 25 open and idle
+// connections recycled after 30 minutes or 5 idle minutes, a statement_timeout equal to the
+// query timeout and a 500ms slow query threshold
+func OptionsFromConfig(cfg *config.Database) Options {
+	opts := Options{
+		MaxOpenConns:       cfg.MaxOpenConns,
+		MaxIdleConns:       cfg.MaxIdleConns,
+		ConnMaxLifetime:    time.Duration(cfg.ConnMaxLifetime) * time.Second,
+		ConnMaxIdleTime:    time.Duration(cfg.ConnMaxIdleTime) * time.Second,
+		QueryTimeout:       time.Duration(cfg.Timeout) * time.Second,
+		StatementTimeout:   time.Duration(cfg.StatementTimeout) * time.Millisecond,
+		LogQueries:         cfg.LogQueries,
+		SlowQueryThreshold: time.Duration(cfg.SlowQueryThreshold) * time.Millisecond,
+	}
+	if opts.MaxOpenConns <= 0 {
+		opts.MaxOpenConns = defaultMaxOpenConns
+	}
+	if opts.MaxIdleConns <= 0 || opts.MaxIdleConns > opts.MaxOpenConns {
+		opts.MaxIdleConns = opts.MaxOpenConns
+	}
+	if opts.ConnMaxLifetime <= 0 {
+		opts.ConnMaxLifetime = defaultConnMaxLifetime
+	}
+	if opts.ConnMaxIdleTime <= 0 {
+		opts.ConnMaxIdleTime = defaultConnMaxIdleTime
+	}
+	if opts.StatementTimeout <= 0 {
+		opts.StatementTimeout = opts.QueryTimeout
+	}
+	if opts.SlowQueryThreshold <= 0 {
+		opts.SlowQueryThreshold = defaultSlowQueryThreshold
+	}
+	return opts
+}
+
+// CredentialKeys are the secrets the connection string is built from
+var CredentialKeys = []string{"PSQL_DBNAME", "PSQL_HOST", "PSQL_USER", "PSQL_PASS", "PSQL_PORT", "PSQL_SSLMODE"}
+
+// Connect opens a pool configured from the DB_* environment variables
 func Connect() (*sql.DB, error) {
-	dsn := GetDSN()
-	zaplog.Logger.Info("Connecting to DB\n", dsn)
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return nil, err
+	}
+	return ConnectWith(OptionsFromConfig(cfg))
+}
+
+// ConnectWith opens a pool configured with opts, the credentials are read once from the
+// provider selected by SECRETS_PROVIDER
+func ConnectWith(opts Options) (*sql.DB, error) {
+	provider, err := secrets.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := secrets.Read(context.Background(), provider, CredentialKeys...)
+	if err != nil {
+		return nil, err
+	}
+	logConnect(creds)
+	return Open(DSN(creds), opts)
+}
+
+// ConnectWatched opens a pool configured with opts whose credentials are watched by w, the
+// pool reconnects with the new credentials once they are rotated
+func ConnectWatched(ctx context.Context, w *secrets.Watcher, opts Options) (*sql.DB, error) {
+	var db *sql.DB
+	var rotator *Rotator
+	err := w.Watch(ctx, CredentialKeys, func(_ context.Context, creds map[string]string) error {
+		logConnect(creds)
+		if rotator != nil {
+			return rotator.SetDSN(DSN(creds))
+		}
+		var err error
+		db, rotator, err = OpenRotating(DSN(creds), opts)
+		return err
+	})
+	return db, err
+}
+
+func logConnect(creds map[string]string) {
+	zaplog.Logger.Infow("Connecting to DB",
+		"host", creds["PSQL_HOST"],
+		"port", creds["PSQL_PORT"],
+		"dbname", creds["PSQL_DBNAME"],
+		"user", creds["PSQL_USER"])
+}
+
+// Open opens a pool to the database of dsn, a connection string or url, configured with opts
+func Open(dsn string, opts Options) (*sql.DB, error) {
+	db, _, err := OpenRotating(dsn, opts)
+	return db, err
+}
+
+// OpenRotating opens a pool like Open along with the Rotator swapping its dsn
+func OpenRotating(dsn string, opts Options) (*sql.DB, *Rotator, error) {
+	rotator, err := NewRotator(dsn, func(dsn string) (driver.Connector, error) {
+		if opts.StatementTimeout > 0 {
+			// unknown settings are sent to the server as run-time parameters of the session
+			dsn = withParameter(dsn, "statement_timeout", strconv.FormatInt(opts.StatementTimeout.Milliseconds(), 10))
+		}
+		if opts.SearchPath != "" {
+			dsn = withParameter(dsn, "search_path", opts.SearchPath)
+		}
+		return pq.NewConnector(dsn)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var db *sql.DB
 	if testutls.IsInTests() {
-		return sql.Open("postgres", dsn)
+		db = sql.OpenDB(WrapConnector(rotator, opts))
+	} else {
+		db = otelsql.OpenDB(WrapConnector(rotator, opts), otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
+	}
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	return db, rotator, nil
+}
+
+func withParameter(dsn, key, value string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return fmt.Sprintf("%s %s=%s", dsn, key, value)
 	}
-	return otelsql.Open("postgres", dsn, otelsql.WithAttributes(semconv.DBSystemPostgreSQL))
+	if strings.Contains(dsn, "?") {
+		return fmt.Sprintf("%s&%s=%s", dsn, key, value)
+	}
+	return fmt.Sprintf("%s?%s=%s", dsn, key, value)
 }
 
+// GetDSN returns the connection string of the PSQL_* environment variables
 func GetDSN() string {
-	dsn := fmt.Sprintf("dbname=%s host=%s user=%s password=%s port=%s sslmode=%s",
-		os.Getenv("PSQL_DBNAME"),
-		os.Getenv("PSQL_HOST"),
-		os.Getenv("PSQL_USER"),
-		os.Getenv("PSQL_PASS"),
-		os.Getenv("PSQL_PORT"),
-		os.Getenv("PSQL_SSLMODE"))
-	return dsn
+	creds := make(map[string]string, len(CredentialKeys))
+	for _, key := range CredentialKeys {
+		creds[key] = os.Getenv(key)
+	}
+	return DSN(creds)
+}
+
+// DSN returns the connection string of the PSQL_* credentials of creds
+func DSN(creds map[string]string) string {
+	return fmt.Sprintf("dbname=%s host=%s user=%s password=%s port=%s sslmode=%s",
+		creds["PSQL_DBNAME"],
+		creds["PSQL_HOST"],
+		creds["PSQL_USER"],
+		creds["PSQL_PASS"],
+		creds["PSQL_PORT"],
+		creds["PSQL_SSLMODE"])
 }
@@ -1,17 +1,20 @@
 package postgres_test
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"go-template/internal/config"
 	"go-template/internal/postgres"
 	"go-template/testutls"
-	"os"
-	"reflect"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	. "github.com/agiledragon/gomonkey/v2"
-	_ "github.com/lib/pq"
-	"github.com/uptrace/opentelemetry-go-extra/otelsql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetDSN(t *testing.T) {
@@ -36,58 +39,177 @@ func TestGetDSN(t *testing.T) {
 
 func TestConnect(t *testing.T) {
 	tests := []struct {
-		name    string
-		want    *sql.DB
-		useOtel bool
-		wantErr bool
+		name      string
+		useOtel   bool
+		connErr   error
+		wantErr   bool
+		wantConns int
 	}{
 		{
-			name:    "Return nil when hitting sql.Open",
-			want:    nil,
-			useOtel: false,
-			wantErr: false,
+			name:      "Open with sql.OpenDB",
+			wantConns: 25,
 		},
 		{
-			name:    "Return nil when hitting otelsql.Open",
-			want:    nil,
-			useOtel: true,
-			wantErr: false,
+			name:      "Open with otelsql.OpenDB",
+			useOtel:   true,
+			wantConns: 25,
 		},
 		{
-			name:    "Return err when hitting otelsql.Open",
-			want:    nil,
-			useOtel: true,
+			name:    "Return err when the dsn is invalid",
+			connErr: fmt.Errorf("this is an error"),
 			wantErr: true,
 		},
 	}
-	mock, db, _ := testutls.SetupEnvAndDB(t, testutls.Parameters{
-		EnvFileLocation: "../../.env.local",
-	})
-	fmt.Println(mock, db)
+	testutls.SetupEnv("../../.env.local")
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.useOtel {
-				os.Args = []string{"something"}
-				ApplyFunc(otelsql.Open, func(string, string, ...otelsql.Option) (*sql.DB, error) {
-					if tt.wantErr {
-						return nil, fmt.Errorf("this is an error")
-					}
-					return tt.want, nil
-				})
-			} else {
-				ApplyFunc(sql.Open, func(string, string) (*sql.DB, error) {
-					return tt.want, nil
+			patches := ApplyFunc(testutls.IsInTests, func() bool {
+				return !tt.useOtel
+			})
+			defer patches.Reset()
+			if tt.connErr != nil {
+				patches.ApplyFunc(pq.NewConnector, func(string) (*pq.Connector, error) {
+					return nil, tt.connErr
 				})
 			}
 
 			got, err := postgres.Connect()
-			if (err != nil) != tt.wantErr {
-				t.Errorf("Connect() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("Connect() = %v, want %v", got, tt.want)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantConns, got.Stats().MaxOpenConnections)
+				assert.Nil(t, got.Close())
 			}
 		})
 	}
 }
+
+func TestOptionsFromConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *config.Database
+		want postgres.Options
+	}{
+		{
+			name: "Defaults",
+			cfg:  &config.Database{Timeout: 5},
+			want: postgres.Options{
+				MaxOpenConns:       25,
+				MaxIdleConns:       25,
+				ConnMaxLifetime:    30 * time.Minute,
+				ConnMaxIdleTime:    5 * time.Minute,
+				QueryTimeout:       5 * time.Second,
+				StatementTimeout:   5 * time.Second,
+				SlowQueryThreshold: 500 * time.Millisecond,
+			},
+		},
+		{
+			name: "Overrides",
+			cfg: &config.Database{
+				LogQueries:         true,
+				Timeout:            5,
+				MaxOpenConns:       10,
+				MaxIdleConns:       20,
+				ConnMaxLifetime:    60,
+				ConnMaxIdleTime:    30,
+				StatementTimeout:   2000,
+				SlowQueryThreshold: 100,
+			},
+			want: postgres.Options{
+				MaxOpenConns:       10,
+				MaxIdleConns:       10,
+				ConnMaxLifetime:    time.Minute,
+				ConnMaxIdleTime:    30 * time.Second,
+				QueryTimeout:       5 * time.Second,
+				StatementTimeout:   2 * time.Second,
+				LogQueries:         true,
+				SlowQueryThreshold: 100 * time.Millisecond,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, postgres.OptionsFromConfig(tt.cfg))
+		})
+	}
+}
+
+type dsnConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func TestWrapConnector(t *testing.T) {
+	mockDB, mock, err := sqlmock.NewWithDSN("wrap-connector")
+	assert.Nil(t, err)
+	defer mockDB.Close()
+	db := sql.OpenDB(postgres.WrapConnector(dsnConnector{driver: mockDB.Driver(), dsn: "wrap-connector"},
+		postgres.Options{QueryTimeout: 50 * time.Millisecond, LogQueries: true, SlowQueryThreshold: time.Second}))
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectExec("SELECT pg_sleep").WillDelayFor(time.Second).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// rows outlive the query call and stay readable until they are closed
+	rows, err := db.QueryContext(context.Background(), "SELECT id FROM users")
+	assert.Nil(t, err)
+	var ids []int
+	for rows.Next() {
+		var id int
+		assert.Nil(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	assert.Nil(t, rows.Close())
+	assert.Equal(t, []int{1, 2}, ids)
+
+	start := time.Now()
+	_, err = db.ExecContext(context.Background(), "SELECT pg_sleep(1)")
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRotator(t *testing.T) {
+	first, firstMock, err := sqlmock.NewWithDSN("rotator-first")
+	assert.Nil(t, err)
+	defer first.Close()
+	second, secondMock, err := sqlmock.NewWithDSN("rotator-second")
+	assert.Nil(t, err)
+	defer second.Close()
+
+	rotator, err := postgres.NewRotator("rotator-first", func(dsn string) (driver.Connector, error) {
+		if dsn == "" {
+			return nil, fmt.Errorf("empty dsn")
+		}
+		return dsnConnector{driver: first.Driver(), dsn: dsn}, nil
+	})
+	assert.Nil(t, err)
+	db := sql.OpenDB(postgres.WrapConnector(rotator, postgres.Options{}))
+	defer db.Close()
+
+	firstMock.ExpectExec("SELECT 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	firstMock.ExpectClose()
+	_, err = db.Exec("SELECT 1")
+	assert.Nil(t, err)
+
+	// the idle connection opened with the previous credentials is closed instead of reused
+	assert.NotNil(t, rotator.SetDSN(""))
+	assert.Nil(t, rotator.SetDSN("rotator-second"))
+	secondMock.ExpectExec("SELECT 2").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = db.Exec("SELECT 2")
+	assert.Nil(t, err)
+	assert.Nil(t, firstMock.ExpectationsWereMet())
+	assert.Nil(t, secondMock.ExpectationsWereMet())
+}
+
+func TestDSN(t *testing.T) {
+	assert.Equal(t, "dbname=db host=rds user=app password=secret port=5432 sslmode=",
+		postgres.DSN(map[string]string{"PSQL_DBNAME": "db", "PSQL_HOST": "rds", "PSQL_USER": "app",
+			"PSQL_PASS": "secret", "PSQL_PORT": "5432"}))
+}
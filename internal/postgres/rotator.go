@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+)
+
+// Rotator is a connector opening the connections with its current dsn. SetDSN swaps the
+// dsn once the credentials are rotated, the pool then retires the connections opened with
+// the previous one instead of reusing them.
+type Rotator struct {
+	open func(dsn string) (driver.Connector, error)
+
+	mu         sync.RWMutex
+	connector  driver.Connector
+	generation atomic.Uint64
+}
+
+// NewRotator returns a rotator connecting to dsn with the connectors returned by open
+func NewRotator(dsn string, open func(dsn string) (driver.Connector, error)) (*Rotator, error) {
+	connector, err := open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Rotator{open: open, connector: connector}, nil
+}
+
+// SetDSN makes the new connections use dsn and marks the open ones as stale
+func (r *Rotator) SetDSN(dsn string) error {
+	connector, err := r.open(dsn)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connector = connector
+	r.generation.Add(1)
+	return nil
+}
+
+// Connect ...
+func (r *Rotator) Connect(ctx context.Context) (driver.Conn, error) {
+	r.mu.RLock()
+	connector := r.connector
+	r.mu.RUnlock()
+	return connector.Connect(ctx)
+}
+
+// Driver ...
+func (r *Rotator) Driver() driver.Driver {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.connector.Driver()
+}
+
+// staleFunc reports whether a connection opened now outlived the dsn it was opened with
+func (r *Rotator) staleFunc() func() bool {
+	generation := r.generation.Load()
+	return func() bool { return r.generation.Load() != generation }
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go-template/models"
+	"go-template/pkg/utl/rediscache"
+)
+
+// CachedUsers serves FindByID from redis, falling back to the wrapped repository on a miss
+type CachedUsers struct {
+	UserRepository
+}
+
+// FindByID ...
+func (c CachedUsers) FindByID(ctx context.Context, id int) (*models.User, error) {
+	return cached(fmt.Sprintf("user%d", id), func() (*models.User, error) {
+		return c.UserRepository.FindByID(ctx, id)
+	})
+}
+
+// CachedRoles serves FindByID from redis, falling back to the wrapped repository on a miss
+type CachedRoles struct {
+	RoleRepository
+}
+
+// FindByID ...
+func (c CachedRoles) FindByID(ctx context.Context, id int) (*models.Role, error) {
+	return cached(fmt.Sprintf("role%d", id), func() (*models.Role, error) {
+		return c.RoleRepository.FindByID(ctx, id)
+	})
+}
+
+// cached returns the value cached at key, loading and caching it when missing
+func cached[T any](key string, load func() (*T, error)) (*T, error) {
+	value, err := rediscache.GetKeyValue(key)
+	if err != nil {
+		return nil, err
+	}
+	if b, ok := value.([]byte); ok {
+		var v T
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := rediscache.SetKeyValue(key, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
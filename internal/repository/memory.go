@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"go-template/models"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null/v8"
+)
+
+// uniqueViolation is what Postgres fails with when a unique column is duplicated, the memory
+// repositories use it too so that the callers map it the same way
+var uniqueViolation = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+
+// MemoryUsers stores the users in memory, the records it returns are copies
+type MemoryUsers struct {
+	mu     sync.RWMutex
+	users  map[int]models.User
+	lastID int
+}
+
+// NewMemoryUsers returns a repository holding users, their ids are kept
+func NewMemoryUsers(users ...models.User) *MemoryUsers {
+	m := &MemoryUsers{users: map[int]models.User{}}
+	for _, u := range users {
+		u.R = nil
+		m.users[u.ID] = u
+		if u.ID > m.lastID {
+			m.lastID = u.ID
+		}
+	}
+	return m
+}
+
+// FindByID ...
+func (m *MemoryUsers) FindByID(_ context.Context, id int) (*models.User, error) {
+	return m.find(func(u models.User) bool { return u.ID == id })
+}
+
+// FindByUsername ...
+func (m *MemoryUsers) FindByUsername(_ context.Context, username string) (*models.User, error) {
+	return m.find(func(u models.User) bool { return u.Username.Valid && u.Username.String == username })
+}
+
+// FindByEmail ...
+func (m *MemoryUsers) FindByEmail(_ context.Context, email string) (*models.User, error) {
+	return m.find(func(u models.User) bool { return u.Email.Valid && u.Email.String == email })
+}
+
+// FindByToken ...
+func (m *MemoryUsers) FindByToken(_ context.Context, token string) (*models.User, error) {
+	return m.find(func(u models.User) bool { return u.Token.Valid && u.Token.String == token })
+}
+
+// FindAll ...
+func (m *MemoryUsers) FindAll(_ context.Context, page Page) (models.UserSlice, int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	users := make(models.UserSlice, 0, len(m.users))
+	for _, u := range m.users {
+		users = append(users, &u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	total := int64(len(users))
+	if page.Limit != 0 {
+		users = users[min(page.Offset, len(users)):min(page.Offset+page.Limit, len(users))]
+	}
+	return users, total, nil
+}
+
+// Create assigns the next id to user unless it has one
+func (m *MemoryUsers) Create(_ context.Context, _ *sql.Tx, user models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if user.ID == 0 {
+		user.ID = m.lastID + 1
+	}
+	if _, ok := m.users[user.ID]; ok || m.taken(user) {
+		return user, uniqueViolation
+	}
+	if user.ID > m.lastID {
+		m.lastID = user.ID
+	}
+	now := time.Now().UTC()
+	user.CreatedAt = null.TimeFrom(now)
+	user.UpdatedAt = null.TimeFrom(now)
+	user.R = nil
+	m.users[user.ID] = user
+	return user, nil
+}
+
+// Update replaces the stored user having the id of user, updating a missing user does nothing
+func (m *MemoryUsers) Update(_ context.Context, _ *sql.Tx, user models.User) (models.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[user.ID]; !ok {
+		return user, nil
+	}
+	if m.taken(user) {
+		return user, uniqueViolation
+	}
+	user.UpdatedAt = null.TimeFrom(time.Now().UTC())
+	user.R = nil
+	m.users[user.ID] = user
+	return user, nil
+}
+
+// Delete ...
+func (m *MemoryUsers) Delete(_ context.Context, _ *sql.Tx, user models.User) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[user.ID]; !ok {
+		return 0, nil
+	}
+	delete(m.users, user.ID)
+	return 1, nil
+}
+
+func (m *MemoryUsers) find(match func(models.User) bool) (*models.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, u := range m.users {
+		if match(u) {
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// taken reports whether another user has the username or the email of user
+func (m *MemoryUsers) taken(user models.User) bool {
+	for _, u := range m.users {
+		if u.ID == user.ID {
+			continue
+		}
+		if (user.Username.Valid && u.Username == user.Username) || (user.Email.Valid && u.Email == user.Email) {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryRoles stores the roles in memory, the records it returns are copies
+type MemoryRoles struct {
+	mu     sync.RWMutex
+	roles  map[int]models.Role
+	lastID int
+}
+
+// NewMemoryRoles returns a repository holding roles, their ids are kept
+func NewMemoryRoles(roles ...models.Role) *MemoryRoles {
+	m := &MemoryRoles{roles: map[int]models.Role{}}
+	for _, r := range roles {
+		r.R = nil
+		m.roles[r.ID] = r
+		if r.ID > m.lastID {
+			m.lastID = r.ID
+		}
+	}
+	return m
+}
+
+// FindByID ...
+func (m *MemoryRoles) FindByID(_ context.Context, id int) (*models.Role, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	role, ok := m.roles[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &role, nil
+}
+
+// FindAll ...
+func (m *MemoryRoles) FindAll(_ context.Context) (models.RoleSlice, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	roles := make(models.RoleSlice, 0, len(m.roles))
+	for _, r := range m.roles {
+		roles = append(roles, &r)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+	return roles, nil
+}
+
+// Create assigns the next id to role unless it has one
+func (m *MemoryRoles) Create(_ context.Context, _ *sql.Tx, role models.Role) (models.Role, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if role.ID == 0 {
+		role.ID = m.lastID + 1
+	}
+	if _, ok := m.roles[role.ID]; ok {
+		return role, uniqueViolation
+	}
+	if role.ID > m.lastID {
+		m.lastID = role.ID
+	}
+	now := time.Now().UTC()
+	role.CreatedAt = null.TimeFrom(now)
+	role.UpdatedAt = null.TimeFrom(now)
+	role.R = nil
+	m.roles[role.ID] = role
+	return role, nil
+}
+
+// MemoryTransactor runs units of work without a transaction, the memory repositories
+// ignore the transaction they are given
+type MemoryTransactor struct{}
+
+// WithTx ...
+func (MemoryTransactor) WithTx(_ context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
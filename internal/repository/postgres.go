@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-template/daos"
+	"go-template/models"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// PostgresUsers stores the users with the daos
+type PostgresUsers struct{}
+
+// FindByID ...
+func (PostgresUsers) FindByID(ctx context.Context, id int) (*models.User, error) {
+	return daos.FindUserByID(id, ctx)
+}
+
+// FindByUsername ...
+func (PostgresUsers) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+	return daos.FindUserByUserName(username, ctx)
+}
+
+// FindByEmail ...
+func (PostgresUsers) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return daos.FindUserByEmail(email, ctx)
+}
+
+// FindByToken ...
+func (PostgresUsers) FindByToken(ctx context.Context, token string) (*models.User, error) {
+	return daos.FindUserByToken(token, ctx)
+}
+
+// FindAll ...
+func (PostgresUsers) FindAll(ctx context.Context, page Page) (models.UserSlice, int64, error) {
+	queryMods := []qm.QueryMod{qm.OrderBy(models.UserColumns.ID)}
+	if page.Limit != 0 {
+		queryMods = append(queryMods, qm.Limit(page.Limit), qm.Offset(page.Offset))
+	}
+	return daos.FindAllUsersWithCount(queryMods, ctx)
+}
+
+// Create ...
+func (PostgresUsers) Create(ctx context.Context, tx *sql.Tx, user models.User) (models.User, error) {
+	return daos.CreateUserTx(user, ctx, tx)
+}
+
+// Update ...
+func (PostgresUsers) Update(ctx context.Context, tx *sql.Tx, user models.User) (models.User, error) {
+	return daos.UpdateUserTx(user, ctx, tx)
+}
+
+// Delete ...
+func (PostgresUsers) Delete(ctx context.Context, tx *sql.Tx, user models.User) (int64, error) {
+	return daos.DeleteUserTx(user, ctx, tx)
+}
+
+// PostgresRoles stores the roles with the daos
+type PostgresRoles struct{}
+
+// FindByID ...
+func (PostgresRoles) FindByID(ctx context.Context, id int) (*models.Role, error) {
+	return daos.FindRoleByID(id, ctx)
+}
+
+// FindAll ...
+func (PostgresRoles) FindAll(ctx context.Context) (models.RoleSlice, error) {
+	return daos.FindAllRoles(ctx)
+}
+
+// Create ...
+func (PostgresRoles) Create(ctx context.Context, tx *sql.Tx, role models.Role) (models.Role, error) {
+	return daos.CreateRoleTx(role, ctx, tx)
+}
+
+// PostgresTransactor runs units of work in Postgres transactions
+type PostgresTransactor struct{}
+
+// WithTx ...
+func (PostgresTransactor) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return daos.WithTx(ctx, fn)
+}
@@ -0,0 +1,42 @@
+// Package repository abstracts the storage of users and roles behind interfaces so that
+// the services can run against Postgres in production and against memory in tests.
+// Lookups of missing records fail with sql.ErrNoRows whatever the implementation.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go-template/models"
+)
+
+// Page selects a page of a listing, a zero Limit meaning all the records
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// UserRepository stores users. The writes take part in tx, nil running them on their own.
+type UserRepository interface {
+	FindByID(ctx context.Context, id int) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByToken(ctx context.Context, token string) (*models.User, error)
+	// FindAll returns the users of page ordered by id and the total number of users
+	FindAll(ctx context.Context, page Page) (models.UserSlice, int64, error)
+	Create(ctx context.Context, tx *sql.Tx, user models.User) (models.User, error)
+	Update(ctx context.Context, tx *sql.Tx, user models.User) (models.User, error)
+	Delete(ctx context.Context, tx *sql.Tx, user models.User) (int64, error)
+}
+
+// RoleRepository stores roles. The writes take part in tx, nil running them on their own.
+type RoleRepository interface {
+	FindByID(ctx context.Context, id int) (*models.Role, error)
+	FindAll(ctx context.Context) (models.RoleSlice, error)
+	Create(ctx context.Context, tx *sql.Tx, role models.Role) (models.Role, error)
+}
+
+// Transactor runs units of work, see daos.WithTx
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
@@ -0,0 +1,246 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go-template/daos"
+	"go-template/internal/repository"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/rediscache"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func user(id int, username string) models.User {
+	return models.User{
+		ID:       id,
+		Username: null.StringFrom(username),
+		Email:    null.StringFrom(username + "@wednesday.is"),
+		Token:    null.StringFrom("token-" + username),
+		RoleID:   null.IntFrom(1),
+	}
+}
+
+func TestMemoryUsersFind(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUsers(user(1, "alice"), user(2, "bob"))
+	tests := []struct {
+		name   string
+		find   func() (*models.User, error)
+		wantID int
+	}{
+		{"ByID", func() (*models.User, error) { return repo.FindByID(ctx, 2) }, 2},
+		{"ByUsername", func() (*models.User, error) { return repo.FindByUsername(ctx, "alice") }, 1},
+		{"ByEmail", func() (*models.User, error) { return repo.FindByEmail(ctx, "bob@wednesday.is") }, 2},
+		{"ByToken", func() (*models.User, error) { return repo.FindByToken(ctx, "token-alice") }, 1},
+		{"Missing", func() (*models.User, error) { return repo.FindByID(ctx, 3) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.find()
+			if tt.wantID == 0 {
+				assert.ErrorIs(t, err, sql.ErrNoRows)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, u.ID)
+		})
+	}
+}
+
+func TestMemoryUsersFindAll(t *testing.T) {
+	repo := repository.NewMemoryUsers(user(3, "carol"), user(1, "alice"), user(2, "bob"))
+	tests := []struct {
+		name    string
+		page    repository.Page
+		wantIDs []int
+	}{
+		{"All", repository.Page{}, []int{1, 2, 3}},
+		{"FirstPage", repository.Page{Limit: 2}, []int{1, 2}},
+		{"LastPage", repository.Page{Limit: 2, Offset: 2}, []int{3}},
+		{"PastTheEnd", repository.Page{Limit: 2, Offset: 4}, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := repo.FindAll(context.Background(), tt.page)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), total)
+			ids := []int{}
+			for _, u := range users {
+				ids = append(ids, u.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestMemoryUsersWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryUsers(user(1, "alice"))
+
+	created, err := repo.Create(ctx, nil, user(0, "bob"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+	assert.True(t, created.CreatedAt.Valid)
+
+	_, err = repo.Create(ctx, nil, user(0, "alice"))
+	assert.Equal(t, apperror.Conflict, apperror.CodeOf(apperror.FromSQL(err, "user")))
+
+	created.FirstName = null.StringFrom("Bob")
+	_, err = repo.Update(ctx, nil, created)
+	assert.NoError(t, err)
+	found, _ := repo.FindByID(ctx, 2)
+	assert.Equal(t, "Bob", found.FirstName.String)
+
+	// the records returned are copies
+	found.FirstName = null.StringFrom("Robert")
+	found, _ = repo.FindByID(ctx, 2)
+	assert.Equal(t, "Bob", found.FirstName.String)
+
+	renamed := created
+	renamed.Username = null.StringFrom("alice")
+	_, err = repo.Update(ctx, nil, renamed)
+	assert.Error(t, err)
+
+	n, err := repo.Delete(ctx, nil, created)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, _ = repo.Delete(ctx, nil, created)
+	assert.Equal(t, int64(0), n)
+	_, err = repo.FindByID(ctx, 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestMemoryRoles(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRoles(models.Role{ID: 1, Name: "SUPER_ADMIN", AccessLevel: 100})
+
+	created, err := repo.Create(ctx, nil, models.Role{Name: "USER", AccessLevel: 200})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, created.ID)
+	_, err = repo.Create(ctx, nil, models.Role{ID: 1})
+	assert.Error(t, err)
+
+	role, err := repo.FindByID(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "USER", role.Name)
+	_, err = repo.FindByID(ctx, 3)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	roles, err := repo.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, roles, 2)
+	assert.Equal(t, 1, roles[0].ID)
+}
+
+func TestMemoryTransactor(t *testing.T) {
+	called := false
+	err := repository.MemoryTransactor{}.WithTx(context.Background(), func(tx *sql.Tx) error {
+		called = true
+		assert.Nil(t, tx)
+		return errors.New("rolled back")
+	})
+	assert.True(t, called)
+	assert.EqualError(t, err, "rolled back")
+}
+
+func TestPostgresUsersFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     repository.Page
+		wantMods int
+	}{
+		{"All", repository.Page{}, 1},
+		{"Page", repository.Page{Limit: 10, Offset: 20}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mods []qm.QueryMod
+			patches := gomonkey.ApplyFunc(daos.FindAllUsersWithCount,
+				func(queryMods []qm.QueryMod, ctx context.Context) (models.UserSlice, int64, error) {
+					mods = queryMods
+					return models.UserSlice{}, 0, nil
+				})
+			defer patches.Reset()
+			_, _, err := repository.PostgresUsers{}.FindAll(context.Background(), tt.page)
+			assert.NoError(t, err)
+			assert.Len(t, mods, tt.wantMods)
+		})
+	}
+}
+
+func TestPostgresDelegates(t *testing.T) {
+	ctx := context.Background()
+	u := user(1, "alice")
+	patches := gomonkey.ApplyFunc(daos.FindUserByUserName, func(username string, ctx context.Context) (*models.User, error) {
+		return &u, nil
+	}).ApplyFunc(daos.CreateRoleTx, func(role models.Role, ctx context.Context, tx *sql.Tx) (models.Role, error) {
+		role.ID = 7
+		return role, nil
+	}).ApplyFunc(daos.WithTx, func(ctx context.Context, fn func(tx *sql.Tx) error) error {
+		return fn(nil)
+	})
+	defer patches.Reset()
+
+	found, err := repository.PostgresUsers{}.FindByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, found.ID)
+	role, err := repository.PostgresRoles{}.Create(ctx, nil, models.Role{Name: "USER"})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, role.ID)
+	assert.NoError(t, repository.PostgresTransactor{}.WithTx(ctx, func(*sql.Tx) error { return nil }))
+}
+
+func TestCachedUsers(t *testing.T) {
+	cachedUser, _ := json.Marshal(user(1, "cached"))
+	tests := []struct {
+		name         string
+		cached       interface{}
+		getErr       error
+		wantUsername string
+		wantStored   bool
+		wantErr      bool
+	}{
+		{name: "Hit", cached: cachedUser, wantUsername: "cached"},
+		{name: "Miss", wantUsername: "alice", wantStored: true},
+		{name: "RedisError", getErr: errors.New("redis down"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := false
+			patches := gomonkey.ApplyFunc(rediscache.GetKeyValue, func(key string) (interface{}, error) {
+				assert.Equal(t, "user1", key)
+				return tt.cached, tt.getErr
+			}).ApplyFunc(rediscache.SetKeyValue, func(key string, data interface{}) error {
+				stored = true
+				return nil
+			})
+			defer patches.Reset()
+
+			repo := repository.CachedUsers{UserRepository: repository.NewMemoryUsers(user(1, "alice"))}
+			u, err := repo.FindByID(context.Background(), 1)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantStored, stored)
+			if !tt.wantErr {
+				assert.Equal(t, tt.wantUsername, u.Username.String)
+			}
+		})
+	}
+}
+
+func TestCachedRolesMissing(t *testing.T) {
+	patches := gomonkey.ApplyFunc(rediscache.GetKeyValue, func(key string) (interface{}, error) {
+		return nil, nil
+	})
+	defer patches.Reset()
+	_, err := repository.CachedRoles{RoleRepository: repository.NewMemoryRoles()}.FindByID(context.Background(), 1)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
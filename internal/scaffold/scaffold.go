@@ -0,0 +1,410 @@
+// Package scaffold generates the migration, the daos, the repository, the service, the graphql
+// schema and the resolvers of a new entity, along with their tests, following the conventions of
+// the existing entities.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/99designs/gqlgen/codegen/templates"
+	"github.com/volatiletech/strmangle"
+)
+
+// Kind is the type of a field
+type Kind string
+
+// Kinds of fields
+const (
+	String Kind = "string"
+	Int    Kind = "int"
+	Float  Kind = "float"
+	Bool   Kind = "bool"
+	// Time fields are timestamps, exposed to graphql as unix milliseconds like the timestamps of
+	// the other types
+	Time Kind = "time"
+)
+
+// kinds are the column, model and graphql types of each kind, along with the go type gqlgen
+// generates for the graphql one
+var kinds = map[Kind]struct {
+	sql, nullModel, graphql, filter, mods, nullFrom, goType string
+}{
+	String: {"TEXT", "null.String", "String", "StringFilter", "StringMods", "null.StringFromPtr", "string"},
+	Int:    {"INTEGER", "null.Int", "Int", "IntFilter", "IntMods", "null.IntFromPtr", "int"},
+	Float: {"DOUBLE PRECISION", "null.Float64", "Float", "FloatFilter", "FloatMods", "null.Float64FromPtr",
+		"float64"},
+	Bool: {"BOOLEAN", "null.Bool", "Boolean", "BooleanFilter", "BooleanMods", "null.BoolFromPtr", "bool"},
+	Time: {"TIMESTAMP WITH TIME ZONE", "null.Time", "Int", "IntFilter", "TimeMods",
+		"convert.PointerIntToNullDotTime", "int"},
+}
+
+// Field is a column of an entity, along with the names of its model and graphql fields
+type Field struct {
+	// Column is the snake case name of the column
+	Column string
+	Kind   Kind
+	// Nullable fields are optional on creation
+	Nullable bool
+}
+
+// Model is the name of the field of the sqlboiler model
+func (f Field) Model() string { return strmangle.TitleCase(f.Column) }
+
+// GraphQL is the name of the field of the graphql type
+func (f Field) GraphQL() string { return lowerCamel(f.Column) }
+
+// Go is the name of the field of the struct gqlgen generates for the graphql type
+func (f Field) Go() string { return templates.ToGo(f.GraphQL()) }
+
+// GoType is the type of the field of the struct gqlgen generates for the graphql type, left
+// out of the pointer of the optional fields
+func (f Field) GoType() string { return kinds[f.Kind].goType }
+
+// SQL is the definition of the column
+func (f Field) SQL() string {
+	if f.Nullable {
+		return f.Column + " " + kinds[f.Kind].sql
+	}
+	return f.Column + " " + kinds[f.Kind].sql + " NOT NULL"
+}
+
+// Type is the graphql type of the field
+func (f Field) Type() string {
+	if f.Nullable {
+		return kinds[f.Kind].graphql
+	}
+	return kinds[f.Kind].graphql + "!"
+}
+
+// OptionalType is the graphql type of the field when it may be left out
+func (f Field) OptionalType() string { return kinds[f.Kind].graphql }
+
+// Filter is the graphql filter input of the field
+func (f Field) Filter() string { return kinds[f.Kind].filter }
+
+// Mods is the gqlfilter func translating the filter of the field to query mods
+func (f Field) Mods() string { return "gqlfilter." + kinds[f.Kind].mods }
+
+// CreateValue is the value of the model field for the field v of the create input
+func (f Field) CreateValue(v string) string {
+	switch {
+	case f.Nullable:
+		return kinds[f.Kind].nullFrom + "(" + v + ")"
+	case f.Kind == Time:
+		return "time.UnixMilli(int64(" + v + "))"
+	}
+	return v
+}
+
+// UpdateValue is the value of the model field for the set field v of the update input
+func (f Field) UpdateValue(v string) string {
+	value := "*" + v
+	if f.Kind == Time {
+		value = "time.UnixMilli(int64(*" + v + "))"
+	}
+	if f.Nullable {
+		return kinds[f.Kind].nullModel + "From(" + value + ")"
+	}
+	return value
+}
+
+// Example is a value of the field for the tests
+func (f Field) Example() string {
+	switch f.Kind {
+	case Int:
+		return "1"
+	case Float:
+		return "1.5"
+	case Bool:
+		return "true"
+	case Time:
+		return "1700000000000"
+	}
+	return fmt.Sprintf("%q", f.Column)
+}
+
+// Entity is the entity to scaffold
+type Entity struct {
+	// Name is the snake case singular name of the entity
+	Name   string
+	Fields []Field
+}
+
+// Table is the name of the table of the entity
+func (e Entity) Table() string { return strmangle.Plural(e.Name) }
+
+// Model is the name of the sqlboiler model and of the graphql type of the entity
+func (e Entity) Model() string { return strmangle.TitleCase(e.Name) }
+
+// Models is the plural of Model
+func (e Entity) Models() string { return strmangle.TitleCase(e.Table()) }
+
+// Var is the name of the graphql field querying an entity, and of the variables holding one
+func (e Entity) Var() string { return lowerCamel(e.Name) }
+
+// Vars is the plural of Var
+func (e Entity) Vars() string { return lowerCamel(e.Table()) }
+
+// GoVar is the name of the field of the payload holding an entity, in the structs gqlgen
+// generates
+func (e Entity) GoVar() string { return templates.ToGo(e.Var()) }
+
+// GoVars is the plural of GoVar
+func (e Entity) GoVars() string { return templates.ToGo(e.Vars()) }
+
+// Label names the entity in messages
+func (e Entity) Label() string { return strings.ReplaceAll(e.Name, "_", " ") }
+
+// Package is the name of the package of the service of the entity
+func (e Entity) Package() string { return strings.ReplaceAll(e.Table(), "_", "") }
+
+// Labels is the plural of Label
+func (e Entity) Labels() string { return strings.ReplaceAll(e.Table(), "_", " ") }
+
+// Searched are the string fields the search of the filter matches
+func (e Entity) Searched() []Field {
+	var searched []Field
+	for _, f := range e.Fields {
+		if f.Kind == String {
+			searched = append(searched, f)
+		}
+	}
+	return searched
+}
+
+// CreateUses reports whether the CreateValue of one of the fields needs pkg: null, time or
+// convert
+func (e Entity) CreateUses(pkg string) bool {
+	for _, f := range e.Fields {
+		switch {
+		case pkg == "null" && f.Nullable && f.Kind != Time,
+			pkg == "time" && f.Kind == Time && !f.Nullable,
+			pkg == "convert" && f.Kind == Time && f.Nullable:
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateUses reports whether the UpdateValue of one of the fields needs pkg: null or time
+func (e Entity) UpdateUses(pkg string) bool {
+	for _, f := range e.Fields {
+		switch {
+		case pkg == "null" && f.Nullable,
+			pkg == "time" && f.Kind == Time:
+			return true
+		}
+	}
+	return false
+}
+
+// Returned are the columns sqlboiler reads back after inserting a zero entity: the id and the
+// nullable columns, left to their default
+func (e Entity) Returned() []string {
+	returned := []string{"id"}
+	for _, f := range e.Fields {
+		if f.Nullable {
+			returned = append(returned, f.Column)
+		}
+	}
+	return append(returned, "deleted_at")
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	// builtin are the columns every entity has
+	builtin = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true}
+	// reserved are the keywords postgres doesn't accept as column names
+	reserved = map[string]bool{"all": true, "and": true, "as": true, "asc": true, "check": true, "default": true,
+		"desc": true, "end": true, "from": true, "group": true, "limit": true, "offset": true, "or": true,
+		"order": true, "primary": true, "references": true, "select": true, "table": true, "to": true, "user": true,
+		"where": true}
+)
+
+// Parse returns the entity name with the fields of specs, each spec being name:kind with a
+// trailing ? for nullable fields, e.g. due_at:time?
+func Parse(name string, specs []string) (Entity, error) {
+	name = strmangle.Singular(name)
+	if !namePattern.MatchString(name) {
+		return Entity{}, fmt.Errorf("invalid entity %q, use lowercase letters, digits and underscores", name)
+	}
+	e := Entity{Name: name}
+	if e.Table() == e.Name {
+		return Entity{}, fmt.Errorf("the plural of %s is %s, use a name with a distinct plural", name, name)
+	}
+	if len(specs) == 0 {
+		return Entity{}, errors.New("the entity needs at least one field")
+	}
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		column, kind, ok := strings.Cut(spec, ":")
+		f := Field{Column: column, Kind: Kind(strings.TrimSuffix(kind, "?")), Nullable: strings.HasSuffix(kind, "?")}
+		_, known := kinds[f.Kind]
+		switch {
+		case !ok || !known:
+			return Entity{}, fmt.Errorf("invalid field %q, use name:kind with a kind among string, int, float, bool "+
+				"and time, followed by ? when it is nullable", spec)
+		case !namePattern.MatchString(column):
+			return Entity{}, fmt.Errorf("invalid field %q, use lowercase letters, digits and underscores", column)
+		case builtin[column]:
+			return Entity{}, fmt.Errorf("%s is added to every entity, remove it from the fields", column)
+		case reserved[column]:
+			return Entity{}, fmt.Errorf("%s is a reserved word of postgres, rename the field", column)
+		case seen[column]:
+			return Entity{}, fmt.Errorf("the field %s is repeated", column)
+		}
+		seen[column] = true
+		e.Fields = append(e.Fields, f)
+	}
+	return e, nil
+}
+
+// lowerCamel turns a snake case name into the lower camel case of the graphql fields, e.g.
+// user_id into userId
+func lowerCamel(name string) string {
+	words := strings.Split(name, "_")
+	for i := 1; i < len(words); i++ {
+		if words[i] != "" {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// File is a generated file, its path is relative to the root of the repository
+type File struct {
+	Path    string
+	Content []byte
+}
+
+//go:embed templates
+var files embed.FS
+
+var tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+	// inc numbers the placeholders of the queries of the tests
+	"inc": func(i int) int { return i + 1 },
+}).ParseFS(files, "templates/*.tmpl"))
+
+// Files returns the files of e sorted by path, the migration being named after now like the
+// migrations of cmd/migrations new
+func Files(e Entity, now time.Time) ([]File, error) {
+	paths := map[string]string{
+		"migration.sql.tmpl": filepath.Join("internal", "migrations",
+			fmt.Sprintf("%s_create_%s.sql", now.UTC().Format("20060102150405"), e.Table())),
+		"daos.go.tmpl":                   filepath.Join("daos", e.Table()+".go"),
+		"daos_test.go.tmpl":              filepath.Join("daos", e.Table()+"_test.go"),
+		"repository.go.tmpl":             filepath.Join("internal", "repository", e.Table()+".go"),
+		"service.go.tmpl":                filepath.Join("internal", "service", e.Package(), e.Package()+".go"),
+		"service_test.go.tmpl":           filepath.Join("internal", "service", e.Package(), e.Package()+"_test.go"),
+		"schema.graphql.tmpl":            filepath.Join("schema", e.Name+".graphql"),
+		"queries.graphql.tmpl":           filepath.Join("schema", e.Name+"_queries.graphql"),
+		"mutations.graphql.tmpl":         filepath.Join("schema", e.Name+"_mutations.graphql"),
+		"filters.go.tmpl":                filepath.Join("resolver", e.Table()+".go"),
+		"queries.resolvers.go.tmpl":      filepath.Join("resolver", e.Name+"_queries.resolvers.go"),
+		"queries.resolvers_test.go.tmpl": filepath.Join("resolver", e.Name+"_queries.resolvers_test.go"),
+		"mutations.resolvers.go.tmpl":    filepath.Join("resolver", e.Name+"_mutations.resolvers.go"),
+		"mutations.resolvers_test.go.tmpl": filepath.Join("resolver",
+			e.Name+"_mutations.resolvers_test.go"),
+	}
+	names, err := fs.Glob(files, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	generated := make([]File, 0, len(names))
+	for _, name := range names {
+		name = filepath.Base(name)
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, e); err != nil {
+			return nil, err
+		}
+		content := buf.Bytes()
+		if strings.HasSuffix(paths[name], ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("formatting %s: %w", paths[name], err)
+			}
+		}
+		generated = append(generated, File{Path: paths[name], Content: content})
+	}
+	sort.Slice(generated, func(i, j int) bool { return generated[i].Path < generated[j].Path })
+	return generated, nil
+}
+
+// Write writes files under root, creating their directories, it writes nothing when one of them
+// exists
+func Write(root string, files []File) error {
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(root, f.Path)); err == nil {
+			return fmt.Errorf("%s already exists", f.Path)
+		}
+	}
+	for _, f := range files {
+		if err := os.MkdirAll(filepath.Join(root, filepath.Dir(f.Path)), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(root, f.Path), f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddModelTable adds table to the modelTables of the source of gendbmdls, so that its model is
+// generated. The source is returned unchanged when it already lists table.
+func AddModelTable(src []byte, table string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var list *ast.CompositeLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 || spec.Names[0].Name != "modelTables" || len(spec.Values) != 1 {
+			return true
+		}
+		list, _ = spec.Values[0].(*ast.CompositeLit)
+		return false
+	})
+	if list == nil {
+		return nil, errors.New("modelTables not found")
+	}
+	tables := []string{table}
+	for _, elt := range list.Elts {
+		lit, ok := elt.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return nil, errors.New("modelTables is not a list of strings")
+		}
+		name, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return nil, err
+		}
+		if name == table {
+			return src, nil
+		}
+		tables = append(tables, name)
+	}
+	sort.Strings(tables)
+	quoted := make([]string, 0, len(tables))
+	for _, name := range tables {
+		quoted = append(quoted, strconv.Quote(name))
+	}
+	start, end := fset.Position(list.Lbrace).Offset+1, fset.Position(list.Rbrace).Offset
+	updated := append(append(append([]byte{}, src[:start]...), strings.Join(quoted, ", ")...), src[end:]...)
+	return format.Source(updated)
+}
@@ -0,0 +1,201 @@
+package scaffold_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-template/internal/scaffold"
+
+	migrate "github.com/rubenv/sql-migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		entity  string
+		specs   []string
+		want    scaffold.Entity
+		wantErr string
+	}{
+		{
+			name:   "Success",
+			entity: "line_items",
+			specs:  []string{"label:string", "quantity:int", "due_at:time?"},
+			want: scaffold.Entity{Name: "line_item", Fields: []scaffold.Field{
+				{Column: "label", Kind: scaffold.String},
+				{Column: "quantity", Kind: scaffold.Int},
+				{Column: "due_at", Kind: scaffold.Time, Nullable: true},
+			}},
+		},
+		{name: "InvalidEntity", entity: "LineItem", specs: []string{"label:string"},
+			wantErr: `invalid entity "LineItem", use lowercase letters, digits and underscores`},
+		{name: "SamePlural", entity: "news", specs: []string{"label:string"},
+			wantErr: "the plural of news is news, use a name with a distinct plural"},
+		{name: "NoFields", entity: "invoice", wantErr: "the entity needs at least one field"},
+		{name: "InvalidKind", entity: "invoice", specs: []string{"amount:decimal"},
+			wantErr: `invalid field "amount:decimal", use name:kind with a kind among string, int, float, bool and time, ` +
+				"followed by ? when it is nullable"},
+		{name: "MissingKind", entity: "invoice", specs: []string{"amount"},
+			wantErr: `invalid field "amount", use name:kind with a kind among string, int, float, bool and time, ` +
+				"followed by ? when it is nullable"},
+		{name: "InvalidField", entity: "invoice", specs: []string{"Amount:float"},
+			wantErr: `invalid field "Amount", use lowercase letters, digits and underscores`},
+		{name: "Builtin", entity: "invoice", specs: []string{"created_at:time"},
+			wantErr: "created_at is added to every entity, remove it from the fields"},
+		{name: "Reserved", entity: "invoice", specs: []string{"order:int"},
+			wantErr: "order is a reserved word of postgres, rename the field"},
+		{name: "Repeated", entity: "invoice", specs: []string{"amount:float", "amount:int"},
+			wantErr: "the field amount is repeated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scaffold.Parse(tt.entity, tt.specs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	e := scaffold.Entity{Name: "api_key"}
+	assert.Equal(t, "api_keys", e.Table())
+	assert.Equal(t, "APIKey", e.Model())
+	assert.Equal(t, "APIKeys", e.Models())
+	assert.Equal(t, "apiKey", e.Var())
+	assert.Equal(t, "apiKeys", e.Vars())
+	assert.Equal(t, "APIKey", e.GoVar())
+	assert.Equal(t, "api key", e.Label())
+	assert.Equal(t, "api keys", e.Labels())
+	assert.Equal(t, "apikeys", e.Package())
+
+	f := scaffold.Field{Column: "owner_id", Kind: scaffold.Int, Nullable: true}
+	assert.Equal(t, "OwnerID", f.Model())
+	assert.Equal(t, "ownerId", f.GraphQL())
+	assert.Equal(t, "OwnerID", f.Go())
+	assert.Equal(t, "owner_id INTEGER", f.SQL())
+	assert.Equal(t, "Int", f.Type())
+	assert.Equal(t, "int", f.GoType())
+	assert.Equal(t, "null.IntFromPtr(input.OwnerID)", f.CreateValue("input.OwnerID"))
+	assert.Equal(t, "null.IntFrom(*input.OwnerID)", f.UpdateValue("input.OwnerID"))
+
+	f = scaffold.Field{Column: "due_at", Kind: scaffold.Time}
+	assert.Equal(t, "due_at TIMESTAMP WITH TIME ZONE NOT NULL", f.SQL())
+	assert.Equal(t, "Int!", f.Type())
+	assert.Equal(t, "time.UnixMilli(int64(input.DueAt))", f.CreateValue("input.DueAt"))
+	assert.Equal(t, "time.UnixMilli(int64(*input.DueAt))", f.UpdateValue("input.DueAt"))
+}
+
+func TestFiles(t *testing.T) {
+	e, err := scaffold.Parse("invoice", []string{"number:string", "amount:float", "note:string?", "due_at:time?"})
+	assert.NoError(t, err)
+	files, err := scaffold.Files(e, time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	contents := map[string]string{}
+	for _, f := range files {
+		contents[filepath.ToSlash(f.Path)] = string(f.Content)
+	}
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	assert.ElementsMatch(t, []string{
+		"internal/migrations/20261019083000_create_invoices.sql",
+		"daos/invoices.go",
+		"daos/invoices_test.go",
+		"internal/repository/invoices.go",
+		"internal/service/invoices/invoices.go",
+		"internal/service/invoices/invoices_test.go",
+		"schema/invoice.graphql",
+		"schema/invoice_queries.graphql",
+		"schema/invoice_mutations.graphql",
+		"resolver/invoices.go",
+		"resolver/invoice_queries.resolvers.go",
+		"resolver/invoice_queries.resolvers_test.go",
+		"resolver/invoice_mutations.resolvers.go",
+		"resolver/invoice_mutations.resolvers_test.go",
+	}, paths)
+
+	migration, err := migrate.ParseMigration("20261019083000_create_invoices.sql",
+		strings.NewReader(contents["internal/migrations/20261019083000_create_invoices.sql"]))
+	assert.NoError(t, err)
+	assert.Contains(t, migration.Up[0], "amount DOUBLE PRECISION NOT NULL,\n\t\t\tnote TEXT,")
+	assert.Equal(t, []string{"\nDROP TABLE invoices;\n"}, migration.Down)
+
+	assert.Contains(t, contents["daos/invoices.go"],
+		"func FindAllInvoicesWithCount(queryMods []qm.QueryMod, ctx context.Context) (models.InvoiceSlice, int64, error) {")
+	assert.Contains(t, contents["daos/invoices_test.go"],
+		`sqlmock.NewRows([]string{"id", "note", "due_at", "deleted_at"})`)
+	assert.Contains(t, contents["schema/invoice.graphql"], "    Matches the invoices one of whose number, note contains it")
+	assert.Contains(t, contents["schema/invoice.graphql"], "    amount: FloatFilter\n")
+	assert.Contains(t, contents["schema/invoice_queries.graphql"],
+		"invoices(filter: InvoiceFilter, pagination: InvoicePagination): InvoicesPayload!")
+	assert.Contains(t, contents["resolver/invoices.go"],
+		"gqlfilter.Search(filter.Search, models.InvoiceColumns.Number, models.InvoiceColumns.Note)")
+	assert.Contains(t, contents["internal/repository/invoices.go"],
+		"func (PostgresInvoices) FindAll(ctx context.Context, queryMods []qm.QueryMod) (models.InvoiceSlice, int64, error) {")
+	assert.Contains(t, contents["internal/service/invoices/invoices.go"],
+		"invoice.DueAt = null.TimeFrom(time.UnixMilli(int64(*changes.DueAt)))")
+	assert.Contains(t, contents["resolver/invoice_mutations.resolvers.go"],
+		"DueAt:  convert.PointerIntToNullDotTime(input.DueAt),")
+	assert.Contains(t, contents["resolver/invoice_mutations.resolvers.go"],
+		"if err := auth.RequireSuperAdmin(ctx); err != nil {")
+	assert.Contains(t, contents["resolver/invoice_queries.resolvers.go"],
+		"invoices, count, err := r.InvoiceService.List(ctx, queryMods)")
+	assert.Contains(t, contents["resolver/invoice_queries.resolvers_test.go"],
+		`WHERE (("number" ILIKE $1 OR "note" ILIKE $2)) AND ("id" IN ($3)) LIMIT 5 OFFSET 10;`)
+}
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "daos"), 0o755))
+	files := []scaffold.File{
+		{Path: filepath.Join("daos", "invoices.go"), Content: []byte("package daos\n")},
+		{Path: filepath.Join("schema", "invoice.graphql"), Content: []byte("type Invoice")},
+		{Path: filepath.Join("internal", "service", "invoices", "invoices.go"), Content: []byte("package invoices\n")},
+	}
+	// the missing directories are created
+	assert.NoError(t, scaffold.Write(root, files[1:]))
+	assert.FileExists(t, filepath.Join(root, "internal", "service", "invoices", "invoices.go"))
+
+	// nothing is written when one of the files exists
+	assert.EqualError(t, scaffold.Write(root, files), filepath.Join("schema", "invoice.graphql")+" already exists")
+	assert.NoFileExists(t, filepath.Join(root, "daos", "invoices.go"))
+}
+
+func TestAddModelTable(t *testing.T) {
+	src := []byte(`package main
+
+// modelTables are the tables with sqlboiler models
+var modelTables = []string{"gorp_migrations", "roles", "users"}
+`)
+	got, err := scaffold.AddModelTable(src, "invoices")
+	assert.NoError(t, err)
+	assert.Equal(t, `package main
+
+// modelTables are the tables with sqlboiler models
+var modelTables = []string{"gorp_migrations", "invoices", "roles", "users"}
+`, string(got))
+
+	got, err = scaffold.AddModelTable(src, "roles")
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(src, got))
+
+	_, err = scaffold.AddModelTable([]byte("package main\n"), "invoices")
+	assert.EqualError(t, err, "modelTables not found")
+
+	// the source of gendbmdls lists its tables the way AddModelTable expects
+	main, err := os.ReadFile(filepath.Join("..", "..", "cmd", "gendbmdls", "main.go"))
+	assert.NoError(t, err)
+	_, err = scaffold.AddModelTable(main, "invoices")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,165 @@
+// Package secrets reads the credentials of the application, such as the PSQL_* settings and
+// JWT_SECRET, from the environment, from mounted files or from Vault, and refreshes them so
+// that rotated credentials are picked up without a restart.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by the providers that don't hold a secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider returns the current value of a secret
+type Provider interface {
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Env reads the secrets from the environment variables of the same name
+type Env struct{}
+
+// Get ...
+func (Env) Get(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// File reads the secrets from the files of the same name in Dir, the way Docker and
+// Kubernetes mount them. The files are read on every call so that rotated secrets are seen.
+type File struct {
+	Dir string
+}
+
+// Get ...
+func (f File) Get(_ context.Context, name string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(f.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Chain returns the secret of the first provider holding it
+type Chain []Provider
+
+// Get ...
+func (c Chain) Get(ctx context.Context, name string) (string, error) {
+	for _, p := range c {
+		value, err := p.Get(ctx, name)
+		if !errors.Is(err, ErrNotFound) {
+			return value, err
+		}
+	}
+	return "", ErrNotFound
+}
+
+// CopilotDB reads the PSQL_* secrets from the DB_SECRET JSON document that AWS Copilot
+// injects when COPILOT_DB_CREDS_VIA_SECRETS_MANAGER is set
+type CopilotDB struct{}
+
+// copilotKeys maps the PSQL_* secrets to the fields of DB_SECRET
+var copilotKeys = map[string]string{
+	"PSQL_DBNAME": "dbname",
+	"PSQL_HOST":   "host",
+	"PSQL_PASS":   "password",
+	"PSQL_PORT":   "port",
+	"PSQL_USER":   "username",
+}
+
+// Get ...
+func (CopilotDB) Get(_ context.Context, name string) (string, error) {
+	key, ok := copilotKeys[name]
+	doc := os.Getenv("DB_SECRET")
+	if !ok || doc == "" {
+		return "", ErrNotFound
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &fields); err != nil {
+		return "", fmt.Errorf("invalid DB_SECRET: %w", err)
+	}
+	switch value := fields[key].(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	}
+	return "", ErrNotFound
+}
+
+// FromEnv returns the provider selected by SECRETS_PROVIDER, the secrets it doesn't hold
+// are read from the environment:
+//   - env (default) reads the environment only
+//   - file reads the files of SECRETS_DIR, /run/secrets by default
+//   - vault reads the KV secret at VAULT_SECRET_PATH from VAULT_ADDR with VAULT_TOKEN
+//
+// The PSQL_* secrets are read from DB_SECRET first when COPILOT_DB_CREDS_VIA_SECRETS_MANAGER
+// is true.
+func FromEnv() (Provider, error) {
+	var chain Chain
+	if copilot, _ := strconv.ParseBool(os.Getenv("COPILOT_DB_CREDS_VIA_SECRETS_MANAGER")); copilot {
+		chain = append(chain, CopilotDB{})
+	}
+	switch name := os.Getenv("SECRETS_PROVIDER"); name {
+	case "", "env":
+	case "file":
+		dir := os.Getenv("SECRETS_DIR")
+		if dir == "" {
+			dir = "/run/secrets"
+		}
+		chain = append(chain, File{Dir: dir})
+	case "vault":
+		vault, err := VaultFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, vault)
+	default:
+		return nil, fmt.Errorf("unsupported SECRETS_PROVIDER %q", name)
+	}
+	return append(chain, Env{}), nil
+}
+
+// WatcherFromEnv returns a watcher over the provider of FromEnv refreshing the secrets every
+// SECRETS_REFRESH_SECONDS, 300 by default, 0 disables the refreshes
+func WatcherFromEnv() (*Watcher, error) {
+	p, err := FromEnv()
+	if err != nil {
+		return nil, err
+	}
+	interval := 5 * time.Minute
+	if value := os.Getenv("SECRETS_REFRESH_SECONDS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("SECRETS_REFRESH_SECONDS must be a positive integer, got %q", value)
+		}
+		interval = time.Duration(n) * time.Second
+	}
+	return NewWatcher(p, interval), nil
+}
+
+// Read returns the values of names, the secrets p doesn't hold are empty
+func Read(ctx context.Context, p Provider, names ...string) (map[string]string, error) {
+	values := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := p.Get(ctx, name)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("reading %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
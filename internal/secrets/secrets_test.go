@@ -0,0 +1,249 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-template/internal/secrets"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// static holds the secrets of a map
+type static map[string]string
+
+func (s static) Get(_ context.Context, name string) (string, error) {
+	value, ok := s[name]
+	if !ok {
+		return "", secrets.ErrNotFound
+	}
+	return value, nil
+}
+
+func TestProviders(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PSQL_PASS"), []byte("mounted\n"), 0o600))
+	t.Setenv("PSQL_USER", "from-env")
+	t.Setenv("PSQL_HOST", "")
+	t.Setenv("DB_SECRET", `{"username": "copilot", "password": "pass", "port": 5432, "dbname": "db", "host": "rds"}`)
+
+	tests := []struct {
+		name     string
+		provider secrets.Provider
+		secret   string
+		want     string
+		wantErr  error
+	}{
+		{name: "Env", provider: secrets.Env{}, secret: "PSQL_USER", want: "from-env"},
+		{name: "Env empty", provider: secrets.Env{}, secret: "PSQL_HOST", wantErr: secrets.ErrNotFound},
+		{name: "File", provider: secrets.File{Dir: dir}, secret: "PSQL_PASS", want: "mounted"},
+		{name: "File missing", provider: secrets.File{Dir: dir}, secret: "PSQL_USER", wantErr: secrets.ErrNotFound},
+		{name: "Copilot", provider: secrets.CopilotDB{}, secret: "PSQL_USER", want: "copilot"},
+		{name: "Copilot port", provider: secrets.CopilotDB{}, secret: "PSQL_PORT", want: "5432"},
+		{name: "Copilot unknown", provider: secrets.CopilotDB{}, secret: "JWT_SECRET", wantErr: secrets.ErrNotFound},
+		{
+			name:     "Chain falls back to the next provider",
+			provider: secrets.Chain{secrets.File{Dir: dir}, secrets.Env{}},
+			secret:   "PSQL_USER",
+			want:     "from-env",
+		},
+		{
+			name:     "Chain stops at the first provider holding the secret",
+			provider: secrets.Chain{secrets.File{Dir: dir}, static{"PSQL_PASS": "other"}},
+			secret:   "PSQL_PASS",
+			want:     "mounted",
+		},
+		{name: "Chain without the secret", provider: secrets.Chain{}, secret: "PSQL_PASS", wantErr: secrets.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Get(context.Background(), tt.secret)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			_, _ = w.Write([]byte(`{"data": {"data": {"PSQL_PASS": "v2"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/app":
+			_, _ = w.Write([]byte(`{"data": {"PSQL_PASS": "v1", "PSQL_PORT": 5432}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		token   string
+		secret  string
+		want    string
+		wantErr string
+	}{
+		{name: "KV version 2", path: "secret/data/app", secret: "PSQL_PASS", want: "v2"},
+		{name: "KV version 1", path: "kv/app", secret: "PSQL_PASS", want: "v1"},
+		{name: "Missing field", path: "kv/app", secret: "PSQL_USER", wantErr: secrets.ErrNotFound.Error()},
+		{name: "Missing secret", path: "kv/other", secret: "PSQL_PASS", wantErr: secrets.ErrNotFound.Error()},
+		{name: "Not a string", path: "kv/app", secret: "PSQL_PORT", wantErr: "vault field PSQL_PORT is not a string"},
+		{name: "Forbidden", path: "kv/app", token: "wrong", secret: "PSQL_PASS",
+			wantErr: "vault answered 403 Forbidden for kv/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := tt.token
+			if token == "" {
+				token = "token"
+			}
+			got, err := secrets.Vault{Address: srv.URL, Token: token, Path: tt.path}.Get(context.Background(), tt.secret)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    secrets.Provider
+		wantErr string
+	}{
+		{name: "Env", want: secrets.Chain{secrets.Env{}}},
+		{
+			name: "File",
+			env:  map[string]string{"SECRETS_PROVIDER": "file"},
+			want: secrets.Chain{secrets.File{Dir: "/run/secrets"}, secrets.Env{}},
+		},
+		{
+			name: "Copilot",
+			env:  map[string]string{"SECRETS_PROVIDER": "file", "SECRETS_DIR": "/secrets", "COPILOT_DB_CREDS_VIA_SECRETS_MANAGER": "true"},
+			want: secrets.Chain{secrets.CopilotDB{}, secrets.File{Dir: "/secrets"}, secrets.Env{}},
+		},
+		{
+			name:    "Vault without address",
+			env:     map[string]string{"SECRETS_PROVIDER": "vault"},
+			wantErr: "VAULT_ADDR and VAULT_SECRET_PATH are required by the vault secrets provider",
+		},
+		{
+			name:    "Unsupported provider",
+			env:     map[string]string{"SECRETS_PROVIDER": "s3"},
+			wantErr: `unsupported SECRETS_PROVIDER "s3"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"SECRETS_PROVIDER", "SECRETS_DIR", "COPILOT_DB_CREDS_VIA_SECRETS_MANAGER",
+				"VAULT_ADDR", "VAULT_SECRET_PATH"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := secrets.FromEnv()
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	creds := static{"PSQL_USER": "user", "PSQL_PASS": "v1"}
+	w := secrets.NewWatcher(creds, 10*time.Millisecond)
+	var rotations []map[string]string
+	failing := false
+	err := w.Watch(context.Background(), []string{"PSQL_USER", "PSQL_PASS", "PSQL_SSLMODE"},
+		func(_ context.Context, values map[string]string) error {
+			if failing {
+				return errors.New("reconnect failed")
+			}
+			rotations = append(rotations, values)
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"PSQL_USER": "user", "PSQL_PASS": "v1", "PSQL_SSLMODE": ""}}, rotations)
+
+	// unchanged secrets aren't rotated
+	assert.NoError(t, w.Refresh(context.Background()))
+	assert.Len(t, rotations, 1)
+
+	creds["PSQL_PASS"] = "v2"
+	failing = true
+	assert.EqualError(t, w.Refresh(context.Background()), "reconnect failed")
+	assert.Len(t, rotations, 1)
+
+	// a failed rotation is retried by the next refresh
+	failing = false
+	assert.NoError(t, w.Refresh(context.Background()))
+	assert.Equal(t, "v2", rotations[1]["PSQL_PASS"])
+
+	// the callback rejecting the current values isn't watched
+	err = w.Watch(context.Background(), []string{"JWT_SECRET"}, func(context.Context, map[string]string) error {
+		return errors.New("jwt secret length is 0")
+	})
+	assert.EqualError(t, err, "jwt secret length is 0")
+	assert.NoError(t, w.Refresh(context.Background()))
+
+	values, err := w.Read(context.Background(), "PSQL_PASS", "JWT_SECRET")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"PSQL_PASS": "v2", "JWT_SECRET": ""}, values)
+}
+
+// rotating holds a secret that can be rotated while it is refreshed
+type rotating struct {
+	value atomic.Value
+}
+
+func (r *rotating) Get(context.Context, string) (string, error) {
+	return r.value.Load().(string), nil
+}
+
+func TestWatcherStartStop(t *testing.T) {
+	secret := &rotating{}
+	secret.value.Store("v1")
+	w := secrets.NewWatcher(secret, 5*time.Millisecond)
+	rotated := make(chan string, 1)
+	assert.NoError(t, w.Watch(context.Background(), []string{"JWT_SECRET"},
+		func(_ context.Context, values map[string]string) error {
+			if values["JWT_SECRET"] != "v1" {
+				rotated <- values["JWT_SECRET"]
+			}
+			return nil
+		}))
+	w.Start()
+	secret.value.Store("v2")
+	select {
+	case value := <-rotated:
+		assert.Equal(t, "v2", value)
+	case <-time.After(time.Second):
+		t.Fatal("the secret wasn't rotated")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, w.Stop(ctx))
+	// stopping twice is harmless
+	assert.NoError(t, w.Stop(ctx))
+	// a watcher without an interval is never started
+	assert.NoError(t, secrets.NewWatcher(secret, 0).Stop(ctx))
+}
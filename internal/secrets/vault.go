@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Vault reads the secrets from the fields of a KV secret of Vault, or of any server
+// answering its HTTP API. Both versions of the KV engine are supported, Path is the API path
+// of the secret, e.g. secret/data/go-template for version 2.
+type Vault struct {
+	Address string
+	Token   string
+	Path    string
+	Client  *http.Client
+}
+
+// VaultFromEnv returns the Vault provider configured by VAULT_ADDR, VAULT_TOKEN and
+// VAULT_SECRET_PATH
+func VaultFromEnv() (Vault, error) {
+	v := Vault{
+		Address: os.Getenv("VAULT_ADDR"),
+		Token:   os.Getenv("VAULT_TOKEN"),
+		Path:    os.Getenv("VAULT_SECRET_PATH"),
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if v.Address == "" || v.Path == "" {
+		return v, fmt.Errorf("VAULT_ADDR and VAULT_SECRET_PATH are required by the vault secrets provider")
+	}
+	return v, nil
+}
+
+// Get ...
+func (v Vault) Get(ctx context.Context, name string) (string, error) {
+	fields, err := v.read(ctx)
+	if err != nil {
+		return "", err
+	}
+	value, ok := fields[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault field %s is not a string", name)
+	}
+	return s, nil
+}
+
+// read returns the fields of the secret
+func (v Vault) read(ctx context.Context) (map[string]interface{}, error) {
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + strings.TrimLeft(v.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault answered %s for %s", resp.Status, v.Path)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %w", err)
+	}
+	// version 2 of the KV engine nests the fields with their metadata
+	if nested, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, versioned := body.Data["metadata"]; versioned {
+			return nested, nil
+		}
+	}
+	return body.Data, nil
+}
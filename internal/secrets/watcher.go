@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-template/pkg/utl/zaplog"
+)
+
+// RotateFunc is called with the values of a group of secrets
+type RotateFunc func(ctx context.Context, values map[string]string) error
+
+// Watcher reads secrets again on an interval and calls the rotation callbacks of the groups
+// whose values changed
+type Watcher struct {
+	provider Provider
+	interval time.Duration
+
+	mu      sync.Mutex
+	watches []*watch
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+type watch struct {
+	names  []string
+	values map[string]string
+	fn     RotateFunc
+}
+
+// NewWatcher returns a watcher reading the secrets from p every interval once started,
+// 0 disables the refreshes
+func NewWatcher(p Provider, interval time.Duration) *Watcher {
+	return &Watcher{
+		provider: p,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Watch calls fn with the current values of names, then with their new values whenever one
+// of them is rotated. Names are watched only once fn accepted their current values.
+func (w *Watcher) Watch(ctx context.Context, names []string, fn RotateFunc) error {
+	values, err := Read(ctx, w.provider, names...)
+	if err != nil {
+		return err
+	}
+	if err := fn(ctx, values); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watches = append(w.watches, &watch{names: names, values: values, fn: fn})
+	return nil
+}
+
+// Read returns the current values of names without watching them, for connections that
+// don't outlive a rotation
+func (w *Watcher) Read(ctx context.Context, names ...string) (map[string]string, error) {
+	return Read(ctx, w.provider, names...)
+}
+
+// Refresh reads the watched secrets and calls the callbacks of the rotated ones. A group
+// whose secrets can't be read, or whose callback fails, keeps its values and is retried by
+// the next refresh.
+func (w *Watcher) Refresh(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var errs []error
+	for _, watch := range w.watches {
+		values, err := Read(ctx, w.provider, watch.names...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if equal(values, watch.values) {
+			continue
+		}
+		if err := watch.fn(ctx, values); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		watch.values = values
+	}
+	return errors.Join(errs...)
+}
+
+// Start refreshes the secrets every interval until Stop is called
+func (w *Watcher) Start() {
+	if w.interval <= 0 {
+		return
+	}
+	w.started.Store(true)
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				if err := w.Refresh(context.Background()); err != nil {
+					zaplog.Logger.Errorw("refreshing the secrets failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the refreshes
+func (w *Watcher) Stop(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+	if !w.started.Load() {
+		return nil
+	}
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
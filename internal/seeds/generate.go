@@ -0,0 +1,174 @@
+package seeds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/constants"
+	"go-template/models"
+
+	"github.com/volatiletech/null/v8"
+)
+
+var (
+	firstNames = []string{"Aarav", "Aisha", "Alex", "Amelia", "Arjun", "Chen", "Chloe", "Daniel", "Diya", "Elena",
+		"Ethan", "Fatima", "Gabriel", "Hana", "Isaac", "Ishaan", "Jade", "James", "Kabir", "Lena", "Leo", "Lucas",
+		"Maya", "Mei", "Mohammed", "Nadia", "Noah", "Olivia", "Omar", "Priya", "Rafael", "Riya", "Sara", "Sofia",
+		"Tariq", "Vivaan", "Yusuf", "Zara"}
+	lastNames = []string{"Ahmed", "Brown", "Chen", "Costa", "Das", "Fernandes", "Garcia", "Gupta", "Hassan", "Iyer",
+		"Jones", "Kapoor", "Khan", "Kim", "Lopez", "Martin", "Mehta", "Miller", "Nair", "Nguyen", "Patel", "Rao",
+		"Reddy", "Rossi", "Sato", "Shah", "Silva", "Singh", "Smith", "Tanaka", "Wang", "Williams"}
+	streets = []string{"MG Road", "Park Street", "Linking Road", "Church Street", "Brigade Road", "Main Street",
+		"High Street", "Station Road", "Lake View Road", "Hill Road", "Oak Avenue", "Maple Drive", "Cedar Lane",
+		"Elm Street", "Sunset Boulevard", "Harbour Road"}
+	cities = []string{"Pune", "Mumbai", "Bengaluru", "Chennai", "Hyderabad", "Delhi", "Kolkata", "Jaipur", "London",
+		"Lisbon", "Singapore", "Dubai", "Toronto", "Austin", "Berlin", "Tokyo"}
+)
+
+// GeneratedRolePrefix prefixes the names of the generated roles
+const GeneratedRolePrefix = "GENERATED_"
+
+// Fake generates fake records, the same seed always yields the same records in the same order
+type Fake struct {
+	rand *rand.Rand
+}
+
+// NewFake returns a generator seeded with seed
+func NewFake(seed int64) *Fake {
+	return &Fake{rand: rand.New(rand.NewSource(seed))} //nolint:gosec // fake data, not secrets
+}
+
+// Role returns the i-th generated role
+func (f *Fake) Role(i int) Role {
+	return Role{Name: fmt.Sprintf("%sROLE_%d", GeneratedRolePrefix, i), AccessLevel: 300 + f.rand.Intn(700)}
+}
+
+// User returns the i-th generated user, its username and email are unique among the generated
+// users
+func (f *Fake) User(i int) User {
+	first := firstNames[f.rand.Intn(len(firstNames))]
+	last := lastNames[f.rand.Intn(len(lastNames))]
+	handle := fmt.Sprintf("%s.%s.%d", strings.ToLower(first), strings.ToLower(last), i)
+	return User{
+		Username:  handle,
+		Email:     handle + "@example.com",
+		FirstName: first,
+		LastName:  last,
+		Mobile:    fmt.Sprintf("+91 9%04d %05d", f.rand.Intn(10000), f.rand.Intn(100000)),
+		Address: fmt.Sprintf("%d %s, %s", 1+f.rand.Intn(999), streets[f.rand.Intn(len(streets))],
+			cities[f.rand.Intn(len(cities))]),
+	}
+}
+
+// GenerateOptions configure Generate
+type GenerateOptions struct {
+	// Seed seeds the fake data
+	Seed  int64
+	Roles int
+	Users int
+	// BatchSize is the number of users copied per transaction
+	BatchSize int
+	// PasswordHash is the hashed password of every user, it is hashed once since hashing the
+	// password of every user would dominate the runtime
+	PasswordHash string
+}
+
+// Generate copies opts.Roles fake roles and opts.Users fake users to the database, the users
+// are assigned the generated roles, or the existing non admin ones when no role is generated.
+// progress is called with the number of users copied after every batch. It refuses to run in
+// Production.
+func (s Seeder) Generate(ctx context.Context, opts GenerateOptions, progress func(users int)) error {
+	if s.Environment == Production {
+		return errors.New("refusing to generate fake data in the production database")
+	}
+	if opts.BatchSize <= 0 {
+		return errors.New("the batch size must be positive")
+	}
+	fake := NewFake(opts.Seed)
+	now := null.TimeFrom(time.Now())
+
+	roles, err := s.generateRoles(ctx, fake, opts.Roles, now)
+	if err != nil {
+		return fmt.Errorf("generating roles: %w", err)
+	}
+	roleIDs := make([]int, 0, len(roles))
+	for _, r := range roles {
+		roleIDs = append(roleIDs, r.ID)
+	}
+	if opts.Users > 0 && len(roleIDs) == 0 {
+		return errors.New("there are no non admin roles to assign the users to, generate some with --roles")
+	}
+
+	password := null.StringFrom(opts.PasswordHash)
+	for start := 1; start <= opts.Users; start += opts.BatchSize {
+		end := start + opts.BatchSize - 1
+		if end > opts.Users {
+			end = opts.Users
+		}
+		batch := make([]models.User, 0, end-start+1)
+		for i := start; i <= end; i++ {
+			u := fake.User(i)
+			batch = append(batch, models.User{
+				FirstName: null.StringFrom(u.FirstName),
+				LastName:  null.StringFrom(u.LastName),
+				Username:  null.StringFrom(u.Username),
+				Password:  password,
+				Email:     null.StringFrom(u.Email),
+				Mobile:    null.StringFrom(u.Mobile),
+				Address:   null.StringFrom(u.Address),
+				Active:    null.BoolFrom(true),
+				RoleID:    null.IntFrom(roleIDs[fake.rand.Intn(len(roleIDs))]),
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		}
+		if err := daos.WithTx(ctx, func(tx *sql.Tx) error { return daos.CopyUsersTx(batch, ctx, tx) }); err != nil {
+			return fmt.Errorf("generating users %d to %d: %w", start, end, err)
+		}
+		if progress != nil {
+			progress(end)
+		}
+	}
+	return nil
+}
+
+// generateRoles copies n fake roles and returns them, or returns the existing roles when n is 0.
+// The existing admin roles are left out so that no fake user can administer the database.
+func (s Seeder) generateRoles(ctx context.Context, fake *Fake, n int, now null.Time) (models.RoleSlice, error) {
+	if n == 0 {
+		existing, err := daos.FindAllRoles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var roles models.RoleSlice
+		for _, r := range existing {
+			if r.AccessLevel >= int(constants.UserRole) {
+				roles = append(roles, r)
+			}
+		}
+		return roles, nil
+	}
+	generated := make([]models.Role, 0, n)
+	names := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		r := fake.Role(i)
+		generated = append(generated, models.Role{Name: r.Name, AccessLevel: r.AccessLevel, CreatedAt: now, UpdatedAt: now})
+		names = append(names, r.Name)
+	}
+	var roles models.RoleSlice
+	err := daos.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := daos.CopyRolesTx(generated, ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		roles, err = daos.FindRolesByNamesTx(names, ctx, tx)
+		return err
+	})
+	return roles, err
+}
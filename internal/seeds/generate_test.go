@@ -0,0 +1,84 @@
+package seeds_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"go-template/internal/seeds"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	a, b := seeds.NewFake(42), seeds.NewFake(42)
+	for i := 1; i <= 100; i++ {
+		assert.Equal(t, a.Role(i), b.Role(i))
+		assert.Equal(t, a.User(i), b.User(i))
+	}
+
+	user := seeds.NewFake(42).User(7)
+	assert.Regexp(t, `^[a-z]+\.[a-z]+\.7$`, user.Username)
+	assert.Equal(t, user.Username+"@example.com", user.Email)
+	assert.Regexp(t, `^\+91 9\d{4} \d{5}$`, user.Mobile)
+	assert.Regexp(t, `^\d+ [A-Za-z ]+, [A-Za-z]+$`, user.Address)
+	assert.NotEqual(t, user, seeds.NewFake(43).User(7))
+	assert.Equal(t, "GENERATED_ROLE_3", seeds.NewFake(42).Role(3).Name)
+}
+
+// expectCopy expects a COPY of rows rows into table
+func expectCopy(mock sqlmock.Sqlmock, table string, rows int) {
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta(`COPY "` + table + `"`))
+	for i := 0; i < rows; i++ {
+		prepared.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	// the flush
+	prepared.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestGenerate(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectBegin()
+	expectCopy(mock, "roles", 2)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" WHERE ("roles"."name" IN ($1,$2)) ORDER BY id;`)).
+		WithArgs("GENERATED_ROLE_1", "GENERATED_ROLE_2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(6, "GENERATED_ROLE_1").AddRow(7, "GENERATED_ROLE_2"))
+	mock.ExpectCommit()
+	for _, rows := range []int{2, 1} {
+		mock.ExpectBegin()
+		expectCopy(mock, "users", rows)
+		mock.ExpectCommit()
+	}
+
+	var progress []int
+	err := seeds.Seeder{Environment: "load"}.Generate(context.Background(),
+		seeds.GenerateOptions{Seed: 1, Roles: 2, Users: 3, BatchSize: 2, PasswordHash: "hash"},
+		func(users int) { progress = append(progress, users) })
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, progress)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGenerateFails(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	// the fake users never get the admin roles
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" ORDER BY id;`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_level", "name"}).
+			AddRow(1, 100, "SUPER_ADMIN").AddRow(2, 110, "ADMIN"))
+
+	seeder := seeds.Seeder{Environment: "load"}
+	err := seeder.Generate(context.Background(), seeds.GenerateOptions{Users: 1, BatchSize: 1}, nil)
+	assert.EqualError(t, err, "there are no non admin roles to assign the users to, generate some with --roles")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	err = seeder.Generate(context.Background(), seeds.GenerateOptions{Users: 1}, nil)
+	assert.EqualError(t, err, "the batch size must be positive")
+
+	production := seeds.Seeder{Environment: seeds.Production}
+	err = production.Generate(context.Background(), seeds.GenerateOptions{Users: 1, BatchSize: 1}, nil)
+	assert.EqualError(t, err, "refusing to generate fake data in the production database")
+}
@@ -0,0 +1,135 @@
+package seeds
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"go-template/daos"
+	"go-template/models"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Production is the environment Reset refuses to empty
+const Production = "production"
+
+// Result is the outcome of applying a fixture
+type Result struct {
+	Name string
+	// Skipped fixtures were already applied with the same content
+	Skipped bool
+	Records int
+}
+
+// Seeder applies the fixtures of an environment to the database of boil. Every fixture is
+// upserted in a transaction and recorded in the seeds table, so that running the seeder
+// again only applies the fixtures that changed.
+type Seeder struct {
+	Environment string
+	// Hash hashes the passwords of the users
+	Hash func(password string) string
+	// Getenv reads the passwords of the users given by environment variable, os.Getenv when nil
+	Getenv func(key string) string
+}
+
+// Apply applies fixtures in order
+func (s Seeder) Apply(ctx context.Context, fixtures []Fixture) ([]Result, error) {
+	results := make([]Result, 0, len(fixtures))
+	for _, f := range fixtures {
+		result := Result{Name: f.Name}
+		err := daos.WithTx(ctx, func(tx *sql.Tx) error {
+			applied, err := daos.FindSeedTx(f.Name, s.Environment, ctx, tx)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if applied != nil && applied.Checksum == f.Checksum {
+				result.Skipped = true
+				return nil
+			}
+			if result.Records, err = s.apply(ctx, f, tx); err != nil {
+				return err
+			}
+			return daos.UpsertSeedTx(daos.Seed{Name: f.Name, Environment: s.Environment, Checksum: f.Checksum}, ctx, tx)
+		})
+		if err != nil {
+			return results, fmt.Errorf("seeding %s: %w", f.Name, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s Seeder) apply(ctx context.Context, f Fixture, tx *sql.Tx) (int, error) {
+	for _, r := range f.Roles {
+		if _, err := daos.UpsertRoleTx(models.Role{Name: r.Name, AccessLevel: r.AccessLevel}, ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+	for _, u := range f.Users {
+		user, err := s.user(ctx, u, tx)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := daos.UpsertUserTx(user, ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+	return len(f.Roles) + len(f.Users), nil
+}
+
+func (s Seeder) user(ctx context.Context, u User, tx *sql.Tx) (models.User, error) {
+	password := u.Password
+	if u.PasswordEnv != "" {
+		getenv := s.Getenv
+		if getenv == nil {
+			getenv = os.Getenv
+		}
+		if password = getenv(u.PasswordEnv); password == "" {
+			return models.User{}, fmt.Errorf("%s is not set, it holds the password of user %s", u.PasswordEnv, u.Username)
+		}
+	}
+	role, err := daos.FindRoleByNameTx(u.Role, ctx, tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, fmt.Errorf("user %s has the unknown role %s", u.Username, u.Role)
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	active := true
+	if u.Active != nil {
+		active = *u.Active
+	}
+	return models.User{
+		Username:  null.StringFrom(u.Username),
+		Email:     null.StringFrom(u.Email),
+		FirstName: null.NewString(u.FirstName, u.FirstName != ""),
+		LastName:  null.NewString(u.LastName, u.LastName != ""),
+		Mobile:    null.NewString(u.Mobile, u.Mobile != ""),
+		Address:   null.NewString(u.Address, u.Address != ""),
+		Active:    null.BoolFrom(active),
+		Password:  null.StringFrom(s.Hash(password)),
+		RoleID:    null.IntFrom(role.ID),
+	}, nil
+}
+
+// Reset empties the tables of fixtures, and the tables referencing them, and forgets the
+// applied fixtures so that Apply seeds them again. It refuses to run in Production.
+func (s Seeder) Reset(ctx context.Context, fixtures []Fixture) error {
+	if s.Environment == Production {
+		return errors.New("refusing to reset the production database")
+	}
+	return daos.WithTx(ctx, func(tx *sql.Tx) error {
+		// the users reference the roles, the tables are emptied in the reverse order of Kinds
+		for i := len(fixtures) - 1; i >= 0; i-- {
+			// the table names are the kinds, never user input
+			if _, err := tx.ExecContext(ctx, `TRUNCATE TABLE `+fixtures[i].Name+` RESTART IDENTITY CASCADE`); err != nil {
+				return err
+			}
+		}
+		_, err := daos.DeleteSeedsTx(ctx, tx)
+		return err
+	})
+}
@@ -0,0 +1,141 @@
+package seeds_test
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"go-template/internal/seeds"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var fixtures = []seeds.Fixture{
+	{Name: seeds.Roles, Checksum: "r1", Roles: []seeds.Role{{Name: "SUPER_ADMIN", AccessLevel: 100}}},
+	{Name: seeds.Users, Checksum: "u1", Users: []seeds.User{{Username: "admin", Email: "admin@mail.com",
+		Role: "SUPER_ADMIN", PasswordEnv: "SEED_ADMIN_PASSWORD"}}},
+}
+
+func newSeeder(password string) seeds.Seeder {
+	return seeds.Seeder{
+		Environment: "local",
+		Hash:        func(password string) string { return "hashed:" + password },
+		Getenv: func(key string) string {
+			if key == "SEED_ADMIN_PASSWORD" {
+				return password
+			}
+			return ""
+		},
+	}
+}
+
+// expectApplied expects the seeder to read the record of the fixture name
+func expectApplied(mock sqlmock.Sqlmock, name, checksum string) {
+	rows := sqlmock.NewRows([]string{"name", "environment", "checksum", "applied_at"})
+	if checksum != "" {
+		rows.AddRow(name, "local", checksum, time.Now())
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM seeds WHERE name = $1 AND environment = $2`)).
+		WithArgs(name, "local").WillReturnRows(rows)
+}
+
+func TestApply(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+
+	// roles changed since they were applied
+	mock.ExpectBegin()
+	expectApplied(mock, seeds.Roles, "r0")
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "roles" ("access_level", "name", "created_at", "updated_at") `+
+		`VALUES ($1,$2,$3,$4) ON CONFLICT ("name") DO UPDATE SET "access_level" = EXCLUDED."access_level",`)).
+		WithArgs(100, "SUPER_ADMIN", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "deleted_at"}).AddRow(1, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO seeds`)).WithArgs(seeds.Roles, "local", "r1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// users were never applied
+	mock.ExpectBegin()
+	expectApplied(mock, seeds.Users, "")
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "roles".* FROM "roles" WHERE ("roles"."name" = $1) LIMIT 1;`)).
+		WithArgs("SUPER_ADMIN").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "access_level", "name"}).AddRow(1, 100, "SUPER_ADMIN"))
+	// the password of an existing user is kept
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT \("username"\) DO UPDATE SET "first_name" = [^;]*RETURNING`).
+		WithArgs("admin", "hashed:adminuser", "admin@mail.com", true, 1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "mobile", "address", "last_login",
+			"last_password_change", "token", "deleted_at", "token_issued_at"}).
+			AddRow(1, nil, nil, nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO seeds`)).WithArgs(seeds.Users, "local", "u1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	results, err := newSeeder("adminuser").Apply(context.Background(), fixtures)
+	assert.NoError(t, err)
+	assert.Equal(t, []seeds.Result{{Name: seeds.Roles, Records: 1}, {Name: seeds.Users, Records: 1}}, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyUnchanged(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectBegin()
+	expectApplied(mock, seeds.Roles, "r1")
+	mock.ExpectCommit()
+
+	results, err := newSeeder("adminuser").Apply(context.Background(), fixtures[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, []seeds.Result{{Name: seeds.Roles, Skipped: true}}, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{name: "Password not set",
+			wantErr: "seeding users: SEED_ADMIN_PASSWORD is not set, it holds the password of user admin"},
+		{name: "Unknown role", password: "adminuser",
+			wantErr: "seeding users: user admin has the unknown role SUPER_ADMIN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			mock.ExpectBegin()
+			expectApplied(mock, seeds.Users, "")
+			if tt.password != "" {
+				mock.ExpectQuery(regexp.QuoteMeta(`FROM "roles"`)).WillReturnError(sql.ErrNoRows)
+			}
+			mock.ExpectRollback()
+
+			results, err := newSeeder(tt.password).Apply(context.Background(), fixtures[1:])
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Empty(t, results)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`TRUNCATE TABLE users RESTART IDENTITY CASCADE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`TRUNCATE TABLE roles RESTART IDENTITY CASCADE`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM seeds`)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, newSeeder("").Reset(context.Background(), fixtures))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	production := seeds.Seeder{Environment: seeds.Production}
+	assert.EqualError(t, production.Reset(context.Background(), fixtures), "refusing to reset the production database")
+}
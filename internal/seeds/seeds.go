@@ -0,0 +1,181 @@
+// Package seeds loads the fixtures the seeder applies to a database. The fixtures of each
+// environment are YAML or JSON files under fixtures/<environment>, one per kind of record,
+// and are embedded so that the seeder runs from any directory.
+package seeds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of fixtures, in the order they are applied in
+const (
+	Roles = "roles"
+	Users = "users"
+)
+
+// Kinds are the kinds of fixtures in the order they are applied in, the users reference the
+// roles
+var Kinds = []string{Roles, Users}
+
+// extensions are the extensions of the fixture files, in the order they are looked up in
+var extensions = []string{".yaml", ".yml", ".json"}
+
+//go:embed fixtures
+var files embed.FS
+
+// Files returns the embedded fixtures
+func Files() fs.FS {
+	sub, err := fs.Sub(files, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// Role is a role of a fixture
+type Role struct {
+	Name        string `json:"name"         yaml:"name"`
+	AccessLevel int    `json:"access_level" yaml:"access_level"`
+}
+
+// User is a user of a fixture. Its password is read from the environment variable
+// PasswordEnv, or given in Password for the development fixtures. It is only set when the
+// user is created.
+type User struct {
+	Username    string `json:"username"     yaml:"username"`
+	Email       string `json:"email"        yaml:"email"`
+	FirstName   string `json:"first_name"   yaml:"first_name"`
+	LastName    string `json:"last_name"    yaml:"last_name"`
+	Mobile      string `json:"mobile"       yaml:"mobile"`
+	Address     string `json:"address"      yaml:"address"`
+	Active      *bool  `json:"active"       yaml:"active"`
+	Role        string `json:"role"         yaml:"role"`
+	Password    string `json:"password"     yaml:"password"`
+	PasswordEnv string `json:"password_env" yaml:"password_env"`
+}
+
+// Fixture is the content of a fixture file
+type Fixture struct {
+	// Name is the kind of the records of the fixture
+	Name string `json:"-" yaml:"-"`
+	// Checksum identifies the content of the file, the fixture is applied again once it changes
+	Checksum string `json:"-" yaml:"-"`
+
+	Roles []Role `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Users []User `json:"users,omitempty" yaml:"users,omitempty"`
+}
+
+// Load reads the fixtures of environment from fsys in the order of Kinds, the kinds without
+// a file are left out
+func Load(fsys fs.FS, environment string) ([]Fixture, error) {
+	if _, err := fs.Stat(fsys, environment); err != nil {
+		return nil, fmt.Errorf("no fixtures for the environment %q", environment)
+	}
+	var fixtures []Fixture
+	for _, kind := range Kinds {
+		for _, ext := range extensions {
+			name := path.Join(environment, kind+ext)
+			b, err := fs.ReadFile(fsys, name)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			f, err := decode(b, ext)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			f.Name = kind
+			if err := f.validate(); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			sum := sha256.Sum256(b)
+			f.Checksum = hex.EncodeToString(sum[:])
+			fixtures = append(fixtures, f)
+			break
+		}
+	}
+	return fixtures, nil
+}
+
+func decode(b []byte, ext string) (Fixture, error) {
+	var f Fixture
+	if ext == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&f)
+		return f, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return f, err
+	}
+	return f, nil
+}
+
+func (f Fixture) validate() error {
+	switch {
+	case f.Name == Roles && len(f.Users) > 0:
+		return errors.New("a roles fixture only holds roles")
+	case f.Name == Users && len(f.Roles) > 0:
+		return errors.New("a users fixture only holds users")
+	}
+	for _, r := range f.Roles {
+		if r.Name == "" {
+			return errors.New("every role needs a name")
+		}
+	}
+	for _, u := range f.Users {
+		switch {
+		case u.Username == "" || u.Email == "":
+			return errors.New("every user needs a username and an email")
+		case u.Role == "":
+			return fmt.Errorf("user %s needs a role", u.Username)
+		case (u.Password == "") == (u.PasswordEnv == ""):
+			return fmt.Errorf("user %s needs either a password or a password_env", u.Username)
+		}
+	}
+	return nil
+}
+
+// Select returns the fixtures named in only, all of them when only is empty
+func Select(fixtures []Fixture, only []string) ([]Fixture, error) {
+	if len(only) == 0 {
+		return fixtures, nil
+	}
+	selected := make([]Fixture, 0, len(only))
+	for _, name := range only {
+		if !contains(Kinds, name) {
+			return nil, fmt.Errorf("unknown fixture %q, use %s", name, strings.Join(Kinds, ", "))
+		}
+	}
+	for _, f := range fixtures {
+		if contains(only, f.Name) {
+			selected = append(selected, f)
+		}
+	}
+	return selected, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
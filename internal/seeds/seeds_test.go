@@ -0,0 +1,110 @@
+package seeds_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"go-template/internal/seeds"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFiles(t *testing.T) {
+	for _, env := range []string{"local", "docker", "develop"} {
+		fixtures, err := seeds.Load(seeds.Files(), env)
+		assert.NoError(t, err, env)
+		assert.Len(t, fixtures, len(seeds.Kinds), env)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []seeds.Fixture
+		wantErr string
+	}{
+		{
+			name: "YAML and JSON",
+			files: fstest.MapFS{
+				"test/users.json": {Data: []byte(`{"users": [{"username": "admin", "email": "admin@mail.com",
+					"role": "ADMIN", "password_env": "SEED_ADMIN_PASSWORD"}]}`)},
+				"test/roles.yaml":  {Data: []byte("roles:\n  - name: ADMIN\n    access_level: 110\n")},
+				"other/roles.yaml": {Data: []byte("roles:\n  - name: USER\n")},
+			},
+			want: []seeds.Fixture{
+				{Name: seeds.Roles, Roles: []seeds.Role{{Name: "ADMIN", AccessLevel: 110}}},
+				{Name: seeds.Users, Users: []seeds.User{{Username: "admin", Email: "admin@mail.com", Role: "ADMIN",
+					PasswordEnv: "SEED_ADMIN_PASSWORD"}}},
+			},
+		},
+		{name: "Empty file", files: fstest.MapFS{"test/roles.yml": {Data: []byte("")}},
+			want: []seeds.Fixture{{Name: seeds.Roles}}},
+		{name: "Unknown environment", files: fstest.MapFS{"other/roles.yaml": {}},
+			wantErr: `no fixtures for the environment "test"`},
+		{name: "Unknown field", files: fstest.MapFS{"test/roles.yaml": {Data: []byte("roles:\n  - title: ADMIN\n")}},
+			wantErr: "test/roles.yaml: yaml: unmarshal errors:\n  line 2: field title not found in type seeds.Role"},
+		{name: "Wrong kind", files: fstest.MapFS{"test/roles.yaml": {Data: []byte("users:\n  - username: admin\n")}},
+			wantErr: "test/roles.yaml: a roles fixture only holds roles"},
+		{name: "Role without a name", files: fstest.MapFS{"test/roles.yaml": {Data: []byte("roles:\n  - access_level: 1\n")}},
+			wantErr: "test/roles.yaml: every role needs a name"},
+		{name: "User without a role", files: fstest.MapFS{"test/users.json": {Data: []byte(
+			`{"users": [{"username": "admin", "email": "admin@mail.com", "password": "admin"}]}`)}},
+			wantErr: "test/users.json: user admin needs a role"},
+		{name: "User with two passwords", files: fstest.MapFS{"test/users.json": {Data: []byte(
+			`{"users": [{"username": "admin", "email": "admin@mail.com", "role": "ADMIN", "password": "admin",
+			"password_env": "SEED_ADMIN_PASSWORD"}]}`)}},
+			wantErr: "test/users.json: user admin needs either a password or a password_env"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seeds.Load(tt.files, "test")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for i := range got {
+				assert.Len(t, got[i].Checksum, 64)
+				got[i].Checksum = ""
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadChecksum(t *testing.T) {
+	files := fstest.MapFS{"test/roles.yaml": {Data: []byte("roles:\n  - name: ADMIN\n")}}
+	before, err := seeds.Load(files, "test")
+	assert.NoError(t, err)
+	files["test/roles.yaml"].Data = []byte("roles:\n  - name: ADMIN\n    access_level: 110\n")
+	after, err := seeds.Load(files, "test")
+	assert.NoError(t, err)
+	assert.NotEqual(t, before[0].Checksum, after[0].Checksum)
+}
+
+func TestSelect(t *testing.T) {
+	fixtures := []seeds.Fixture{{Name: seeds.Roles}, {Name: seeds.Users}}
+	tests := []struct {
+		name    string
+		only    []string
+		want    []seeds.Fixture
+		wantErr string
+	}{
+		{name: "All", want: fixtures},
+		{name: "Only users", only: []string{seeds.Users}, want: []seeds.Fixture{{Name: seeds.Users}}},
+		{name: "Applied in order", only: []string{seeds.Users, seeds.Roles}, want: fixtures},
+		{name: "Unknown", only: []string{"jobs"}, wantErr: `unknown fixture "jobs", use roles, users`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := seeds.Select(fixtures, tt.only)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	controller "go-template/internal/controller"
+	"go-template/internal/health"
 	"go-template/internal/middleware/secure"
+	"go-template/internal/service/metrics"
 	"go-template/internal/service/tracer"
 	"go-template/pkg/utl/zaplog"
 
@@ -30,8 +33,7 @@ func New() *echo.Echo {
 	e := echo.New()
 	e.Use(
 		otelecho.Middleware(os.Getenv("SERVICE_NAME")),
-		middleware.Logger(),
-		middleware.Recover(),
+		metrics.Middleware(),
 		func(next echo.HandlerFunc) echo.HandlerFunc {
 			return func(c echo.Context) error {
 				req := c.Request()
@@ -47,10 +49,9 @@ func New() *echo.Echo {
 				return next(cc)
 			}
 		},
-		middleware.BodyDump(func(c echo.Context, reqBody, resBody []byte) {
-			zaplog.Info(c.Request().Context(), string(reqBody))
-			zaplog.Info(c.Request().Context(), string(resBody))
-		}),
+		zaplog.RequestLogger(),
+		middleware.Recover(),
+		zaplog.BodyLogger(zaplog.BodySampleRate()),
 		secure.Headers(),
 		secure.CORS(),
 	)
@@ -68,9 +69,27 @@ type Config struct {
 	ReadTimeoutSeconds  int
 	WriteTimeoutSeconds int
 	Debug               bool
+	Health              *health.Checker
+	// ShutdownDelaySeconds is how long the server keeps serving once it reported itself
+	// as not ready, so that load balancers stop routing new requests to it
+	ShutdownDelaySeconds int
+	// ShutdownTimeoutSeconds bounds the draining of in-flight requests and websockets
+	ShutdownTimeoutSeconds int
+	// Websockets are closed once the server stopped accepting new connections
+	Websockets *Websockets
+	// OnShutdown hooks run in order once the connections are drained, they release the
+	// resources the requests depended on, such as the database and redis pools
+	OnShutdown []func(ctx context.Context) error
 }
 
-// Start starts echo server
+const defaultShutdownTimeout = 10 * time.Second
+
+// Start starts echo server and blocks until it receives SIGINT or SIGTERM, then shuts it
+// down gracefully:
+//  1. readiness reports 503 and the server keeps serving for ShutdownDelaySeconds
+//  2. in-flight requests and websocket connections are drained
+//  3. the OnShutdown hooks release the resources
+//  4. the tracer flushes the spans recorded until then
 func Start(e *echo.Echo, cfg *Config) {
 	tp := tracer.Init()
 	s := &http.Server{
@@ -88,19 +107,47 @@ func Start(e *echo.Echo, cfg *Config) {
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server with
-	// a timeout of 10 seconds.
 	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer func() {
-		cancel()
-		if err := tp.Shutdown(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer provider: %v", err)
-		}
-	}()
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	sig := <-quit
+	signal.Stop(quit)
+	zaplog.Logger.Infow("shutting down", "signal", sig.String())
+
+	if cfg.Health != nil {
+		cfg.Health.SetShuttingDown()
+	}
+	time.Sleep(time.Duration(cfg.ShutdownDelaySeconds) * time.Second)
+
+	timeout := time.Duration(cfg.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// hijacked websocket connections aren't tracked by http.Server.Shutdown, they are
+	// closed alongside the drain of regular requests
+	wsDrained := make(chan error, 1)
+	go func() { wsDrained <- cfg.Websockets.Close(ctx) }()
 	if err := e.Shutdown(ctx); err != nil {
-		e.StdLogger.Fatal(err)
+		zaplog.Logger.Error("error draining requests: ", err)
+		_ = e.Close()
+	}
+	if err := <-wsDrained; err != nil {
+		zaplog.Logger.Error("error draining websockets: ", err)
+	}
+
+	for _, hook := range cfg.OnShutdown {
+		if err := hook(ctx); err != nil {
+			zaplog.Logger.Error("error releasing resources: ", err)
+		}
+	}
+
+	// the tracer goes last so that the spans of the drained requests are exported, it
+	// gets its own deadline since draining may have used up ctx
+	tpCtx, tpCancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer tpCancel()
+	if err := tp.Shutdown(tpCtx); err != nil {
+		log.Printf("Error shutting down tracer provider: %v", err)
 	}
 }
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Websockets tracks the websocket connections of the routes it is applied to, so that
+// they can be closed and waited for on shutdown
+type Websockets struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	closing chan struct{}
+	closed  bool
+}
+
+// NewWebsockets ...
+func NewWebsockets() *Websockets {
+	return &Websockets{closing: make(chan struct{})}
+}
+
+// Middleware cancels the context of websocket requests when Close is called. gqlgen closes
+// the connection, and ends its subscriptions, as soon as the context is done.
+func (w *Websockets) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !c.IsWebSocket() {
+				return next(c)
+			}
+			w.mu.Lock()
+			if w.closed {
+				w.mu.Unlock()
+				return echo.ErrServiceUnavailable
+			}
+			w.wg.Add(1)
+			w.mu.Unlock()
+			defer w.wg.Done()
+
+			ctx, cancel := context.WithCancel(c.Request().Context())
+			defer cancel()
+			go func() {
+				select {
+				case <-w.closing:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// Close closes the tracked connections and waits for their handlers to return, or for
+// ctx to be done. It is a no-op on a nil receiver.
+func (w *Websockets) Close(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.closing)
+	}
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,86 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-template/internal/server"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func websocketRequest() *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set(echo.HeaderUpgrade, "websocket")
+	return req
+}
+
+func TestWebsocketsClose(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   func(c echo.Context) error
+		wantErr   bool
+		wantAfter int
+	}{
+		{
+			name: "Closes open connections",
+			handler: func(c echo.Context) error {
+				<-c.Request().Context().Done()
+				return nil
+			},
+			wantAfter: http.StatusServiceUnavailable,
+		},
+		{
+			name: "Times out on connections that don't close",
+			handler: func(c echo.Context) error {
+				time.Sleep(500 * time.Millisecond)
+				return nil
+			},
+			wantErr:   true,
+			wantAfter: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := server.NewWebsockets()
+			e := echo.New()
+			e.GET("/graphql", tt.handler, ws.Middleware())
+
+			started := make(chan struct{})
+			go func() {
+				close(started)
+				e.ServeHTTP(httptest.NewRecorder(), websocketRequest())
+			}()
+			<-started
+			time.Sleep(50 * time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			assert.Equal(t, tt.wantErr, ws.Close(ctx) != nil)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, websocketRequest())
+			assert.Equal(t, tt.wantAfter, rec.Code)
+		})
+	}
+}
+
+func TestWebsocketsIgnoresRegularRequests(t *testing.T) {
+	ws := server.NewWebsockets()
+	e := echo.New()
+	e.GET("/graphql", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, ws.Middleware())
+	assert.Nil(t, ws.Close(context.Background()))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var nilWebsockets *server.Websockets
+	assert.Nil(t, nilWebsockets.Close(context.Background()))
+}
@@ -1,12 +1,23 @@
 package service
 
 import (
+	"context"
 	"crypto/sha1"
-	"os"
+	"database/sql"
+	"errors"
+	"time"
 
 	"go-template/internal/config"
 	"go-template/internal/jwt"
+	"go-template/internal/repository"
+	"go-template/internal/service/settings"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/convert"
+	"go-template/pkg/utl/resultwrapper"
 	"go-template/pkg/utl/secure"
+
+	"github.com/volatiletech/null/v8"
 )
 
 // Secure returns new secure service
@@ -14,7 +25,112 @@ func Secure(cfg *config.Configuration) secure.Service {
 	return secure.New(cfg.App.MinPasswordStr, sha1.New())
 }
 
+// SecureWithSettings returns new security service whose minimum password strength is the
+// password.min_strength setting of store
+func SecureWithSettings(store *settings.Store) secure.Service {
+	return secure.NewWithPolicy(func() int { return store.Int(settings.PasswordMinStrength) }, sha1.New())
+}
+
 // JWT returns new JWT service
 func JWT(cfg *config.Configuration) (jwt.Service, error) {
-	return jwt.New(cfg.JWT.SigningAlgorithm, os.Getenv("JWT_SECRET"), cfg.JWT.DurationMinutes, cfg.JWT.MinSecretLength)
+	return jwt.New(cfg.JWT.SigningAlgorithm, cfg.JWT.Secret, cfg.JWT.DurationMinutes, cfg.JWT.MinSecretLength)
+}
+
+// Auth logs the users in and manages their credentials
+type Auth struct {
+	users  repository.UserRepository
+	roles  repository.RoleRepository
+	secure secure.Service
+	tokens jwt.Service
+}
+
+// NewAuth returns the auth service, sec and tokens are built once at startup
+func NewAuth(
+	users repository.UserRepository,
+	roles repository.RoleRepository,
+	sec secure.Service,
+	tokens jwt.Service,
+) *Auth {
+	return &Auth{users: users, roles: roles, secure: sec, tokens: tokens}
+}
+
+// Login checks the credentials of username and returns an access token along with a new
+// refresh token
+func (a *Auth) Login(ctx context.Context, username, password string) (token, refreshToken string, err error) {
+	u, err := a.users.FindByUsername(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", apperror.NewUnauthenticated("username or password does not exist ")
+	}
+	if err != nil {
+		return "", "", resultwrapper.ResolverSQLError(err, "username")
+	}
+	if !u.Password.Valid || !a.secure.HashMatchesPassword(u.Password.String, password) {
+		return "", "", apperror.NewUnauthenticated("username or password does not exist ")
+	}
+	if !u.Active.Valid || !u.Active.Bool {
+		return "", "", resultwrapper.ErrUnauthorized
+	}
+
+	token, err = a.token(ctx, u)
+	if err != nil {
+		return "", "", resultwrapper.ErrUnauthorized
+	}
+
+	refreshToken = a.secure.Token(token)
+	u.Token = null.StringFrom(refreshToken)
+	u.TokenIssuedAt = null.TimeFrom(time.Now())
+	if _, err := a.users.Update(ctx, nil, *u); err != nil {
+		return "", "", resultwrapper.ResolverSQLError(err, "token")
+	}
+	return token, refreshToken, nil
+}
+
+// ChangePassword replaces the password of the user userID once oldPassword is checked and
+// newPassword is found strong enough
+func (a *Auth) ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	u, err := a.users.FindByID(ctx, userID)
+	if err != nil {
+		return resultwrapper.ResolverSQLError(err, "data")
+	}
+	if !a.secure.HashMatchesPassword(convert.NullDotStringToString(u.Password), oldPassword) {
+		return apperror.NewValidation("incorrect old password")
+	}
+	if !a.secure.Password(newPassword,
+		convert.NullDotStringToString(u.FirstName),
+		convert.NullDotStringToString(u.LastName),
+		convert.NullDotStringToString(u.Username),
+		convert.NullDotStringToString(u.Email)) {
+		return apperror.NewValidation("insecure password")
+	}
+
+	u.Password = null.StringFrom(a.secure.Hash(newPassword))
+	if _, err := a.users.Update(ctx, nil, *u); err != nil {
+		return resultwrapper.ResolverSQLError(err, "new information")
+	}
+	return nil
+}
+
+// RefreshToken returns a new access token for the user holding refreshToken
+func (a *Auth) RefreshToken(ctx context.Context, refreshToken string) (string, error) {
+	u, err := a.users.FindByToken(ctx, refreshToken)
+	if err != nil {
+		return "", resultwrapper.ResolverSQLError(err, "token")
+	}
+	token, err := a.token(ctx, u)
+	if err != nil {
+		return "", apperror.Wrap(apperror.Internal, err, err.Error())
+	}
+	return token, nil
+}
+
+// token generates an access token for u carrying the name of its role
+func (a *Auth) token(ctx context.Context, u *models.User) (string, error) {
+	if !u.RoleID.Valid {
+		return "", errors.New("user has no role")
+	}
+	role, err := a.roles.FindByID(ctx, u.RoleID.Int)
+	if err != nil {
+		return "", err
+	}
+	return a.tokens.GenerateTokenWithRole(u, role.Name)
 }
@@ -1,16 +1,23 @@
 package service_test
 
 import (
+	"context"
+	"crypto/sha1"
 	"log"
-	"os"
 	"testing"
+	"time"
 
 	"go-template/internal/config"
+	"go-template/internal/jwt"
+	"go-template/internal/repository"
 	"go-template/internal/service"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/secure"
 	"go-template/testutls"
 
-	. "github.com/agiledragon/gomonkey/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 )
 
 const SuccessCase = "Success"
@@ -53,10 +60,6 @@ func TestJWT(t *testing.T) {
 			},
 		},
 	}
-	patches := ApplyFunc(os.Getenv, func(s string) string {
-		return testutls.MockJWTSecret
-	})
-	defer patches.Reset()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got, err := service.JWT(tt.args.cfg)
@@ -67,3 +70,106 @@ func TestJWT(t *testing.T) {
 		})
 	}
 }
+
+const testPassword = "pass123"
+
+// newAuth returns the auth service over in-memory repositories holding an active user, an
+// inactive one and one without a role
+func newAuth(t *testing.T) (*service.Auth, *repository.MemoryUsers) {
+	sec := secure.New(1, sha1.New())
+	tokens, err := jwt.New("HS256", testutls.MockJWTSecret, 60, 64)
+	assert.NoError(t, err)
+	hash := null.StringFrom(sec.Hash(testPassword))
+	users := repository.NewMemoryUsers(
+		models.User{ID: 1, Username: null.StringFrom("active"), Password: hash,
+			Active: null.BoolFrom(true), RoleID: null.IntFrom(1), Token: null.StringFrom("refresh")},
+		models.User{ID: 2, Username: null.StringFrom("inactive"), Password: hash,
+			Active: null.BoolFrom(false), RoleID: null.IntFrom(1)},
+		models.User{ID: 3, Username: null.StringFrom("roleless"), Password: hash,
+			Active: null.BoolFrom(true), Token: null.StringFrom("roleless")},
+	)
+	roles := repository.NewMemoryRoles(models.Role{ID: 1, Name: "USER", AccessLevel: 200})
+	return service.NewAuth(users, roles, sec, tokens), users
+}
+
+func TestAuthLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		wantCode apperror.Code
+	}{
+		{name: SuccessCase, username: "active", password: testPassword},
+		{name: "UnknownUser", username: "nobody", password: testPassword, wantCode: apperror.Unauthenticated},
+		{name: "WrongPassword", username: "active", password: "wrong", wantCode: apperror.Unauthenticated},
+		{name: "Inactive", username: "inactive", password: testPassword, wantCode: apperror.Unauthenticated},
+		{name: "NoRole", username: "roleless", password: testPassword, wantCode: apperror.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, users := newAuth(t)
+			token, refreshToken, err := auth.Login(context.Background(), tt.username, tt.password)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, token)
+			u, _ := users.FindByToken(context.Background(), refreshToken)
+			assert.Equal(t, 1, u.ID)
+			assert.WithinDuration(t, time.Now(), u.TokenIssuedAt.Time, time.Minute)
+		})
+	}
+}
+
+func TestAuthChangePassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		userID      int
+		oldPassword string
+		newPassword string
+		wantCode    apperror.Code
+	}{
+		{name: SuccessCase, userID: 1, oldPassword: testPassword, newPassword: "correct horse battery staple"},
+		{name: "UnknownUser", userID: 9, oldPassword: testPassword, newPassword: "x", wantCode: apperror.NotFound},
+		{name: "WrongPassword", userID: 1, oldPassword: "wrong", newPassword: "x", wantCode: apperror.Validation},
+		{name: "Insecure", userID: 1, oldPassword: testPassword, newPassword: "active", wantCode: apperror.Validation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, _ := newAuth(t)
+			err := auth.ChangePassword(context.Background(), tt.userID, tt.oldPassword, tt.newPassword)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			_, _, err = auth.Login(context.Background(), "active", tt.newPassword)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthRefreshToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		wantCode apperror.Code
+	}{
+		{name: SuccessCase, token: "refresh"},
+		{name: "UnknownToken", token: "unknown", wantCode: apperror.NotFound},
+		{name: "NoRole", token: "roleless", wantCode: apperror.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, _ := newAuth(t)
+			token, err := auth.RefreshToken(context.Background(), tt.token)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, token)
+		})
+	}
+}
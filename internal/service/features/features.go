@@ -0,0 +1,145 @@
+// Package features holds the feature flags, which ship code paths behind a toggle or roll them
+// out to some users first. The flags are stored in the feature_flags table and cached in memory.
+package features
+
+import (
+	"context"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+)
+
+// Type is the type of a feature flag
+type Type string
+
+// Types of feature flags
+const (
+	// Boolean flags are on for everyone when enabled, or only for their targets when they
+	// have some
+	Boolean Type = "boolean"
+	// Percentage flags are on for their targets and for a stable percentage of the other users
+	Percentage Type = "percentage"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// Flag is a feature flag and its targeting rules
+type Flag struct {
+	Name        string
+	Description string
+	Type        Type
+	Enabled     bool
+	// Percentage is the percentage of the users the percentage flags are on for
+	Percentage int
+	// UserIDs, Roles and Organizations are the targets the flag is on for whatever its type
+	UserIDs       []int
+	Roles         []string
+	Organizations []string
+	UpdatedBy     int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Validate checks the name, the type and the percentage of the flag
+func (f Flag) Validate() error {
+	if !namePattern.MatchString(f.Name) {
+		return apperror.Newf(apperror.Validation,
+			"invalid feature flag name %q, use up to 64 lowercase letters, digits, '.', '_' or '-'", f.Name)
+	}
+	switch f.Type {
+	case Boolean:
+		if f.Percentage != 0 {
+			return apperror.NewValidation("only percentage feature flags have a percentage")
+		}
+	case Percentage:
+		if f.Percentage < 0 || f.Percentage > 100 {
+			return apperror.NewValidation("the percentage must be between 0 and 100")
+		}
+	default:
+		return apperror.Newf(apperror.Validation, "invalid feature flag type %q", f.Type)
+	}
+	return nil
+}
+
+// Subject is who a flag is evaluated for
+type Subject struct {
+	UserID       int
+	Role         string
+	Organization string
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx whose flags are evaluated for s instead of the
+// authenticated user, e.g. in jobs acting on behalf of a user
+func WithSubject(ctx context.Context, s Subject) context.Context {
+	return context.WithValue(ctx, subjectKey{}, s)
+}
+
+// SubjectFromContext returns the subject of WithSubject, or the authenticated user of ctx
+func SubjectFromContext(ctx context.Context) Subject {
+	if s, ok := ctx.Value(subjectKey{}).(Subject); ok {
+		return s
+	}
+	return Subject{
+		UserID:       auth.UserIDFromContext(ctx),
+		Role:         auth.RoleFromContext(ctx),
+		Organization: auth.OrganizationFromContext(ctx),
+	}
+}
+
+// Evaluate reports whether the flag is on for s
+func (f Flag) Evaluate(s Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if f.targets(s) {
+		return true
+	}
+	switch f.Type {
+	case Boolean:
+		return len(f.UserIDs) == 0 && len(f.Roles) == 0 && len(f.Organizations) == 0
+	case Percentage:
+		// anonymous subjects have no stable bucket, they only see fully rolled out flags
+		if s.UserID == 0 {
+			return f.Percentage >= 100
+		}
+		return bucket(f.Name, s.UserID) < f.Percentage
+	}
+	return false
+}
+
+func (f Flag) targets(s Subject) bool {
+	if s.UserID != 0 {
+		for _, id := range f.UserIDs {
+			if id == s.UserID {
+				return true
+			}
+		}
+	}
+	return contains(f.Roles, s.Role) || contains(f.Organizations, s.Organization)
+}
+
+func contains(values []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// bucket places userID in one of 100 buckets, the buckets of a user differ between flags so
+// that the same users aren't always the first to see the rollouts
+func bucket(name string, userID int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + "/" + strconv.Itoa(userID)))
+	return int(h.Sum32() % 100)
+}
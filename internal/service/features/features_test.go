@@ -0,0 +1,217 @@
+package features_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service/features"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    features.Flag
+		wantErr string
+	}{
+		{name: "Boolean", flag: features.Flag{Name: "exports", Type: features.Boolean}},
+		{name: "Percentage", flag: features.Flag{Name: "new.dashboard_v2", Type: features.Percentage, Percentage: 100}},
+		{name: "Invalid name", flag: features.Flag{Name: "New Dashboard", Type: features.Boolean},
+			wantErr: `invalid feature flag name "New Dashboard", use up to 64 lowercase letters, digits, '.', '_' or '-'`},
+		{name: "Invalid type", flag: features.Flag{Name: "exports", Type: "gradual"},
+			wantErr: `invalid feature flag type "gradual"`},
+		{name: "Boolean with a percentage", flag: features.Flag{Name: "exports", Type: features.Boolean, Percentage: 10},
+			wantErr: "only percentage feature flags have a percentage"},
+		{name: "Percentage out of range", flag: features.Flag{Name: "exports", Type: features.Percentage, Percentage: 101},
+			wantErr: "the percentage must be between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flag.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Equal(t, apperror.Validation, apperror.CodeOf(err))
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	admin := features.Subject{UserID: 1, Role: "SUPER_ADMIN"}
+	user := features.Subject{UserID: 2, Role: "USER", Organization: "acme"}
+	tests := []struct {
+		name    string
+		flag    features.Flag
+		subject features.Subject
+		want    bool
+	}{
+		{name: "Disabled", flag: features.Flag{Type: features.Boolean}, subject: admin},
+		{name: "Boolean without targets", flag: features.Flag{Type: features.Boolean, Enabled: true},
+			subject: features.Subject{}, want: true},
+		{name: "Targeted user", flag: features.Flag{Type: features.Boolean, Enabled: true, UserIDs: []int{2}},
+			subject: user, want: true},
+		{name: "Targeted role", flag: features.Flag{Type: features.Boolean, Enabled: true, Roles: []string{"SUPER_ADMIN"}},
+			subject: admin, want: true},
+		{name: "Targeted organization", flag: features.Flag{Type: features.Boolean, Enabled: true,
+			Organizations: []string{"acme"}}, subject: user, want: true},
+		{name: "Not targeted", flag: features.Flag{Type: features.Boolean, Enabled: true, Roles: []string{"SUPER_ADMIN"}},
+			subject: user},
+		{name: "Disabled targeted", flag: features.Flag{Type: features.Boolean, UserIDs: []int{2}}, subject: user},
+		{name: "Percentage 0", flag: features.Flag{Type: features.Percentage, Enabled: true}, subject: user},
+		{name: "Percentage 100", flag: features.Flag{Type: features.Percentage, Enabled: true, Percentage: 100},
+			subject: user, want: true},
+		{name: "Percentage targets", flag: features.Flag{Type: features.Percentage, Enabled: true,
+			Roles: []string{"USER"}}, subject: user, want: true},
+		{name: "Percentage anonymous", flag: features.Flag{Type: features.Percentage, Enabled: true, Percentage: 99},
+			subject: features.Subject{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flag.Name = "flag"
+			assert.Equal(t, tt.want, tt.flag.Evaluate(tt.subject))
+		})
+	}
+}
+
+func TestEvaluatePercentage(t *testing.T) {
+	flag := features.Flag{Name: "rollout", Type: features.Percentage, Enabled: true, Percentage: 30}
+	on := 0
+	for id := 1; id <= 10000; id++ {
+		subject := features.Subject{UserID: id}
+		got := flag.Evaluate(subject)
+		// users stay in their bucket
+		assert.Equal(t, got, flag.Evaluate(subject))
+		if got {
+			on++
+		}
+	}
+	assert.InDelta(t, 3000, on, 300)
+
+	// raising the percentage keeps the users already rolled out to
+	wider := flag
+	wider.Percentage = 60
+	for id := 1; id <= 1000; id++ {
+		if flag.Evaluate(features.Subject{UserID: id}) {
+			assert.True(t, wider.Evaluate(features.Subject{UserID: id}))
+		}
+	}
+}
+
+func TestSubjectFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), auth.UserCtxKey, &models.User{ID: 3})
+	ctx = context.WithValue(ctx, auth.RoleCtxKey, "USER")
+	ctx = context.WithValue(ctx, auth.OrganizationCtxKey, "acme")
+	assert.Equal(t, features.Subject{UserID: 3, Role: "USER", Organization: "acme"}, features.SubjectFromContext(ctx))
+
+	override := features.Subject{UserID: 4}
+	assert.Equal(t, override, features.SubjectFromContext(features.WithSubject(ctx, override)))
+	assert.Equal(t, features.Subject{}, features.SubjectFromContext(context.Background()))
+}
+
+func newService(t *testing.T, flags ...features.Flag) *features.Service {
+	t.Helper()
+	s := features.New(features.NewMemory(flags...), 0)
+	assert.NoError(t, s.Load(context.Background()))
+	return s
+}
+
+func TestService(t *testing.T) {
+	s := newService(t,
+		features.Flag{Name: "exports", Type: features.Boolean, Enabled: true, Roles: []string{"SUPER_ADMIN"}},
+		features.Flag{Name: "beta", Type: features.Boolean, Enabled: true},
+	)
+	admin := features.WithSubject(context.Background(), features.Subject{UserID: 1, Role: "SUPER_ADMIN"})
+	user := features.WithSubject(context.Background(), features.Subject{UserID: 2, Role: "USER"})
+
+	assert.True(t, s.Enabled(admin, "exports"))
+	assert.False(t, s.Enabled(user, "exports"))
+	assert.False(t, s.Enabled(admin, "unknown"))
+	assert.Equal(t, []string{"beta", "exports"}, s.EnabledFlags(admin))
+	assert.Equal(t, []string{"beta"}, s.EnabledFlags(user))
+
+	saved, err := s.Save(admin, 1, features.Flag{Name: "exports", Type: features.Boolean, Enabled: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.UpdatedBy)
+	assert.False(t, saved.UpdatedAt.IsZero())
+	assert.True(t, s.Enabled(user, "exports"))
+
+	_, err = s.Save(admin, 1, features.Flag{Name: "exports", Type: "gradual"})
+	assert.Equal(t, apperror.Validation, apperror.CodeOf(err))
+
+	assert.NoError(t, s.Delete(admin, "exports"))
+	assert.False(t, s.Enabled(admin, "exports"))
+	_, err = s.Get("exports")
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(s.Delete(admin, "exports")))
+}
+
+func TestDirective(t *testing.T) {
+	s := newService(t, features.Flag{Name: "exports", Type: features.Boolean, Enabled: true, UserIDs: []int{1}})
+	next := func(context.Context) (interface{}, error) { return "resolved", nil }
+	tests := []struct {
+		name    string
+		subject features.Subject
+		want    interface{}
+	}{
+		{name: "On", subject: features.Subject{UserID: 1}, want: "resolved"},
+		{name: "Off", subject: features.Subject{UserID: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Directive(features.WithSubject(context.Background(), tt.subject), nil, next, "exports")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// failing fails to read the flags
+type failing struct{ features.Memory }
+
+func (*failing) FindAll(context.Context) ([]features.Flag, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestLoad(t *testing.T) {
+	repo := features.NewMemory()
+	s := features.New(repo, 5*time.Millisecond)
+	assert.NoError(t, s.Load(context.Background()))
+	s.Start()
+	// flags saved through another instance are picked up by the reloads
+	_, err := repo.Upsert(context.Background(), features.Flag{Name: "beta", Type: features.Boolean, Enabled: true})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return s.EnabledFor(features.Subject{}, "beta")
+	}, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, s.Stop(ctx))
+	assert.NoError(t, s.Stop(ctx))
+
+	assert.EqualError(t, features.New(&failing{}, 0).Load(context.Background()), "connection refused")
+}
+
+func TestIntervalFromEnv(t *testing.T) {
+	t.Setenv("FEATURE_FLAGS_REFRESH_SECONDS", "")
+	interval, err := features.IntervalFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, interval)
+
+	t.Setenv("FEATURE_FLAGS_REFRESH_SECONDS", "0")
+	interval, err = features.IntervalFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), interval)
+
+	t.Setenv("FEATURE_FLAGS_REFRESH_SECONDS", "soon")
+	_, err = features.IntervalFromEnv()
+	assert.EqualError(t, err, `FEATURE_FLAGS_REFRESH_SECONDS must be a positive integer, got "soon"`)
+}
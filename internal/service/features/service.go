@@ -0,0 +1,332 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-template/daos"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/volatiletech/null/v8"
+)
+
+// Repository persists the feature flags
+type Repository interface {
+	FindAll(ctx context.Context) ([]Flag, error)
+	Upsert(ctx context.Context, flag Flag) (*Flag, error)
+	Delete(ctx context.Context, name string) (bool, error)
+}
+
+// Postgres stores the flags in the feature_flags table
+type Postgres struct{}
+
+// FindAll ...
+func (Postgres) FindAll(ctx context.Context) ([]Flag, error) {
+	rows, err := daos.FindAllFeatureFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := make([]Flag, 0, len(rows))
+	for _, row := range rows {
+		flags = append(flags, fromRow(row))
+	}
+	return flags, nil
+}
+
+// Upsert ...
+func (Postgres) Upsert(ctx context.Context, flag Flag) (*Flag, error) {
+	userIDs := make([]int64, 0, len(flag.UserIDs))
+	for _, id := range flag.UserIDs {
+		userIDs = append(userIDs, int64(id))
+	}
+	row, err := daos.UpsertFeatureFlag(daos.FeatureFlag{
+		Name:          flag.Name,
+		Description:   flag.Description,
+		Type:          string(flag.Type),
+		Enabled:       flag.Enabled,
+		Percentage:    flag.Percentage,
+		UserIDs:       userIDs,
+		Roles:         nonNil(flag.Roles),
+		Organizations: nonNil(flag.Organizations),
+		UpdatedBy:     null.NewInt(flag.UpdatedBy, flag.UpdatedBy != 0),
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	f := fromRow(*row)
+	return &f, nil
+}
+
+// Delete ...
+func (Postgres) Delete(ctx context.Context, name string) (bool, error) {
+	n, err := daos.DeleteFeatureFlag(name, ctx)
+	return n > 0, err
+}
+
+func fromRow(row daos.FeatureFlag) Flag {
+	userIDs := make([]int, 0, len(row.UserIDs))
+	for _, id := range row.UserIDs {
+		userIDs = append(userIDs, int(id))
+	}
+	return Flag{
+		Name:          row.Name,
+		Description:   row.Description,
+		Type:          Type(row.Type),
+		Enabled:       row.Enabled,
+		Percentage:    row.Percentage,
+		UserIDs:       userIDs,
+		Roles:         row.Roles,
+		Organizations: row.Organizations,
+		UpdatedBy:     row.UpdatedBy.Int,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Memory stores the flags in memory
+type Memory struct {
+	mu    sync.Mutex
+	flags map[string]Flag
+}
+
+// NewMemory returns a memory repository holding flags
+func NewMemory(flags ...Flag) *Memory {
+	m := &Memory{flags: map[string]Flag{}}
+	for _, f := range flags {
+		m.flags[f.Name] = f
+	}
+	return m
+}
+
+// FindAll ...
+func (m *Memory) FindAll(context.Context) ([]Flag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	flags := make([]Flag, 0, len(m.flags))
+	for _, f := range m.flags {
+		flags = append(flags, f)
+	}
+	return flags, nil
+}
+
+// Upsert ...
+func (m *Memory) Upsert(_ context.Context, flag Flag) (*Flag, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	flag.CreatedAt, flag.UpdatedAt = now, now
+	if existing, ok := m.flags[flag.Name]; ok {
+		flag.CreatedAt = existing.CreatedAt
+	}
+	m.flags[flag.Name] = flag
+	return &flag, nil
+}
+
+// Delete ...
+func (m *Memory) Delete(_ context.Context, name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.flags[name]
+	delete(m.flags, name)
+	return ok, nil
+}
+
+// IntervalFromEnv reads FEATURE_FLAGS_REFRESH_SECONDS, 30 by default, 0 disables the reloads
+func IntervalFromEnv() (time.Duration, error) {
+	value := os.Getenv("FEATURE_FLAGS_REFRESH_SECONDS")
+	if value == "" {
+		return 30 * time.Second, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("FEATURE_FLAGS_REFRESH_SECONDS must be a positive integer, got %q", value)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// Service evaluates the feature flags. The flags are cached in memory and reloaded on an
+// interval so that the changes made through the other instances are picked up. Unknown flags
+// are off.
+type Service struct {
+	repo     Repository
+	interval time.Duration
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New returns a service reading the flags from repo every interval once started, Load reads
+// them first
+func New(repo Repository, interval time.Duration) *Service {
+	return &Service{
+		repo:     repo,
+		interval: interval,
+		flags:    map[string]Flag{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Load reads the flags of the repository
+func (s *Service) Load(ctx context.Context) error {
+	rows, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	flags := make(map[string]Flag, len(rows))
+	for _, f := range rows {
+		flags[f.Name] = f
+	}
+	s.mu.Lock()
+	s.flags = flags
+	s.mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether the flag name is on for the subject of ctx
+func (s *Service) Enabled(ctx context.Context, name string) bool {
+	return s.EnabledFor(SubjectFromContext(ctx), name)
+}
+
+// EnabledFor reports whether the flag name is on for subject
+func (s *Service) EnabledFor(subject Subject, name string) bool {
+	s.mu.RLock()
+	f, ok := s.flags[name]
+	s.mu.RUnlock()
+	return ok && f.Evaluate(subject)
+}
+
+// EnabledFlags returns the names of the flags on for the subject of ctx
+func (s *Service) EnabledFlags(ctx context.Context) []string {
+	subject := SubjectFromContext(ctx)
+	names := []string{}
+	for _, f := range s.List() {
+		if f.Evaluate(subject) {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Get returns the flag name
+func (s *Service) Get(name string) (Flag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.flags[name]
+	if !ok {
+		return Flag{}, apperror.Newf(apperror.NotFound, "unknown feature flag %s", name)
+	}
+	return f, nil
+}
+
+// List returns the flags sorted by name
+func (s *Service) List() []Flag {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	flags := make([]Flag, 0, len(s.flags))
+	for _, f := range s.flags {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// Save validates flag and creates it, or replaces the flag of the same name. actorID is the
+// user making the change.
+func (s *Service) Save(ctx context.Context, actorID int, flag Flag) (Flag, error) {
+	if err := flag.Validate(); err != nil {
+		return Flag{}, err
+	}
+	flag.UpdatedBy = actorID
+	saved, err := s.repo.Upsert(ctx, flag)
+	if err != nil {
+		return Flag{}, apperror.FromSQL(err, "feature flag")
+	}
+	s.mu.Lock()
+	s.flags[saved.Name] = *saved
+	s.mu.Unlock()
+	zaplog.For(ctx).Infow("feature flag saved", "flag", saved.Name, "enabled", saved.Enabled)
+	return *saved, nil
+}
+
+// Delete deletes the flag name, which is then off for everyone
+func (s *Service) Delete(ctx context.Context, name string) error {
+	deleted, err := s.repo.Delete(ctx, name)
+	if err != nil {
+		return apperror.FromSQL(err, "feature flag")
+	}
+	if !deleted {
+		return apperror.Newf(apperror.NotFound, "unknown feature flag %s", name)
+	}
+	s.mu.Lock()
+	delete(s.flags, name)
+	s.mu.Unlock()
+	zaplog.For(ctx).Infow("feature flag deleted", "flag", name)
+	return nil
+}
+
+// Directive implements the @feature(name:) directive, it resolves the field to null when the
+// flag is off for the subject of ctx
+func (s *Service) Directive(ctx context.Context, _ interface{}, next graphql.Resolver, name string) (interface{}, error) {
+	if !s.Enabled(ctx, name) {
+		return nil, nil
+	}
+	return next(ctx)
+}
+
+// Start reloads the flags every interval until Stop is called
+func (s *Service) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	s.started.Store(true)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.Load(context.Background()); err != nil {
+					zaplog.Logger.Errorw("reloading the feature flags failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the reloads
+func (s *Service) Stop(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	if !s.started.Load() {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
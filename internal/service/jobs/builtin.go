@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-template/daos"
+	"go-template/pkg/utl/rediscache"
+	"go-template/pkg/utl/zaplog"
+)
+
+// Kinds of the jobs shipped with the template
+const (
+	KindCleanupExpiredTokens = "cleanup_expired_tokens"
+	KindWarmRoleCache        = "warm_role_cache"
+)
+
+// CleanupExpiredTokens is the payload of KindCleanupExpiredTokens jobs
+type CleanupExpiredTokens struct {
+	// MaxAgeMinutes is the lifetime of a refresh token
+	MaxAgeMinutes int `json:"maxAgeMinutes"`
+}
+
+// WarmRoleCache is the payload of KindWarmRoleCache jobs
+type WarmRoleCache struct{}
+
+// RegisterBuiltins registers the handlers of the jobs shipped with the template
+func RegisterBuiltins(r *Registry) {
+	Handle(r, KindCleanupExpiredTokens, cleanupExpiredTokens)
+	Handle(r, KindWarmRoleCache, warmRoleCache)
+}
+
+// ScheduleBuiltins schedules the jobs shipped with the template, refresh tokens expire
+// after maxRefreshMinutes
+func ScheduleBuiltins(s *Scheduler, maxRefreshMinutes int) error {
+	if maxRefreshMinutes > 0 {
+		if err := s.Add(KindCleanupExpiredTokens, "@hourly", KindCleanupExpiredTokens,
+			CleanupExpiredTokens{MaxAgeMinutes: maxRefreshMinutes}); err != nil {
+			return err
+		}
+	}
+	return s.Add(KindWarmRoleCache, "*/10 * * * *", KindWarmRoleCache, WarmRoleCache{})
+}
+
+func cleanupExpiredTokens(ctx context.Context, payload CleanupExpiredTokens) error {
+	if payload.MaxAgeMinutes <= 0 {
+		return fmt.Errorf("maxAgeMinutes must be positive")
+	}
+	n, err := daos.ClearTokensIssuedBefore(time.Now().Add(-time.Duration(payload.MaxAgeMinutes)*time.Minute), ctx)
+	if err != nil {
+		return err
+	}
+	zaplog.Logger.Infow("cleaned up expired refresh tokens", "count", n)
+	return nil
+}
+
+func warmRoleCache(ctx context.Context, _ WarmRoleCache) error {
+	roles, err := daos.FindAllRoles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := rediscache.SetKeyValue(fmt.Sprintf("role%d", role.ID), role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/robfig/cron/v3"
+)
+
+type schedule struct {
+	name     string
+	schedule cron.Schedule
+	kind     string
+	payload  interface{}
+	next     time.Time
+}
+
+// Scheduler enqueues jobs on cron schedules. Every occurrence is enqueued with a unique key
+// so that several schedulers, one per worker, enqueue it only once.
+type Scheduler struct {
+	mu        sync.Mutex
+	schedules []*schedule
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewScheduler ...
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Add enqueues a job of kind with payload on spec, a standard 5 fields cron expression or a
+// descriptor such as @hourly or @every 10m. name identifies the schedule in unique keys.
+func (s *Scheduler) Add(name, spec, kind string, payload interface{}) error {
+	sched, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule for %s: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules = append(s.schedules, &schedule{
+		name:     name,
+		schedule: sched,
+		kind:     kind,
+		payload:  payload,
+		next:     sched.Next(time.Now()),
+	})
+	return nil
+}
+
+// Start enqueues the due occurrences every second until Stop is called
+func (s *Scheduler) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case now := <-ticker.C:
+				s.Tick(context.Background(), now)
+			}
+		}
+	}()
+}
+
+// Stop ...
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Tick enqueues the occurrences due at now. An occurrence that fails to be enqueued is
+// retried on the next tick.
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sched := range s.schedules {
+		for !sched.next.After(now) {
+			key := fmt.Sprintf("cron:%s:%d", sched.name, sched.next.Unix())
+			if _, err := Enqueue(ctx, sched.kind, sched.payload, RunAt(sched.next), UniqueKey(key)); err != nil {
+				zaplog.Logger.Error("failed to enqueue scheduled job "+sched.name+": ", err)
+				break
+			}
+			sched.next = sched.schedule.Next(sched.next)
+		}
+	}
+}
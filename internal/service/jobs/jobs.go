@@ -0,0 +1,94 @@
+// Package jobs runs work off the request path. Jobs are rows of the jobs table claimed by
+// workers with FOR UPDATE SKIP LOCKED, failed jobs are retried with an exponential backoff
+// until they run out of attempts and are moved to the dead-letter state.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-template/daos"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// DefaultMaxAttempts is the number of attempts of a job enqueued without MaxAttempts
+const DefaultMaxAttempts = 10
+
+// Handler runs a job of a given kind
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Registry maps job kinds to their handlers
+type Registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry ...
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]Handler{}}
+}
+
+// Handle registers fn for the jobs of kind, their payload is decoded into T
+func Handle[T any](r *Registry, kind string, fn func(ctx context.Context, payload T) error) {
+	r.handlers[kind] = func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("invalid %s payload: %w", kind, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Kinds returns the registered kinds
+func (r *Registry) Kinds() []string {
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	return kinds
+}
+
+// Option customizes an enqueued job
+type Option func(*daos.Job)
+
+// RunAt delays the job until t
+func RunAt(t time.Time) Option {
+	return func(j *daos.Job) { j.RunAt = t }
+}
+
+// MaxAttempts sets the number of attempts before the job is dead
+func MaxAttempts(n int) Option {
+	return func(j *daos.Job) { j.MaxAttempts = n }
+}
+
+// UniqueKey prevents enqueuing the job twice, e.g. from several schedulers
+func UniqueKey(key string) Option {
+	return func(j *daos.Job) { j.UniqueKey = null.StringFrom(key) }
+}
+
+// Enqueue adds a job of kind with payload JSON encoded, it returns 0 when a job with the
+// same unique key already exists
+func Enqueue(ctx context.Context, kind string, payload interface{}, opts ...Option) (int64, error) {
+	return EnqueueTx(ctx, nil, kind, payload, opts...)
+}
+
+// EnqueueTx is Enqueue as part of tx, the job only becomes visible to workers once tx commits
+func EnqueueTx(ctx context.Context, tx *sql.Tx, kind string, payload interface{}, opts ...Option) (int64, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	job := daos.Job{
+		Kind:        kind,
+		Payload:     b,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+	return daos.CreateJobTx(job, ctx, tx)
+}
@@ -0,0 +1,265 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/service/jobs"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+type emailPayload struct {
+	To string `json:"to"`
+}
+
+var (
+	insertJob = regexp.QuoteMeta(`INSERT INTO jobs (kind, payload, max_attempts, run_at, unique_key)`)
+	failJob   = regexp.QuoteMeta(`UPDATE jobs SET status = $2, last_error = $3`)
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    jobs.Options
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			want: jobs.Options{
+				Concurrency: 4,
+				Interval:    time.Second,
+				MaxBackoff:  time.Hour,
+				Lease:       15 * time.Minute,
+				Retention:   7 * 24 * time.Hour,
+			},
+		},
+		{
+			name: "Overrides",
+			env: map[string]string{
+				"WORKER_CONCURRENCY":       "8",
+				"WORKER_POLL_INTERVAL_MS":  "250",
+				"JOBS_MAX_BACKOFF_SECONDS": "60",
+				"JOBS_LEASE_SECONDS":       "30",
+				"JOBS_RETENTION_HOURS":     "1",
+			},
+			want: jobs.Options{
+				Concurrency: 8,
+				Interval:    250 * time.Millisecond,
+				MaxBackoff:  time.Minute,
+				Lease:       30 * time.Second,
+				Retention:   time.Hour,
+			},
+		},
+		{
+			name:    "Invalid value",
+			env:     map[string]string{"WORKER_CONCURRENCY": "0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"WORKER_CONCURRENCY", "WORKER_POLL_INTERVAL_MS",
+				"JOBS_MAX_BACKOFF_SECONDS", "JOBS_LEASE_SECONDS", "JOBS_RETENTION_HOURS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := jobs.OptionsFromEnv()
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	runAt := time.Now().Add(time.Hour)
+	mock.ExpectQuery(insertJob).
+		WithArgs("email", []byte(`{"to":"a@b.c"}`), 3, runAt, null.StringFrom("welcome:1")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+	id, err := jobs.Enqueue(context.Background(), "email", emailPayload{To: "a@b.c"},
+		jobs.RunAt(runAt), jobs.MaxAttempts(3), jobs.UniqueKey("welcome:1"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(9), id)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		payload string
+		attempt int
+		err     error
+		panics  bool
+		expect  func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "Success",
+			kind:    "email",
+			payload: `{"to":"a@b.c"}`,
+			attempt: 1,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(regexp.QuoteMeta(`UPDATE jobs SET status = 'succeeded'`)).WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Failure is retried with a backoff",
+			kind:    "email",
+			payload: `{"to":"a@b.c"}`,
+			attempt: 2,
+			err:     errors.New("smtp is down"),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(failJob).WithArgs(1, daos.JobPending, "smtp is down", int64(4000)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Last attempt moves the job to dead",
+			kind:    "email",
+			payload: `{"to":"a@b.c"}`,
+			attempt: 3,
+			err:     errors.New("smtp is down"),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(failJob).WithArgs(1, daos.JobDead, "smtp is down", sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Panic is recorded as a failure",
+			kind:    "email",
+			payload: `{"to":"a@b.c"}`,
+			attempt: 1,
+			panics:  true,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(failJob).WithArgs(1, daos.JobPending, "panic: boom", int64(2000)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Invalid payload",
+			kind:    "email",
+			payload: `[]`,
+			attempt: 1,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(failJob).WithArgs(1, daos.JobPending, sqlmock.AnyArg(), int64(2000)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "Unknown kind",
+			kind:    "sms",
+			payload: `{}`,
+			attempt: 1,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(failJob).WithArgs(1, daos.JobPending, "no handler for sms jobs", int64(2000)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			tt.expect(mock)
+
+			registry := jobs.NewRegistry()
+			var got emailPayload
+			jobs.Handle(registry, "email", func(_ context.Context, payload emailPayload) error {
+				if tt.panics {
+					panic("boom")
+				}
+				got = payload
+				return tt.err
+			})
+			worker := jobs.NewWorker(registry, jobs.Options{Concurrency: 1, MaxBackoff: time.Hour})
+			worker.Run(context.Background(), daos.Job{
+				ID: 1, Kind: tt.kind, Payload: []byte(tt.payload), Attempts: tt.attempt, MaxAttempts: 3,
+			})
+			if tt.kind == "email" && !tt.panics && tt.payload != `[]` {
+				assert.Equal(t, "a@b.c", got.To)
+			}
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWorker(t *testing.T) {
+	var claimed, completed int32
+	patches := gomonkey.ApplyFunc(daos.ClaimJobs,
+		func(_ string, kinds []string, limit int, _ context.Context) ([]daos.Job, error) {
+			if atomic.AddInt32(&claimed, 1) > 1 {
+				return nil, nil
+			}
+			assert.Equal(t, []string{"email"}, kinds)
+			assert.Equal(t, 2, limit)
+			return []daos.Job{{ID: 1, Kind: "email", Payload: []byte(`{}`), Attempts: 1, MaxAttempts: 3}}, nil
+		})
+	defer patches.Reset()
+	patches.ApplyFunc(daos.CompleteJob, func(id int64, _ context.Context) error {
+		atomic.AddInt32(&completed, 1)
+		return nil
+	})
+	patches.ApplyFunc(daos.RescueStaleJobs, func(time.Duration, context.Context) (int64, error) {
+		return 0, nil
+	})
+	patches.ApplyFunc(daos.DeleteJobsFinishedBefore, func(time.Time, context.Context) (int64, error) {
+		return 0, nil
+	})
+
+	registry := jobs.NewRegistry()
+	ran := make(chan struct{})
+	jobs.Handle(registry, "email", func(context.Context, emailPayload) error {
+		close(ran)
+		return nil
+	})
+	worker := jobs.NewWorker(registry, jobs.Options{Concurrency: 2, Interval: 10 * time.Millisecond})
+	worker.Start()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job wasn't run")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, worker.Stop(ctx))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&completed))
+}
+
+func TestSchedulerTick(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	scheduler := jobs.NewScheduler()
+	assert.NotNil(t, scheduler.Add("report", "not a schedule", "report", nil))
+	assert.Nil(t, scheduler.Add("report", "@every 1m", "report", emailPayload{To: "a@b.c"}))
+
+	mock.ExpectQuery(insertJob).
+		WithArgs("report", []byte(`{"to":"a@b.c"}`), jobs.DefaultMaxAttempts, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	now := time.Now().Add(90 * time.Second)
+	scheduler.Tick(context.Background(), now)
+	// the occurrence was enqueued, ticking again doesn't enqueue it twice
+	scheduler.Tick(context.Background(), now)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduleBuiltins(t *testing.T) {
+	assert.Nil(t, jobs.ScheduleBuiltins(jobs.NewScheduler(), 60))
+	registry := jobs.NewRegistry()
+	jobs.RegisterBuiltins(registry)
+	assert.ElementsMatch(t, []string{jobs.KindCleanupExpiredTokens, jobs.KindWarmRoleCache}, registry.Kinds())
+}
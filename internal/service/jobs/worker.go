@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/config"
+	"go-template/pkg/utl/backoff"
+	"go-template/pkg/utl/zaplog"
+)
+
+// Options configure a Worker
+type Options struct {
+	// Concurrency is the number of jobs run at the same time
+	Concurrency int
+	// Interval between two polls of the queue when it is idle
+	Interval time.Duration
+	// MaxBackoff caps the delay before retrying a failed job
+	MaxBackoff time.Duration
+	// Lease is how long a job may run before it is considered abandoned and run again
+	Lease time.Duration
+	// Retention is how long succeeded jobs are kept
+	Retention time.Duration
+}
+
+// OptionsFromEnv reads WORKER_CONCURRENCY, WORKER_POLL_INTERVAL_MS, JOBS_MAX_BACKOFF_SECONDS,
+// JOBS_LEASE_SECONDS and JOBS_RETENTION_HOURS
+func OptionsFromEnv() (Options, error) {
+	opts := Options{
+		Concurrency: 4,
+		Interval:    time.Second,
+		MaxBackoff:  time.Hour,
+		Lease:       15 * time.Minute,
+		Retention:   7 * 24 * time.Hour,
+	}
+	n, err := config.GetPositiveInt("WORKER_CONCURRENCY")
+	if err != nil {
+		return opts, err
+	}
+	if n > 0 {
+		opts.Concurrency = n
+	}
+	for _, v := range []struct {
+		key  string
+		unit time.Duration
+		dst  *time.Duration
+	}{
+		{"WORKER_POLL_INTERVAL_MS", time.Millisecond, &opts.Interval},
+		{"JOBS_MAX_BACKOFF_SECONDS", time.Second, &opts.MaxBackoff},
+		{"JOBS_LEASE_SECONDS", time.Second, &opts.Lease},
+		{"JOBS_RETENTION_HOURS", time.Hour, &opts.Retention},
+	} {
+		n, err := config.GetPositiveInt(v.key)
+		if err != nil {
+			return opts, err
+		}
+		if n > 0 {
+			*v.dst = time.Duration(n) * v.unit
+		}
+	}
+	return opts, nil
+}
+
+const maintenanceInterval = time.Minute
+
+// Worker claims the jobs of the registered kinds and runs them
+type Worker struct {
+	id       string
+	registry *Registry
+	opts     Options
+
+	slots   chan struct{}
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	running sync.WaitGroup
+	once    sync.Once
+	// ctx is handed to the handlers, it is cancelled when Stop gives up waiting for them
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewWorker ...
+func NewWorker(registry *Registry, opts Options) *Worker {
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Worker{
+		id:       fmt.Sprintf("%s-%d", host, os.Getpid()),
+		registry: registry,
+		opts:     opts,
+		slots:    make(chan struct{}, opts.Concurrency),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start polls the queue in the background until Stop is called
+func (w *Worker) Start() {
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+		var lastMaintenance time.Time
+		for {
+			if time.Since(lastMaintenance) >= maintenanceInterval {
+				lastMaintenance = time.Now()
+				w.maintain()
+			}
+			// keep claiming while the queue has due jobs and slots are free
+			for w.claim() > 0 {
+			}
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			case <-w.wake:
+			}
+		}
+	}()
+}
+
+// Stop stops claiming jobs and waits for the running ones. When ctx is done first, the
+// context of the handlers is cancelled and their jobs will be rescued once their lease
+// expires.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+	finished := make(chan struct{})
+	go func() {
+		<-w.done
+		w.running.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		return ctx.Err()
+	}
+}
+
+// claim runs as many due jobs as there are free slots and returns how many it started
+func (w *Worker) claim() int {
+	free := cap(w.slots) - len(w.slots)
+	if free == 0 {
+		return 0
+	}
+	jobs, err := daos.ClaimJobs(w.id, w.registry.Kinds(), free, w.ctx)
+	if err != nil {
+		zaplog.Logger.Error("failed to claim jobs: ", err)
+		return 0
+	}
+	for _, job := range jobs {
+		w.slots <- struct{}{}
+		w.running.Add(1)
+		go func(job daos.Job) {
+			defer func() {
+				<-w.slots
+				w.running.Done()
+				select {
+				case w.wake <- struct{}{}:
+				default:
+				}
+			}()
+			w.Run(w.ctx, job)
+		}(job)
+	}
+	return len(jobs)
+}
+
+// Run runs a claimed job and records its outcome
+func (w *Worker) Run(ctx context.Context, job daos.Job) {
+	logger := zaplog.Logger.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	err := w.handle(ctx, job)
+	if err == nil {
+		if err := daos.CompleteJob(job.ID, context.Background()); err != nil {
+			logger.Error("failed to complete job: ", err)
+		}
+		return
+	}
+	dead := job.Attempts >= job.MaxAttempts
+	retryIn := backoff.Exponential(job.Attempts, w.opts.MaxBackoff)
+	if dead {
+		logger.Errorw("job is dead", "error", err.Error())
+	} else {
+		logger.Warnw("job failed", "error", err.Error(), "retry_in", retryIn.String())
+	}
+	if err := daos.FailJob(job.ID, err, retryIn, dead, context.Background()); err != nil {
+		logger.Error("failed to record job failure: ", err)
+	}
+}
+
+func (w *Worker) handle(ctx context.Context, job daos.Job) (err error) {
+	handler, ok := w.registry.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler for %s jobs", job.Kind)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// maintain rescues abandoned jobs and purges old succeeded ones
+func (w *Worker) maintain() {
+	if n, err := daos.RescueStaleJobs(w.opts.Lease, w.ctx); err != nil {
+		zaplog.Logger.Error("failed to rescue stale jobs: ", err)
+	} else if n > 0 {
+		zaplog.Logger.Warnw("rescued stale jobs", "count", n)
+	}
+	if _, err := daos.DeleteJobsFinishedBefore(time.Now().Add(-w.opts.Retention), w.ctx); err != nil {
+		zaplog.Logger.Error("failed to purge jobs: ", err)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const unmatchedRoute = "unmatched"
+
+// Middleware records the duration of every request handled by echo. Requests are labelled
+// with the matched route pattern rather than the raw path to keep the label set bounded.
+func Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				if he, ok := err.(*echo.HTTPError); ok {
+					status = he.Code
+				} else if !c.Response().Committed {
+					status = http.StatusInternalServerError
+				}
+			}
+			// echo reports the raw path for requests that matched no route
+			route := c.Path()
+			if route == "" || err == echo.ErrNotFound || err == echo.ErrMethodNotAllowed {
+				route = unmatchedRoute
+			}
+			httpRequestDuration.
+				WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).
+				Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-template/pkg/utl/apperror"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+const anonymousOperation = "anonymous"
+
+// GraphQL is a gqlgen extension recording the latency and the errors of every operation
+type GraphQL struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = GraphQL{}
+
+// ExtensionName ...
+func (GraphQL) ExtensionName() string {
+	return "PrometheusMetrics"
+}
+
+// Validate ...
+func (GraphQL) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse ...
+func (GraphQL) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	start := time.Now()
+	res := next(ctx)
+
+	oc := graphql.GetOperationContext(ctx)
+	opType, name := "unknown", oc.OperationName
+	if oc.Operation != nil {
+		opType = string(oc.Operation.Operation)
+		if name == "" {
+			name = oc.Operation.Name
+		}
+	}
+	if name == "" {
+		name = anonymousOperation
+	}
+	graphqlOperationDuration.WithLabelValues(opType, name).Observe(time.Since(start).Seconds())
+	if res == nil {
+		return res
+	}
+	for _, err := range res.Errors {
+		code := "UNKNOWN"
+		if c, ok := err.Extensions[apperror.CodeExtension]; ok {
+			code = fmt.Sprint(c)
+		}
+		graphqlOperationErrors.WithLabelValues(opType, name, code).Inc()
+	}
+	return res
+}
@@ -0,0 +1,142 @@
+// Package metrics exposes the service's Prometheus metrics: HTTP and GraphQL
+// traffic, database pool and Redis usage, throttling and Go runtime stats.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "go_template"
+
+// Registry holds every collector exposed on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	graphqlOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "graphql_operation_duration_seconds",
+		Help:      "Duration of GraphQL operations by operation type and name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type", "operation"})
+
+	graphqlOperationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "graphql_operation_errors_total",
+		Help:      "Errors returned by GraphQL operations by operation type, name and error code.",
+	}, []string{"type", "operation", "code"})
+
+	redisCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_call_duration_seconds",
+		Help:      "Duration of Redis commands by command and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"})
+
+	throttleRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "throttle_rejections_total",
+		Help:      "Requests rejected by the rate limiter by GraphQL path.",
+	}, []string{"path"})
+
+	pubsubOverflows = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pubsub_overflows_total",
+		Help:      "Messages that didn't fit in a subscriber buffer by topic and overflow policy.",
+	}, []string{"topic", "policy"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		graphqlOperationDuration,
+		graphqlOperationErrors,
+		redisCallDuration,
+		throttleRejections,
+		pubsubOverflows,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// NewAdminServer returns a server exposing only /metrics on addr, it lets the metrics be
+// scraped on a port that is not reachable from the public network
+func NewAdminServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// RegisterDB exposes the connection pool stats of db
+func RegisterDB(db *sql.DB, name string) error {
+	return register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterGauge exposes the value returned by fn as a gauge
+func RegisterGauge(name, help string, fn func() float64) error {
+	return register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// RegisterCounter exposes the value returned by fn as a counter, fn must never decrease
+func RegisterCounter(name, help string, fn func() float64) error {
+	return register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn))
+}
+
+// register ignores collectors that are already registered so that setup code can run more than once
+func register(c prometheus.Collector) error {
+	if err := Registry.Register(c); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// ObserveRedisCall records the duration of a Redis command started at start
+func ObserveRedisCall(command string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	redisCallDuration.WithLabelValues(command, result).Observe(time.Since(start).Seconds())
+}
+
+// ThrottleRejected counts a request rejected by the rate limiter
+func ThrottleRejected(path string) {
+	throttleRejections.WithLabelValues(path).Inc()
+}
+
+// PubSubOverflow counts a message that didn't fit in the buffer of a subscriber
+func PubSubOverflow(topic, policy string) {
+	pubsubOverflows.WithLabelValues(topic, policy).Inc()
+}
@@ -0,0 +1,119 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-template/internal/service/metrics"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	b, err := io.ReadAll(rec.Body)
+	assert.Nil(t, err)
+	return string(b)
+}
+
+func TestMiddleware(t *testing.T) {
+	e := echo.New()
+	e.Use(metrics.Middleware())
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "bad")
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "Matched route",
+			path: "/users/1",
+			want: `go_template_http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"}`,
+		},
+		{
+			name: "Handler error",
+			path: "/fail",
+			want: `go_template_http_request_duration_seconds_count{method="GET",route="/fail",status="400"}`,
+		},
+		{
+			name: "Unmatched route",
+			path: "/unknown/path",
+			want: `route="unmatched",status="404"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Contains(t, scrape(t), tt.want)
+		})
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "Me",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query},
+	})
+	res := metrics.GraphQL{}.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		return &graphql.Response{Errors: gqlerror.List{
+			{Message: "no user", Extensions: map[string]interface{}{apperror.CodeExtension: apperror.NotFound}},
+		}}
+	})
+	assert.Len(t, res.Errors, 1)
+
+	got := scrape(t)
+	assert.Contains(t, got, `go_template_graphql_operation_duration_seconds_count{operation="Me",type="query"} 1`)
+	assert.Contains(t, got, `go_template_graphql_operation_errors_total{code="NOT_FOUND",operation="Me",type="query"} 1`)
+}
+
+func TestObserveRedisCall(t *testing.T) {
+	metrics.ObserveRedisCall("GET", time.Now(), nil)
+	metrics.ObserveRedisCall("GET", time.Now(), errors.New("connection refused"))
+	metrics.ThrottleRejected("login")
+
+	got := scrape(t)
+	assert.Contains(t, got, `go_template_redis_call_duration_seconds_count{command="GET",result="ok"} 1`)
+	assert.Contains(t, got, `go_template_redis_call_duration_seconds_count{command="GET",result="error"} 1`)
+	assert.Contains(t, got, `go_template_throttle_rejections_total{path="login"} 1`)
+	assert.Contains(t, got, "go_goroutines")
+}
+
+func TestRegisterGauge(t *testing.T) {
+	value := 3.0
+	assert.Nil(t, metrics.RegisterGauge("test_gauge", "Test gauge.", func() float64 { return value }))
+	// registering the same gauge again is not an error
+	assert.Nil(t, metrics.RegisterGauge("test_gauge", "Test gauge.", func() float64 { return value }))
+	assert.Contains(t, scrape(t), "go_template_test_gauge 3")
+}
+
+func TestNewAdminServer(t *testing.T) {
+	s := metrics.NewAdminServer(":9100")
+	assert.Equal(t, ":9100", s.Addr)
+
+	rec := httptest.NewRecorder()
+	s.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	s.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const natsTimeout = 5 * time.Second
+
+// NATSSink publishes events to a NATS-compatible server on <prefix>.<topic>. It speaks the
+// text protocol directly and waits for the server to acknowledge each event with a PONG,
+// the event id is sent in the Nats-Msg-Id header so that JetStream can deduplicate
+// redeliveries.
+type NATSSink struct {
+	addr   string
+	user   *url.Userinfo
+	prefix string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewNATSSink ...
+func NewNATSSink(u *url.URL, prefix string) *NATSSink {
+	return &NATSSink{addr: u.Host, user: u.User, prefix: prefix}
+}
+
+// Publish ...
+func (s *NATSSink) Publish(ctx context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(ctx, event); err != nil {
+		// the connection is in an unknown state, start over on the next event
+		s.close()
+		return err
+	}
+	return nil
+}
+
+// Close ...
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.close()
+}
+
+func (s *NATSSink) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+func (s *NATSSink) publish(ctx context.Context, event Event) error {
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	deadline := time.Now().Add(natsTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	headers := fmt.Sprintf("NATS/1.0\r\nNats-Msg-Id: %d\r\n\r\n", event.ID)
+	subject := s.prefix + "." + event.Topic
+	msg := fmt.Sprintf("HPUB %s %d %d\r\n%s%s\r\nPING\r\n",
+		subject, len(headers), len(headers)+len(event.Payload), headers, event.Payload)
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.waitPong()
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+	if err := conn.SetDeadline(time.Now().Add(natsTimeout)); err != nil {
+		return err
+	}
+	line, err := s.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  true,
+		"name":     "go-template-outbox",
+		"lang":     "go",
+	}
+	if s.user != nil {
+		options["user"] = s.user.Username()
+		if pass, ok := s.user.Password(); ok {
+			options["pass"] = pass
+		}
+	}
+	b, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.Write([]byte("CONNECT " + string(b) + "\r\nPING\r\n")); err != nil {
+		return err
+	}
+	return s.waitPong()
+}
+
+// waitPong reads until the PONG answering our PING, the server reports a failed CONNECT or
+// PUB with -ERR before it
+func (s *NATSSink) waitPong() error {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package outbox implements the transactional outbox: events are recorded in the
+// transaction of the change they describe and a relay publishes them to a sink once that
+// transaction committed. Delivery is at least once, sinks and consumers must tolerate
+// duplicates, which carry the same event id.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/config"
+)
+
+// Event is an outbox event handed to a Sink
+type Event struct {
+	ID        int64           `json:"id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+	// DeliveredTo names the sinks of a Multi that received the event on an earlier attempt
+	DeliveredTo []string `json:"-"`
+}
+
+// Sink publishes events, an error makes the relay retry the event later
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Record JSON encodes v and adds it to the outbox of tx, it is published to topic only if
+// tx commits
+func Record(ctx context.Context, tx *sql.Tx, topic string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = daos.CreateOutboxEventTx(topic, payload, ctx, tx)
+	return err
+}
+
+const (
+	defaultInterval   = time.Second
+	defaultBatchSize  = 100
+	defaultMaxBackoff = 5 * time.Minute
+	defaultLease      = 5 * time.Minute
+	defaultRetention  = 7 * 24 * time.Hour
+)
+
+// Options configure the relay
+type Options struct {
+	// Interval between two polls of the outbox
+	Interval time.Duration
+	// BatchSize is the maximum number of events published per poll
+	BatchSize int
+	// MaxBackoff caps the delay before retrying an event that failed to publish
+	MaxBackoff time.Duration
+	// Lease is how long a claimed event is reserved to the relay publishing it, it is claimed
+	// again after it when the relay died before marking it
+	Lease time.Duration
+	// Retention is how long published events are kept before being purged
+	Retention time.Duration
+}
+
+// OptionsFromEnv reads OUTBOX_POLL_INTERVAL_MS, OUTBOX_BATCH_SIZE, OUTBOX_MAX_BACKOFF_SECONDS,
+// OUTBOX_LEASE_SECONDS and OUTBOX_RETENTION_HOURS
+func OptionsFromEnv() (Options, error) {
+	opts := Options{
+		Interval:   defaultInterval,
+		BatchSize:  defaultBatchSize,
+		MaxBackoff: defaultMaxBackoff,
+		Lease:      defaultLease,
+		Retention:  defaultRetention,
+	}
+	for _, v := range []struct {
+		key  string
+		unit time.Duration
+		dst  *time.Duration
+	}{
+		{"OUTBOX_POLL_INTERVAL_MS", time.Millisecond, &opts.Interval},
+		{"OUTBOX_MAX_BACKOFF_SECONDS", time.Second, &opts.MaxBackoff},
+		{"OUTBOX_LEASE_SECONDS", time.Second, &opts.Lease},
+		{"OUTBOX_RETENTION_HOURS", time.Hour, &opts.Retention},
+	} {
+		n, err := config.GetPositiveInt(v.key)
+		if err != nil {
+			return opts, err
+		}
+		if n > 0 {
+			*v.dst = time.Duration(n) * v.unit
+		}
+	}
+	n, err := config.GetPositiveInt("OUTBOX_BATCH_SIZE")
+	if err != nil {
+		return opts, err
+	}
+	if n > 0 {
+		opts.BatchSize = n
+	}
+	return opts, nil
+}
@@ -0,0 +1,344 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"go-template/internal/service/outbox"
+	"go-template/internal/service/pubsub"
+	"go-template/testutls"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	redigo "github.com/gomodule/redigo/redis"
+	redigomock "github.com/rafaeljusto/redigomock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type sinkMock struct {
+	events []outbox.Event
+	err    error
+}
+
+func (s *sinkMock) Publish(_ context.Context, event outbox.Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+var (
+	claimPending   = regexp.QuoteMeta(`UPDATE outbox_events SET next_attempt_at = now() + $2`)
+	markPublished  = regexp.QuoteMeta(`UPDATE outbox_events SET published_at = now()`)
+	markFailed     = regexp.QuoteMeta(`UPDATE outbox_events SET attempts = attempts + 1`)
+	pendingColumns = []string{"id", "topic", "payload", "attempts", "delivered_to", "created_at"}
+)
+
+func TestRecord(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO outbox_events (topic, payload) VALUES ($1, $2) RETURNING id`)).
+		WithArgs("user", []byte(`{"id":"1"}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	err := outbox.Record(context.Background(), nil, "user", map[string]string{"id": "1"})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPublishPending(t *testing.T) {
+	createdAt := time.Now()
+	tests := []struct {
+		name    string
+		sinkErr error
+		expect  func(mock sqlmock.Sqlmock)
+		wantN   int
+		wantErr bool
+	}{
+		{
+			name: "Publishes and marks the events",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(claimPending).WithArgs(10, int64(60000)).WillReturnRows(sqlmock.NewRows(pendingColumns).
+					AddRow(1, "user", []byte(`{}`), 0, "{}", createdAt).
+					AddRow(2, "role", []byte(`{}`), 0, "{}", createdAt))
+				mock.ExpectExec(markPublished).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(markPublished).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantN: 2,
+		},
+		{
+			name:    "Schedules a retry when the sink fails",
+			sinkErr: errors.New("sink is down"),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(claimPending).WithArgs(10, int64(60000)).WillReturnRows(sqlmock.NewRows(pendingColumns).
+					AddRow(1, "user", []byte(`{}`), 2, "{}", createdAt))
+				mock.ExpectExec(markFailed).WithArgs(1, "sink is down", "{}", int64(4000)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantN: 1,
+		},
+		{
+			name: "Publishes the rest of the batch when an event can't be marked",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(claimPending).WithArgs(10, int64(60000)).WillReturnRows(sqlmock.NewRows(pendingColumns).
+					AddRow(1, "user", []byte(`{}`), 0, "{}", createdAt).
+					AddRow(2, "role", []byte(`{}`), 0, "{}", createdAt))
+				mock.ExpectExec(markPublished).WithArgs(1).WillReturnError(errors.New("connection reset"))
+				mock.ExpectExec(markPublished).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantN:   2,
+			wantErr: true,
+		},
+		{
+			name: "Fails when the outbox can't be claimed",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(claimPending).WillReturnError(errors.New("connection reset"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, cleanup, _ := testutls.SetupMockDB(t)
+			defer cleanup()
+			tt.expect(mock)
+
+			sink := &sinkMock{err: tt.sinkErr}
+			relay := outbox.NewRelay(sink, outbox.Options{BatchSize: 10, MaxBackoff: time.Minute, Lease: time.Minute})
+			n, err := relay.PublishPending(context.Background())
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantN, n)
+			assert.Len(t, sink.events, tt.wantN)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRelayStop(t *testing.T) {
+	relay := outbox.NewRelay(&sinkMock{}, outbox.Options{Interval: time.Hour, BatchSize: 1})
+	relay.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, relay.Stop(ctx))
+	assert.Nil(t, relay.Stop(ctx))
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    outbox.Options
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			want: outbox.Options{
+				Interval:   time.Second,
+				BatchSize:  100,
+				MaxBackoff: 5 * time.Minute,
+				Lease:      5 * time.Minute,
+				Retention:  7 * 24 * time.Hour,
+			},
+		},
+		{
+			name: "Overrides",
+			env: map[string]string{
+				"OUTBOX_POLL_INTERVAL_MS":    "250",
+				"OUTBOX_BATCH_SIZE":          "10",
+				"OUTBOX_MAX_BACKOFF_SECONDS": "30",
+				"OUTBOX_LEASE_SECONDS":       "60",
+				"OUTBOX_RETENTION_HOURS":     "1",
+			},
+			want: outbox.Options{
+				Interval:   250 * time.Millisecond,
+				BatchSize:  10,
+				MaxBackoff: 30 * time.Second,
+				Lease:      time.Minute,
+				Retention:  time.Hour,
+			},
+		},
+		{
+			name:    "Invalid",
+			env:     map[string]string{"OUTBOX_BATCH_SIZE": "-1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"OUTBOX_POLL_INTERVAL_MS", "OUTBOX_BATCH_SIZE",
+				"OUTBOX_MAX_BACKOFF_SECONDS", "OUTBOX_LEASE_SECONDS", "OUTBOX_RETENTION_HOURS"} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := outbox.OptionsFromEnv()
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "Disabled", spec: ""},
+		{name: "Log", spec: "log", want: outbox.LogSink{}},
+		{name: "Redis", spec: "redis", want: &outbox.RedisStreamSink{}},
+		{name: "NATS", spec: "nats://localhost:4222", want: &outbox.NATSSink{}},
+		{name: "Unsupported", spec: "kafka://localhost:9092", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := outbox.NewSink(tt.spec, "events", &redigo.Pool{})
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.IsType(t, tt.want, got)
+		})
+	}
+}
+
+func TestMulti(t *testing.T) {
+	ok, failing, delivered := &sinkMock{}, &sinkMock{err: errors.New("down")}, &sinkMock{}
+	multi := outbox.Multi{"ok": ok, "disabled": nil, "failing": failing, "delivered": delivered}
+	err := multi.Publish(context.Background(), outbox.Event{ID: 1, DeliveredTo: []string{"delivered"}})
+	assert.EqualError(t, err, "failing: down")
+	var deliveryErr *outbox.DeliveryError
+	assert.ErrorAs(t, err, &deliveryErr)
+	assert.Equal(t, []string{"delivered", "ok"}, deliveryErr.Delivered)
+	assert.Len(t, ok.events, 1)
+	assert.Len(t, failing.events, 1)
+	assert.Empty(t, delivered.events)
+
+	failing.err = nil
+	assert.Nil(t, multi.Publish(context.Background(), outbox.Event{ID: 1, DeliveredTo: deliveryErr.Delivered}))
+	assert.Len(t, ok.events, 1)
+	assert.Len(t, failing.events, 2)
+}
+
+func TestPublishPendingRecordsDeliveries(t *testing.T) {
+	mock, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	mock.ExpectQuery(claimPending).WillReturnRows(sqlmock.NewRows(pendingColumns).
+		AddRow(1, "user", []byte(`{}`), 1, "{webhooks}", time.Now()))
+	mock.ExpectExec(markFailed).WithArgs(1, "sink: down", `{"subscriptions","webhooks"}`, int64(2000)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	subscriptions, webhooks := &sinkMock{}, &sinkMock{}
+	relay := outbox.NewRelay(outbox.Multi{
+		"subscriptions": subscriptions,
+		"webhooks":      webhooks,
+		"sink":          &sinkMock{err: errors.New("down")},
+	}, outbox.Options{BatchSize: 10, MaxBackoff: time.Minute, Lease: time.Minute})
+	n, err := relay.PublishPending(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, subscriptions.events, 1)
+	assert.Empty(t, webhooks.events)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestPubSubSink(t *testing.T) {
+	ps := pubsub.NewMemory(pubsub.Options{})
+	defer ps.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	messages, err := ps.Subscribe(ctx, "user")
+	assert.Nil(t, err)
+
+	err = outbox.PubSubSink{PubSub: ps}.Publish(ctx, outbox.Event{Topic: "user", Payload: []byte(`{"id":"1"}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"id":"1"}`), <-messages)
+}
+
+func TestRedisStreamSink(t *testing.T) {
+	createdAt := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	conn := redigomock.NewConn()
+	cmd := conn.Command("XADD", "events", "MAXLEN", "~", 100000, "*",
+		"id", "7", "topic", "user", "payload", []byte(`{}`), "created_at", "2022-01-02T03:04:05Z").
+		Expect("1-0")
+	pool := &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }}
+
+	sink := outbox.NewRedisStreamSink(pool, "events")
+	err := sink.Publish(context.Background(), outbox.Event{ID: 7, Topic: "user", Payload: []byte(`{}`), CreatedAt: createdAt})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, conn.Stats(cmd))
+}
+
+// natsServer answers connections like a NATS server would, rejecting the publications on
+// subjects starting with "denied"
+func natsServer(t *testing.T, received chan<- string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveNATS(conn, received)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveNATS(conn net.Conn, received chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	_, _ = conn.Write([]byte(`INFO {"server_id":"test","headers":true}` + "\r\n"))
+	var denied bool
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "PING":
+			if denied {
+				_, _ = conn.Write([]byte("-ERR 'Permissions Violation'\r\n"))
+				denied = false
+			}
+			_, _ = conn.Write([]byte("PONG\r\n"))
+		case "HPUB":
+			header, _ := r.ReadString('\n')
+			for header != "\r\n" {
+				received <- strings.TrimSpace(header)
+				header, _ = r.ReadString('\n')
+			}
+			payload, _ := r.ReadString('\n')
+			received <- fields[1] + " " + strings.TrimSpace(payload)
+			denied = strings.HasPrefix(fields[1], "denied")
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	received := make(chan string, 10)
+	addr := natsServer(t, received)
+	sink := outbox.NewNATSSink(&url.URL{Scheme: "nats", Host: addr}, "events")
+	defer sink.Close()
+
+	err := sink.Publish(context.Background(), outbox.Event{ID: 3, Topic: "user", Payload: []byte(`{"id":"1"}`)})
+	assert.Nil(t, err)
+	assert.Equal(t, "NATS/1.0", <-received)
+	assert.Equal(t, "Nats-Msg-Id: 3", <-received)
+	assert.Equal(t, `events.user {"id":"1"}`, <-received)
+
+	sink = outbox.NewNATSSink(&url.URL{Scheme: "nats", Host: addr}, "denied")
+	err = sink.Publish(context.Background(), outbox.Event{ID: 4, Topic: "user", Payload: []byte(`{}`)})
+	assert.EqualError(t, err, "nats: Permissions Violation")
+}
@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go-template/daos"
+	"go-template/pkg/utl/backoff"
+	"go-template/pkg/utl/zaplog"
+)
+
+const purgeInterval = time.Hour
+
+// Relay polls the outbox and publishes the pending events to its sink. Several relays may
+// run against the same database, each event is claimed by a single one at a time.
+type Relay struct {
+	sink Sink
+	opts Options
+
+	lastPurge time.Time
+	stop      chan struct{}
+	done      chan struct{}
+	once      sync.Once
+}
+
+// NewRelay ...
+func NewRelay(sink Sink, opts Options) *Relay {
+	return &Relay{
+		sink: sink,
+		opts: opts,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start polls the outbox in the background until Stop is called
+func (r *Relay) Start() {
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.opts.Interval)
+		defer ticker.Stop()
+		ctx := context.Background()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+			// drain the backlog before waiting for the next tick
+			for {
+				n, err := r.PublishPending(ctx)
+				if err != nil {
+					zaplog.Logger.Error("outbox relay failed: ", err)
+				}
+				if err != nil || n < r.opts.BatchSize {
+					break
+				}
+			}
+			r.purge(ctx)
+		}
+	}()
+}
+
+// Stop waits for the batch being published, if any, and stops polling
+func (r *Relay) Stop(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PublishPending claims a batch of due events, publishes them and returns how many were
+// attempted. The events are leased rather than locked while they are published, so that no
+// transaction is held open across the calls to the sink. Events that fail to publish are
+// retried with an exponential backoff.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	events, err := daos.ClaimOutboxEvents(r.opts.BatchSize, r.opts.Lease, ctx)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, e := range events {
+		// an event that can't be marked is published again once its lease expires
+		if err := r.publish(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(events), errors.Join(errs...)
+}
+
+func (r *Relay) publish(ctx context.Context, e daos.OutboxEvent) error {
+	err := r.sink.Publish(ctx, Event{
+		ID:          e.ID,
+		Topic:       e.Topic,
+		Payload:     e.Payload,
+		CreatedAt:   e.CreatedAt,
+		DeliveredTo: e.DeliveredTo,
+	})
+	if err == nil {
+		return daos.MarkOutboxEventPublished(e.ID, ctx)
+	}
+	deliveredTo := e.DeliveredTo
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		deliveredTo = deliveryErr.Delivered
+	}
+	retryIn := backoff.Exponential(e.Attempts, r.opts.MaxBackoff)
+	zaplog.Logger.Warnw("failed to publish outbox event",
+		"id", e.ID, "topic", e.Topic, "attempts", e.Attempts+1, "retry_in", retryIn.String(), "error", err.Error())
+	return daos.MarkOutboxEventFailed(e.ID, err, deliveredTo, retryIn, ctx)
+}
+
+func (r *Relay) purge(ctx context.Context) {
+	if time.Since(r.lastPurge) < purgeInterval {
+		return
+	}
+	r.lastPurge = time.Now()
+	if _, err := daos.DeleteOutboxEventsPublishedBefore(time.Now().Add(-r.opts.Retention), ctx); err != nil {
+		zaplog.Logger.Error("failed to purge the outbox: ", err)
+	}
+}
@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+
+	"go-template/internal/service/pubsub"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// NewSink returns the sink configured by spec, stream names the Redis stream or prefixes
+// the NATS subjects events are published to:
+//   - an empty spec disables external publishing
+//   - log logs every event
+//   - redis appends events to a Redis stream through the shared pool
+//   - nats://[user:pass@]host:port publishes events to a NATS-compatible server
+func NewSink(spec, stream string, pool *redis.Pool) (Sink, error) {
+	switch spec {
+	case "":
+		return nil, nil
+	case "log":
+		return LogSink{}, nil
+	case "redis":
+		return NewRedisStreamSink(pool, stream), nil
+	}
+	u, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outbox sink: %w", err)
+	}
+	if u.Scheme == "nats" {
+		return NewNATSSink(u, stream), nil
+	}
+	return nil, fmt.Errorf("unsupported outbox sink %q", spec)
+}
+
+// Multi publishes every event to all its sinks, keyed by their name. When some of them fail
+// the names of the others are recorded with the event, so that its retries skip them.
+type Multi map[string]Sink
+
+// DeliveryError is returned by Multi when some of its sinks failed, Delivered names the ones
+// that received the event, during this attempt or an earlier one
+type DeliveryError struct {
+	Delivered []string
+	Err       error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Publish ...
+func (m Multi) Publish(ctx context.Context, event Event) error {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	delivered := append([]string{}, event.DeliveredTo...)
+	var errs []error
+	for _, name := range names {
+		if m[name] == nil || slices.Contains(event.DeliveredTo, name) {
+			continue
+		}
+		if err := m[name].Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		delivered = append(delivered, name)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(delivered)
+	return &DeliveryError{Delivered: delivered, Err: errors.Join(errs...)}
+}
+
+// LogSink logs the events, it is meant for local runs
+type LogSink struct{}
+
+// Publish ...
+func (LogSink) Publish(ctx context.Context, event Event) error {
+	zaplog.For(ctx).Infow("outbox event", "id", event.ID, "topic", event.Topic, "payload", string(event.Payload))
+	return nil
+}
+
+// PubSubSink delivers the events to the GraphQL subscribers of their topic
+type PubSubSink struct {
+	PubSub pubsub.PubSub
+}
+
+// Publish ...
+func (s PubSubSink) Publish(ctx context.Context, event Event) error {
+	return s.PubSub.Publish(ctx, event.Topic, event.Payload)
+}
+
+// redisStreamMaxLen approximately caps the length of the stream so that it doesn't grow
+// unbounded when nobody consumes it
+const redisStreamMaxLen = 100000
+
+// RedisStreamSink appends the events to a Redis stream
+type RedisStreamSink struct {
+	pool   *redis.Pool
+	stream string
+}
+
+// NewRedisStreamSink ...
+func NewRedisStreamSink(pool *redis.Pool, stream string) *RedisStreamSink {
+	return &RedisStreamSink{pool: pool, stream: stream}
+}
+
+// Publish ...
+func (s *RedisStreamSink) Publish(ctx context.Context, event Event) error {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Do("XADD", s.stream, "MAXLEN", "~", redisStreamMaxLen, "*",
+		"id", strconv.FormatInt(event.ID, 10),
+		"topic", event.Topic,
+		"payload", []byte(event.Payload),
+		"created_at", event.CreatedAt.UTC().Format("2006-01-02T15:04:05.999999999Z07:00"))
+	return err
+}
@@ -0,0 +1,114 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+
+	"go-template/internal/service/metrics"
+)
+
+type subscriber struct {
+	ch   chan []byte
+	done chan struct{}
+}
+
+// broker holds the subscribers of this process and delivers messages to them
+type broker struct {
+	opts   Options
+	mu     sync.RWMutex
+	topics map[string]map[*subscriber]struct{}
+	closed bool
+}
+
+func newBroker(opts Options) *broker {
+	if opts.Buffer < 1 {
+		opts.Buffer = defaultBuffer
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = Drop
+	}
+	return &broker{opts: opts, topics: map[string]map[*subscriber]struct{}{}}
+}
+
+func (b *broker) subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	s := &subscriber{ch: make(chan []byte, b.opts.Buffer), done: make(chan struct{})}
+	if b.topics[topic] == nil {
+		b.topics[topic] = map[*subscriber]struct{}{}
+	}
+	b.topics[topic][s] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			b.remove(topic, s)
+		case <-s.done:
+		}
+	}()
+	return s.ch, nil
+}
+
+// deliver never blocks: a subscriber whose buffer is full either misses the message or is
+// disconnected, depending on the overflow policy
+func (b *broker) deliver(topic string, payload []byte) {
+	var overflowed []*subscriber
+	b.mu.RLock()
+	for s := range b.topics[topic] {
+		select {
+		case s.ch <- payload:
+		default:
+			overflowed = append(overflowed, s)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, s := range overflowed {
+		metrics.PubSubOverflow(topic, string(b.opts.Overflow))
+		if b.opts.Overflow == Disconnect {
+			b.remove(topic, s)
+		}
+	}
+}
+
+// remove closes the channel of s, sends only happen under the read lock so it can't race
+// with deliver
+func (b *broker) remove(topic string, s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.topics[topic][s]; !ok {
+		return
+	}
+	delete(b.topics[topic], s)
+	if len(b.topics[topic]) == 0 {
+		delete(b.topics, topic)
+	}
+	close(s.ch)
+	close(s.done)
+}
+
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for topic, subscribers := range b.topics {
+		for s := range subscribers {
+			close(s.ch)
+			close(s.done)
+		}
+		delete(b.topics, topic)
+	}
+}
+
+func (b *broker) isClosed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.closed
+}
@@ -0,0 +1,33 @@
+package pubsub
+
+import "context"
+
+// Memory delivers messages to the subscribers of the current process only
+type Memory struct {
+	broker *broker
+}
+
+// NewMemory ...
+func NewMemory(opts Options) *Memory {
+	return &Memory{broker: newBroker(opts)}
+}
+
+// Publish ...
+func (m *Memory) Publish(_ context.Context, topic string, payload []byte) error {
+	if m.broker.isClosed() {
+		return ErrClosed
+	}
+	m.broker.deliver(topic, payload)
+	return nil
+}
+
+// Subscribe ...
+func (m *Memory) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	return m.broker.subscribe(ctx, topic)
+}
+
+// Close ...
+func (m *Memory) Close() error {
+	m.broker.close()
+	return nil
+}
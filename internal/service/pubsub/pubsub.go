@@ -0,0 +1,68 @@
+// Package pubsub fans published messages out to the subscribers of a topic. The in-memory
+// implementation only reaches subscribers of the same process, the Redis one reaches the
+// subscribers of every instance.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrClosed is returned when publishing or subscribing after Close
+var ErrClosed = errors.New("pubsub is closed")
+
+// PubSub publishes messages to topics and streams them to subscribers
+type PubSub interface {
+	// Publish sends payload to the current subscribers of topic, it never blocks on slow
+	// subscribers
+	Publish(ctx context.Context, topic string, payload []byte) error
+	// Subscribe streams the messages published to topic. The channel is closed when ctx
+	// is done, when the subscriber is disconnected for falling behind or on Close.
+	Subscribe(ctx context.Context, topic string) (<-chan []byte, error)
+	// Close closes every subscription
+	Close() error
+}
+
+// Overflow tells what happens when a message is published to a subscriber whose buffer is full
+type Overflow string
+
+// Overflow policies
+const (
+	// Drop discards the message for that subscriber only
+	Drop Overflow = "drop"
+	// Disconnect closes the subscription of that subscriber
+	Disconnect Overflow = "disconnect"
+)
+
+const defaultBuffer = 16
+
+// Options configure the delivery to subscribers
+type Options struct {
+	// Buffer is the number of messages a subscriber may fall behind by
+	Buffer   int
+	Overflow Overflow
+}
+
+// OptionsFromEnv reads PUBSUB_BUFFER and PUBSUB_OVERFLOW
+func OptionsFromEnv() (Options, error) {
+	opts := Options{Buffer: defaultBuffer, Overflow: Drop}
+	if b := os.Getenv("PUBSUB_BUFFER"); b != "" {
+		buffer, err := strconv.Atoi(b)
+		if err != nil || buffer < 1 {
+			return opts, fmt.Errorf("PUBSUB_BUFFER must be a positive integer, got %q", b)
+		}
+		opts.Buffer = buffer
+	}
+	switch o := Overflow(strings.ToLower(os.Getenv("PUBSUB_OVERFLOW"))); o {
+	case "":
+	case Drop, Disconnect:
+		opts.Overflow = o
+	default:
+		return opts, fmt.Errorf("PUBSUB_OVERFLOW must be %q or %q, got %q", Drop, Disconnect, o)
+	}
+	return opts, nil
+}
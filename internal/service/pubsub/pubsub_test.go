@@ -0,0 +1,179 @@
+package pubsub_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-template/internal/service/pubsub"
+
+	redigo "github.com/gomodule/redigo/redis"
+	redigomock "github.com/rafaeljusto/redigomock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const topic = "user"
+
+func receive(t *testing.T, ch <-chan []byte) (string, bool) {
+	t.Helper()
+	select {
+	case msg, open := <-ch:
+		return string(msg), open
+	case <-time.After(time.Second):
+		t.Fatal("nothing received")
+	}
+	return "", false
+}
+
+func TestMemoryFanOut(t *testing.T) {
+	ps := pubsub.NewMemory(pubsub.Options{Buffer: 1})
+	ctx := context.Background()
+
+	first, err := ps.Subscribe(ctx, topic)
+	assert.Nil(t, err)
+	second, err := ps.Subscribe(ctx, topic)
+	assert.Nil(t, err)
+	other, err := ps.Subscribe(ctx, "role")
+	assert.Nil(t, err)
+
+	assert.Nil(t, ps.Publish(ctx, topic, []byte("created")))
+	msg, _ := receive(t, first)
+	assert.Equal(t, "created", msg)
+	msg, _ = receive(t, second)
+	assert.Equal(t, "created", msg)
+	assert.Len(t, other, 0)
+
+	assert.Nil(t, ps.Close())
+	_, open := receive(t, first)
+	assert.False(t, open)
+	assert.Equal(t, pubsub.ErrClosed, ps.Publish(ctx, topic, []byte("updated")))
+	_, err = ps.Subscribe(ctx, topic)
+	assert.Equal(t, pubsub.ErrClosed, err)
+}
+
+func TestMemoryOverflow(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow pubsub.Overflow
+		wantOpen bool
+	}{
+		{
+			name:     "Drop",
+			overflow: pubsub.Drop,
+			wantOpen: true,
+		},
+		{
+			name:     "Disconnect",
+			overflow: pubsub.Disconnect,
+			wantOpen: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := pubsub.NewMemory(pubsub.Options{Buffer: 1, Overflow: tt.overflow})
+			ctx := context.Background()
+			slow, err := ps.Subscribe(ctx, topic)
+			assert.Nil(t, err)
+
+			// the second message doesn't fit in the buffer, publishing must not block
+			assert.Nil(t, ps.Publish(ctx, topic, []byte("first")))
+			assert.Nil(t, ps.Publish(ctx, topic, []byte("second")))
+
+			msg, _ := receive(t, slow)
+			assert.Equal(t, "first", msg)
+			if tt.wantOpen {
+				assert.Nil(t, ps.Publish(ctx, topic, []byte("third")))
+				msg, open := receive(t, slow)
+				assert.True(t, open)
+				assert.Equal(t, "third", msg)
+			} else {
+				_, open := receive(t, slow)
+				assert.False(t, open)
+			}
+		})
+	}
+}
+
+func TestMemoryUnsubscribe(t *testing.T) {
+	ps := pubsub.NewMemory(pubsub.Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := ps.Subscribe(ctx, topic)
+	assert.Nil(t, err)
+	cancel()
+	_, open := receive(t, ch)
+	assert.False(t, open)
+	assert.Nil(t, ps.Publish(context.Background(), topic, []byte("created")))
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		buffer   string
+		overflow string
+		want     pubsub.Options
+		wantErr  bool
+	}{
+		{
+			name: "Defaults",
+			want: pubsub.Options{Buffer: 16, Overflow: pubsub.Drop},
+		},
+		{
+			name:     "Configured",
+			buffer:   "4",
+			overflow: "DISCONNECT",
+			want:     pubsub.Options{Buffer: 4, Overflow: pubsub.Disconnect},
+		},
+		{
+			name:    "Invalid buffer",
+			buffer:  "0",
+			wantErr: true,
+		},
+		{
+			name:     "Invalid overflow",
+			overflow: "block",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PUBSUB_BUFFER", tt.buffer)
+			t.Setenv("PUBSUB_OVERFLOW", tt.overflow)
+			got, err := pubsub.OptionsFromEnv()
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRedis(t *testing.T) {
+	conn := redigomock.NewConn()
+	conn.Command("PUBLISH", "pubsub:user", []byte("created")).Expect(int64(1))
+	conn.Command("PSUBSCRIBE", "pubsub:*").Expect([]interface{}{[]byte("psubscribe"), []byte("pubsub:*"), int64(1)})
+	conn.AddSubscriptionMessage([]interface{}{
+		[]byte("pmessage"), []byte("pubsub:*"), []byte("pubsub:user"), []byte("updated"),
+	})
+	pool := &redigo.Pool{Dial: func() (redigo.Conn, error) { return conn, nil }}
+
+	ps := pubsub.NewRedis(pool, pubsub.Options{})
+	ctx := context.Background()
+	assert.Nil(t, ps.Publish(ctx, topic, []byte("created")))
+
+	ch, err := ps.Subscribe(ctx, topic)
+	assert.Nil(t, err)
+	msg, _ := receive(t, ch)
+	assert.Equal(t, "updated", msg)
+
+	assert.Nil(t, ps.Close())
+	_, open := receive(t, ch)
+	assert.False(t, open)
+	// closing again is a no-op
+	assert.Nil(t, ps.Close())
+}
+
+func TestRedisCloseUnstarted(t *testing.T) {
+	ps := pubsub.NewRedis(&redigo.Pool{}, pubsub.Options{})
+	assert.Nil(t, ps.Close())
+	assert.Nil(t, ps.Close())
+}
@@ -0,0 +1,144 @@
+package pubsub
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go-template/pkg/utl/zaplog"
+
+	redigo "github.com/gomodule/redigo/redis"
+)
+
+const (
+	channelPrefix = "pubsub:"
+	minBackoff    = 100 * time.Millisecond
+	maxBackoff    = 30 * time.Second
+)
+
+// Redis publishes messages on Redis channels so that they reach the subscribers of every
+// instance. Each instance holds a single Redis subscription, started on the first
+// Subscribe, and fans the messages out to its own subscribers.
+type Redis struct {
+	broker *broker
+	pool   *redigo.Pool
+
+	start   sync.Once
+	closing sync.Once
+	mu      sync.Mutex
+	conn    redigo.Conn
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewRedis returns a PubSub publishing through connections of pool
+func NewRedis(pool *redigo.Pool, opts Options) *Redis {
+	return &Redis{
+		broker: newBroker(opts),
+		pool:   pool,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Publish ...
+func (r *Redis) Publish(_ context.Context, topic string, payload []byte) error {
+	if r.broker.isClosed() {
+		return ErrClosed
+	}
+	conn := r.pool.Get()
+	defer conn.Close()
+	_, err := conn.Do("PUBLISH", channelPrefix+topic, payload)
+	return err
+}
+
+// Subscribe ...
+func (r *Redis) Subscribe(ctx context.Context, topic string) (<-chan []byte, error) {
+	ch, err := r.broker.subscribe(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	r.start.Do(func() { go r.receive() })
+	return ch, nil
+}
+
+// Close ...
+func (r *Redis) Close() error {
+	r.closing.Do(func() {
+		r.broker.close()
+		started := true
+		r.start.Do(func() { started = false })
+		close(r.stop)
+		if !started {
+			return
+		}
+		r.mu.Lock()
+		if r.conn != nil {
+			// unblocks Receive
+			r.conn.Close()
+		}
+		r.mu.Unlock()
+		<-r.done
+	})
+	return nil
+}
+
+// receive relays the messages of every topic to the local subscribers, reconnecting with
+// an exponential backoff when the connection drops
+func (r *Redis) receive() {
+	defer close(r.done)
+	backoff := minBackoff
+	for {
+		err := r.listen(func() { backoff = minBackoff })
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		zaplog.Logger.Warnw("pubsub subscription lost, reconnecting", "error", err, "backoff", backoff.String())
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (r *Redis) listen(onSubscribed func()) error {
+	// the subscription gets a connection of its own, pooled connections aren't meant to be
+	// closed while another goroutine is receiving on them
+	conn, err := r.pool.Dial()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	select {
+	case <-r.stop:
+		r.mu.Unlock()
+		conn.Close()
+		return nil
+	default:
+	}
+	r.conn = conn
+	r.mu.Unlock()
+	defer conn.Close()
+
+	psc := redigo.PubSubConn{Conn: conn}
+	if err := psc.PSubscribe(channelPrefix + "*"); err != nil {
+		return err
+	}
+	for {
+		switch v := psc.Receive().(type) {
+		case redigo.Message:
+			r.broker.deliver(strings.TrimPrefix(v.Channel, channelPrefix), v.Data)
+		case redigo.Subscription:
+			onSubscribed()
+		case error:
+			return v
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// reportTimeout bounds the report of a panic, it is sent after the response with its own context
+const reportTimeout = 10 * time.Second
+
+var recoveredPanics int64
+
+// RecoveredPanics returns the number of resolver panics recovered since the process started
+func RecoveredPanics() int64 {
+	return atomic.LoadInt64(&recoveredPanics)
+}
+
+// RecoverFunc returns a gqlgen recover function that logs the panic and its stack with the
+// request id, forwards it to sink in the background and answers the client with a masked internal
+// error
+func RecoverFunc(sink Sink) graphql.RecoverFunc {
+	return func(ctx context.Context, err interface{}) error {
+		stack := string(debug.Stack())
+		atomic.AddInt64(&recoveredPanics, 1)
+		zaplog.For(ctx).Errorw("panic recovered", "panic", fmt.Sprint(err), "stack", stack)
+
+		requestID, _ := ctx.Value(zaplog.RequestIdCtxKey).(string)
+		event := Event{
+			Message:   fmt.Sprint(err),
+			Stack:     stack,
+			RequestID: requestID,
+			Timestamp: time.Now(),
+		}
+		if graphql.HasOperationContext(ctx) {
+			event.Tags = map[string]string{"operation": graphql.GetOperationContext(ctx).OperationName}
+		}
+		go func() {
+			// the request may be cancelled once it is answered while the report is still in flight
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reportTimeout)
+			defer cancel()
+			if reportErr := sink.Report(ctx, event); reportErr != nil {
+				zaplog.Error(ctx, "failed to report panic: ", reportErr)
+			}
+		}()
+		return apperror.NewInternal(fmt.Errorf("panic: %v", err))
+	}
+}
@@ -0,0 +1,54 @@
+// Package reporter forwards unexpected failures, such as resolver panics, to an
+// external error-reporting sink.
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Event is a single error report
+type Event struct {
+	Message   string            `json:"message"`
+	Stack     string            `json:"stack,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Tags      map[string]string `json:"tags,omitempty"`
+}
+
+// Sink receives error reports
+type Sink interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// New returns the sink configured by dsn:
+//   - an empty dsn disables reporting
+//   - file:///path/to/file appends events as JSON lines to a local file
+//   - http(s)://<key>@<host>/<project> sends events to a Sentry-compatible server
+func New(dsn string) (Sink, error) {
+	if dsn == "" {
+		return NoopSink{}, nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid error reporting dsn: %w", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "file":
+		return NewFileSink(u.Path), nil
+	case "http", "https":
+		return NewSentrySink(u)
+	}
+	return nil, fmt.Errorf("unsupported error reporting dsn scheme %q", u.Scheme)
+}
+
+// NoopSink drops every report
+type NoopSink struct{}
+
+// Report ...
+func (NoopSink) Report(context.Context, Event) error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package reporter_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go-template/internal/service/reporter"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const RequestID = "request-id"
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		dsn     string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name: "Empty dsn",
+			dsn:  "",
+			want: reporter.NoopSink{},
+		},
+		{
+			name: "File dsn",
+			dsn:  "file:///tmp/errors.log",
+			want: &reporter.FileSink{},
+		},
+		{
+			name: "Sentry dsn",
+			dsn:  "https://public@sentry.example.com/42",
+			want: &reporter.SentrySink{},
+		},
+		{
+			name:    "Sentry dsn without key",
+			dsn:     "https://sentry.example.com/42",
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported scheme",
+			dsn:     "udp://sentry.example.com/42",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reporter.New(tt.dsn)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.IsType(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSentrySink(t *testing.T) {
+	var (
+		path string
+		auth string
+		body map[string]interface{}
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("X-Sentry-Auth")
+		b, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(b, &body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	sink, err := reporter.New(strings.Replace(ts.URL, "://", "://public@", 1) + "/42")
+	assert.Nil(t, err)
+	err = sink.Report(context.Background(), reporter.Event{
+		Message:   "boom",
+		RequestID: RequestID,
+		Timestamp: time.Now(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/42/store/", path)
+	assert.Contains(t, auth, "sentry_key=public")
+	assert.Equal(t, "boom", body["message"])
+	assert.Equal(t, RequestID, body["tags"].(map[string]interface{})["request_id"])
+}
+
+func TestFileSink(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "errors.log")
+	sink, err := reporter.New("file://" + file)
+	assert.Nil(t, err)
+
+	err = sink.Report(context.Background(), reporter.Event{
+		Message:   "nil pointer dereference",
+		Stack:     "goroutine 1 [running]:",
+		RequestID: RequestID,
+	})
+	assert.Nil(t, err)
+
+	f, err := os.Open(file)
+	assert.Nil(t, err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	assert.True(t, scanner.Scan())
+	var event reporter.Event
+	assert.Nil(t, json.Unmarshal(scanner.Bytes(), &event))
+	assert.Equal(t, "nil pointer dereference", event.Message)
+	assert.Equal(t, RequestID, event.RequestID)
+	assert.Equal(t, "goroutine 1 [running]:", event.Stack)
+}
+
+// chanSink hands the reported events and the state of their context over to the test
+type chanSink struct {
+	reports chan report
+}
+
+type report struct {
+	event       reporter.Event
+	err         error
+	hasDeadline bool
+}
+
+func (s chanSink) Report(ctx context.Context, event reporter.Event) error {
+	_, hasDeadline := ctx.Deadline()
+	s.reports <- report{event: event, err: ctx.Err(), hasDeadline: hasDeadline}
+	return nil
+}
+
+func TestRecoverFunc(t *testing.T) {
+	sink := chanSink{reports: make(chan report, 1)}
+
+	before := reporter.RecoveredPanics()
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), zaplog.RequestIdCtxKey, RequestID))
+	// the report outlives the request, it is not cancelled along with it
+	cancel()
+	got := reporter.RecoverFunc(sink)(ctx, "nil pointer dereference")
+
+	assert.Equal(t, apperror.Internal, apperror.CodeOf(got))
+	assert.Equal(t, "internal server error", got.Error())
+	assert.Equal(t, before+1, reporter.RecoveredPanics())
+
+	select {
+	case r := <-sink.reports:
+		assert.Equal(t, "nil pointer dereference", r.event.Message)
+		assert.Equal(t, RequestID, r.event.RequestID)
+		assert.NotEmpty(t, r.event.Stack)
+		assert.Nil(t, r.err)
+		assert.True(t, r.hasDeadline)
+	case <-time.After(time.Second):
+		t.Fatal("the panic was not reported")
+	}
+}
@@ -0,0 +1,120 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSink appends reports as JSON lines to a local file, it is meant for local runs and tests
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink returns a sink writing to path
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Report ...
+func (s *FileSink) Report(_ context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+const sentryTimeout = 5 * time.Second
+
+// SentrySink sends reports to the store endpoint of a Sentry-compatible server
+type SentrySink struct {
+	endpoint  string
+	publicKey string
+	client    *http.Client
+}
+
+// NewSentrySink builds a sink from a dsn of the form scheme://<key>@<host>/<project>
+func NewSentrySink(dsn *url.URL) (*SentrySink, error) {
+	project := strings.Trim(dsn.Path, "/")
+	if dsn.User == nil || dsn.User.Username() == "" || project == "" {
+		return nil, fmt.Errorf("error reporting dsn must contain a public key and a project id")
+	}
+	return &SentrySink{
+		endpoint:  fmt.Sprintf("%s://%s/api/%s/store/", dsn.Scheme, dsn.Host, project),
+		publicKey: dsn.User.Username(),
+		client:    &http.Client{Timeout: sentryTimeout},
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID   string            `json:"event_id"`
+	Timestamp string            `json:"timestamp"`
+	Level     string            `json:"level"`
+	Platform  string            `json:"platform"`
+	Logger    string            `json:"logger"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
+}
+
+// Report ...
+func (s *SentrySink) Report(ctx context.Context, event Event) error {
+	tags := map[string]string{"request_id": event.RequestID}
+	for k, v := range event.Tags {
+		tags[k] = v
+	}
+	body, err := json.Marshal(sentryEvent{
+		EventID:   eventID(),
+		Timestamp: event.Timestamp.UTC().Format(time.RFC3339),
+		Level:     "fatal",
+		Platform:  "go",
+		Logger:    os.Getenv("SERVICE_NAME"),
+		Message:   event.Message,
+		Tags:      tags,
+		Extra:     map[string]string{"stacktrace": event.Stack},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth",
+		fmt.Sprintf("Sentry sentry_version=7, sentry_client=go-template/1.0, sentry_key=%s", s.publicKey))
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("error reporting sink responded with %s", res.Status)
+	}
+	return nil
+}
+
+func eventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,82 @@
+// Package roles holds the business logic of the roles, the resolvers only map it to and
+// from GraphQL
+package roles
+
+import (
+	"context"
+	"database/sql"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/constants"
+	"go-template/internal/repository"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/convert"
+	"go-template/pkg/utl/resultwrapper"
+)
+
+// Events records the changes made to the roles in the transaction that made them
+type Events interface {
+	RecordRole(ctx context.Context, tx *sql.Tx, eventType fm.EventType, role *models.Role) error
+}
+
+// Service manages the roles
+type Service struct {
+	roles  repository.RoleRepository
+	users  repository.UserRepository
+	tx     repository.Transactor
+	events Events
+}
+
+// New returns the roles service
+func New(
+	roles repository.RoleRepository,
+	users repository.UserRepository,
+	tx repository.Transactor,
+	events Events,
+) *Service {
+	return &Service{roles: roles, users: users, tx: tx, events: events}
+}
+
+// Get returns the role roleID
+func (s *Service) Get(ctx context.Context, roleID int) (*models.Role, error) {
+	role, err := s.roles.FindByID(ctx, roleID)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return role, nil
+}
+
+// RoleOf returns the role of the user userID
+func (s *Service) RoleOf(ctx context.Context, userID int) (*models.Role, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return s.Get(ctx, convert.NullDotIntToInt(user.RoleID))
+}
+
+// Create stores role, only super admins are allowed to
+func (s *Service) Create(ctx context.Context, actorID int, role models.Role) (*models.Role, error) {
+	actorRole, err := s.RoleOf(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if actorRole.AccessLevel != int(constants.SuperAdminRole) {
+		return nil, apperror.NewForbidden("You don't appear to have enough access level for this request ")
+	}
+
+	var created models.Role
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		created, err = s.roles.Create(ctx, tx, role)
+		if err != nil {
+			return err
+		}
+		return s.events.RecordRole(ctx, tx, fm.EventTypeCreated, &created)
+	})
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "role")
+	}
+	return &created, nil
+}
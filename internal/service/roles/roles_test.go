@@ -0,0 +1,82 @@
+package roles_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/constants"
+	"go-template/internal/repository"
+	"go-template/internal/service/roles"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+// events collects the recorded events, failing when err is set
+type events struct {
+	types []fm.EventType
+	err   error
+}
+
+func (e *events) RecordRole(_ context.Context, _ *sql.Tx, eventType fm.EventType, _ *models.Role) error {
+	e.types = append(e.types, eventType)
+	return e.err
+}
+
+func newService(recorder *events) *roles.Service {
+	roleRepo := repository.NewMemoryRoles(
+		models.Role{ID: 1, Name: "SUPER_ADMIN", AccessLevel: int(constants.SuperAdminRole)},
+		models.Role{ID: 2, Name: "USER", AccessLevel: int(constants.UserRole)},
+	)
+	userRepo := repository.NewMemoryUsers(
+		models.User{ID: 1, RoleID: null.IntFrom(1)},
+		models.User{ID: 2, RoleID: null.IntFrom(2)},
+		models.User{ID: 3, RoleID: null.IntFrom(9)},
+	)
+	return roles.New(roleRepo, userRepo, repository.MemoryTransactor{}, recorder)
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		actorID    int
+		eventErr   error
+		wantCode   apperror.Code
+		wantEvents []fm.EventType
+	}{
+		{name: "Success", actorID: 1, wantEvents: []fm.EventType{fm.EventTypeCreated}},
+		{name: "NotSuperAdmin", actorID: 2, wantCode: apperror.Forbidden},
+		{name: "UnknownActor", actorID: 9, wantCode: apperror.NotFound},
+		{name: "UnknownActorRole", actorID: 3, wantCode: apperror.NotFound},
+		{name: "EventError", actorID: 1, eventErr: errors.New("outbox"), wantCode: apperror.Internal,
+			wantEvents: []fm.EventType{fm.EventTypeCreated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &events{err: tt.eventErr}
+			s := newService(recorder)
+			role, err := s.Create(context.Background(), tt.actorID, models.Role{Name: "EDITOR", AccessLevel: 150})
+			assert.Equal(t, tt.wantEvents, recorder.types)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 3, role.ID)
+			stored, err := s.Get(context.Background(), role.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "EDITOR", stored.Name)
+		})
+	}
+}
+
+func TestRoleOf(t *testing.T) {
+	role, err := newService(&events{}).RoleOf(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "USER", role.Name)
+}
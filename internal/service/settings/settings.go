@@ -0,0 +1,134 @@
+// Package settings holds the runtime settings, which can be changed without a redeploy. Their
+// values are stored in the settings table, cached in memory and optionally pinned by a file.
+package settings
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go-template/internal/config"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+)
+
+// Keys of the builtin settings
+const (
+	// ThrottleLimit is the number of throttled requests an IP can make in the throttle window
+	ThrottleLimit = "throttle.limit"
+	// ThrottleWindow is the throttle window in seconds
+	ThrottleWindow = "throttle.window_seconds"
+	// PasswordMinStrength is the minimum zxcvbn score of the new passwords
+	PasswordMinStrength = "password.min_strength"
+	// LogLevel is the level of the logger
+	LogLevel = "log.level"
+	// PlaygroundEnabled serves the GraphQL playground
+	PlaygroundEnabled = "playground.enabled"
+)
+
+// Type is the type of the value of a setting
+type Type string
+
+// Types of settings
+const (
+	Int    Type = "int"
+	Bool   Type = "bool"
+	String Type = "string"
+	Enum   Type = "enum"
+)
+
+// Source is where the value of a setting comes from
+type Source string
+
+// Sources of the values
+const (
+	SourceDefault  Source = "default"
+	SourceDatabase Source = "database"
+	SourceFile     Source = "file"
+)
+
+// Definition describes a setting
+type Definition struct {
+	Key         string
+	Type        Type
+	Default     string
+	Description string
+	// Min and Max bound the int settings when they are set
+	Min, Max *int
+	// Values are the values allowed for enum settings
+	Values []string
+}
+
+// Validate checks that value is valid for the setting and returns its canonical form
+func (d Definition) Validate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch d.Type {
+	case Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", apperror.Newf(apperror.Validation, "%s must be an integer", d.Key)
+		}
+		if d.Min != nil && n < *d.Min {
+			return "", apperror.Newf(apperror.Validation, "%s must be at least %d", d.Key, *d.Min)
+		}
+		if d.Max != nil && n > *d.Max {
+			return "", apperror.Newf(apperror.Validation, "%s must be at most %d", d.Key, *d.Max)
+		}
+		return strconv.Itoa(n), nil
+	case Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", apperror.Newf(apperror.Validation, "%s must be true or false", d.Key)
+		}
+		return strconv.FormatBool(b), nil
+	case Enum:
+		for _, v := range d.Values {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", apperror.Newf(apperror.Validation, "%s must be one of %s", d.Key, strings.Join(d.Values, ", "))
+	}
+	return value, nil
+}
+
+// Setting is the current value of a setting
+type Setting struct {
+	Definition
+	Value     string
+	Source    Source
+	UpdatedBy int
+	UpdatedAt time.Time
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+// Builtins returns the settings of the application, their defaults are the values read from
+// cfg and the environment at startup
+func Builtins(cfg *config.Configuration) []Definition {
+	return []Definition{
+		{
+			Key: ThrottleLimit, Type: Int, Default: "5", Min: intPtr(1),
+			Description: "Number of throttled requests an IP can make in the throttle window.",
+		},
+		{
+			Key: ThrottleWindow, Type: Int, Default: "10", Min: intPtr(1),
+			Description: "Throttle window in seconds.",
+		},
+		{
+			Key: PasswordMinStrength, Type: Int, Default: strconv.Itoa(cfg.App.MinPasswordStr),
+			Min: intPtr(0), Max: intPtr(4),
+			Description: "Minimum zxcvbn score, from 0 to 4, of the new passwords.",
+		},
+		{
+			Key: LogLevel, Type: Enum, Default: zaplog.Level(), Values: []string{"debug", "info", "warn", "error"},
+			Description: "Level of the logger.",
+		},
+		{
+			Key: PlaygroundEnabled, Type: Bool, Default: "true",
+			Description: "Serves the GraphQL playground on /playground.",
+		},
+	}
+}
@@ -0,0 +1,219 @@
+package settings_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/config"
+	"go-template/internal/service/settings"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func newStore(t *testing.T, file string, rows ...daos.Setting) *settings.Store {
+	t.Helper()
+	cfg := &config.Configuration{App: &config.Application{MinPasswordStr: 1}}
+	store := settings.NewStore(settings.Builtins(cfg), settings.NewMemory(rows...), settings.Options{File: file})
+	assert.NoError(t, store.Load(context.Background()))
+	return store
+}
+
+func TestValidate(t *testing.T) {
+	min, max := 0, 4
+	tests := []struct {
+		name    string
+		def     settings.Definition
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "Int", def: settings.Definition{Key: "k", Type: settings.Int}, value: " 07 ", want: "7"},
+		{name: "Int invalid", def: settings.Definition{Key: "k", Type: settings.Int}, value: "seven",
+			wantErr: "k must be an integer"},
+		{name: "Int below min", def: settings.Definition{Key: "k", Type: settings.Int, Min: &min}, value: "-1",
+			wantErr: "k must be at least 0"},
+		{name: "Int above max", def: settings.Definition{Key: "k", Type: settings.Int, Max: &max}, value: "5",
+			wantErr: "k must be at most 4"},
+		{name: "Bool", def: settings.Definition{Key: "k", Type: settings.Bool}, value: "1", want: "true"},
+		{name: "Bool invalid", def: settings.Definition{Key: "k", Type: settings.Bool}, value: "yes",
+			wantErr: "k must be true or false"},
+		{name: "Enum", def: settings.Definition{Key: "k", Type: settings.Enum, Values: []string{"info", "warn"}},
+			value: "WARN", want: "warn"},
+		{name: "Enum invalid", def: settings.Definition{Key: "k", Type: settings.Enum, Values: []string{"info", "warn"}},
+			value: "trace", wantErr: "k must be one of info, warn"},
+		{name: "String", def: settings.Definition{Key: "k", Type: settings.String}, value: "anything", want: "anything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.def.Validate(tt.value)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Equal(t, apperror.Validation, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("throttle.limit: 50\nunknown: 1\n"), 0o600))
+	store := newStore(t, file,
+		daos.Setting{Key: settings.ThrottleLimit, Value: "20"},
+		daos.Setting{Key: settings.ThrottleWindow, Value: "30", UpdatedBy: null.IntFrom(1)},
+		daos.Setting{Key: settings.PasswordMinStrength, Value: "9"},
+	)
+
+	tests := []struct {
+		name       string
+		key        string
+		wantValue  string
+		wantSource settings.Source
+	}{
+		{name: "File takes precedence", key: settings.ThrottleLimit, wantValue: "50", wantSource: settings.SourceFile},
+		{name: "Database", key: settings.ThrottleWindow, wantValue: "30", wantSource: settings.SourceDatabase},
+		{name: "Invalid stored value", key: settings.PasswordMinStrength, wantValue: "1",
+			wantSource: settings.SourceDefault},
+		{name: "Default", key: settings.PlaygroundEnabled, wantValue: "true", wantSource: settings.SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Get(tt.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantValue, got.Value)
+			assert.Equal(t, tt.wantSource, got.Source)
+		})
+	}
+	assert.Equal(t, 1, store.Int(settings.PasswordMinStrength))
+	assert.True(t, store.Bool(settings.PlaygroundEnabled))
+	assert.Len(t, store.List(), 5)
+	assert.Equal(t, settings.LogLevel, store.List()[0].Key)
+
+	_, err := store.Get("unknown")
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+
+	// a missing file fails the load and keeps the cached values
+	broken := settings.NewStore(settings.Builtins(&config.Configuration{App: &config.Application{}}),
+		settings.NewMemory(), settings.Options{File: filepath.Join(t.TempDir(), "missing.yaml")})
+	assert.True(t, errors.Is(broken.Load(context.Background()), os.ErrNotExist))
+	assert.Equal(t, "5", broken.String(settings.ThrottleLimit))
+}
+
+func TestSetReset(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"playground.enabled": false}`), 0o600))
+	store := newStore(t, file)
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		want     string
+		wantCode apperror.Code
+	}{
+		{name: "Success", key: settings.ThrottleLimit, value: "100", want: "100"},
+		{name: "Invalid", key: settings.PasswordMinStrength, value: "5", wantCode: apperror.Validation},
+		{name: "Unknown", key: "unknown", value: "5", wantCode: apperror.NotFound},
+		{name: "Pinned by the file", key: settings.PlaygroundEnabled, value: "true", wantCode: apperror.Conflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Set(context.Background(), 1, tt.key, tt.value)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, settings.SourceDatabase, got.Source)
+			assert.Equal(t, 1, got.UpdatedBy)
+			assert.Equal(t, tt.want, store.String(tt.key))
+		})
+	}
+
+	// the values set are read back by the next load
+	assert.NoError(t, store.Load(context.Background()))
+	assert.Equal(t, 100, store.Int(settings.ThrottleLimit))
+
+	got, err := store.Reset(context.Background(), settings.ThrottleLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, settings.SourceDefault, got.Source)
+	assert.NoError(t, store.Load(context.Background()))
+	assert.Equal(t, 5, store.Int(settings.ThrottleLimit))
+
+	_, err = store.Reset(context.Background(), settings.PlaygroundEnabled)
+	assert.Equal(t, apperror.Conflict, apperror.CodeOf(err))
+}
+
+func TestSubscribe(t *testing.T) {
+	repo := settings.NewMemory()
+	store := settings.NewStore(settings.Builtins(&config.Configuration{App: &config.Application{}}), repo,
+		settings.Options{})
+	var values []string
+	unsubscribe := store.Subscribe(settings.ThrottleLimit, func(s settings.Setting) {
+		values = append(values, s.Value)
+	})
+
+	_, err := store.Set(context.Background(), 1, settings.ThrottleLimit, "7")
+	assert.NoError(t, err)
+	// unchanged values aren't notified
+	_, err = store.Set(context.Background(), 1, settings.ThrottleLimit, "7")
+	assert.NoError(t, err)
+	_, err = store.Set(context.Background(), 1, settings.ThrottleWindow, "7")
+	assert.NoError(t, err)
+	// changes made by the other instances are notified by the next load
+	_, err = repo.Upsert(context.Background(), daos.Setting{Key: settings.ThrottleLimit, Value: "8"})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Load(context.Background()))
+	assert.Equal(t, []string{"7", "8"}, values)
+
+	unsubscribe()
+	_, err = store.Reset(context.Background(), settings.ThrottleLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"7", "8"}, values)
+}
+
+func TestStartStop(t *testing.T) {
+	repo := settings.NewMemory()
+	store := settings.NewStore(settings.Builtins(&config.Configuration{App: &config.Application{}}), repo,
+		settings.Options{Interval: 5 * time.Millisecond})
+	changed := make(chan string, 1)
+	store.Subscribe(settings.ThrottleLimit, func(s settings.Setting) {
+		changed <- s.Value
+	})
+	store.Start()
+	_, err := repo.Upsert(context.Background(), daos.Setting{Key: settings.ThrottleLimit, Value: "9"})
+	assert.NoError(t, err)
+	select {
+	case value := <-changed:
+		assert.Equal(t, "9", value)
+	case <-time.After(time.Second):
+		t.Fatal("the setting wasn't reloaded")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, store.Stop(ctx))
+	assert.NoError(t, store.Stop(ctx))
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("SETTINGS_FILE", "/etc/settings.yaml")
+	t.Setenv("SETTINGS_REFRESH_SECONDS", "")
+	opts, err := settings.OptionsFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, settings.Options{File: "/etc/settings.yaml", Interval: 30 * time.Second}, opts)
+
+	t.Setenv("SETTINGS_REFRESH_SECONDS", "-1")
+	_, err = settings.OptionsFromEnv()
+	assert.EqualError(t, err, `SETTINGS_REFRESH_SECONDS must be a positive integer, got "-1"`)
+}
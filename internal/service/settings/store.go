@@ -0,0 +1,405 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-template/daos"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/volatiletech/null/v8"
+	"gopkg.in/yaml.v3"
+)
+
+// Repository persists the values set through the store
+type Repository interface {
+	FindAll(ctx context.Context) ([]daos.Setting, error)
+	Upsert(ctx context.Context, setting daos.Setting) (*daos.Setting, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Postgres stores the values in the settings table
+type Postgres struct{}
+
+// FindAll ...
+func (Postgres) FindAll(ctx context.Context) ([]daos.Setting, error) {
+	return daos.FindAllSettings(ctx)
+}
+
+// Upsert ...
+func (Postgres) Upsert(ctx context.Context, setting daos.Setting) (*daos.Setting, error) {
+	return daos.UpsertSetting(setting, ctx)
+}
+
+// Delete ...
+func (Postgres) Delete(ctx context.Context, key string) error {
+	_, err := daos.DeleteSetting(key, ctx)
+	return err
+}
+
+// Memory stores the values in memory
+type Memory struct {
+	mu     sync.Mutex
+	values map[string]daos.Setting
+}
+
+// NewMemory returns a memory repository holding settings
+func NewMemory(settings ...daos.Setting) *Memory {
+	m := &Memory{values: map[string]daos.Setting{}}
+	for _, s := range settings {
+		m.values[s.Key] = s
+	}
+	return m
+}
+
+// FindAll ...
+func (m *Memory) FindAll(context.Context) ([]daos.Setting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	settings := make([]daos.Setting, 0, len(m.values))
+	for _, s := range m.values {
+		settings = append(settings, s)
+	}
+	return settings, nil
+}
+
+// Upsert ...
+func (m *Memory) Upsert(_ context.Context, setting daos.Setting) (*daos.Setting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	setting.UpdatedAt = time.Now()
+	m.values[setting.Key] = setting
+	return &setting, nil
+}
+
+// Delete ...
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.values, key)
+	return nil
+}
+
+// Options configure the store
+type Options struct {
+	// File is a YAML or JSON file mapping keys to values, they take precedence over the
+	// database and can't be changed through the store
+	File string
+	// Interval is the interval the values are reloaded on, 0 disables the reloads
+	Interval time.Duration
+}
+
+// OptionsFromEnv reads SETTINGS_FILE and SETTINGS_REFRESH_SECONDS, 30 by default
+func OptionsFromEnv() (Options, error) {
+	opts := Options{File: os.Getenv("SETTINGS_FILE"), Interval: 30 * time.Second}
+	if value := os.Getenv("SETTINGS_REFRESH_SECONDS"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("SETTINGS_REFRESH_SECONDS must be a positive integer, got %q", value)
+		}
+		opts.Interval = time.Duration(n) * time.Second
+	}
+	return opts, nil
+}
+
+// Store caches the settings in memory and notifies the subscribers of a setting when its
+// value changes. The values are reloaded on an interval so that the changes made by the
+// other instances, or to the file, are picked up.
+type Store struct {
+	defs map[string]Definition
+	repo Repository
+	opts Options
+
+	mu       sync.RWMutex
+	settings map[string]Setting
+
+	subsMu sync.Mutex
+	subs   map[string][]*subscriber
+
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+type subscriber struct {
+	fn func(Setting)
+}
+
+// NewStore returns a store of the settings of defs holding their defaults, Load reads their
+// values
+func NewStore(defs []Definition, repo Repository, opts Options) *Store {
+	s := &Store{
+		defs:     make(map[string]Definition, len(defs)),
+		repo:     repo,
+		opts:     opts,
+		settings: make(map[string]Setting, len(defs)),
+		subs:     map[string][]*subscriber{},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, def := range defs {
+		s.defs[def.Key] = def
+		s.settings[def.Key] = Setting{Definition: def, Value: def.Default, Source: SourceDefault}
+	}
+	return s
+}
+
+// Load reads the values of the database and of the file, then notifies the subscribers of
+// the settings whose value changed. The invalid values are logged and ignored.
+func (s *Store) Load(ctx context.Context) error {
+	rows, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	pinned, err := readFile(s.opts.File)
+	if err != nil {
+		return err
+	}
+	settings := make(map[string]Setting, len(s.defs))
+	for key, def := range s.defs {
+		settings[key] = Setting{Definition: def, Value: def.Default, Source: SourceDefault}
+	}
+	for _, row := range rows {
+		if setting, ok := s.resolve(row.Key, row.Value, SourceDatabase); ok {
+			setting.UpdatedBy, setting.UpdatedAt = row.UpdatedBy.Int, row.UpdatedAt
+			settings[row.Key] = setting
+		}
+	}
+	for key, value := range pinned {
+		if setting, ok := s.resolve(key, value, SourceFile); ok {
+			settings[key] = setting
+		}
+	}
+
+	s.mu.Lock()
+	var changed []Setting
+	for key, setting := range settings {
+		if s.settings[key].Value != setting.Value {
+			changed = append(changed, setting)
+		}
+	}
+	s.settings = settings
+	s.mu.Unlock()
+	for _, setting := range changed {
+		s.notify(setting)
+	}
+	return nil
+}
+
+// resolve returns the setting of key having value, it is false when the value is invalid
+func (s *Store) resolve(key, value string, source Source) (Setting, bool) {
+	def, ok := s.defs[key]
+	if !ok {
+		zaplog.Logger.Warnw("ignoring unknown setting", "key", key, "source", source)
+		return Setting{}, false
+	}
+	value, err := def.Validate(value)
+	if err != nil {
+		zaplog.Logger.Warnw("ignoring invalid setting", "key", key, "source", source, "error", err.Error())
+		return Setting{}, false
+	}
+	return Setting{Definition: def, Value: value, Source: source}, true
+}
+
+// readFile returns the values of file, a YAML or JSON document mapping keys to values
+func readFile(file string) (map[string]string, error) {
+	if file == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	values := make(map[string]string, len(doc))
+	for key, value := range doc {
+		values[key] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+// Get returns the setting of key
+func (s *Store) Get(key string) (Setting, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	setting, ok := s.settings[key]
+	if !ok {
+		return Setting{}, apperror.Newf(apperror.NotFound, "unknown setting %s", key)
+	}
+	return setting, nil
+}
+
+// List returns the settings sorted by key
+func (s *Store) List() []Setting {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	settings := make([]Setting, 0, len(s.settings))
+	for _, setting := range s.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// String returns the value of the setting of key, the unknown settings are empty
+func (s *Store) String(key string) string {
+	setting, _ := s.Get(key)
+	return setting.Value
+}
+
+// Int returns the value of the int setting of key
+func (s *Store) Int(key string) int {
+	n, _ := strconv.Atoi(s.String(key))
+	return n
+}
+
+// Bool returns the value of the bool setting of key
+func (s *Store) Bool(key string) bool {
+	b, _ := strconv.ParseBool(s.String(key))
+	return b
+}
+
+// Set validates value and stores it as the value of the setting of key, actorID is the user
+// making the change
+func (s *Store) Set(ctx context.Context, actorID int, key, value string) (Setting, error) {
+	def, err := s.updatable(key)
+	if err != nil {
+		return Setting{}, err
+	}
+	if value, err = def.Validate(value); err != nil {
+		return Setting{}, err
+	}
+	row, err := s.repo.Upsert(ctx, daos.Setting{Key: key, Value: value, UpdatedBy: null.IntFrom(actorID)})
+	if err != nil {
+		return Setting{}, apperror.FromSQL(err, "setting")
+	}
+	return s.apply(Setting{Definition: def, Value: row.Value, Source: SourceDatabase,
+		UpdatedBy: row.UpdatedBy.Int, UpdatedAt: row.UpdatedAt}), nil
+}
+
+// Reset deletes the value of the setting of key, which falls back to its default
+func (s *Store) Reset(ctx context.Context, key string) (Setting, error) {
+	def, err := s.updatable(key)
+	if err != nil {
+		return Setting{}, err
+	}
+	if err := s.repo.Delete(ctx, key); err != nil {
+		return Setting{}, apperror.FromSQL(err, "setting")
+	}
+	return s.apply(Setting{Definition: def, Value: def.Default, Source: SourceDefault}), nil
+}
+
+// updatable returns the definition of the setting of key unless the file pins its value
+func (s *Store) updatable(key string) (Definition, error) {
+	setting, err := s.Get(key)
+	if err != nil {
+		return Definition{}, err
+	}
+	if setting.Source == SourceFile {
+		return Definition{}, apperror.Newf(apperror.Conflict, "%s is set by the settings file", key)
+	}
+	return setting.Definition, nil
+}
+
+// apply caches setting and notifies its subscribers when its value changed
+func (s *Store) apply(setting Setting) Setting {
+	s.mu.Lock()
+	previous := s.settings[setting.Key]
+	s.settings[setting.Key] = setting
+	s.mu.Unlock()
+	if previous.Value != setting.Value {
+		s.notify(setting)
+	}
+	return setting
+}
+
+// Subscribe calls fn with the setting of key whenever its value changes, until the returned
+// function is called
+func (s *Store) Subscribe(key string, fn func(Setting)) (unsubscribe func()) {
+	sub := &subscriber{fn: fn}
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	s.subs[key] = append(s.subs[key], sub)
+	return func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		subs := s.subs[key]
+		for i := range subs {
+			if subs[i] == sub {
+				s.subs[key] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) notify(setting Setting) {
+	s.subsMu.Lock()
+	subs := append([]*subscriber(nil), s.subs[setting.Key]...)
+	s.subsMu.Unlock()
+	zaplog.Logger.Infow("setting changed", "key", setting.Key, "value", setting.Value, "source", setting.Source)
+	for _, sub := range subs {
+		sub.fn(setting)
+	}
+}
+
+// Start reloads the values every interval until Stop is called
+func (s *Store) Start() {
+	if s.opts.Interval <= 0 {
+		return
+	}
+	s.started.Store(true)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.Load(context.Background()); err != nil {
+					zaplog.Logger.Errorw("reloading the settings failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the reloads
+func (s *Store) Stop(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	if !s.started.Load() {
+		return nil
+	}
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ApplyLogLevel sets the level of the logger to the log.level setting of s whenever it changes
+func ApplyLogLevel(s *Store) {
+	apply := func(setting Setting) {
+		if err := zaplog.SetLevel(setting.Value); err != nil {
+			zaplog.Logger.Errorw("invalid log level", "error", err.Error())
+		}
+	}
+	s.Subscribe(LogLevel, apply)
+	if setting, err := s.Get(LogLevel); err == nil {
+		apply(setting)
+	}
+}
@@ -0,0 +1,92 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "go-template/graphql"
+
+// GraphQL is a gqlgen extension starting a span for every operation and a child span for
+// every field that is backed by a resolver
+type GraphQL struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = GraphQL{}
+
+// ExtensionName ...
+func (GraphQL) ExtensionName() string {
+	return "OpenTelemetryTracing"
+}
+
+// Validate ...
+func (GraphQL) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse ...
+func (GraphQL) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+	oc := graphql.GetOperationContext(ctx)
+	opType, name := "unknown", oc.OperationName
+	if oc.Operation != nil {
+		opType = string(oc.Operation.Operation)
+		if name == "" {
+			name = oc.Operation.Name
+		}
+	}
+	spanName := opType
+	if name != "" {
+		spanName = fmt.Sprintf("%s %s", opType, name)
+	}
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.type", opType),
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.document", zaplog.RedactDocument(oc.RawQuery)),
+		),
+	)
+	defer span.End()
+
+	res := next(ctx)
+	if res != nil && len(res.Errors) > 0 {
+		span.SetStatus(codes.Error, res.Errors.Error())
+	}
+	return res
+}
+
+// InterceptField ...
+func (GraphQL) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	attrs := []attribute.KeyValue{attribute.String("graphql.field.path", fc.Path().String())}
+	if fc.Field.Definition != nil {
+		attrs = append(attrs, attribute.String("graphql.field.type", fc.Field.Definition.Type.String()))
+	}
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, fmt.Sprintf("%s.%s", fc.Object, fc.Field.Name),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
@@ -1,63 +1,235 @@
+// Package tracer sets up OpenTelemetry tracing. The exporter, the sampler and the
+// resource attributes are configured from the environment.
 package tracer
 
 import (
 	"context"
-	"log"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
+	"go-template/internal/version"
+	"go-template/pkg/utl/zaplog"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
 	"google.golang.org/grpc/credentials"
+)
 
-	"go.opentelemetry.io/otel/sdk/resource"
+// Supported exporters
+const (
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+)
 
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+// signozTokenHeader is the header SigNoz collectors authenticate the exporters with
+const signozTokenHeader = "signoz-access-token"
+
+// Supported samplers, named after the OTEL_TRACES_SAMPLER values of the OpenTelemetry spec
+const (
+	SamplerAlwaysOn                = "always_on"
+	SamplerAlwaysOff               = "always_off"
+	SamplerTraceIDRatio            = "traceidratio"
+	SamplerParentBasedAlwaysOn     = "parentbased_always_on"
+	SamplerParentBasedAlwaysOff    = "parentbased_always_off"
+	SamplerParentBasedTraceIDRatio = "parentbased_traceidratio"
 )
 
-func Init() *sdktrace.TracerProvider {
-	var (
-		signozToken  = os.Getenv("SIGNOZ_ACCESS_TOKEN")
-		collectorURL = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-		insecure     = os.Getenv("INSECURE_MODE")
-	)
-	headers := map[string]string{
-		"signoz-access-token": signozToken,
-	}
-
-	secureOption := otlptracegrpc.WithTLSCredentials(
-		credentials.NewClientTLSFromCert(nil, ""),
-	) // config can be passed to configure TLS
-	if len(insecure) > 0 {
-		secureOption = otlptracegrpc.WithInsecure()
-	}
-
-	exporter, err := otlptrace.New(
-		context.Background(),
-		otlptracegrpc.NewClient(
-			secureOption,
-			otlptracegrpc.WithEndpoint(collectorURL),
-			otlptracegrpc.WithHeaders(headers),
-		),
-	)
+// Config represents tracing specific config
+type Config struct {
+	ServiceName    string
+	ServiceVersion string
+	Environment    string
+
+	Exporter string
+	Endpoint string
+	Insecure bool
+	Headers  map[string]string
+
+	Sampler      string
+	SamplerRatio float64
+}
+
+// ConfigFromEnv reads the tracing config from the environment
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		ServiceName:    os.Getenv("SERVICE_NAME"),
+		ServiceVersion: os.Getenv("SERVICE_VERSION"),
+		Environment:    os.Getenv("ENVIRONMENT_NAME"),
+		Exporter:       strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")),
+		Endpoint:       os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Insecure:       len(os.Getenv("INSECURE_MODE")) > 0,
+		Sampler:        strings.ToLower(os.Getenv("OTEL_TRACES_SAMPLER")),
+		SamplerRatio:   1,
+	}
+	if cfg.ServiceVersion == "" {
+		cfg.ServiceVersion = version.Get().Version
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = ExporterOTLPGRPC
+	}
+	if cfg.Sampler == "" {
+		cfg.Sampler = SamplerParentBasedAlwaysOn
+	}
+	headers, err := ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
 	if err != nil {
-		log.Fatal(err)
+		return cfg, err
+	}
+	// SIGNOZ_ACCESS_TOKEN predates OTEL_EXPORTER_OTLP_HEADERS, the deployments still setting it
+	// keep authenticating to their collector
+	if token := os.Getenv("SIGNOZ_ACCESS_TOKEN"); token != "" {
+		zaplog.Logger.Warnw("SIGNOZ_ACCESS_TOKEN is deprecated, set signoz-access-token in OTEL_EXPORTER_OTLP_HEADERS")
+		if _, ok := headers[signozTokenHeader]; !ok {
+			headers[signozTokenHeader] = token
+		}
 	}
+	cfg.Headers = headers
+	if arg := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); arg != "" {
+		ratio, err := strconv.ParseFloat(arg, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return cfg, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be a ratio between 0 and 1, got %q", arg)
+		}
+		cfg.SamplerRatio = ratio
+	}
+	return cfg, nil
+}
 
-	// For the demonstration, use sdktrace.AlwaysSample sampler to sample all traces.
-	// In a production application, use sdktrace.ProbabilitySampler with a desired probability.
-	traceProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithSpanProcessor(sdktrace.NewBatchSpanProcessor(exporter)),
-		sdktrace.WithResource(
-			resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(os.Getenv("SERVICE_NAME"))),
-		),
-	)
+// ParseHeaders parses headers in the key1=value1,key2=value2 format used by OTEL_EXPORTER_OTLP_HEADERS
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q, expected key=value", pair)
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers, nil
+}
 
-	otel.SetTracerProvider(traceProvider)
+// Init installs the global tracer provider configured from the environment. Tracing must
+// never prevent the server from starting, so when the exporter can't be created the error
+// is logged and spans are recorded without being exported.
+func Init() *sdktrace.TracerProvider {
+	cfg, err := ConfigFromEnv()
+	var tp *sdktrace.TracerProvider
+	if err == nil {
+		tp, err = New(context.Background(), cfg)
+	}
+	if err != nil {
+		zaplog.Logger.Error("tracing disabled: ", err)
+		tp = sdktrace.NewTracerProvider(
+			sdktrace.WithResource(newResource(cfg)),
+			sdktrace.WithSpanProcessor(discardProcessor{}),
+		)
+	}
+
+	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	return traceProvider
+	return tp
+}
+
+// New returns a tracer provider exporting spans as described by cfg
+func New(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	sampler, err := newSampler(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithResource(newResource(cfg)),
+	}
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	} else {
+		opts = append(opts, sdktrace.WithSpanProcessor(discardProcessor{}))
+	}
+	return sdktrace.NewTracerProvider(opts...), nil
 }
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewClientTLSFromCert(nil, "")))
+		}
+		return otlptrace.New(ctx, otlptracegrpc.NewClient(opts...))
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptrace.New(ctx, otlptracehttp.NewClient(opts...))
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported trace exporter %q", cfg.Exporter)
+}
+
+func newSampler(cfg Config) (sdktrace.Sampler, error) {
+	switch cfg.Sampler {
+	case SamplerAlwaysOn:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerAlwaysOff:
+		return sdktrace.NeverSample(), nil
+	case SamplerTraceIDRatio:
+		return sdktrace.TraceIDRatioBased(cfg.SamplerRatio), nil
+	case SamplerParentBasedAlwaysOn:
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case SamplerParentBasedAlwaysOff:
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case SamplerParentBasedTraceIDRatio:
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplerRatio)), nil
+	}
+	return nil, fmt.Errorf("unsupported trace sampler %q", cfg.Sampler)
+}
+
+func newResource(cfg Config) *resource.Resource {
+	attrs := []attribute.KeyValue{semconv.ServiceNameKey.String(cfg.ServiceName)}
+	if cfg.ServiceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(cfg.ServiceVersion))
+	}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(cfg.Environment))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
+
+// discardProcessor drops every span. The sdk refuses to shut down a provider that has no
+// span processor, so it stands in for the exporter when tracing is disabled.
+type discardProcessor struct{}
+
+func (discardProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+func (discardProcessor) OnEnd(sdktrace.ReadOnlySpan)                     {}
+func (discardProcessor) Shutdown(context.Context) error                  { return nil }
+func (discardProcessor) ForceFlush(context.Context) error                { return nil }
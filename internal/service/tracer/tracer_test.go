@@ -0,0 +1,200 @@
+package tracer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-template/internal/service/tracer"
+	"go-template/internal/version"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    tracer.Config
+		wantErr bool
+	}{
+		{
+			name: "Defaults",
+			env:  map[string]string{"SERVICE_NAME": "goTemplate"},
+			want: tracer.Config{
+				ServiceName:    "goTemplate",
+				ServiceVersion: version.Version,
+				Exporter:       tracer.ExporterOTLPGRPC,
+				Headers:        map[string]string{},
+				Sampler:        tracer.SamplerParentBasedAlwaysOn,
+				SamplerRatio:   1,
+			},
+		},
+		{
+			name: "Configured",
+			env: map[string]string{
+				"SERVICE_NAME":                "goTemplate",
+				"SERVICE_VERSION":             "1.2.3",
+				"ENVIRONMENT_NAME":            "develop",
+				"OTEL_TRACES_EXPORTER":        "OTLP-HTTP",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "collector:4318",
+				"OTEL_EXPORTER_OTLP_HEADERS":  "signoz-access-token=abc, x-tenant = team",
+				"INSECURE_MODE":               "true",
+				"OTEL_TRACES_SAMPLER":         "parentbased_traceidratio",
+				"OTEL_TRACES_SAMPLER_ARG":     "0.25",
+			},
+			want: tracer.Config{
+				ServiceName:    "goTemplate",
+				ServiceVersion: "1.2.3",
+				Environment:    "develop",
+				Exporter:       tracer.ExporterOTLPHTTP,
+				Endpoint:       "collector:4318",
+				Insecure:       true,
+				Headers:        map[string]string{"signoz-access-token": "abc", "x-tenant": "team"},
+				Sampler:        tracer.SamplerParentBasedTraceIDRatio,
+				SamplerRatio:   0.25,
+			},
+		},
+		{
+			name: "SigNoz token",
+			env:  map[string]string{"SIGNOZ_ACCESS_TOKEN": "abc", "OTEL_EXPORTER_OTLP_HEADERS": "x-tenant=team"},
+			want: tracer.Config{
+				ServiceVersion: version.Version,
+				Exporter:       tracer.ExporterOTLPGRPC,
+				Headers:        map[string]string{"signoz-access-token": "abc", "x-tenant": "team"},
+				Sampler:        tracer.SamplerParentBasedAlwaysOn,
+				SamplerRatio:   1,
+			},
+		},
+		{
+			name: "SigNoz header",
+			env:  map[string]string{"SIGNOZ_ACCESS_TOKEN": "abc", "OTEL_EXPORTER_OTLP_HEADERS": "signoz-access-token=def"},
+			want: tracer.Config{
+				ServiceVersion: version.Version,
+				Exporter:       tracer.ExporterOTLPGRPC,
+				Headers:        map[string]string{"signoz-access-token": "def"},
+				Sampler:        tracer.SamplerParentBasedAlwaysOn,
+				SamplerRatio:   1,
+			},
+		},
+		{
+			name:    "Invalid header",
+			env:     map[string]string{"OTEL_EXPORTER_OTLP_HEADERS": "token"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid ratio",
+			env:     map[string]string{"OTEL_TRACES_SAMPLER_ARG": "2"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{
+				"SERVICE_NAME", "SERVICE_VERSION", "ENVIRONMENT_NAME", "OTEL_TRACES_EXPORTER",
+				"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "INSECURE_MODE",
+				"OTEL_TRACES_SAMPLER", "OTEL_TRACES_SAMPLER_ARG", "SIGNOZ_ACCESS_TOKEN",
+			} {
+				t.Setenv(key, tt.env[key])
+			}
+			got, err := tracer.ConfigFromEnv()
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     tracer.Config
+		wantErr bool
+	}{
+		{
+			name: "OTLP gRPC",
+			cfg:  tracer.Config{Exporter: tracer.ExporterOTLPGRPC, Endpoint: "localhost:4317", Sampler: tracer.SamplerAlwaysOn},
+		},
+		{
+			name: "OTLP HTTP",
+			cfg:  tracer.Config{Exporter: tracer.ExporterOTLPHTTP, Insecure: true, Sampler: tracer.SamplerTraceIDRatio},
+		},
+		{
+			name: "Stdout",
+			cfg:  tracer.Config{Exporter: tracer.ExporterStdout, Sampler: tracer.SamplerAlwaysOff},
+		},
+		{
+			name: "None",
+			cfg:  tracer.Config{Exporter: tracer.ExporterNone, Sampler: tracer.SamplerParentBasedAlwaysOff},
+		},
+		{
+			name:    "Unsupported exporter",
+			cfg:     tracer.Config{Exporter: "zipkin", Sampler: tracer.SamplerAlwaysOn},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported sampler",
+			cfg:     tracer.Config{Exporter: tracer.ExporterNone, Sampler: "sometimes"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := tracer.New(context.Background(), tt.cfg)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.NotNil(t, tp)
+				assert.Nil(t, tp.Shutdown(context.Background()))
+			}
+		})
+	}
+}
+
+func TestInitWithInvalidConfig(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", "zipkin")
+	tp := tracer.Init()
+	assert.NotNil(t, tp)
+	assert.Nil(t, tp.Shutdown(context.Background()))
+}
+
+func TestGraphQL(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := graphql.WithOperationContext(context.Background(), &graphql.OperationContext{
+		OperationName: "Me",
+		Operation:     &ast.OperationDefinition{Operation: ast.Query},
+		RawQuery:      `query Me { me(token: "secret123") { id } }`,
+	})
+	ext := tracer.GraphQL{}
+	ext.InterceptResponse(ctx, func(ctx context.Context) *graphql.Response {
+		fieldCtx := graphql.WithFieldContext(ctx, &graphql.FieldContext{
+			Object:     "Query",
+			Field:      graphql.CollectedField{Field: &ast.Field{Name: "me", Alias: "me"}},
+			IsResolver: true,
+		})
+		_, err := ext.InterceptField(fieldCtx, func(ctx context.Context) (interface{}, error) {
+			return nil, errors.New("user not found")
+		})
+		assert.NotNil(t, err)
+		return &graphql.Response{}
+	})
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	field, operation := spans[0], spans[1]
+	assert.Equal(t, "query Me", operation.Name())
+	assert.Equal(t, "Query.me", field.Name())
+	assert.Equal(t, operation.SpanContext().SpanID(), field.Parent().SpanID())
+	assert.Equal(t, codes.Error, field.Status().Code)
+	assert.Contains(t, operation.Attributes(),
+		attribute.String("graphql.document", `query Me { me(token: "[REDACTED]") { id } }`))
+}
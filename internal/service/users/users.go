@@ -0,0 +1,128 @@
+// Package users holds the business logic of the user accounts, the resolvers only map it to
+// and from GraphQL
+package users
+
+import (
+	"context"
+	"database/sql"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/repository"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/resultwrapper"
+	"go-template/pkg/utl/secure"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Events records the changes made to the users in the transaction that made them
+type Events interface {
+	RecordUser(ctx context.Context, tx *sql.Tx, eventType fm.EventType, user *models.User) error
+}
+
+// Changes are the profile fields a user updates, nil fields are left as they are
+type Changes struct {
+	FirstName *string
+	LastName  *string
+	Mobile    *string
+	Address   *string
+}
+
+// Service manages the users
+type Service struct {
+	users  repository.UserRepository
+	tx     repository.Transactor
+	secure secure.Service
+	events Events
+}
+
+// New returns the users service
+func New(users repository.UserRepository, tx repository.Transactor, sec secure.Service, events Events) *Service {
+	return &Service{users: users, tx: tx, secure: sec, events: events}
+}
+
+// Get returns the user userID
+func (s *Service) Get(ctx context.Context, userID int) (*models.User, error) {
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return u, nil
+}
+
+// List returns the users of page and the total number of users
+func (s *Service) List(ctx context.Context, page repository.Page) (models.UserSlice, int64, error) {
+	users, count, err := s.users.FindAll(ctx, page)
+	if err != nil {
+		return nil, 0, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return users, count, nil
+}
+
+// Create stores user with its password hashed
+func (s *Service) Create(ctx context.Context, user models.User) (*models.User, error) {
+	user.Password = null.StringFrom(s.secure.Hash(user.Password.String))
+	var created models.User
+	err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		created, err = s.users.Create(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+		return s.events.RecordUser(ctx, tx, fm.EventTypeCreated, &created)
+	})
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "user information")
+	}
+	return &created, nil
+}
+
+// Update applies changes to the user userID
+func (s *Service) Update(ctx context.Context, userID int, changes Changes) (*models.User, error) {
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperror.NewNotFound("user not found")
+	}
+	for _, change := range []struct {
+		value *string
+		dst   *null.String
+	}{
+		{changes.FirstName, &u.FirstName},
+		{changes.LastName, &u.LastName},
+		{changes.Mobile, &u.Mobile},
+		{changes.Address, &u.Address},
+	} {
+		if change.value != nil {
+			*change.dst = null.StringFromPtr(change.value)
+		}
+	}
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.users.Update(ctx, tx, *u); err != nil {
+			return err
+		}
+		return s.events.RecordUser(ctx, tx, fm.EventTypeUpdated, u)
+	})
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "new information")
+	}
+	return u, nil
+}
+
+// Delete deletes the user userID and returns it
+func (s *Service) Delete(ctx context.Context, userID int) (*models.User, error) {
+	u, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.users.Delete(ctx, tx, *u); err != nil {
+			return err
+		}
+		return s.events.RecordUser(ctx, tx, fm.EventTypeDeleted, u)
+	})
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "user")
+	}
+	return u, nil
+}
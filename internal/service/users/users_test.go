@@ -0,0 +1,141 @@
+package users_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"errors"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/repository"
+	"go-template/internal/service/users"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/secure"
+	"go-template/testutls"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+// events collects the recorded events, failing when err is set
+type events struct {
+	types []fm.EventType
+	err   error
+}
+
+func (e *events) RecordUser(_ context.Context, _ *sql.Tx, eventType fm.EventType, _ *models.User) error {
+	e.types = append(e.types, eventType)
+	return e.err
+}
+
+func newService(recorder *events) (*users.Service, *repository.MemoryUsers) {
+	repo := repository.NewMemoryUsers(*testutls.MockUser())
+	return users.New(repo, repository.MemoryTransactor{}, secure.New(1, sha1.New()), recorder), repo
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		username   string
+		eventErr   error
+		wantCode   apperror.Code
+		wantEvents []fm.EventType
+	}{
+		{name: "Success", username: "new", wantEvents: []fm.EventType{fm.EventTypeCreated}},
+		{name: "Duplicate", username: testutls.MockUser().Username.String, wantCode: apperror.Conflict},
+		{name: "EventError", username: "new", eventErr: errors.New("outbox"), wantCode: apperror.Internal,
+			wantEvents: []fm.EventType{fm.EventTypeCreated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &events{err: tt.eventErr}
+			s, _ := newService(recorder)
+			u, err := s.Create(context.Background(), models.User{
+				Username: null.StringFrom(tt.username),
+				Password: null.StringFrom("pass123"),
+			})
+			assert.Equal(t, tt.wantEvents, recorder.types)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, "pass123", u.Password.String)
+			assert.True(t, secure.New(1, sha1.New()).HashMatchesPassword(u.Password.String, "pass123"))
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	firstName := "Updated"
+	tests := []struct {
+		name     string
+		userID   int
+		wantCode apperror.Code
+	}{
+		{name: "Success", userID: testutls.MockID},
+		{name: "NotFound", userID: 9, wantCode: apperror.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &events{}
+			s, repo := newService(recorder)
+			u, err := s.Update(context.Background(), tt.userID, users.Changes{FirstName: &firstName})
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				assert.Empty(t, recorder.types)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, firstName, u.FirstName.String)
+			// the fields left out are kept
+			assert.Equal(t, testutls.MockUser().LastName, u.LastName)
+			stored, _ := repo.FindByID(context.Background(), tt.userID)
+			assert.Equal(t, firstName, stored.FirstName.String)
+			assert.Equal(t, []fm.EventType{fm.EventTypeUpdated}, recorder.types)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int
+		wantCode apperror.Code
+	}{
+		{name: "Success", userID: testutls.MockID},
+		{name: "NotFound", userID: 9, wantCode: apperror.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &events{}
+			s, repo := newService(recorder)
+			u, err := s.Delete(context.Background(), tt.userID)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.userID, u.ID)
+			_, err = repo.FindByID(context.Background(), tt.userID)
+			assert.ErrorIs(t, err, sql.ErrNoRows)
+			assert.Equal(t, []fm.EventType{fm.EventTypeDeleted}, recorder.types)
+		})
+	}
+}
+
+func TestGetAndList(t *testing.T) {
+	s, _ := newService(&events{})
+	u, err := s.Get(context.Background(), testutls.MockID)
+	assert.NoError(t, err)
+	assert.Equal(t, testutls.MockUser().Username, u.Username)
+	_, err = s.Get(context.Background(), 9)
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+
+	list, total, err := s.List(context.Background(), repository.Page{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, list, 1)
+}
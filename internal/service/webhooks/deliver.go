@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/service/jobs"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// KindDeliver is the kind of the jobs sending deliveries
+const KindDeliver = "deliver_webhook"
+
+// maxResponseBody is the number of bytes of the response kept in the delivery log
+const maxResponseBody = 1024
+
+// Deliver is the payload of KindDeliver jobs
+type Deliver struct {
+	DeliveryID int64 `json:"deliveryId"`
+}
+
+// RegisterHandlers registers the handler sending the deliveries
+func RegisterHandlers(r *jobs.Registry, opts Options) {
+	client := &http.Client{Timeout: opts.Timeout}
+	jobs.Handle(r, KindDeliver, func(ctx context.Context, payload Deliver) error {
+		return deliver(ctx, client, payload.DeliveryID)
+	})
+}
+
+// deliver POSTs a delivery to its webhook and records the outcome, an error makes the job
+// retry it
+func deliver(ctx context.Context, client *http.Client, deliveryID int64) error {
+	delivery, err := daos.FindWebhookDeliveryByID(deliveryID, ctx)
+	if err != nil {
+		return err
+	}
+	webhook, err := daos.FindWebhookByID(delivery.WebhookID, ctx)
+	if err != nil {
+		return err
+	}
+	if !webhook.Active {
+		// the webhook was disabled after the event, give the delivery up
+		return daos.RecordWebhookAttempt(delivery.ID, daos.WebhookAttempt{
+			Status: daos.WebhookDeliveryFailed,
+			Error:  null.StringFrom("webhook is disabled"),
+		}, ctx)
+	}
+
+	start := time.Now()
+	attempt, sendErr := send(ctx, client, webhook, delivery)
+	attempt.Duration = time.Since(start)
+	if sendErr != nil {
+		attempt.Status = daos.WebhookDeliveryFailed
+		attempt.Error = null.StringFrom(sendErr.Error())
+	} else {
+		attempt.Status = daos.WebhookDeliverySucceeded
+	}
+	if err := daos.RecordWebhookAttempt(delivery.ID, attempt, ctx); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func send(ctx context.Context, client *http.Client, webhook *daos.Webhook,
+	delivery *daos.WebhookDelivery) (daos.WebhookAttempt, error) {
+	var attempt daos.WebhookAttempt
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return attempt, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-template-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return attempt, err
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	attempt.ResponseStatus = null.IntFrom(res.StatusCode)
+	// postgres text can hold neither invalid UTF-8 nor NUL bytes
+	attempt.ResponseBody = null.StringFrom(strings.ReplaceAll(strings.ToValidUTF8(string(body), "\uFFFD"), "\x00", ""))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return attempt, fmt.Errorf("webhook responded with %s", res.Status)
+	}
+	return attempt, nil
+}
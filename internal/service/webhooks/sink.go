@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"go-template/daos"
+	"go-template/internal/service/jobs"
+	"go-template/internal/service/outbox"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Sink fans the outbox events out to the webhooks subscribed to them, it is plugged in the
+// outbox relay. An event relayed twice is delivered once per webhook.
+type Sink struct {
+	Options Options
+}
+
+// Envelope is the body of a delivery
+type Envelope struct {
+	Event   string          `json:"event"`
+	EventID int64           `json:"eventId"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Publish ...
+func (s Sink) Publish(ctx context.Context, event outbox.Event) error {
+	var payload struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil || payload.Type == "" {
+		// not a lifecycle event
+		return nil
+	}
+	eventType := EventType(event.Topic, payload.Type)
+	webhooks, err := daos.FindActiveWebhooksForEvent(eventType, ctx)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	body, err := json.Marshal(Envelope{Event: eventType, EventID: event.ID, Data: event.Payload})
+	if err != nil {
+		return err
+	}
+	return daos.WithTx(ctx, func(tx *sql.Tx) error {
+		for _, webhook := range webhooks {
+			if _, err := s.enqueue(ctx, tx, daos.WebhookDelivery{
+				WebhookID: webhook.ID,
+				EventID:   null.Int64From(event.ID),
+				EventType: eventType,
+				Payload:   body,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Redeliver sends the body of a delivery again as a new delivery, which is returned
+func (s Sink) Redeliver(ctx context.Context, deliveryID int64) (*daos.WebhookDelivery, error) {
+	delivery, err := daos.FindWebhookDeliveryByID(deliveryID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var id int64
+	err = daos.WithTx(ctx, func(tx *sql.Tx) error {
+		id, err = s.enqueue(ctx, tx, daos.WebhookDelivery{
+			WebhookID:    delivery.WebhookID,
+			EventID:      delivery.EventID,
+			RedeliveryOf: null.Int64From(delivery.ID),
+			EventType:    delivery.EventType,
+			Payload:      delivery.Payload,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return daos.FindWebhookDeliveryByID(id, ctx)
+}
+
+// enqueue creates the delivery and the job sending it, an existing delivery isn't sent again
+func (s Sink) enqueue(ctx context.Context, tx *sql.Tx, delivery daos.WebhookDelivery) (int64, error) {
+	id, err := daos.CreateWebhookDeliveryTx(delivery, ctx, tx)
+	if err != nil || id == 0 {
+		return id, err
+	}
+	maxAttempts := s.Options.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = jobs.DefaultMaxAttempts
+	}
+	_, err = jobs.EnqueueTx(ctx, tx, KindDeliver, Deliver{DeliveryID: id}, jobs.MaxAttempts(maxAttempts))
+	return id, err
+}
@@ -0,0 +1,97 @@
+// Package webhooks delivers the user and role events to the endpoints registered by the
+// admins. Events reach the package through the outbox, every matching webhook gets a
+// delivery that a job POSTs, signed with the secret of the webhook, and retries with the
+// backoff of the job queue.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// EventType names the events webhooks subscribe to, e.g. user.created
+func EventType(topic, eventType string) string {
+	return topic + "." + strings.ToLower(eventType)
+}
+
+// Sign returns the signature of a delivery of body sent at timestamp: the hex encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with secret, prefixed with sha256=
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a delivery, deliveries older than
+// tolerance are rejected to prevent replays
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp")
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("timestamp is outside of the tolerance")
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// NewSecret returns a random secret for a webhook registered without one
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Options configure the deliveries
+type Options struct {
+	// Timeout of a delivery request
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts of a delivery before it is given up
+	MaxAttempts int
+}
+
+// OptionsFromEnv reads WEBHOOK_TIMEOUT_SECONDS and WEBHOOK_MAX_ATTEMPTS
+func OptionsFromEnv() (Options, error) {
+	opts := Options{Timeout: 10 * time.Second, MaxAttempts: 8}
+	for _, v := range []struct {
+		key string
+		set func(n int)
+	}{
+		{"WEBHOOK_TIMEOUT_SECONDS", func(n int) { opts.Timeout = time.Duration(n) * time.Second }},
+		{"WEBHOOK_MAX_ATTEMPTS", func(n int) { opts.MaxAttempts = n }},
+	} {
+		value := os.Getenv(v.key)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("%s must be a positive integer, got %q", v.key, value)
+		}
+		v.set(n)
+	}
+	return opts, nil
+}
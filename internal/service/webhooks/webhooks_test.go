@@ -0,0 +1,259 @@
+package webhooks_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	"go-template/internal/service/jobs"
+	"go-template/internal/service/outbox"
+	"go-template/internal/service/webhooks"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+const secret = "0123456789abcdef"
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"event":"user.created"}`)
+	signature := webhooks.Sign(secret, now.Unix(), body)
+	assert.Equal(t, 71, len(signature))
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		wantErr   bool
+	}{
+		{name: "Valid", timestamp: "1700000000", signature: signature, body: body},
+		{name: "Tampered body", timestamp: "1700000000", signature: signature, body: []byte(`{}`), wantErr: true},
+		{name: "Replayed timestamp", timestamp: "1699999000", signature: signature, body: body, wantErr: true},
+		{name: "Invalid timestamp", timestamp: "yesterday", signature: signature, body: body, wantErr: true},
+		{name: "Wrong secret", timestamp: "1700000000", signature: webhooks.Sign("other", now.Unix(), body),
+			body: body, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhooks.Verify(secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute, now)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_TIMEOUT_SECONDS", "3")
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "")
+	opts, err := webhooks.OptionsFromEnv()
+	assert.Nil(t, err)
+	assert.Equal(t, webhooks.Options{Timeout: 3 * time.Second, MaxAttempts: 8}, opts)
+
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "-1")
+	_, err = webhooks.OptionsFromEnv()
+	assert.NotNil(t, err)
+}
+
+func TestPublish(t *testing.T) {
+	tests := []struct {
+		name          string
+		event         outbox.Event
+		webhooks      []daos.Webhook
+		wantEventType string
+		wantEnqueued  int
+	}{
+		{
+			name:          "Fans out to the subscribed webhooks",
+			event:         outbox.Event{ID: 9, Topic: "user", Payload: json.RawMessage(`{"type":"CREATED"}`)},
+			webhooks:      []daos.Webhook{{ID: 1}, {ID: 2}},
+			wantEventType: "user.created",
+			wantEnqueued:  2,
+		},
+		{
+			name:          "No webhook",
+			event:         outbox.Event{ID: 9, Topic: "role", Payload: json.RawMessage(`{"type":"DELETED"}`)},
+			wantEventType: "role.deleted",
+		},
+		{
+			name:  "Not a lifecycle event",
+			event: outbox.Event{ID: 9, Topic: "audit", Payload: json.RawMessage(`[]`)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				eventType  string
+				deliveries []daos.WebhookDelivery
+				enqueued   []webhooks.Deliver
+			)
+			patches := gomonkey.ApplyFunc(daos.FindActiveWebhooksForEvent,
+				func(e string, ctx context.Context) ([]daos.Webhook, error) {
+					eventType = e
+					return tt.webhooks, nil
+				})
+			defer patches.Reset()
+			patches.ApplyFunc(daos.WithTx, func(ctx context.Context, fn func(tx *sql.Tx) error) error {
+				return fn(nil)
+			})
+			patches.ApplyFunc(daos.CreateWebhookDeliveryTx,
+				func(d daos.WebhookDelivery, ctx context.Context, tx *sql.Tx) (int64, error) {
+					deliveries = append(deliveries, d)
+					return int64(len(deliveries)), nil
+				})
+			patches.ApplyFunc(jobs.EnqueueTx, func(ctx context.Context, tx *sql.Tx, kind string, payload interface{},
+				opts ...jobs.Option) (int64, error) {
+				assert.Equal(t, webhooks.KindDeliver, kind)
+				enqueued = append(enqueued, payload.(webhooks.Deliver))
+				return 1, nil
+			})
+
+			err := webhooks.Sink{Options: webhooks.Options{MaxAttempts: 3}}.Publish(context.Background(), tt.event)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantEventType, eventType)
+			assert.Len(t, enqueued, tt.wantEnqueued)
+			for i, d := range deliveries {
+				assert.Equal(t, null.Int64From(9), d.EventID)
+				var envelope webhooks.Envelope
+				assert.Nil(t, json.Unmarshal(d.Payload, &envelope))
+				assert.Equal(t, webhooks.Envelope{Event: "user.created", EventID: 9, Data: tt.event.Payload}, envelope)
+				assert.Equal(t, int64(i+1), enqueued[i].DeliveryID)
+			}
+		})
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	original := daos.WebhookDelivery{
+		ID: 3, WebhookID: 1, EventID: null.Int64From(9), EventType: "user.created", Payload: []byte(`{}`),
+		Status: daos.WebhookDeliveryFailed,
+	}
+	var created daos.WebhookDelivery
+	patches := gomonkey.ApplyFunc(daos.FindWebhookDeliveryByID, func(id int64, ctx context.Context) (*daos.WebhookDelivery, error) {
+		if id == original.ID {
+			return &original, nil
+		}
+		created.ID = id
+		return &created, nil
+	})
+	defer patches.Reset()
+	patches.ApplyFunc(daos.WithTx, func(ctx context.Context, fn func(tx *sql.Tx) error) error {
+		return fn(nil)
+	})
+	patches.ApplyFunc(daos.CreateWebhookDeliveryTx,
+		func(d daos.WebhookDelivery, ctx context.Context, tx *sql.Tx) (int64, error) {
+			created = d
+			return 4, nil
+		})
+	patches.ApplyFunc(jobs.EnqueueTx, func(ctx context.Context, tx *sql.Tx, kind string, payload interface{},
+		opts ...jobs.Option) (int64, error) {
+		assert.Equal(t, webhooks.Deliver{DeliveryID: 4}, payload)
+		return 1, nil
+	})
+
+	delivery, err := webhooks.Sink{}.Redeliver(context.Background(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), delivery.ID)
+	assert.Equal(t, null.Int64From(3), delivery.RedeliveryOf)
+	assert.Equal(t, original.Payload, delivery.Payload)
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name       string
+		active     bool
+		status     int
+		wantStatus string
+		wantFailed bool
+	}{
+		{name: "Success", active: true, status: http.StatusNoContent, wantStatus: daos.WebhookDeliverySucceeded},
+		{name: "Error response is retried", active: true, status: http.StatusBadGateway,
+			wantStatus: daos.WebhookDeliveryFailed, wantFailed: true},
+		{name: "Disabled webhook is given up", active: false, wantStatus: daos.WebhookDeliveryFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"event":"user.created","eventId":9,"data":{}}`)
+			var headers http.Header
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header
+				got, _ := io.ReadAll(r.Body)
+				assert.Equal(t, body, got)
+				assert.Nil(t, webhooks.Verify(secret, r.Header.Get(webhooks.HeaderTimestamp),
+					r.Header.Get(webhooks.HeaderSignature), got, time.Minute, time.Now()))
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			var (
+				attempt   daos.WebhookAttempt
+				completed bool
+				failed    error
+			)
+			patches := gomonkey.ApplyFunc(daos.FindWebhookDeliveryByID,
+				func(id int64, ctx context.Context) (*daos.WebhookDelivery, error) {
+					return &daos.WebhookDelivery{ID: id, WebhookID: 1, EventType: "user.created", Payload: body}, nil
+				})
+			defer patches.Reset()
+			patches.ApplyFunc(daos.FindWebhookByID, func(id int, ctx context.Context) (*daos.Webhook, error) {
+				return &daos.Webhook{ID: id, URL: ts.URL, Secret: secret, Active: tt.active}, nil
+			})
+			patches.ApplyFunc(daos.RecordWebhookAttempt, func(id int64, a daos.WebhookAttempt, ctx context.Context) error {
+				attempt = a
+				return nil
+			})
+			patches.ApplyFunc(daos.CompleteJob, func(id int64, ctx context.Context) error {
+				completed = true
+				return nil
+			})
+			patches.ApplyFunc(daos.FailJob,
+				func(id int64, cause error, retryIn time.Duration, dead bool, ctx context.Context) error {
+					failed = cause
+					return nil
+				})
+
+			registry := jobs.NewRegistry()
+			webhooks.RegisterHandlers(registry, webhooks.Options{Timeout: time.Second})
+			jobs.NewWorker(registry, jobs.Options{Concurrency: 1, MaxBackoff: time.Hour}).Run(context.Background(),
+				daos.Job{ID: 1, Kind: webhooks.KindDeliver, Payload: []byte(`{"deliveryId":7}`), Attempts: 1,
+					MaxAttempts: 3})
+
+			assert.Equal(t, tt.wantStatus, attempt.Status)
+			assert.Equal(t, tt.wantFailed, failed != nil)
+			assert.Equal(t, !tt.wantFailed, completed)
+			if tt.active {
+				assert.Equal(t, null.IntFrom(tt.status), attempt.ResponseStatus)
+				assert.Equal(t, "user.created", headers.Get(webhooks.HeaderEvent))
+				assert.Equal(t, strconv.Itoa(7), headers.Get(webhooks.HeaderDelivery))
+			}
+		})
+	}
+}
+
+func TestDeliverLookupError(t *testing.T) {
+	patches := gomonkey.ApplyFunc(daos.FindWebhookDeliveryByID,
+		func(id int64, ctx context.Context) (*daos.WebhookDelivery, error) {
+			return nil, errors.New("connection refused")
+		})
+	defer patches.Reset()
+	var failed error
+	patches.ApplyFunc(daos.FailJob, func(id int64, cause error, retryIn time.Duration, dead bool, ctx context.Context) error {
+		failed = cause
+		return nil
+	})
+
+	registry := jobs.NewRegistry()
+	webhooks.RegisterHandlers(registry, webhooks.Options{Timeout: time.Second})
+	jobs.NewWorker(registry, jobs.Options{Concurrency: 1}).Run(context.Background(),
+		daos.Job{ID: 1, Kind: webhooks.KindDeliver, Payload: []byte(`{"deliveryId":7}`), Attempts: 1, MaxAttempts: 3})
+	assert.EqualError(t, failed, "connection refused")
+}
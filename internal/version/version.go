@@ -0,0 +1,49 @@
+// Package version holds the build information of the binaries. The values are set at
+// build time, for example
+//
+//	go build -ldflags "-X go-template/internal/version.Version=1.2.0 -X go-template/internal/version.Commit=$(git rev-parse HEAD)"
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	// Version is the released version of the build
+	Version = "dev"
+	// Commit is the git commit the build was made from
+	Commit = ""
+	// BuildTime is the time the build was made at, in RFC 3339
+	BuildTime = ""
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information, falling back to the vcs details recorded by the Go
+// toolchain when they weren't set with -ldflags
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	return info
+}
@@ -40,6 +40,7 @@ type User struct {
 	CreatedAt          null.Time   `boil:"created_at" json:"created_at,omitempty" toml:"created_at" yaml:"created_at,omitempty"`
 	UpdatedAt          null.Time   `boil:"updated_at" json:"updated_at,omitempty" toml:"updated_at" yaml:"updated_at,omitempty"`
 	DeletedAt          null.Time   `boil:"deleted_at" json:"deleted_at,omitempty" toml:"deleted_at" yaml:"deleted_at,omitempty"`
+	TokenIssuedAt      null.Time   `boil:"token_issued_at" json:"token_issued_at,omitempty" toml:"token_issued_at" yaml:"token_issued_at,omitempty"`
 
 	R *userR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L userL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -62,6 +63,7 @@ var UserColumns = struct {
 	CreatedAt          string
 	UpdatedAt          string
 	DeletedAt          string
+	TokenIssuedAt      string
 }{
 	ID:                 "id",
 	FirstName:          "first_name",
@@ -79,6 +81,7 @@ var UserColumns = struct {
 	CreatedAt:          "created_at",
 	UpdatedAt:          "updated_at",
 	DeletedAt:          "deleted_at",
+	TokenIssuedAt:      "token_issued_at",
 }
 
 var UserTableColumns = struct {
@@ -98,6 +101,7 @@ var UserTableColumns = struct {
 	CreatedAt          string
 	UpdatedAt          string
 	DeletedAt          string
+	TokenIssuedAt      string
 }{
 	ID:                 "users.id",
 	FirstName:          "users.first_name",
@@ -115,6 +119,7 @@ var UserTableColumns = struct {
 	CreatedAt:          "users.created_at",
 	UpdatedAt:          "users.updated_at",
 	DeletedAt:          "users.deleted_at",
+	TokenIssuedAt:      "users.token_issued_at",
 }
 
 // Generated where
@@ -208,6 +213,7 @@ var UserWhere = struct {
 	CreatedAt          whereHelpernull_Time
 	UpdatedAt          whereHelpernull_Time
 	DeletedAt          whereHelpernull_Time
+	TokenIssuedAt      whereHelpernull_Time
 }{
 	ID:                 whereHelperint{field: "\"users\".\"id\""},
 	FirstName:          whereHelpernull_String{field: "\"users\".\"first_name\""},
@@ -225,6 +231,7 @@ var UserWhere = struct {
 	CreatedAt:          whereHelpernull_Time{field: "\"users\".\"created_at\""},
 	UpdatedAt:          whereHelpernull_Time{field: "\"users\".\"updated_at\""},
 	DeletedAt:          whereHelpernull_Time{field: "\"users\".\"deleted_at\""},
+	TokenIssuedAt:      whereHelpernull_Time{field: "\"users\".\"token_issued_at\""},
 }
 
 // UserRels is where relationship names are stored.
@@ -255,9 +262,9 @@ func (r *userR) GetRole() *Role {
 type userL struct{}
 
 var (
-	userAllColumns            = []string{"id", "first_name", "last_name", "username", "password", "email", "mobile", "address", "active", "last_login", "last_password_change", "token", "role_id", "created_at", "updated_at", "deleted_at"}
+	userAllColumns            = []string{"id", "first_name", "last_name", "username", "password", "email", "mobile", "address", "active", "last_login", "last_password_change", "token", "role_id", "created_at", "updated_at", "deleted_at", "token_issued_at"}
 	userColumnsWithoutDefault = []string{}
-	userColumnsWithDefault    = []string{"id", "first_name", "last_name", "username", "password", "email", "mobile", "address", "active", "last_login", "last_password_change", "token", "role_id", "created_at", "updated_at", "deleted_at"}
+	userColumnsWithDefault    = []string{"id", "first_name", "last_name", "username", "password", "email", "mobile", "address", "active", "last_login", "last_password_change", "token", "role_id", "created_at", "updated_at", "deleted_at", "token_issued_at"}
 	userPrimaryKeyColumns     = []string{"id"}
 	userGeneratedColumns      = []string{}
 )
@@ -2,17 +2,40 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	"go-template/daos"
 	graphql "go-template/gqlmodels"
 	"go-template/internal/config"
+	"go-template/internal/controller"
+	"go-template/internal/health"
 	"go-template/internal/jwt"
 	authMw "go-template/internal/middleware/auth"
+	"go-template/internal/migrations"
 	"go-template/internal/postgres"
+	"go-template/internal/repository"
+	"go-template/internal/secrets"
 	"go-template/internal/server"
+	"go-template/internal/service"
+	"go-template/internal/service/features"
+	"go-template/internal/service/metrics"
+	"go-template/internal/service/outbox"
+	"go-template/internal/service/pubsub"
+	"go-template/internal/service/reporter"
+	"go-template/internal/service/roles"
+	"go-template/internal/service/settings"
+	"go-template/internal/service/tracer"
+	"go-template/internal/service/users"
+	"go-template/internal/service/webhooks"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/rediscache"
 	throttle "go-template/pkg/utl/throttle"
+	"go-template/pkg/utl/zaplog"
 	"go-template/resolver"
 
 	graphql2 "github.com/99designs/gqlgen/graphql"
@@ -24,45 +47,128 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq" // here
+	migrate "github.com/rubenv/sql-migrate"
 
+	"github.com/vektah/gqlparser/v2/ast"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
+const defaultReplicaHealthCheck = 5 * time.Second
+
+// newResolver builds the services once and wires them into the resolver. The users are
+// read from redis only where a stale record is harmless: the services writing a user back
+// load it from the database.
+func newResolver(
+	tokens jwt.Service,
+	ps pubsub.PubSub,
+	hooks webhooks.Sink,
+	store *settings.Store,
+	flags *features.Service,
+) *resolver.Resolver {
+	userRepo := repository.PostgresUsers{}
+	roleRepo := repository.CachedRoles{RoleRepository: repository.PostgresRoles{}}
+	tx := repository.PostgresTransactor{}
+	sec := service.SecureWithSettings(store)
+	events := resolver.Events{}
+	return &resolver.Resolver{
+		AuthService:   service.NewAuth(userRepo, roleRepo, sec, tokens),
+		UserService:   users.New(userRepo, tx, sec, events),
+		RoleService:   roles.New(roleRepo, repository.CachedUsers{UserRepository: userRepo}, tx, events),
+		PubSub:        ps,
+		Webhooks:      hooks,
+		SettingsStore: store,
+		Features:      flags,
+	}
+}
+
 // Start starts the API service
 func Start(cfg *config.Configuration) (*echo.Echo, error) {
 	// Initialize Echo instance
 	e := server.New()
 
+	// Set up the secrets, the rotated credentials are applied without a restart
+	watcher, err := secrets.WatcherFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the migrations when MIGRATE_ON_START is set
+	if err := setupMigrations(cfg, watcher); err != nil {
+		return nil, err
+	}
+
 	// Set up database connection
-	if err := setupDatabase(); err != nil {
+	db, err := setupDatabase(cfg.DB, watcher)
+	if err != nil {
+		return nil, err
+	}
+	replicas, err := setupReplicas(cfg.DB)
+	if err != nil {
 		return nil, err
 	}
 
+	// Set up health checks
+	checker := setupHealth(e, db)
+
 	// Set up JWT
-	jwt, err := jwt.New(
-		cfg.JWT.SigningAlgorithm,
-		os.Getenv("JWT_SECRET"),
-		cfg.JWT.DurationMinutes,
-		cfg.JWT.MinSecretLength)
+	jwt, err := setupJWT(cfg, watcher)
+	if err != nil {
+		return nil, err
+	}
+	watcher.Start()
+
+	// Set up the runtime settings, they are reloaded from the database and the settings file
+	store, err := setupSettings(cfg)
 	if err != nil {
 		return nil, err
 	}
+
+	// Set up the feature flags
+	flags, err := setupFeatures()
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up error reporting
+	sink, err := reporter.New(os.Getenv("ERROR_REPORTING_DSN"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up subscriptions
+	ps, err := setupPubSub()
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up the outbox relay, it also fans the events out to the webhooks
+	webhookOpts, err := webhooks.OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	hooks := webhooks.Sink{Options: webhookOpts}
+	relay, err := setupOutbox(ps, hooks)
 	if err != nil {
 		return nil, err
 	}
 
 	// Set up GraphQL
-	observers := map[string]chan *graphql.User{}
+	r := newResolver(jwt, ps, hooks, store, flags)
 	graphqlHandler := handler.New(graphql.NewExecutableSchema(graphql.Config{
-		Resolvers: &resolver.Resolver{Observers: observers},
+		Resolvers:  r,
+		Directives: graphql.DirectiveRoot{Feature: flags.Directive},
 	}))
 
+	graphqlHandler.SetRecoverFunc(reporter.RecoverFunc(sink))
+	graphqlHandler.SetErrorPresenter(apperror.Presenter(os.Getenv("ENVIRONMENT_NAME") == "production"))
+
 	graphqlHandler.AroundOperations(func(ctx context.Context, next graphql2.OperationHandler) graphql2.ResponseHandler {
-		return authMw.GraphQLMiddleware(ctx, jwt, next)
+		return authMw.GraphQLMiddleware(readSession(ctx), jwt, next)
 	})
 
 	graphqlHandler.AddTransport(transport.Websocket{
 		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              authMw.WebsocketInitFunc(jwt),
 		Upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -78,36 +184,285 @@ func Start(cfg *config.Configuration) (*echo.Echo, error) {
 	graphqlHandler.Use(extension.AutomaticPersistedQuery{
 		Cache: lru.New(100),
 	})
+	graphqlHandler.Use(metrics.GraphQL{})
+	graphqlHandler.Use(tracer.GraphQL{})
+
+	// Set up metrics
+	metricsServer, err := setupMetrics(e, r)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set up GraphQL endpoints
-	setupGraphQLEndpoints(e, graphqlHandler)
+	websockets := server.NewWebsockets()
+	setupGraphQLEndpoints(e, graphqlHandler, websockets)
 
 	// Set up GraphQL playground
-	setupGraphQLPlayground(e)
+	setupGraphQLPlayground(e, store)
 
 	// Start the server
 	server.Start(e, &server.Config{
-		Port:                cfg.Server.Port,
-		ReadTimeoutSeconds:  cfg.Server.ReadTimeout,
-		WriteTimeoutSeconds: cfg.Server.WriteTimeout,
-		Debug:               cfg.Server.Debug,
+		Port:                   cfg.Server.Port,
+		ReadTimeoutSeconds:     cfg.Server.ReadTimeout,
+		WriteTimeoutSeconds:    cfg.Server.WriteTimeout,
+		Debug:                  cfg.Server.Debug,
+		Health:                 checker,
+		ShutdownDelaySeconds:   cfg.Server.ShutdownDelay,
+		ShutdownTimeoutSeconds: cfg.Server.ShutdownTimeout,
+		Websockets:             websockets,
+		OnShutdown: []func(context.Context) error{
+			watcher.Stop,
+			store.Stop,
+			flags.Stop,
+			func(ctx context.Context) error {
+				if metricsServer == nil {
+					return nil
+				}
+				return metricsServer.Shutdown(ctx)
+			},
+			relay.Stop,
+			func(context.Context) error { return ps.Close() },
+			func(ctx context.Context) error {
+				if replicas == nil {
+					return nil
+				}
+				return replicas.Stop(ctx)
+			},
+			func(context.Context) error { return db.Close() },
+			func(context.Context) error { return rediscache.Close() },
+		},
 	})
 
 	return e, nil
 }
 
-func setupDatabase() error {
-	db, err := postgres.Connect()
+// setupDatabase connects to the primary with the credentials watched by watcher
+func setupDatabase(cfg *config.Database, watcher *secrets.Watcher) (*sql.DB, error) {
+	db, err := postgres.ConnectWatched(context.Background(), watcher, postgres.OptionsFromConfig(cfg))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	boil.SetDB(db)
-	if os.Getenv("ENVIRONMENT_NAME") == "local" {
-		boil.DebugMode = true
+	if err := metrics.RegisterDB(db, "postgres"); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// setupMigrations applies the embedded migrations when MIGRATE_ON_START is set. They run on
+// a dedicated pool, connected with the credentials of watcher, without the query timeouts
+// since migrations may take longer.
+func setupMigrations(cfg *config.Configuration, watcher *secrets.Watcher) error {
+	if !cfg.Server.MigrateOnStart {
+		return nil
 	}
+	creds, err := watcher.Read(context.Background(), postgres.CredentialKeys...)
+	if err != nil {
+		return err
+	}
+	opts := postgres.OptionsFromConfig(cfg.DB)
+	opts.QueryTimeout, opts.StatementTimeout = 0, 0
+	db, err := postgres.Open(postgres.DSN(creds), opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	applied, err := migrations.Migrate(context.Background(), db, migrations.Source())
+	if err != nil {
+		return fmt.Errorf("migrating the database: %w", err)
+	}
+	zaplog.Logger.Infow("migrations applied", "count", applied)
 	return nil
 }
 
-func setupGraphQLEndpoints(e *echo.Echo, graphqlHandler *handler.Server) {
+// setupJWT returns the JWT service signing with the JWT_SECRET of watcher, or of the
+// configuration when the provider doesn't hold it. Rotating the secret rotates the key.
+func setupJWT(cfg *config.Configuration, watcher *secrets.Watcher) (jwt.Service, error) {
+	var tokens *jwt.Service
+	err := watcher.Watch(context.Background(), []string{"JWT_SECRET"}, func(_ context.Context, values map[string]string) error {
+		if tokens != nil {
+			zaplog.Logger.Info("JWT secret rotated")
+			return tokens.SetSecret(values["JWT_SECRET"])
+		}
+		if secret := values["JWT_SECRET"]; secret != "" {
+			cfg.JWT.Secret = secret
+		}
+		s, err := service.JWT(cfg)
+		tokens = &s
+		return err
+	})
+	if err != nil {
+		return jwt.Service{}, err
+	}
+	return *tokens, nil
+}
+
+// setupSettings loads the runtime settings and keeps the level of the logger in sync with them
+func setupSettings(cfg *config.Configuration) (*settings.Store, error) {
+	opts, err := settings.OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	store := settings.NewStore(settings.Builtins(cfg), settings.Postgres{}, opts)
+	if err := store.Load(context.Background()); err != nil {
+		return nil, fmt.Errorf("loading the settings: %w", err)
+	}
+	settings.ApplyLogLevel(store)
+	store.Start()
+	return store, nil
+}
+
+// setupFeatures loads the feature flags, they are reloaded every FEATURE_FLAGS_REFRESH_SECONDS
+func setupFeatures() (*features.Service, error) {
+	interval, err := features.IntervalFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	flags := features.New(features.Postgres{}, interval)
+	if err := flags.Load(context.Background()); err != nil {
+		return nil, fmt.Errorf("loading the feature flags: %w", err)
+	}
+	flags.Start()
+	return flags, nil
+}
+
+// setupReplicas routes the reads to the replicas of DB_REPLICA_DSNS, it returns nil when
+// there are none
+func setupReplicas(cfg *config.Database) (*daos.Router, error) {
+	if cfg == nil || len(cfg.ReplicaDSNs) == 0 {
+		return nil, nil
+	}
+	opts := postgres.OptionsFromConfig(cfg)
+	replicas := make([]*daos.Replica, 0, len(cfg.ReplicaDSNs))
+	for i, dsn := range cfg.ReplicaDSNs {
+		db, err := postgres.Open(dsn, opts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica %d: %w", i, err)
+		}
+		name := fmt.Sprintf("replica-%d", i)
+		if err := metrics.RegisterDB(db, name); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, &daos.Replica{Name: name, DB: db})
+	}
+	interval := defaultReplicaHealthCheck
+	if cfg.ReplicaHealthCheck > 0 {
+		interval = time.Duration(cfg.ReplicaHealthCheck) * time.Second
+	}
+	router := daos.NewRouter(replicas, health.DefaultTimeout)
+	router.Start(interval)
+	daos.SetRouter(router)
+	return router, nil
+}
+
+// readSession gives the operation of ctx a read session, the reads of mutations go to the
+// primary so that they see their own writes
+func readSession(ctx context.Context) context.Context {
+	ctx = daos.WithSession(ctx)
+	if op := graphql2.GetOperationContext(ctx).Operation; op != nil && op.Operation == ast.Mutation {
+		daos.MarkWrite(ctx)
+	}
+	return ctx
+}
+
+// setupPubSub returns the PubSub selected by PUBSUB_DRIVER: redis (default) delivers
+// events to the subscribers of every instance, memory only to those of this instance
+func setupPubSub() (pubsub.PubSub, error) {
+	opts, err := pubsub.OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	switch driver := os.Getenv("PUBSUB_DRIVER"); driver {
+	case "", "redis":
+		return pubsub.NewRedis(rediscache.Pool(), opts), nil
+	case "memory":
+		return pubsub.NewMemory(opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported PUBSUB_DRIVER %q", driver)
+	}
+}
+
+// setupOutbox starts the relay publishing the outbox to the subscriptions, the webhooks and
+// the sink selected by OUTBOX_SINK
+func setupOutbox(ps pubsub.PubSub, hooks webhooks.Sink) (*outbox.Relay, error) {
+	opts, err := outbox.OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	stream := os.Getenv("OUTBOX_STREAM")
+	if stream == "" {
+		stream = "events"
+	}
+	sink, err := outbox.NewSink(os.Getenv("OUTBOX_SINK"), stream, rediscache.Pool())
+	if err != nil {
+		return nil, err
+	}
+	relay := outbox.NewRelay(outbox.Multi{
+		"subscriptions": outbox.PubSubSink{PubSub: ps},
+		"webhooks":      hooks,
+		"sink":          sink,
+	}, opts)
+	relay.Start()
+	return relay, nil
+}
+
+// setupHealth exposes /healthz for liveness and /readyz for readiness, the latter pings
+// Postgres and Redis and reports the schema version
+func setupHealth(e *echo.Echo, db *sql.DB) *health.Checker {
+	timeout := health.DefaultTimeout
+	if ms, err := strconv.Atoi(os.Getenv("HEALTH_CHECK_TIMEOUT_MS")); err == nil && ms > 0 {
+		timeout = time.Duration(ms) * time.Millisecond
+	}
+	checker := health.New(timeout)
+	checker.Register("postgres", db.PingContext)
+	checker.Register("redis", rediscache.Ping)
+	checker.SetSchema(schemaVersion(db, migrations.Source()))
+
+	e.GET("/healthz", controller.LivenessHandler(checker))
+	e.GET("/readyz", controller.ReadinessHandler(checker))
+	return checker
+}
+
+// schemaVersion reads the version of the schema of db against the migrations of src
+func schemaVersion(db *sql.DB, src migrate.MigrationSource) health.SchemaFunc {
+	return func(ctx context.Context) (health.Schema, error) {
+		v, err := migrations.ReadVersion(ctx, db, src)
+		if err != nil {
+			return health.Schema{}, err
+		}
+		return health.Schema{Version: v.Current, Pending: v.Pending, Unknown: v.Unknown}, nil
+	}
+}
+
+// setupMetrics exposes /metrics on the API server, or on a dedicated admin server when
+// METRICS_PORT is set, in which case that server is returned
+func setupMetrics(e *echo.Echo, r *resolver.Resolver) (*http.Server, error) {
+	if err := metrics.RegisterGauge("graphql_active_subscriptions",
+		"Number of clients subscribed to GraphQL subscriptions.",
+		func() float64 { return float64(r.ActiveSubscriptions()) }); err != nil {
+		return nil, err
+	}
+	if err := metrics.RegisterCounter("graphql_recovered_panics_total",
+		"Number of resolver panics recovered since the process started.",
+		func() float64 { return float64(reporter.RecoveredPanics()) }); err != nil {
+		return nil, err
+	}
+
+	port := os.Getenv("METRICS_PORT")
+	if port == "" {
+		e.GET("/metrics", echo.WrapHandler(metrics.Handler()))
+		return nil, nil
+	}
+	admin := metrics.NewAdminServer(":" + port)
+	go func() {
+		if err := admin.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zaplog.Logger.Error("metrics server stopped: ", err)
+		}
+	}()
+	return admin, nil
+}
+
+func setupGraphQLEndpoints(e *echo.Echo, graphqlHandler *handler.Server, websockets *server.Websockets) {
 	graphQLPathname := "/graphql"
 	gqlMiddleware := authMw.GqlMiddleware()
 	throttlerMiddleware := throttle.GqlMiddleware()
@@ -124,14 +479,17 @@ func setupGraphQLEndpoints(e *echo.Echo, graphqlHandler *handler.Server) {
 		res := c.Response()
 		graphqlHandler.ServeHTTP(res, req)
 		return nil
-	}, gqlMiddleware, throttlerMiddleware)
+	}, gqlMiddleware, throttlerMiddleware, websockets.Middleware())
 }
 
-func setupGraphQLPlayground(e *echo.Echo) {
+func setupGraphQLPlayground(e *echo.Echo, store *settings.Store) {
 	graphQLPathname := "/graphql"
 	playgroundHandler := playground.Handler("GraphQL playground", graphQLPathname)
 
 	e.GET("/playground", func(c echo.Context) error {
+		if !store.Bool(settings.PlaygroundEnabled) {
+			return echo.ErrNotFound
+		}
 		req := c.Request()
 		res := c.Response()
 		playgroundHandler.ServeHTTP(res, req)
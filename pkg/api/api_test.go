@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,17 +18,25 @@ import (
 
 	graphql "go-template/gqlmodels"
 	"go-template/internal/config"
+	"go-template/internal/migrations"
+	"go-template/internal/postgres"
+	"go-template/internal/secrets"
 	"go-template/internal/server"
+	"go-template/internal/service/features"
+	"go-template/internal/service/settings"
 	"go-template/resolver"
 	"go-template/testutls"
 
 	graphql2 "github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/agiledragon/gomonkey/v2"
+	jwtgo "github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq"
+	migrate "github.com/rubenv/sql-migrate"
 	"github.com/stretchr/testify/assert"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
@@ -74,7 +83,12 @@ func testStartServerSuccessCase() testStartServerType {
 				return e
 			}).ApplyFunc(boil.SetDB, func(db boil.Executor) {
 				fmt.Print("boil.SetDB called\n", tt.setDbCalled)
-			})
+			}).ApplyMethod(reflect.TypeOf(&settings.Store{}), "Load", func(*settings.Store, context.Context) error {
+				return nil
+			}).ApplyMethod(reflect.TypeOf(&settings.Store{}), "Start", func(*settings.Store) {}).
+				ApplyMethod(reflect.TypeOf(&features.Service{}), "Load", func(*features.Service, context.Context) error {
+					return nil
+				}).ApplyMethod(reflect.TypeOf(&features.Service{}), "Start", func(*features.Service) {})
 		},
 	}
 }
@@ -92,9 +106,8 @@ func testWithTransportCase() testStartServerType {
 		multipartFormTransportCalled: true,
 		websocketTransportCalled:     true,
 		init: func(e *echo.Echo, tt testStartServerType) *gomonkey.Patches {
-			observers := map[string]chan *graphql.User{}
 			graphqlHandler := handler.New(graphql.NewExecutableSchema(graphql.Config{
-				Resolvers: &resolver.Resolver{Observers: observers},
+				Resolvers: &resolver.Resolver{},
 			}))
 			return gomonkey.ApplyFunc(os.Getenv, func(key string) (value string) {
 				if key == "JWT_SECRET" {
@@ -110,7 +123,12 @@ func testWithTransportCase() testStartServerType {
 				return e
 			}).ApplyFunc(boil.SetDB, func(db boil.Executor) {
 				//mocking  boil.setdb
-			}).ApplyMethod(reflect.TypeOf(graphqlHandler), "AddTransport", func(s *handler.Server, t graphql2.Transport) {
+			}).ApplyMethod(reflect.TypeOf(&settings.Store{}), "Load", func(*settings.Store, context.Context) error {
+				return nil
+			}).ApplyMethod(reflect.TypeOf(&settings.Store{}), "Start", func(*settings.Store) {}).
+				ApplyMethod(reflect.TypeOf(&features.Service{}), "Load", func(*features.Service, context.Context) error {
+					return nil
+				}).ApplyMethod(reflect.TypeOf(&features.Service{}), "Start", func(*features.Service) {}).ApplyMethod(reflect.TypeOf(graphqlHandler), "AddTransport", func(s *handler.Server, t graphql2.Transport) {
 				transportGET := transport.GET{}
 				transportMultipartForm := transport.MultipartForm{}
 				transportPOST := transport.POST{}
@@ -214,20 +232,102 @@ func testWithoutTransportCalls(t *testing.T, tt testStartServerType, e *echo.Ech
 	bodyBytes, _ := io.ReadAll(res.Body)
 	assert.Contains(t, string(bodyBytes), "GraphiQL.createFetcher", "Playground not found")
 	ts := httptest.NewServer(e)
+	defer ts.Close()
+	// Start signs with the JWT_SECRET of the environment when it is set
+	token, err := jwtgo.NewWithClaims(jwtgo.GetSigningMethod(tt.args.cfg.JWT.SigningAlgorithm), jwtgo.MapClaims{
+		"e": testutls.MockEmail,
+	}).SignedString([]byte(tt.args.cfg.JWT.Secret))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	p := initWebsocket(t, ts, "bearer "+token)
+	assert.Contains(t, string(p), "{\"type\":\"connection_ack\"}\n", "Connection ack not found")
+	p = initWebsocket(t, ts, "bearer ABC")
+	assert.Contains(t, string(p), "\"type\":\"connection_error\"", "Invalid token accepted")
+}
+
+func initWebsocket(t *testing.T, ts *httptest.Server, authorization string) []byte {
 	u := "ws" + strings.TrimPrefix(ts.URL+graphQLPathname, "http")
 	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
+	defer ws.Close()
 	if err := ws.WriteMessage(websocket.TextMessage, []byte(`{"type":"connection_init","payload":`+
-		`{"authorization":"bearer ABC"}}`)); err != nil {
+		`{"authorization":"`+authorization+`"}}`)); err != nil {
 		t.Fatalf("%v", err)
 	}
 	_, p, err := ws.ReadMessage()
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
-	assert.Contains(t, string(p), "{\"type\":\"connection_ack\"}\n", "Connection ack not found")
-	ts.Close()
-	ws.Close()
+	return p
+}
+
+func TestSetupGraphQLPlayground(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    string
+		wantStatus int
+	}{
+		{name: "Enabled", enabled: "true", wantStatus: http.StatusOK},
+		{name: "Disabled", enabled: "false", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := settings.NewStore(settings.Builtins(testutls.MockConfig()), settings.NewMemory(), settings.Options{})
+			_, err := store.Set(context.Background(), 1, settings.PlaygroundEnabled, tt.enabled)
+			assert.NoError(t, err)
+			e := echo.New()
+			setupGraphQLPlayground(e, store)
+			res, err := checkGraphQLGetResponse(e)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestSetupMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		err     error
+		wantErr string
+	}{
+		{name: "Disabled"},
+		{name: "Enabled", enabled: true},
+		{name: "Ahead", enabled: true, err: migrations.ErrAhead,
+			wantErr: "migrating the database: the database is ahead of the migrations of this binary"},
+	}
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "PSQL_USER"), []byte("watched\n"), 0o600))
+	watcher := secrets.NewWatcher(secrets.File{Dir: dir}, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated := false
+			patches := gomonkey.ApplyFunc(postgres.Open, func(dsn string, opts postgres.Options) (*sql.DB, error) {
+				// the credentials come from the secrets provider rather than the environment
+				assert.Contains(t, dsn, "user=watched")
+				// migrations may run for longer than a query is allowed to
+				assert.Zero(t, opts.QueryTimeout)
+				assert.Zero(t, opts.StatementTimeout)
+				db, _, err := sqlmock.New()
+				return db, err
+			}).ApplyFunc(migrations.Migrate, func(context.Context, *sql.DB, migrate.MigrationSource) (int, error) {
+				migrated = true
+				return 0, tt.err
+			})
+			defer patches.Reset()
+
+			cfg := testutls.MockConfig()
+			cfg.Server.MigrateOnStart = tt.enabled
+			err := setupMigrations(cfg, watcher)
+			assert.Equal(t, tt.enabled, migrated)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
 }
@@ -0,0 +1,158 @@
+// Package apperror provides typed, machine-readable application errors that are
+// surfaced to GraphQL clients through the `code` extension.
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+)
+
+// Code is a machine-readable error classification
+type Code string
+
+const (
+	// NotFound is returned when the requested resource does not exist
+	NotFound Code = "NOT_FOUND"
+	// Unauthenticated is returned when the request has no valid credentials
+	Unauthenticated Code = "UNAUTHENTICATED"
+	// Forbidden is returned when the caller lacks permission for the operation
+	Forbidden Code = "FORBIDDEN"
+	// Validation is returned when the provided input is invalid
+	Validation Code = "VALIDATION"
+	// Conflict is returned when the operation conflicts with existing data
+	Conflict Code = "CONFLICT"
+	// RateLimited is returned when the caller exceeded the allowed request rate
+	RateLimited Code = "RATE_LIMITED"
+	// Internal is returned for unexpected failures, its details are never sent to clients in production
+	Internal Code = "INTERNAL"
+)
+
+// Error is an application error carrying a code, a client-facing message and the underlying cause
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// Error returns the client-facing message
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying cause
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New creates a new error with the given code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf creates a new error with the given code and formatted message
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap creates a new error with the given code and message that keeps err as its cause
+func Wrap(code Code, err error, message string) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// NewNotFound ...
+func NewNotFound(message string) *Error { return New(NotFound, message) }
+
+// NewUnauthenticated ...
+func NewUnauthenticated(message string) *Error { return New(Unauthenticated, message) }
+
+// NewForbidden ...
+func NewForbidden(message string) *Error { return New(Forbidden, message) }
+
+// NewValidation ...
+func NewValidation(message string) *Error { return New(Validation, message) }
+
+// NewConflict ...
+func NewConflict(message string) *Error { return New(Conflict, message) }
+
+// NewRateLimited ...
+func NewRateLimited(message string) *Error { return New(RateLimited, message) }
+
+// NewInternal wraps err as an internal error
+func NewInternal(err error) *Error { return Wrap(Internal, err, "internal server error") }
+
+// postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqUniqueViolation     = "23505"
+	pqForeignKeyViolation = "23503"
+	pqNotNullViolation    = "23502"
+	pqCheckViolation      = "23514"
+	pqDataException       = "22"
+	pqQueryCanceled       = "57014"
+)
+
+// FromSQL classifies an error returned by the database layer. detail describes
+// the data that was being accessed and is used to build the client message.
+func FromSQL(err error, detail string) *Error {
+	if err == nil {
+		return nil
+	}
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return Wrap(NotFound, err, fmt.Sprint("No data found with provided ", detail))
+	}
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return Wrap(Internal, err, fmt.Sprint("Unable to process ", detail))
+	}
+	switch {
+	case pqErr.Code == pqUniqueViolation:
+		return Wrap(Conflict, err, fmt.Sprint("Data already exists for provided ", detail))
+	case pqErr.Code == pqForeignKeyViolation:
+		return Wrap(Conflict, err, "Unable to complete the operation, it has useful data associated to it")
+	case pqErr.Code == pqNotNullViolation, pqErr.Code == pqCheckViolation, pqErr.Code.Class() == pqDataException:
+		return Wrap(Validation, err, fmt.Sprint("Invalid ", detail))
+	case pqErr.Code == pqQueryCanceled:
+		return Wrap(Internal, err, "The request took too long to complete")
+	}
+	return Wrap(Internal, err, fmt.Sprint("Unable to process ", detail))
+}
+
+// FromHTTPStatus maps an HTTP status code to an error code
+func FromHTTPStatus(status int) Code {
+	switch status {
+	case http.StatusNotFound:
+		return NotFound
+	case http.StatusUnauthorized:
+		return Unauthenticated
+	case http.StatusForbidden:
+		return Forbidden
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return Validation
+	case http.StatusConflict:
+		return Conflict
+	case http.StatusTooManyRequests:
+		return RateLimited
+	}
+	return Internal
+}
+
+// CodeOf returns the code of err, errors that are not typed are considered internal
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return FromHTTPStatus(httpErr.Code)
+	}
+	return Internal
+}
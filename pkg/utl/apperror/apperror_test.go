@@ -0,0 +1,174 @@
+package apperror_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/friendsofgo/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	Detail    = "user information"
+	RequestID = "request-id"
+)
+
+func TestFromSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    apperror.Code
+		message string
+	}{
+		{
+			name:    "No rows",
+			err:     sql.ErrNoRows,
+			code:    apperror.NotFound,
+			message: "No data found with provided " + Detail,
+		},
+		{
+			name:    "Unique violation wrapped by sqlboiler",
+			err:     errors.Wrap(&pq.Error{Code: "23505"}, "models: unable to insert into users"),
+			code:    apperror.Conflict,
+			message: "Data already exists for provided " + Detail,
+		},
+		{
+			name:    "Foreign key violation",
+			err:     &pq.Error{Code: "23503"},
+			code:    apperror.Conflict,
+			message: "Unable to complete the operation, it has useful data associated to it",
+		},
+		{
+			name:    "Not null violation",
+			err:     &pq.Error{Code: "23502"},
+			code:    apperror.Validation,
+			message: "Invalid " + Detail,
+		},
+		{
+			name:    "Data exception",
+			err:     &pq.Error{Code: "22001"},
+			code:    apperror.Validation,
+			message: "Invalid " + Detail,
+		},
+		{
+			name:    "Unknown error",
+			err:     fmt.Errorf("connection refused"),
+			code:    apperror.Internal,
+			message: "Unable to process " + Detail,
+		},
+		{
+			name:    "Already typed",
+			err:     apperror.NewForbidden("forbidden"),
+			code:    apperror.Forbidden,
+			message: "forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := apperror.FromSQL(tt.err, Detail)
+			assert.Equal(t, tt.code, err.Code)
+			assert.Equal(t, tt.message, err.Error())
+			assert.True(t, errors.Is(err, tt.err))
+		})
+	}
+	assert.Nil(t, apperror.FromSQL(nil, Detail))
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want apperror.Code
+	}{
+		{
+			name: "Typed error",
+			err:  apperror.NewRateLimited("slow down"),
+			want: apperror.RateLimited,
+		},
+		{
+			name: "Wrapped typed error",
+			err:  fmt.Errorf("wrapped: %w", apperror.NewValidation("invalid")),
+			want: apperror.Validation,
+		},
+		{
+			name: "Echo http error",
+			err:  echo.ErrUnauthorized,
+			want: apperror.Unauthenticated,
+		},
+		{
+			name: "Untyped error",
+			err:  fmt.Errorf("boom"),
+			want: apperror.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, apperror.CodeOf(tt.err))
+		})
+	}
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	assert.Equal(t, apperror.NotFound, apperror.FromHTTPStatus(http.StatusNotFound))
+	assert.Equal(t, apperror.Forbidden, apperror.FromHTTPStatus(http.StatusForbidden))
+	assert.Equal(t, apperror.Validation, apperror.FromHTTPStatus(http.StatusBadRequest))
+	assert.Equal(t, apperror.Conflict, apperror.FromHTTPStatus(http.StatusConflict))
+	assert.Equal(t, apperror.RateLimited, apperror.FromHTTPStatus(http.StatusTooManyRequests))
+	assert.Equal(t, apperror.Internal, apperror.FromHTTPStatus(http.StatusBadGateway))
+}
+
+func TestPresent(t *testing.T) {
+	ctx := context.WithValue(context.Background(), zaplog.RequestIdCtxKey, RequestID)
+	tests := []struct {
+		name    string
+		err     error
+		mask    bool
+		message string
+		code    interface{}
+	}{
+		{
+			name:    "Typed error",
+			err:     apperror.NewNotFound("user not found"),
+			message: "user not found",
+			code:    apperror.NotFound,
+		},
+		{
+			name:    "Internal error unmasked",
+			err:     fmt.Errorf("pq: password authentication failed"),
+			message: "pq: password authentication failed",
+			code:    apperror.Internal,
+		},
+		{
+			name:    "Internal error masked",
+			err:     apperror.FromSQL(fmt.Errorf("pq: password authentication failed"), Detail),
+			mask:    true,
+			message: "internal server error",
+			code:    apperror.Internal,
+		},
+		{
+			name:    "Schema error",
+			err:     gqlerror.Errorf("Cannot query field"),
+			mask:    true,
+			message: "Cannot query field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := apperror.Presenter(tt.mask)(ctx, tt.err)
+			assert.Equal(t, tt.message, got.Message)
+			assert.Equal(t, tt.code, got.Extensions[apperror.CodeExtension])
+			if tt.code != nil {
+				assert.Equal(t, RequestID, got.Extensions[apperror.RequestIDExtension])
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+
+	"go-template/pkg/utl/zaplog"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	// CodeExtension is the gqlerror extension key holding the error code
+	CodeExtension = "code"
+	// RequestIDExtension is the gqlerror extension key holding the request id
+	RequestIDExtension = "requestId"
+
+	maskedMessage = "internal server error"
+)
+
+// Presenter returns a gqlgen error presenter that attaches the error code to every
+// error. Internal errors are logged with their cause and, when mask is set, their
+// message is replaced so that no implementation details reach the client.
+func Presenter(mask bool) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		return Present(ctx, err, mask)
+	}
+}
+
+// Present converts err into a gqlerror carrying its code in the extensions
+func Present(ctx context.Context, err error, mask bool) *gqlerror.Error {
+	var gqlErr *gqlerror.Error
+	if errors.As(err, &gqlErr) && gqlErr.Unwrap() == nil {
+		// raised by gqlgen itself while parsing or validating the operation
+		return gqlErr
+	}
+	if gqlErr == nil {
+		var path ast.Path
+		if graphql.GetFieldContext(ctx) != nil {
+			path = graphql.GetPath(ctx)
+		}
+		gqlErr = gqlerror.WrapPath(path, err)
+	}
+	code := CodeOf(err)
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions[CodeExtension] = code
+	if requestID, ok := ctx.Value(zaplog.RequestIdCtxKey).(string); ok {
+		gqlErr.Extensions[RequestIDExtension] = requestID
+	}
+	if code == Internal {
+		zaplog.Error(ctx, "internal error: ", cause(err))
+		if mask {
+			gqlErr.Message = maskedMessage
+		}
+	}
+	return gqlErr
+}
+
+func cause(err error) error {
+	var appErr *Error
+	if errors.As(err, &appErr) && appErr.Err != nil {
+		return appErr.Err
+	}
+	return err
+}
@@ -0,0 +1,16 @@
+package backoff
+
+import "time"
+
+// Exponential returns the delay before retrying an operation that already failed attempts times,
+// doubling from a second and capped at max
+func Exponential(attempts int, max time.Duration) time.Duration {
+	if attempts > 30 {
+		return max
+	}
+	d := time.Second << attempts
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,26 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"go-template/pkg/utl/backoff"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: 2 * time.Second},
+		{attempts: 5, want: 32 * time.Second},
+		{attempts: 12, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoff.Exponential(tt.attempts, time.Hour))
+	}
+}
@@ -2,12 +2,17 @@ package cnvrttogql
 
 import (
 	"context"
+	"go-template/daos"
 	graphql "go-template/gqlmodels"
 	"go-template/internal/constants"
+	"go-template/internal/service/features"
+	"go-template/internal/service/settings"
 	"go-template/models"
 	"go-template/pkg/utl/convert"
 	"strconv"
+	"strings"
 
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
@@ -70,3 +75,176 @@ func RoleToGraphqlRole(r *models.Role, count int) *graphql.Role {
 		Users:       UsersToGraphQlUsers(users, count),
 	}
 }
+
+// JobsToGraphQlJobs converts array of type daos.Job into array of pointer type graphql.Job
+func JobsToGraphQlJobs(jobs []daos.Job) []*graphql.Job {
+	r := make([]*graphql.Job, 0, len(jobs))
+	for i := range jobs {
+		r = append(r, JobToGraphQlJob(&jobs[i]))
+	}
+	return r
+}
+
+// JobToGraphQlJob converts type daos.Job into pointer type graphql.Job
+func JobToGraphQlJob(j *daos.Job) *graphql.Job {
+	if j == nil {
+		return nil
+	}
+	return &graphql.Job{
+		ID:          strconv.FormatInt(j.ID, 10),
+		Kind:        j.Kind,
+		Payload:     string(j.Payload),
+		Status:      graphql.JobStatus(strings.ToUpper(j.Status)),
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       int(j.RunAt.UnixMilli()),
+		LastError:   convert.NullDotStringToPointerString(j.LastError),
+		FinishedAt:  convert.NullDotTimeToPointerInt(j.FinishedAt),
+		CreatedAt:   int(j.CreatedAt.UnixMilli()),
+	}
+}
+
+// WebhooksToGraphQlWebhooks converts array of type daos.Webhook into array of pointer type graphql.Webhook
+func WebhooksToGraphQlWebhooks(webhooks []daos.Webhook) []*graphql.Webhook {
+	r := make([]*graphql.Webhook, 0, len(webhooks))
+	for i := range webhooks {
+		r = append(r, WebhookToGraphQlWebhook(&webhooks[i]))
+	}
+	return r
+}
+
+// WebhookToGraphQlWebhook converts type daos.Webhook into pointer type graphql.Webhook, the
+// secret is left out
+func WebhookToGraphQlWebhook(w *daos.Webhook) *graphql.Webhook {
+	if w == nil {
+		return nil
+	}
+	eventTypes := make([]graphql.WebhookEventType, 0, len(w.EventTypes))
+	for _, eventType := range w.EventTypes {
+		eventTypes = append(eventTypes, WebhookEventTypeToGraphQl(eventType))
+	}
+	return &graphql.Webhook{
+		ID:         strconv.Itoa(w.ID),
+		URL:        w.URL,
+		EventTypes: eventTypes,
+		Active:     w.Active,
+		CreatedAt:  int(w.CreatedAt.UnixMilli()),
+		UpdatedAt:  int(w.UpdatedAt.UnixMilli()),
+	}
+}
+
+// WebhookEventTypeToGraphQl converts an event type such as user.created into USER_CREATED
+func WebhookEventTypeToGraphQl(eventType string) graphql.WebhookEventType {
+	return graphql.WebhookEventType(strings.ToUpper(strings.ReplaceAll(eventType, ".", "_")))
+}
+
+// WebhookDeliveriesToGraphQlWebhookDeliveries converts array of type daos.WebhookDelivery into
+// array of pointer type graphql.WebhookDelivery
+func WebhookDeliveriesToGraphQlWebhookDeliveries(deliveries []daos.WebhookDelivery) []*graphql.WebhookDelivery {
+	r := make([]*graphql.WebhookDelivery, 0, len(deliveries))
+	for i := range deliveries {
+		r = append(r, WebhookDeliveryToGraphQlWebhookDelivery(&deliveries[i]))
+	}
+	return r
+}
+
+// WebhookDeliveryToGraphQlWebhookDelivery converts type daos.WebhookDelivery into pointer type
+// graphql.WebhookDelivery
+func WebhookDeliveryToGraphQlWebhookDelivery(d *daos.WebhookDelivery) *graphql.WebhookDelivery {
+	if d == nil {
+		return nil
+	}
+	return &graphql.WebhookDelivery{
+		ID:             strconv.FormatInt(d.ID, 10),
+		WebhookID:      strconv.Itoa(d.WebhookID),
+		EventID:        nullInt64ToPointerID(d.EventID),
+		RedeliveryOf:   nullInt64ToPointerID(d.RedeliveryOf),
+		EventType:      WebhookEventTypeToGraphQl(d.EventType),
+		Payload:        string(d.Payload),
+		Status:         graphql.WebhookDeliveryStatus(strings.ToUpper(d.Status)),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus.Ptr(),
+		ResponseBody:   convert.NullDotStringToPointerString(d.ResponseBody),
+		LastError:      convert.NullDotStringToPointerString(d.LastError),
+		DurationMs:     d.DurationMs.Ptr(),
+		DeliveredAt:    convert.NullDotTimeToPointerInt(d.DeliveredAt),
+		CreatedAt:      int(d.CreatedAt.UnixMilli()),
+	}
+}
+
+// SettingsToGraphQlSettings converts array of type settings.Setting into array of pointer type
+// graphql.Setting
+func SettingsToGraphQlSettings(s []settings.Setting) []*graphql.Setting {
+	r := make([]*graphql.Setting, 0, len(s))
+	for i := range s {
+		r = append(r, SettingToGraphQlSetting(s[i]))
+	}
+	return r
+}
+
+// SettingToGraphQlSetting converts type settings.Setting into pointer type graphql.Setting
+func SettingToGraphQlSetting(s settings.Setting) *graphql.Setting {
+	setting := &graphql.Setting{
+		Key:           s.Key,
+		Type:          graphql.SettingType(strings.ToUpper(string(s.Type))),
+		Value:         s.Value,
+		Default:       s.Default,
+		Description:   s.Description,
+		AllowedValues: append([]string{}, s.Values...),
+		Min:           s.Min,
+		Max:           s.Max,
+		Source:        graphql.SettingSource(strings.ToUpper(string(s.Source))),
+	}
+	if s.UpdatedBy != 0 {
+		updatedBy := strconv.Itoa(s.UpdatedBy)
+		setting.UpdatedBy = &updatedBy
+	}
+	if !s.UpdatedAt.IsZero() {
+		updatedAt := int(s.UpdatedAt.UnixMilli())
+		setting.UpdatedAt = &updatedAt
+	}
+	return setting
+}
+
+// FeatureFlagsToGraphQlFeatureFlags converts array of type features.Flag into array of pointer
+// type graphql.FeatureFlag
+func FeatureFlagsToGraphQlFeatureFlags(flags []features.Flag) []*graphql.FeatureFlag {
+	r := make([]*graphql.FeatureFlag, 0, len(flags))
+	for i := range flags {
+		r = append(r, FeatureFlagToGraphQlFeatureFlag(flags[i]))
+	}
+	return r
+}
+
+// FeatureFlagToGraphQlFeatureFlag converts type features.Flag into pointer type graphql.FeatureFlag
+func FeatureFlagToGraphQlFeatureFlag(f features.Flag) *graphql.FeatureFlag {
+	userIDs := make([]string, 0, len(f.UserIDs))
+	for _, id := range f.UserIDs {
+		userIDs = append(userIDs, strconv.Itoa(id))
+	}
+	flag := &graphql.FeatureFlag{
+		Name:          f.Name,
+		Description:   f.Description,
+		Type:          graphql.FeatureFlagType(strings.ToUpper(string(f.Type))),
+		Enabled:       f.Enabled,
+		Percentage:    f.Percentage,
+		UserIds:       userIDs,
+		Roles:         append([]string{}, f.Roles...),
+		Organizations: append([]string{}, f.Organizations...),
+		CreatedAt:     int(f.CreatedAt.UnixMilli()),
+		UpdatedAt:     int(f.UpdatedAt.UnixMilli()),
+	}
+	if f.UpdatedBy != 0 {
+		updatedBy := strconv.Itoa(f.UpdatedBy)
+		flag.UpdatedBy = &updatedBy
+	}
+	return flag
+}
+
+func nullInt64ToPointerID(v null.Int64) *string {
+	if !v.Valid {
+		return nil
+	}
+	id := strconv.FormatInt(v.Int64, 10)
+	return &id
+}
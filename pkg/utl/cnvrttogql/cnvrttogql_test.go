@@ -1,13 +1,16 @@
 package cnvrttogql
 
 import (
+	"go-template/daos"
 	graphql "go-template/gqlmodels"
 	"go-template/models"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 )
 
@@ -111,3 +114,52 @@ func TestUserToGraphQlUser(t *testing.T) {
 		})
 	}
 }
+
+func TestJobsToGraphQlJobs(t *testing.T) {
+	runAt := time.UnixMilli(1640995200000)
+	lastError := "boom"
+	finishedAt := 1640995260000
+	tests := []struct {
+		name string
+		jobs []daos.Job
+		want []*graphql.Job
+	}{
+		{
+			name: SuccessCase,
+			jobs: []daos.Job{{
+				ID:          7,
+				Kind:        "email",
+				Payload:     []byte(`{"to":"a@b.c"}`),
+				Status:      daos.JobDead,
+				Attempts:    10,
+				MaxAttempts: 10,
+				RunAt:       runAt,
+				LastError:   null.StringFrom(lastError),
+				FinishedAt:  null.TimeFrom(time.UnixMilli(int64(finishedAt))),
+				CreatedAt:   runAt,
+			}},
+			want: []*graphql.Job{{
+				ID:          "7",
+				Kind:        "email",
+				Payload:     `{"to":"a@b.c"}`,
+				Status:      graphql.JobStatusDead,
+				Attempts:    10,
+				MaxAttempts: 10,
+				RunAt:       1640995200000,
+				LastError:   &lastError,
+				FinishedAt:  &finishedAt,
+				CreatedAt:   1640995200000,
+			}},
+		},
+		{
+			name: "Empty",
+			jobs: nil,
+			want: []*graphql.Job{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, JobsToGraphQlJobs(tt.jobs))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package convert
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/volatiletech/null/v8"
 )
@@ -76,3 +77,11 @@ func NullDotTimeToPointerInt(t null.Time) *int {
 	}
 	return nil
 }
+
+// PointerIntToNullDotTime converts a pointer to unix milliseconds to a nullable time, null when absent
+func PointerIntToNullDotTime(v *int) null.Time {
+	if v == nil {
+		return null.Time{}
+	}
+	return null.TimeFrom(time.UnixMilli(int64(*v)))
+}
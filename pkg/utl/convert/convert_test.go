@@ -3,6 +3,7 @@ package convert
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/volatiletech/null/v8"
 )
@@ -259,3 +260,29 @@ func TestPointerStringToNullDotInt(t *testing.T) {
 		})
 	}
 }
+
+func TestPointerIntToNullDotTime(t *testing.T) {
+	millis := 1700000000000
+	tests := []struct {
+		name string
+		v    *int
+		want null.Time
+	}{
+		{
+			name: SuccessCase,
+			v:    &millis,
+			want: null.TimeFrom(time.UnixMilli(1700000000000)),
+		},
+		{
+			name: "Success_Nil",
+			want: null.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PointerIntToNullDotTime(tt.v); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PointerIntToNullDotTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
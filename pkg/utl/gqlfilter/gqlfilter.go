@@ -0,0 +1,160 @@
+// Package gqlfilter evaluates the GraphQL where inputs against already loaded models, where the
+// rows never reach the database, e.g. to filter subscription events, and translates them to the
+// query mods of the queries reading the database.
+package gqlfilter
+
+import (
+	"strings"
+
+	graphql "go-template/gqlmodels"
+)
+
+// MatchUser reports whether u satisfies where. As in SQL, a condition on a field that is
+// not set never matches, the only exception being BooleanFilter.isNull.
+func MatchUser(where *graphql.UserWhere, u *graphql.User) bool {
+	if where == nil {
+		return true
+	}
+	if u == nil {
+		return false
+	}
+	match := ID(where.ID, &u.ID) &&
+		String(where.FirstName, u.FirstName) &&
+		String(where.LastName, u.LastName) &&
+		String(where.Username, u.Username) &&
+		String(where.Password, u.Password) &&
+		String(where.Email, u.Email) &&
+		String(where.Mobile, u.Mobile) &&
+		String(where.Address, u.Address) &&
+		Boolean(where.Active, u.Active) &&
+		Int(where.LastLogin, u.LastLogin) &&
+		Int(where.LastPasswordChange, u.LastPasswordChange) &&
+		String(where.Token, u.Token) &&
+		Int(where.CreatedAt, u.CreatedAt) &&
+		Int(where.DeletedAt, u.DeletedAt) &&
+		Int(where.UpdatedAt, u.UpdatedAt) &&
+		(where.Role == nil || MatchRole(where.Role, u.Role)) &&
+		MatchUser(where.And, u)
+	return match || (where.Or != nil && MatchUser(where.Or, u))
+}
+
+// MatchRole reports whether r satisfies where, a users condition matches when any of the
+// users of the role does
+func MatchRole(where *graphql.RoleWhere, r *graphql.Role) bool {
+	if where == nil {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+	match := ID(where.ID, &r.ID) &&
+		Int(where.AccessLevel, &r.AccessLevel) &&
+		String(where.Name, &r.Name) &&
+		Int(where.UpdatedAt, r.UpdatedAt) &&
+		Int(where.DeletedAt, r.DeletedAt) &&
+		Int(where.CreatedAt, r.CreatedAt) &&
+		(where.Users == nil || anyUser(where.Users, r.Users)) &&
+		MatchRole(where.And, r)
+	return match || (where.Or != nil && MatchRole(where.Or, r))
+}
+
+func anyUser(where *graphql.UserWhere, users []*graphql.User) bool {
+	for _, u := range users {
+		if MatchUser(where, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// ID ...
+func ID(f *graphql.IDFilter, v *string) bool {
+	if f == nil {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	return (f.EqualTo == nil || *v == *f.EqualTo) &&
+		(f.NotEqualTo == nil || *v != *f.NotEqualTo) &&
+		(f.In == nil || contains(f.In, *v)) &&
+		(f.NotIn == nil || !contains(f.NotIn, *v))
+}
+
+// String applies the case-insensitive conditions ignoring case and the strict ones as is
+func String(f *graphql.StringFilter, v *string) bool {
+	if f == nil {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	lower := strings.ToLower(*v)
+	fold := func(p *string, cond func(s, substr string) bool) bool {
+		return p == nil || cond(lower, strings.ToLower(*p))
+	}
+	strict := func(p *string, cond func(s, substr string) bool) bool {
+		return p == nil || cond(*v, *p)
+	}
+	not := func(cond func(s, substr string) bool) func(s, substr string) bool {
+		return func(s, substr string) bool { return !cond(s, substr) }
+	}
+	return (f.EqualTo == nil || *v == *f.EqualTo) &&
+		(f.NotEqualTo == nil || *v != *f.NotEqualTo) &&
+		(f.In == nil || contains(f.In, *v)) &&
+		(f.NotIn == nil || !contains(f.NotIn, *v)) &&
+		fold(f.StartWith, strings.HasPrefix) &&
+		fold(f.NotStartWith, not(strings.HasPrefix)) &&
+		fold(f.EndWith, strings.HasSuffix) &&
+		fold(f.NotEndWith, not(strings.HasSuffix)) &&
+		fold(f.Contain, strings.Contains) &&
+		fold(f.NotContain, not(strings.Contains)) &&
+		strict(f.StartWithStrict, strings.HasPrefix) &&
+		strict(f.NotStartWithStrict, not(strings.HasPrefix)) &&
+		strict(f.EndWithStrict, strings.HasSuffix) &&
+		strict(f.NotEndWithStrict, not(strings.HasSuffix)) &&
+		strict(f.ContainStrict, strings.Contains) &&
+		strict(f.NotContainStrict, not(strings.Contains))
+}
+
+// Int ...
+func Int(f *graphql.IntFilter, v *int) bool {
+	if f == nil {
+		return true
+	}
+	if v == nil {
+		return false
+	}
+	return (f.EqualTo == nil || *v == *f.EqualTo) &&
+		(f.NotEqualTo == nil || *v != *f.NotEqualTo) &&
+		(f.LessThan == nil || *v < *f.LessThan) &&
+		(f.LessThanOrEqualTo == nil || *v <= *f.LessThanOrEqualTo) &&
+		(f.MoreThan == nil || *v > *f.MoreThan) &&
+		(f.MoreThanOrEqualTo == nil || *v >= *f.MoreThanOrEqualTo) &&
+		(f.In == nil || contains(f.In, *v)) &&
+		(f.NotIn == nil || !contains(f.NotIn, *v))
+}
+
+// Boolean ...
+func Boolean(f *graphql.BooleanFilter, v *bool) bool {
+	if f == nil {
+		return true
+	}
+	if f.IsNull != nil && *f.IsNull != (v == nil) {
+		return false
+	}
+	if v == nil {
+		return f.IsTrue == nil && f.IsFalse == nil
+	}
+	return (f.IsTrue == nil || *f.IsTrue == *v) &&
+		(f.IsFalse == nil || *f.IsFalse == !*v)
+}
+
+func contains[T comparable](values []T, v T) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package gqlfilter_test
+
+import (
+	"testing"
+
+	graphql "go-template/gqlmodels"
+	"go-template/pkg/utl/gqlfilter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestMatchUser(t *testing.T) {
+	user := &graphql.User{
+		ID:        "1",
+		FirstName: ptr("Mac"),
+		Email:     ptr("mac@wednesday.is"),
+		Active:    ptr(true),
+		CreatedAt: ptr(100),
+		Role:      &graphql.Role{ID: "2", Name: "SuperAdmin", AccessLevel: 100},
+	}
+	tests := []struct {
+		name  string
+		where *graphql.UserWhere
+		want  bool
+	}{
+		{
+			name: "No filter",
+			want: true,
+		},
+		{
+			name:  "ID in",
+			where: &graphql.UserWhere{ID: &graphql.IDFilter{In: []string{"1", "3"}}},
+			want:  true,
+		},
+		{
+			name:  "ID not in",
+			where: &graphql.UserWhere{ID: &graphql.IDFilter{NotIn: []string{"1"}}},
+		},
+		{
+			name:  "Contain ignores case",
+			where: &graphql.UserWhere{FirstName: &graphql.StringFilter{Contain: ptr("MA")}},
+			want:  true,
+		},
+		{
+			name:  "Strict contain respects case",
+			where: &graphql.UserWhere{FirstName: &graphql.StringFilter{ContainStrict: ptr("MA")}},
+		},
+		{
+			name:  "Not end with",
+			where: &graphql.UserWhere{Email: &graphql.StringFilter{NotEndWith: ptr(".IS")}},
+		},
+		{
+			name:  "Unset field never matches",
+			where: &graphql.UserWhere{LastName: &graphql.StringFilter{NotEqualTo: ptr("Mac")}},
+		},
+		{
+			name:  "Int range",
+			where: &graphql.UserWhere{CreatedAt: &graphql.IntFilter{MoreThan: ptr(10), LessThanOrEqualTo: ptr(100)}},
+			want:  true,
+		},
+		{
+			name:  "Boolean",
+			where: &graphql.UserWhere{Active: &graphql.BooleanFilter{IsFalse: ptr(true)}},
+		},
+		{
+			name:  "Is null",
+			where: &graphql.UserWhere{Active: &graphql.BooleanFilter{IsNull: ptr(false)}},
+			want:  true,
+		},
+		{
+			name:  "Role",
+			where: &graphql.UserWhere{Role: &graphql.RoleWhere{AccessLevel: &graphql.IntFilter{EqualTo: ptr(100)}}},
+			want:  true,
+		},
+		{
+			name: "And",
+			where: &graphql.UserWhere{
+				ID:  &graphql.IDFilter{EqualTo: ptr("1")},
+				And: &graphql.UserWhere{FirstName: &graphql.StringFilter{EqualTo: ptr("Bob")}},
+			},
+		},
+		{
+			name: "Or",
+			where: &graphql.UserWhere{
+				ID: &graphql.IDFilter{EqualTo: ptr("2")},
+				Or: &graphql.UserWhere{FirstName: &graphql.StringFilter{StartWith: ptr("m")}},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gqlfilter.MatchUser(tt.where, user))
+		})
+	}
+}
+
+func TestMatchRole(t *testing.T) {
+	role := &graphql.Role{
+		ID:    "2",
+		Name:  "SuperAdmin",
+		Users: []*graphql.User{{ID: "1"}, {ID: "5"}},
+	}
+	tests := []struct {
+		name  string
+		role  *graphql.Role
+		where *graphql.RoleWhere
+		want  bool
+	}{
+		{
+			name:  "Name",
+			role:  role,
+			where: &graphql.RoleWhere{Name: &graphql.StringFilter{EndWith: ptr("admin")}},
+			want:  true,
+		},
+		{
+			name:  "Any user",
+			role:  role,
+			where: &graphql.RoleWhere{Users: &graphql.UserWhere{ID: &graphql.IDFilter{EqualTo: ptr("5")}}},
+			want:  true,
+		},
+		{
+			name:  "No user",
+			role:  role,
+			where: &graphql.RoleWhere{Users: &graphql.UserWhere{ID: &graphql.IDFilter{EqualTo: ptr("3")}}},
+		},
+		{
+			name:  "Missing role",
+			where: &graphql.RoleWhere{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gqlfilter.MatchRole(tt.where, tt.role))
+		})
+	}
+}
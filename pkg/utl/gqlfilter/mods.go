@@ -0,0 +1,227 @@
+package gqlfilter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	graphql "go-template/gqlmodels"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// The funcs below translate the filters to sqlboiler query mods, for the where inputs of the
+// queries reading the database. They follow the semantics of the matchers: the conditions of a
+// filter are and'ed, the case-insensitive string conditions ignore case and the strict ones
+// don't.
+
+// IDMods returns the conditions of f on column
+func IDMods(column string, f *graphql.IDFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", f.EqualTo)
+	compare(&c, "<>", f.NotEqualTo)
+	in(&c, f.In, false)
+	in(&c, f.NotIn, true)
+	return c.mods
+}
+
+// StringMods returns the conditions of f on column
+func StringMods(column string, f *graphql.StringFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", f.EqualTo)
+	compare(&c, "<>", f.NotEqualTo)
+	in(&c, f.In, false)
+	in(&c, f.NotIn, true)
+	like(&c, "ILIKE", f.StartWith, "", "%")
+	like(&c, "NOT ILIKE", f.NotStartWith, "", "%")
+	like(&c, "ILIKE", f.EndWith, "%", "")
+	like(&c, "NOT ILIKE", f.NotEndWith, "%", "")
+	like(&c, "ILIKE", f.Contain, "%", "%")
+	like(&c, "NOT ILIKE", f.NotContain, "%", "%")
+	like(&c, "LIKE", f.StartWithStrict, "", "%")
+	like(&c, "NOT LIKE", f.NotStartWithStrict, "", "%")
+	like(&c, "LIKE", f.EndWithStrict, "%", "")
+	like(&c, "NOT LIKE", f.NotEndWithStrict, "%", "")
+	like(&c, "LIKE", f.ContainStrict, "%", "%")
+	like(&c, "NOT LIKE", f.NotContainStrict, "%", "%")
+	return c.mods
+}
+
+// IntMods returns the conditions of f on column
+func IntMods(column string, f *graphql.IntFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", f.EqualTo)
+	compare(&c, "<>", f.NotEqualTo)
+	compare(&c, "<", f.LessThan)
+	compare(&c, "<=", f.LessThanOrEqualTo)
+	compare(&c, ">", f.MoreThan)
+	compare(&c, ">=", f.MoreThanOrEqualTo)
+	in(&c, f.In, false)
+	in(&c, f.NotIn, true)
+	return c.mods
+}
+
+// FloatMods returns the conditions of f on column
+func FloatMods(column string, f *graphql.FloatFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", f.EqualTo)
+	compare(&c, "<>", f.NotEqualTo)
+	compare(&c, "<", f.LessThan)
+	compare(&c, "<=", f.LessThanOrEqualTo)
+	compare(&c, ">", f.MoreThan)
+	compare(&c, ">=", f.MoreThanOrEqualTo)
+	in(&c, f.In, false)
+	in(&c, f.NotIn, true)
+	return c.mods
+}
+
+// TimeMods returns the conditions of f on the timestamp column, the values of f being unix
+// milliseconds as the timestamps of the graphql types are
+func TimeMods(column string, f *graphql.IntFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", millis(f.EqualTo))
+	compare(&c, "<>", millis(f.NotEqualTo))
+	compare(&c, "<", millis(f.LessThan))
+	compare(&c, "<=", millis(f.LessThanOrEqualTo))
+	compare(&c, ">", millis(f.MoreThan))
+	compare(&c, ">=", millis(f.MoreThanOrEqualTo))
+	in(&c, allMillis(f.In), false)
+	in(&c, allMillis(f.NotIn), true)
+	return c.mods
+}
+
+// BooleanMods returns the conditions of f on column
+func BooleanMods(column string, f *graphql.BooleanFilter) []qm.QueryMod {
+	if f == nil {
+		return nil
+	}
+	c := conditions{column: pq.QuoteIdentifier(column)}
+	compare(&c, "=", f.IsTrue)
+	if f.IsFalse != nil {
+		isTrue := !*f.IsFalse
+		compare(&c, "=", &isTrue)
+	}
+	switch {
+	case f.IsNull == nil:
+	case *f.IsNull:
+		c.mods = append(c.mods, qm.Where(c.column+" IS NULL"))
+	default:
+		c.mods = append(c.mods, qm.Where(c.column+" IS NOT NULL"))
+	}
+	return c.mods
+}
+
+// Search returns the condition matching the rows one of whose columns contains term, ignoring
+// case, no condition when term is empty
+func Search(term *string, columns ...string) []qm.QueryMod {
+	if term == nil || *term == "" || len(columns) == 0 {
+		return nil
+	}
+	pattern := "%" + escapeLike(*term) + "%"
+	clauses := make([]string, 0, len(columns))
+	patterns := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		clauses = append(clauses, pq.QuoteIdentifier(column)+" ILIKE ?")
+		patterns = append(patterns, pattern)
+	}
+	return []qm.QueryMod{qm.Where("("+strings.Join(clauses, " OR ")+")", patterns...)}
+}
+
+// Combine returns the conditions of a where input, its own conditions and those of its and
+// input being and'ed, then or'ed with those of its or input. A where input without conditions
+// matches every row, so does an or'ed one.
+func Combine(conditions, and, or []qm.QueryMod) []qm.QueryMod {
+	if len(and) > 0 {
+		conditions = append(conditions, qm.Expr(and...))
+	}
+	if len(or) == 0 {
+		return conditions
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+	return []qm.QueryMod{qm.Expr(qm.Expr(conditions...), qm.Or2(qm.Expr(or...)))}
+}
+
+// conditions accumulates the conditions of a filter on column
+type conditions struct {
+	column string
+	mods   []qm.QueryMod
+}
+
+func compare[T any](c *conditions, operator string, v *T) {
+	if v == nil {
+		return
+	}
+	c.mods = append(c.mods, qm.Where(fmt.Sprintf("%s %s ?", c.column, operator), *v))
+}
+
+// in adds the condition that the column is, or is not, one of values, nil values meaning the
+// condition isn't set
+func in[T any](c *conditions, values []T, not bool) {
+	switch {
+	case values == nil, len(values) == 0 && not:
+		// nothing is excluded
+	case len(values) == 0:
+		c.mods = append(c.mods, qm.Where("FALSE"))
+	case not:
+		c.mods = append(c.mods, qm.WhereNotIn(c.column+" NOT IN ?", args(values)...))
+	default:
+		c.mods = append(c.mods, qm.WhereIn(c.column+" IN ?", args(values)...))
+	}
+}
+
+func like(c *conditions, operator string, v *string, prefix, suffix string) {
+	if v == nil {
+		return
+	}
+	c.mods = append(c.mods, qm.Where(fmt.Sprintf("%s %s ?", c.column, operator), prefix+escapeLike(*v)+suffix))
+}
+
+// escapeLike escapes the wildcards of s, so that it is matched as is by LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func millis(v *int) *time.Time {
+	if v == nil {
+		return nil
+	}
+	t := time.UnixMilli(int64(*v))
+	return &t
+}
+
+func allMillis(values []int) []time.Time {
+	if values == nil {
+		return nil
+	}
+	times := make([]time.Time, 0, len(values))
+	for _, v := range values {
+		times = append(times, time.UnixMilli(int64(v)))
+	}
+	return times
+}
+
+func args[T any](values []T) []interface{} {
+	all := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		all = append(all, v)
+	}
+	return all
+}
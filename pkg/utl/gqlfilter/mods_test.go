@@ -0,0 +1,111 @@
+package gqlfilter_test
+
+import (
+	"testing"
+	"time"
+
+	graphql "go-template/gqlmodels"
+	"go-template/models"
+	"go-template/pkg/utl/gqlfilter"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// where returns the where clause and the arguments of the query of mods
+func where(mods []qm.QueryMod) (string, []interface{}) {
+	query, args := queries.BuildQuery(models.NewQuery(append([]qm.QueryMod{qm.From("roles")}, mods...)...))
+	return query, args
+}
+
+func TestMods(t *testing.T) {
+	at := time.UnixMilli(1_700_000_000_000)
+	tests := []struct {
+		name     string
+		mods     []qm.QueryMod
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "No filter",
+			mods:    gqlfilter.StringMods("name", nil),
+			wantSQL: `SELECT * FROM "roles";`,
+		},
+		{
+			name:     "ID",
+			mods:     gqlfilter.IDMods("id", &graphql.IDFilter{EqualTo: ptr("1"), NotIn: []string{"2", "3"}}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("id" = $1) AND ("id" NOT IN ($2,$3));`,
+			wantArgs: []interface{}{"1", "2", "3"},
+		},
+		{
+			name: "String",
+			mods: gqlfilter.StringMods("name", &graphql.StringFilter{In: []string{"ADMIN"}, StartWith: ptr("ad"),
+				NotContainStrict: ptr("100%")}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("name" IN ($1)) AND ("name" ILIKE $2) AND ("name" NOT LIKE $3);`,
+			wantArgs: []interface{}{"ADMIN", "ad%", `%100\%%`},
+		},
+		{
+			name:     "Int",
+			mods:     gqlfilter.IntMods("access_level", &graphql.IntFilter{MoreThan: ptr(100), LessThanOrEqualTo: ptr(200)}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("access_level" <= $1) AND ("access_level" > $2);`,
+			wantArgs: []interface{}{200, 100},
+		},
+		{
+			name:     "Empty in",
+			mods:     gqlfilter.IntMods("access_level", &graphql.IntFilter{In: []int{}, NotIn: []int{}}),
+			wantSQL:  `SELECT * FROM "roles" WHERE (FALSE);`,
+			wantArgs: nil,
+		},
+		{
+			name:     "Float",
+			mods:     gqlfilter.FloatMods("amount", &graphql.FloatFilter{NotEqualTo: ptr(1.5)}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("amount" <> $1);`,
+			wantArgs: []interface{}{1.5},
+		},
+		{
+			name:     "Time",
+			mods:     gqlfilter.TimeMods("created_at", &graphql.IntFilter{MoreThanOrEqualTo: ptr(1_700_000_000_000)}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("created_at" >= $1);`,
+			wantArgs: []interface{}{at},
+		},
+		{
+			name:     "Boolean",
+			mods:     gqlfilter.BooleanMods("active", &graphql.BooleanFilter{IsFalse: ptr(true), IsNull: ptr(false)}),
+			wantSQL:  `SELECT * FROM "roles" WHERE ("active" = $1) AND ("active" IS NOT NULL);`,
+			wantArgs: []interface{}{false},
+		},
+		{
+			name:     "Search",
+			mods:     gqlfilter.Search(ptr("ad_"), "name", "description"),
+			wantSQL:  `SELECT * FROM "roles" WHERE (("name" ILIKE $1 OR "description" ILIKE $2));`,
+			wantArgs: []interface{}{`%ad\_%`, `%ad\_%`},
+		},
+		{
+			name:    "Empty search",
+			mods:    gqlfilter.Search(ptr(""), "name"),
+			wantSQL: `SELECT * FROM "roles";`,
+		},
+		{
+			name: "Combine",
+			mods: gqlfilter.Combine(gqlfilter.StringMods("name", &graphql.StringFilter{EqualTo: ptr("ADMIN")}),
+				gqlfilter.IntMods("access_level", &graphql.IntFilter{LessThan: ptr(200)}),
+				gqlfilter.IDMods("id", &graphql.IDFilter{EqualTo: ptr("1")})),
+			wantSQL:  `SELECT * FROM "roles" WHERE (("name" = $1 AND ("access_level" < $2)) OR ("id" = $3));`,
+			wantArgs: []interface{}{"ADMIN", 200, "1"},
+		},
+		{
+			name: "Or without conditions",
+			mods: gqlfilter.Combine(nil, nil, gqlfilter.IDMods("id", &graphql.IDFilter{EqualTo: ptr("1")})),
+			// a where input without conditions matches every row
+			wantSQL: `SELECT * FROM "roles";`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := where(tt.mods)
+			assert.Equal(t, tt.wantSQL, query)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
@@ -1,20 +1,77 @@
 package rediscache
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
+
+	"go-template/internal/service/metrics"
 
 	redigo "github.com/gomodule/redigo/redis"
 )
 
+const (
+	maxIdleConns = 10
+	idleTimeout  = 240 * time.Second
+)
+
+var pool = newPool()
+
+func newPool() *redigo.Pool {
+	return &redigo.Pool{
+		MaxIdle:     maxIdleConns,
+		IdleTimeout: idleTimeout,
+		Dial: func() (redigo.Conn, error) {
+			return redigo.Dial("tcp", os.Getenv("REDIS_ADDRESS"))
+		},
+	}
+}
+
+// redisDial borrows a connection from the pool, callers must close it to give it back
 func redisDial() (redigo.Conn, error) {
-	conn, err := redigo.Dial("tcp", os.Getenv("REDIS_ADDRESS"))
+	conn := pool.Get()
 	// Connection error handling
+	if err := conn.Err(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Pool returns the connection pool shared by the cache and the other redis clients
+func Pool() *redigo.Pool {
+	return pool
+}
+
+// Close closes the connection pool, it is called on shutdown
+func Close() error {
+	return pool.Close()
+}
+
+// do runs command on conn and records its latency
+func do(conn redigo.Conn, command string, args ...interface{}) (interface{}, error) {
+	start := time.Now()
+	reply, err := conn.Do(command, args...)
+	metrics.ObserveRedisCall(command, start, err)
+	return reply, err
+}
+
+// Ping checks that redis is reachable within the deadline of ctx
+func Ping(ctx context.Context) error {
+	var opts []redigo.DialOption
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := time.Until(deadline)
+		opts = append(opts, redigo.DialReadTimeout(timeout), redigo.DialWriteTimeout(timeout))
+	}
+	conn, err := redigo.DialContext(ctx, "tcp", os.Getenv("REDIS_ADDRESS"), opts...)
 	if err != nil {
-		return conn, err
+		return err
 	}
-	return conn, err
+	defer conn.Close()
+	_, err = do(conn, "PING")
+	return err
 }
 
 // SetKeyValue ...
@@ -23,11 +80,12 @@ func SetKeyValue(key string, data interface{}) error {
 	if err != nil {
 		return fmt.Errorf("error in redis connection %s", err)
 	}
+	defer conn.Close()
 	b, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
-	_, err = conn.Do("SET", key, string(b))
+	_, err = do(conn, "SET", key, string(b))
 	return err
 }
 
@@ -37,7 +95,8 @@ func GetKeyValue(key string) (interface{}, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error in redis connection %s", err)
 	}
+	defer conn.Close()
 
-	reply, err := conn.Do("GET", key)
+	reply, err := do(conn, "GET", key)
 	return reply, err
 }
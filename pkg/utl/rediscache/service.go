@@ -87,7 +87,7 @@ func IncVisits(path string) (int, error) {
 	}
 	defer conn.Close()
 
-	return redigo.Int(conn.Do("INCR", path))
+	return redigo.Int(do(conn, "INCR", path))
 }
 
 // StartVisits is called when the visiter is first time entering the
@@ -101,7 +101,7 @@ func StartVisits(path string, exp time.Duration) error {
 
 	ttl := math.Ceil(exp.Seconds())
 
-	_, err = conn.Do("SETEX", path, int(ttl), 1)
+	_, err = do(conn, "SETEX", path, int(ttl), 1)
 	if err != nil {
 		return err
 	}
@@ -5,15 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"net/http/httptest"
+	"os"
 	"regexp"
 	"strings"
 
-	"go-template/internal/server"
+	"go-template/pkg/utl/apperror"
 	"go-template/pkg/utl/zaplog"
 
 	graphql2 "github.com/99designs/gqlgen/graphql"
-	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
@@ -157,44 +156,24 @@ func HandleGraphQLError(errMsg string) graphql2.ResponseHandler {
 	}
 }
 
-// ResolverSQLError ...
-func ResolverSQLError(err error, detail string) error {
-	zaplog.Logger.Info(err.Error(), detail)
-	if strings.Contains(fmt.Sprint(err), "no rows in result") {
-		return ResolverWrapperFromMessage(http.StatusBadRequest, fmt.Sprint("No data found with provided ", detail))
-	}
-	if strings.Contains(fmt.Sprint(err), "unable to update") {
-		return ResolverWrapperFromMessage(http.StatusInternalServerError, fmt.Sprint("Unable to update ", detail))
-	}
-	if strings.Contains(fmt.Sprint(err), "unable to insert") {
-		return ResolverWrapperFromMessage(http.StatusInternalServerError, fmt.Sprint("Unable to save provided ", detail))
-	}
-	if strings.Contains(fmt.Sprint(err), "delete on table") &&
-		strings.Contains(fmt.Sprint(err), "violates foreign key constraint") {
-		return ResolverWrapperFromMessage(http.StatusInternalServerError,
-			"Unable to complete the delete operation, it has useful data associated to it")
+// HandleAppError returns a graphql response holding err presented with its error code, internal
+// errors are masked in production like the error presenter of the server does.
+// ctx is the operation context, gqlgen does not pass one to handlers returned before next is called.
+func HandleAppError(ctx context.Context, err error) graphql2.ResponseHandler {
+	return func(context.Context) *graphql2.Response {
+		return &graphql2.Response{
+			Errors: gqlerror.List{apperror.Present(ctx, err, os.Getenv("ENVIRONMENT_NAME") == "production")},
+		}
 	}
-	return ResolverWrapperFromMessage(http.StatusBadRequest, fmt.Sprint(err))
 }
 
-// ResolverWrapperFromMessage ...
-func ResolverWrapperFromMessage(errorCode int, err string) error {
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "", nil)
-	req.Header.Set("Content-Type", "application/json")
-	e := echo.New()
-	e.Validator = &server.CustomValidator{V: validator.New()}
-	e.Binder = server.NewBinder()
-	c := e.NewContext(req, w)
-
-	errMessage := fmt.Sprint(err)
-	er := ErrorFormatter(errMessage)
-
-	c.Echo().Debug = true
+// ResolverSQLError classifies an error returned by the database layer into a typed application error
+func ResolverSQLError(err error, detail string) error {
+	zaplog.Logger.Infow(err.Error(), "detail", detail)
+	return apperror.FromSQL(err, detail)
+}
 
-	e1 := c.JSON(errorCode, er)
-	if e1 != nil {
-		return e1
-	}
-	return fmt.Errorf("%s", errMessage)
+// ResolverWrapperFromMessage returns a typed application error for the given http status code
+func ResolverWrapperFromMessage(errorCode int, err string) error {
+	return apperror.New(apperror.FromHTTPStatus(errorCode), err)
 }
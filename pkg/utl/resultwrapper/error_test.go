@@ -1,16 +1,21 @@
 package resultwrapper_test
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"go-template/pkg/utl/apperror"
 	resultwrapper "go-template/pkg/utl/resultwrapper"
 	"go-template/testutls"
 
 	"github.com/agiledragon/gomonkey/v2"
+	"github.com/friendsofgo/errors"
 	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -536,10 +541,10 @@ type args struct {
 
 func TestResolverSQLError(t *testing.T) {
 	tests := []struct {
-		name          string
-		args          args
-		errMsg        string
-		dontAddDetail bool
+		name   string
+		args   args
+		errMsg string
+		code   apperror.Code
 	}{
 		{
 			name: SuccessCase,
@@ -547,51 +552,68 @@ func TestResolverSQLError(t *testing.T) {
 				err:    fmt.Errorf("this is some error"),
 				detail: DetailMsg,
 			},
-			errMsg:        "this is some error",
-			dontAddDetail: true,
+			errMsg: "Unable to process " + DetailMsg,
+			code:   apperror.Internal,
 		},
 		{
 			name: "Success_NoData",
 			args: args{
 				detail: DetailMsg,
-				err:    fmt.Errorf("no rows in result"),
-			},
-			errMsg: "No data found with provided",
-		},
-		{
-			name: "Success_UnableToUpdate",
-			args: args{
-				detail: DetailMsg,
-				err:    fmt.Errorf("unable to update"),
+				err:    sql.ErrNoRows,
 			},
-			errMsg: "Unable to update",
+			errMsg: "No data found with provided " + DetailMsg,
+			code:   apperror.NotFound,
 		},
 		{
-			name: "Success_UnableToInsert",
+			name: "Success_Duplicate",
 			args: args{
 				detail: DetailMsg,
-				err:    fmt.Errorf("unable to insert"),
+				err:    errors.Wrap(&pq.Error{Code: "23505"}, "models: unable to insert into users"),
 			},
-			errMsg: "Unable to save provided",
+			errMsg: "Data already exists for provided " + DetailMsg,
+			code:   apperror.Conflict,
 		},
 		{
 			name: "Success_UnableToDelete",
 			args: args{
 				detail: DetailMsg,
-				err:    fmt.Errorf("delete on table. violates foreign key constraint"),
+				err:    &pq.Error{Code: "23503"},
 			},
-			errMsg:        "Unable to complete the delete operation, it has useful data associated to it",
-			dontAddDetail: true,
+			errMsg: "Unable to complete the operation, it has useful data associated to it",
+			code:   apperror.Conflict,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := resultwrapper.ResolverSQLError(tt.args.err, tt.args.detail)
-			errorMessage := fmt.Sprintf("%s %s", tt.errMsg, tt.args.detail)
-			if tt.dontAddDetail {
-				errorMessage = tt.errMsg
-			}
-			assert.Equal(t, fmt.Errorf("%s", errorMessage), err)
+			assert.Equal(t, tt.errMsg, err.Error())
+			assert.Equal(t, tt.code, apperror.CodeOf(err))
+		})
+	}
+}
+
+func TestResolverWrapperFromMessage(t *testing.T) {
+	err := resultwrapper.ResolverWrapperFromMessage(http.StatusNotFound, ErrMsg)
+	assert.Equal(t, ErrMsg, err.Error())
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+}
+
+func TestHandleAppError(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		want        string
+	}{
+		{name: "Production", environment: "production", want: "internal server error"},
+		{name: "Local", environment: "local", want: ErrMsg},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENVIRONMENT_NAME", tt.environment)
+			ctx := context.Background()
+			res := resultwrapper.HandleAppError(ctx, errors.New(ErrMsg))(ctx)
+			assert.Equal(t, tt.want, res.Errors[0].Message)
+			assert.Equal(t, apperror.Internal, res.Errors[0].Extensions[apperror.CodeExtension])
 		})
 	}
 }
@@ -12,19 +12,25 @@ import (
 
 // New initializes security service
 func New(minPWStr int, h hash.Hash) Service {
+	return NewWithPolicy(func() int { return minPWStr }, h)
+}
+
+// NewWithPolicy initializes security service whose minimum password strength is read from
+// minPWStr on every check, so that it can change at runtime
+func NewWithPolicy(minPWStr func() int, h hash.Hash) Service {
 	return Service{minPWStr: minPWStr, h: h}
 }
 
 // Service holds security related methods
 type Service struct {
-	minPWStr int
+	minPWStr func() int
 	h        hash.Hash
 }
 
 // Password checks whether password is secure enough using zxcvbn library
 func (s Service) Password(pass string, inputs ...string) bool {
 	pwStrength := zxcvbn.PasswordStrength(pass, inputs)
-	return pwStrength.Score >= s.minPWStr
+	return pwStrength.Score >= s.minPWStr()
 }
 
 // Hash hashes the password using bcrypt
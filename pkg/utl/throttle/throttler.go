@@ -6,6 +6,8 @@ import (
 	"os"
 	"time"
 
+	"go-template/internal/service/metrics"
+	"go-template/pkg/utl/apperror"
 	rediscache "go-template/pkg/utl/rediscache"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -32,15 +34,16 @@ func Check(ctx context.Context, limit int, dur time.Duration) error {
 
 	num, err := rediscache.IncVisits(key)
 	if err != nil {
-		return fmt.Errorf("Internal error")
+		return apperror.Wrap(apperror.Internal, err, "Internal error")
 	}
 
 	if num > limit {
-		return fmt.Errorf("You reached the rate limit for this query")
+		metrics.ThrottleRejected(query)
+		return apperror.NewRateLimited("You reached the rate limit for this query")
 	} else if num == 1 {
 		err := rediscache.StartVisits(key, dur)
 		if err != nil {
-			return fmt.Errorf("Internal error")
+			return apperror.Wrap(apperror.Internal, err, "Internal error")
 		}
 	}
 
@@ -0,0 +1,78 @@
+package zaplog
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RequestLogger logs one line per request with its status, latency and the ids of the
+// request context. Server errors are logged at error level and client errors at warn level.
+func RequestLogger() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			req, res := c.Request(), c.Response()
+			fields := []interface{}{
+				"method", req.Method,
+				"uri", req.RequestURI,
+				"status", res.Status,
+				"latency", time.Since(start).String(),
+				"remote_ip", c.RealIP(),
+				"bytes_in", req.ContentLength,
+				"bytes_out", res.Size,
+				"user_agent", req.UserAgent(),
+			}
+			if err != nil {
+				fields = append(fields, "error", err.Error())
+			}
+			logger := For(req.Context())
+			switch {
+			case res.Status >= 500:
+				logger.Errorw("request", fields...)
+			case res.Status >= 400:
+				logger.Warnw("request", fields...)
+			default:
+				logger.Infow("request", fields...)
+			}
+			return err
+		}
+	}
+}
+
+// BodySampleRate returns the share of requests whose bodies are logged, read from
+// LOG_BODY_SAMPLE_RATE. Bodies are logged for every request outside production and
+// never in production unless configured otherwise.
+func BodySampleRate() float64 {
+	if rate, err := strconv.ParseFloat(os.Getenv("LOG_BODY_SAMPLE_RATE"), 64); err == nil {
+		return rate
+	}
+	if os.Getenv("ENVIRONMENT_NAME") == "production" {
+		return 0
+	}
+	return 1
+}
+
+// BodyLogger logs the redacted request and response bodies of a sampled share of requests
+func BodyLogger(rate float64) echo.MiddlewareFunc {
+	return middleware.BodyDumpWithConfig(middleware.BodyDumpConfig{
+		Skipper: func(echo.Context) bool {
+			return rate <= 0 || rate < 1 && rand.Float64() >= rate // nolint:gosec
+		},
+		Handler: func(c echo.Context, reqBody, resBody []byte) {
+			For(c.Request().Context()).Infow("body",
+				"request_body", string(Redact(reqBody)),
+				"response_body", string(Redact(resBody)),
+			)
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package zaplog
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against every part of a JSON key, so
+// oldPassword, refreshToken or clientSecret are redacted as well. LOG_REDACT_FIELDS
+// adds comma separated entries to the list.
+var sensitiveKeys = append([]string{"password", "token", "secret", "authorization"},
+	splitList(os.Getenv("LOG_REDACT_FIELDS"))...)
+
+// inlineArgument matches sensitive string arguments written inline in a GraphQL document
+var inlineArgument = regexp.MustCompile(
+	`(?i)(\b\w*(?:` + strings.Join(quoteAll(sensitiveKeys), "|") + `)\w*\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func quoteAll(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = regexp.QuoteMeta(v)
+	}
+	return out
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact masks passwords, tokens and secrets in a JSON body, typically a GraphQL request
+// with its variables or a GraphQL response. Inline arguments of the query document are
+// masked too. Bodies that aren't JSON are returned as they are.
+func Redact(body []byte) []byte {
+	var v interface{}
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	b, err := json.Marshal(redact("", v))
+	if err != nil {
+		return body
+	}
+	return b
+}
+
+// RedactDocument masks the sensitive string arguments written inline in a GraphQL document
+func RedactDocument(query string) string {
+	return inlineArgument.ReplaceAllString(query, `${1}"`+redacted+`"`)
+}
+
+func redact(key string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			if isSensitive(k) {
+				if _, isObject := item.(map[string]interface{}); !isObject {
+					value[k] = redacted
+					continue
+				}
+			}
+			value[k] = redact(k, item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redact(key, item)
+		}
+		return value
+	case string:
+		if key == "query" {
+			return RedactDocument(value)
+		}
+	}
+	return v
+}
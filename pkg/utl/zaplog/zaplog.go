@@ -4,42 +4,118 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var RequestIdCtxKey = &ContextKey{echo.HeaderXRequestID}
 
+// UserIDCtxKey holds the id of the authenticated user, it is set by the auth middleware
+var UserIDCtxKey = &ContextKey{"userID"}
+
 type ContextKey struct {
 	Name string
 }
 
+// level is the level of the logger built by InitLogger, it can be changed at runtime
+var level = zap.NewAtomicLevel()
+
 var Logger = InitLogger()
 
+// SetLevel changes the level of the logger built by InitLogger to debug, info, warn or error
+func SetLevel(name string) error {
+	l, err := zapcore.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// Level returns the level of the logger built by InitLogger
+func Level() string {
+	return level.String()
+}
+
 func SetLogger(logger *zap.SugaredLogger) *zap.SugaredLogger {
 	Logger = logger
 	return Logger
 }
 
-func getRequestID(c context.Context) string {
-	return fmt.Sprintf("\nRequest-ID: %v", c.Value(RequestIdCtxKey))
+// For returns Logger annotated with the request_id, trace_id, span_id and user_id found in c
+func For(c context.Context) *zap.SugaredLogger {
+	return with(Logger, c)
 }
-func Info(c context.Context, args ...interface{}) {
-	Logger.Info(getRequestID(c), "\n", args, "\n")
+
+func with(logger *zap.SugaredLogger, c context.Context) *zap.SugaredLogger {
+	if c == nil {
+		return logger
+	}
+	var fields []interface{}
+	if requestID, ok := c.Value(RequestIdCtxKey).(string); ok && requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+	if sc := trace.SpanContextFromContext(c); sc.IsValid() {
+		fields = append(fields, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	if userID, ok := c.Value(UserIDCtxKey).(int); ok && userID != 0 {
+		fields = append(fields, "user_id", userID)
+	}
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
+
+// caller skips the wrappers below so that log lines point at their call site
+func caller(c context.Context) *zap.SugaredLogger {
+	return with(Logger.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(), c)
 }
+
 func Debug(c context.Context, args ...interface{}) {
-	Logger.Debug(getRequestID(c), "\n", args, "\n")
+	caller(c).Debug(args...)
+}
+func Info(c context.Context, args ...interface{}) {
+	caller(c).Info(args...)
 }
+func Warn(c context.Context, args ...interface{}) {
+	caller(c).Warn(args...)
+}
+func Error(c context.Context, args ...interface{}) {
+	caller(c).Error(args...)
+}
+
+// InitLogger builds the logger from the environment:
+//   - LOG_LEVEL: debug, info, warn or error, defaults to info in production and debug elsewhere
+//   - LOG_FORMAT: json or console, defaults to json in production and console elsewhere
 func InitLogger() *zap.SugaredLogger {
-	var zapLogger *zap.Logger
-	var err error
+	cfg := zap.NewDevelopmentConfig()
 	if os.Getenv("ENVIRONMENT_NAME") == "production" {
-		zapLogger, err = zap.NewProduction()
-	} else {
-		zapLogger, err = zap.NewDevelopment()
+		cfg = zap.NewProductionConfig()
 	}
+	if level := os.Getenv("LOG_LEVEL"); level != "" {
+		l, err := zapcore.ParseLevel(level)
+		if err != nil {
+			panic(fmt.Errorf("invalid LOG_LEVEL: %w", err))
+		}
+		cfg.Level.SetLevel(l)
+	}
+	switch format := strings.ToLower(os.Getenv("LOG_FORMAT")); format {
+	case "":
+	case "json", "console":
+		cfg.Encoding = format
+	default:
+		panic(fmt.Errorf("invalid LOG_FORMAT %q, expected json or console", format))
+	}
+
+	level.SetLevel(cfg.Level.Level())
+	cfg.Level = level
 
+	zapLogger, err := cfg.Build()
 	if err != nil {
 		panic(err)
 	}
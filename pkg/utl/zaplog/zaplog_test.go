@@ -3,11 +3,16 @@ package zaplog
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/agiledragon/gomonkey/v2"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
@@ -15,109 +20,137 @@ import (
 
 const (
 	ErrorFromProduction = "Error production"
-	ErrMsgProduction    = "ZAP_NEW_PRODUCTION_ERROR"
 	InformationTest     = "test info"
 	InfoMessage         = "This is an info log"
 	DebugMessage        = "This is a debug log"
 	NewCase             = "New Case"
+	RequestID           = "request-id"
 )
 
-type SugaredLogger struct {
-	*zap.SugaredLogger
+func observe(level zapcore.Level) *observer.ObservedLogs {
+	observedZapCore, observedLogs := observer.New(level)
+	_ = SetLogger(zap.New(observedZapCore).Sugar())
+	return observedLogs
 }
 
-func TestInfo(t *testing.T) {
-	type args struct {
-		c   context.Context
-		msg string
-	}
-
+func TestLevels(t *testing.T) {
 	tests := []struct {
-		name string
-		args args
+		name  string
+		log   func(c context.Context, args ...interface{})
+		level zapcore.Level
+		msg   string
 	}{
 		{
-			name: InformationTest,
-			args: args{
-				c:   context.Background(),
-				msg: InfoMessage,
-			},
+			name:  InformationTest,
+			log:   Info,
+			level: zapcore.InfoLevel,
+			msg:   InfoMessage,
 		},
 		{
-			name: ErrorFromProduction,
-			args: args{
-				c:   context.Background(),
-				msg: InfoMessage,
-			},
+			name:  DebugMessage,
+			log:   Debug,
+			level: zapcore.DebugLevel,
+			msg:   DebugMessage,
 		},
 		{
-			name: NewCase,
-			args: args{
-				c:   context.Background(),
-				msg: InfoMessage,
-			},
+			name:  "Warn",
+			log:   Warn,
+			level: zapcore.WarnLevel,
+			msg:   "This is a warning",
+		},
+		{
+			name:  ErrorFromProduction,
+			log:   Error,
+			level: zapcore.ErrorLevel,
+			msg:   "This is an error",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			observedZapCore, observedLogs := observer.New(zap.InfoLevel)
-			observedLogger := zap.New(observedZapCore).Sugar()
-
-			_ = SetLogger(observedLogger)
-			Info(tt.args.c, tt.args.msg)
+			observedLogs := observe(zap.DebugLevel)
+			tt.log(context.Background(), tt.msg)
 			assert.Equal(t, 1, observedLogs.Len())
 			log := observedLogs.All()[0]
-			assert.Equal(t, fmt.Sprintf("\nRequest-ID: <nil>\n[%s]\n", tt.args.msg), log.Message)
-			assert.Equal(t, zapcore.Level(0), log.Level)
+			assert.Equal(t, tt.msg, log.Message)
+			assert.Equal(t, tt.level, log.Level)
+			assert.Empty(t, log.Context)
 		})
 	}
 }
 
-func TestDebug(t *testing.T) {
-	type args struct {
-		c   context.Context
-		msg string
-	}
+func TestFor(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("0102030405060708090a0b0c0d0e0f10")
+	spanID, _ := trace.SpanIDFromHex("0102030405060708")
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
 	tests := []struct {
 		name string
-		args args
+		ctx  context.Context
+		want map[string]interface{}
 	}{
 		{
-			name: InformationTest,
-			args: args{
-				c:   context.Background(),
-				msg: DebugMessage,
+			name: "Empty context",
+			ctx:  context.Background(),
+			want: map[string]interface{}{},
+		},
+		{
+			name: NewCase,
+			ctx: context.WithValue(
+				context.WithValue(spanCtx, RequestIdCtxKey, RequestID),
+				UserIDCtxKey, 1,
+			),
+			want: map[string]interface{}{
+				"request_id": RequestID,
+				"trace_id":   traceID.String(),
+				"span_id":    spanID.String(),
+				"user_id":    int64(1),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			observedZapCore, observedLogs := observer.New(zap.DebugLevel)
-			observedLogger := zap.New(observedZapCore).Sugar()
-			_ = SetLogger(observedLogger)
-			Debug(tt.args.c, tt.args.msg)
+			observedLogs := observe(zap.InfoLevel)
+			For(tt.ctx).Infow(InfoMessage)
 			assert.Equal(t, 1, observedLogs.Len())
-			log := observedLogs.All()[0]
-			assert.Equal(t, fmt.Sprintf("\nRequest-ID: <nil>\n[%s]\n", tt.args.msg), log.Message)
-			assert.Equal(t, zapcore.Level(-1), log.Level)
+			assert.Equal(t, tt.want, observedLogs.All()[0].ContextMap())
 		})
 	}
 }
 
 func TestInitLogger(t *testing.T) {
-	mockZapLog := zap.Logger{}
-	mockZapLoggerSugar := mockZapLog.Sugar()
 	tests := []struct {
-		name     string
-		panicErr bool
-		res      *zap.SugaredLogger
+		name      string
+		env       map[string]string
+		level     zapcore.Level
+		panicErr  bool
+		hasCaller bool
 	}{
 		{
-			name: "production",
-			res:  mockZapLoggerSugar,
+			name:  "production",
+			env:   map[string]string{"ENVIRONMENT_NAME": "production"},
+			level: zapcore.InfoLevel,
 		},
 		{
-			name:     "panic error",
+			name:  "local",
+			env:   map[string]string{"ENVIRONMENT_NAME": "local"},
+			level: zapcore.DebugLevel,
+		},
+		{
+			name:  "level and format",
+			env:   map[string]string{"ENVIRONMENT_NAME": "production", "LOG_LEVEL": "warn", "LOG_FORMAT": "console"},
+			level: zapcore.WarnLevel,
+		},
+		{
+			name:     "invalid level",
+			env:      map[string]string{"LOG_LEVEL": "verbose"},
+			panicErr: true,
+		},
+		{
+			name:     "invalid format",
+			env:      map[string]string{"LOG_FORMAT": "xml"},
 			panicErr: true,
 		},
 	}
@@ -125,26 +158,173 @@ func TestInitLogger(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			patchEnv := gomonkey.ApplyFunc(os.Getenv, func(key string) string {
-				return "production"
+				return tt.env[key]
 			})
 			defer patchEnv.Reset()
 
-			patchProduction := gomonkey.ApplyFunc(zap.NewProduction, func(options ...zap.Option) (*zap.Logger, error) {
-				if tt.panicErr {
-					return &mockZapLog, fmt.Errorf("%s", ErrMsgProduction)
-				} else {
-					return &mockZapLog, nil
-				}
-			})
-
-			defer patchProduction.Reset()
-
 			if tt.panicErr {
 				assert.Panics(t, func() { InitLogger() })
 			} else {
 				response := InitLogger()
-				assert.Equal(t, tt.res, response)
+				assert.True(t, response.Desugar().Core().Enabled(tt.level))
+				assert.False(t, response.Desugar().Core().Enabled(tt.level-1))
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "Variables",
+			body: `{"query":"mutation Login($u: String!, $p: String!) { login(username: $u, password: $p) { token } }",` +
+				`"variables":{"u":"admin","password":"secret123","input":{"oldPassword":"a","newPassword":"b"}}}`,
+			want: `{"query":"mutation Login($u: String!, $p: String!) { login(username: $u, password: $p) { token } }",` +
+				`"variables":{"input":{"newPassword":"[REDACTED]","oldPassword":"[REDACTED]"},` +
+				`"password":"[REDACTED]","u":"admin"}}`,
+		},
+		{
+			name: "Inline arguments",
+			body: `{"query":"mutation { login(username: \"admin\", password: \"secret123\") { token } }"}`,
+			want: `{"query":"mutation { login(username: \"admin\", password: \"[REDACTED]\") { token } }"}`,
+		},
+		{
+			name: "Response",
+			body: `{"data":{"login":{"token":"jwt","refreshToken":"refresh","user":{"id":"1"}}}}`,
+			want: `{"data":{"login":{"refreshToken":"[REDACTED]","token":"[REDACTED]","user":{"id":"1"}}}}`,
+		},
+		{
+			name: "Not JSON",
+			body: "plain text",
+			want: "plain text",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(Redact([]byte(tt.body))))
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler echo.HandlerFunc
+		level   zapcore.Level
+		status  int64
+	}{
+		{
+			name: "Success",
+			handler: func(c echo.Context) error {
+				return c.String(http.StatusOK, "ok")
+			},
+			level:  zapcore.InfoLevel,
+			status: http.StatusOK,
+		},
+		{
+			name: "Client error",
+			handler: func(c echo.Context) error {
+				return echo.NewHTTPError(http.StatusBadRequest, "bad")
+			},
+			level:  zapcore.WarnLevel,
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "Server error",
+			handler: func(c echo.Context) error {
+				return fmt.Errorf("boom")
+			},
+			level:  zapcore.ErrorLevel,
+			status: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observedLogs := observe(zap.DebugLevel)
+			e := echo.New()
+			e.Use(RequestLogger())
+			e.GET("/", tt.handler)
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, 1, observedLogs.Len())
+			log := observedLogs.All()[0]
+			assert.Equal(t, tt.level, log.Level)
+			assert.Equal(t, tt.status, log.ContextMap()["status"])
+		})
+	}
+}
+
+func TestBodyLogger(t *testing.T) {
+	tests := []struct {
+		name string
+		rate float64
+		want int
+	}{
+		{
+			name: "Sampled",
+			rate: 1,
+			want: 1,
+		},
+		{
+			name: "Disabled",
+			rate: 0,
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			observedLogs := observe(zap.DebugLevel)
+			e := echo.New()
+			e.Use(BodyLogger(tt.rate))
+			e.POST("/", func(c echo.Context) error {
+				return c.String(http.StatusOK, `{"data":{"login":{"token":"jwt"}}}`)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(`{"variables":{"password":"secret123"}}`))
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, observedLogs.Len())
+			if tt.want > 0 {
+				fields := observedLogs.All()[0].ContextMap()
+				assert.Equal(t, `{"variables":{"password":"[REDACTED]"}}`, fields["request_body"])
+				assert.Equal(t, `{"data":{"login":{"token":"[REDACTED]"}}}`, fields["response_body"])
+			}
+		})
+	}
+}
+
+func TestBodySampleRate(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want float64
+	}{
+		{
+			name: "local",
+			env:  map[string]string{"ENVIRONMENT_NAME": "local"},
+			want: 1,
+		},
+		{
+			name: "production",
+			env:  map[string]string{"ENVIRONMENT_NAME": "production"},
+			want: 0,
+		},
+		{
+			name: "configured",
+			env:  map[string]string{"ENVIRONMENT_NAME": "production", "LOG_BODY_SAMPLE_RATE": "0.1"},
+			want: 0.1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"ENVIRONMENT_NAME", "LOG_BODY_SAMPLE_RATE"} {
+				t.Setenv(key, tt.env[key])
 			}
+			assert.Equal(t, tt.want, BodySampleRate())
 		})
 	}
 }
@@ -0,0 +1,58 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service/features"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/cnvrttogql"
+	"strconv"
+	"strings"
+)
+
+// SaveFeatureFlag is the resolver for the saveFeatureFlag field.
+func (r *mutationResolver) SaveFeatureFlag(ctx context.Context, input gqlmodels.FeatureFlagInput) (*gqlmodels.FeatureFlag, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	flag := features.Flag{
+		Name:          input.Name,
+		Type:          features.Type(strings.ToLower(string(input.Type))),
+		Enabled:       input.Enabled,
+		Roles:         input.Roles,
+		Organizations: input.Organizations,
+	}
+	if input.Description != nil {
+		flag.Description = *input.Description
+	}
+	if input.Percentage != nil {
+		flag.Percentage = *input.Percentage
+	}
+	for _, userID := range input.UserIds {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			return nil, apperror.Newf(apperror.Validation, "invalid user id %s", userID)
+		}
+		flag.UserIDs = append(flag.UserIDs, id)
+	}
+	saved, err := r.Features.Save(ctx, auth.UserIDFromContext(ctx), flag)
+	if err != nil {
+		return nil, err
+	}
+	return cnvrttogql.FeatureFlagToGraphQlFeatureFlag(saved), nil
+}
+
+// DeleteFeatureFlag is the resolver for the deleteFeatureFlag field.
+func (r *mutationResolver) DeleteFeatureFlag(ctx context.Context, name string) (*gqlmodels.FeatureFlagDeletePayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.Features.Delete(ctx, name); err != nil {
+		return nil, err
+	}
+	return &gqlmodels.FeatureFlagDeletePayload{Name: name}, nil
+}
@@ -0,0 +1,87 @@
+package resolver_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveFeatureFlag(t *testing.T) {
+	description := "CSV exports of the users"
+	tests := []struct {
+		name     string
+		input    fm.FeatureFlagInput
+		want     *fm.FeatureFlag
+		wantCode apperror.Code
+	}{
+		{
+			name: "Success",
+			input: fm.FeatureFlagInput{Name: "exports", Description: &description, Type: fm.FeatureFlagTypePercentage,
+				Enabled: true, Percentage: intPtr(10), UserIds: []string{"2"}, Roles: []string{UserRoleName}},
+			want: &fm.FeatureFlag{Name: "exports", Description: description, Type: fm.FeatureFlagTypePercentage,
+				Enabled: true, Percentage: 10, UserIds: []string{"2"}, Roles: []string{UserRoleName},
+				Organizations: []string{}},
+		},
+		{name: "InvalidUserID", input: fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean,
+			UserIds: []string{"two"}}, wantCode: apperror.Validation},
+		{name: "InvalidPercentage", input: fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean,
+			Percentage: intPtr(10)}, wantCode: apperror.Validation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver1, _ := newResolver(t)
+			got, err := resolver1.Mutation().SaveFeatureFlag(adminContext(), tt.input)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, strconv.Itoa(SuperAdminID), *got.UpdatedBy)
+			assert.NotZero(t, got.CreatedAt)
+			got.UpdatedBy, got.CreatedAt, got.UpdatedAt = nil, 0, 0
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDeleteFeatureFlag(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	saveFlags(t, resolver1, fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean, Enabled: true})
+
+	got, err := resolver1.Mutation().DeleteFeatureFlag(adminContext(), "exports")
+	assert.Nil(t, err)
+	assert.Equal(t, &fm.FeatureFlagDeletePayload{Name: "exports"}, got)
+
+	_, err = resolver1.Mutation().DeleteFeatureFlag(adminContext(), "exports")
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+}
+
+func TestFeatureFlagsRequireSuperAdmin(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	saveFlags(t, resolver1, fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean, Enabled: true})
+	ctx := context.WithValue(userContext(RegularUserID), auth.RoleCtxKey, UserRoleName)
+	operations := map[string]func() error{
+		"FeatureFlags": func() error { _, err := resolver1.Query().FeatureFlags(ctx); return err },
+		"FeatureFlag":  func() error { _, err := resolver1.Query().FeatureFlag(ctx, "exports"); return err },
+		"SaveFeatureFlag": func() error {
+			_, err := resolver1.Mutation().SaveFeatureFlag(ctx,
+				fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean, Enabled: false})
+			return err
+		},
+		"DeleteFeatureFlag": func() error { _, err := resolver1.Mutation().DeleteFeatureFlag(ctx, "exports"); return err },
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, apperror.Forbidden, apperror.CodeOf(operation()))
+		})
+	}
+	flag, err := resolver1.Features.Get("exports")
+	assert.NoError(t, err)
+	assert.True(t, flag.Enabled)
+}
@@ -0,0 +1,48 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/version"
+	"go-template/pkg/utl/cnvrttogql"
+)
+
+// FeatureFlags is the resolver for the featureFlags field.
+func (r *queryResolver) FeatureFlags(ctx context.Context) ([]*gqlmodels.FeatureFlag, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return cnvrttogql.FeatureFlagsToGraphQlFeatureFlags(r.Features.List()), nil
+}
+
+// FeatureFlag is the resolver for the featureFlag field.
+func (r *queryResolver) FeatureFlag(ctx context.Context, name string) (*gqlmodels.FeatureFlag, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	flag, err := r.Features.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return cnvrttogql.FeatureFlagToGraphQlFeatureFlag(flag), nil
+}
+
+// EnabledFeatures is the resolver for the enabledFeatures field.
+func (r *queryResolver) EnabledFeatures(ctx context.Context) ([]string, error) {
+	return r.Features.EnabledFlags(ctx), nil
+}
+
+// ServerVersion is the resolver for the serverVersion field.
+func (r *queryResolver) ServerVersion(ctx context.Context) (*string, error) {
+	v := version.Get().Version
+	return &v, nil
+}
+
+// Query returns gqlmodels.QueryResolver implementation.
+func (r *Resolver) Query() gqlmodels.QueryResolver { return &queryResolver{r} }
+
+type queryResolver struct{ *Resolver }
@@ -0,0 +1,112 @@
+package resolver_test
+
+import (
+	"context"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service/features"
+	"go-template/internal/version"
+	"go-template/pkg/utl/apperror"
+	"go-template/resolver"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+// saveFlags saves flags through the feature flag service of r
+func saveFlags(t *testing.T, r *resolver.Resolver, flags ...fm.FeatureFlagInput) {
+	t.Helper()
+	for _, flag := range flags {
+		_, err := r.Mutation().SaveFeatureFlag(adminContext(), flag)
+		assert.Nil(t, err)
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	saveFlags(t, resolver1,
+		fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean, Enabled: true},
+		fm.FeatureFlagInput{Name: "beta", Type: fm.FeatureFlagTypePercentage, Percentage: intPtr(20)},
+	)
+	got, err := resolver1.Query().FeatureFlags(adminContext())
+	assert.Nil(t, err)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "beta", got[0].Name)
+	assert.Equal(t, fm.FeatureFlagTypePercentage, got[0].Type)
+	assert.Equal(t, 20, got[0].Percentage)
+
+	flag, err := resolver1.Query().FeatureFlag(adminContext(), "exports")
+	assert.Nil(t, err)
+	assert.True(t, flag.Enabled)
+
+	_, err = resolver1.Query().FeatureFlag(adminContext(), "unknown")
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+}
+
+func TestEnabledFeatures(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	saveFlags(t, resolver1,
+		fm.FeatureFlagInput{Name: "exports", Type: fm.FeatureFlagTypeBoolean, Enabled: true},
+		fm.FeatureFlagInput{Name: "admin-tools", Type: fm.FeatureFlagTypeBoolean, Enabled: true,
+			Roles: []string{SuperAdminRoleName}},
+		fm.FeatureFlagInput{Name: "beta", Type: fm.FeatureFlagTypeBoolean, UserIds: []string{"2"}},
+	)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{name: "Anonymous", ctx: context.Background(), want: []string{"exports"}},
+		{name: "SuperAdmin", ctx: context.WithValue(userContext(SuperAdminID), auth.RoleCtxKey, SuperAdminRoleName),
+			want: []string{"admin-tools", "exports"}},
+		{name: "User", ctx: context.WithValue(userContext(RegularUserID), auth.RoleCtxKey, UserRoleName),
+			want: []string{"exports"}},
+		{name: "Subject", ctx: features.WithSubject(context.Background(), features.Subject{Role: SuperAdminRoleName}),
+			want: []string{"admin-tools", "exports"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver1.Query().EnabledFeatures(tt.ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFeatureDirective(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	saveFlags(t, resolver1, fm.FeatureFlagInput{Name: "server-version", Type: fm.FeatureFlagTypeBoolean,
+		Enabled: true, Roles: []string{SuperAdminRoleName}})
+	srv := handler.New(fm.NewExecutableSchema(fm.Config{
+		Resolvers:  resolver1,
+		Directives: fm.DirectiveRoot{Feature: resolver1.Features.Directive},
+	}))
+	srv.AddTransport(transport.POST{})
+	gqlClient := client.New(srv)
+
+	serverVersion := version.Get().Version
+	tests := []struct {
+		name    string
+		subject features.Subject
+		want    *string
+	}{
+		{name: "Off", subject: features.Subject{UserID: RegularUserID, Role: UserRoleName}},
+		{name: "On", subject: features.Subject{UserID: SuperAdminID, Role: SuperAdminRoleName}, want: &serverVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp struct {
+				ServerVersion *string
+			}
+			err := gqlClient.Post(`query { serverVersion }`, &resp, func(r *client.Request) {
+				r.HTTP = r.HTTP.WithContext(features.WithSubject(r.HTTP.Context(), tt.subject))
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, resp.ServerVersion)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-template/daos"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/cnvrttogql"
+	"go-template/pkg/utl/resultwrapper"
+	"strconv"
+)
+
+// RetryJob is the resolver for the retryJob field.
+func (r *mutationResolver) RetryJob(ctx context.Context, id string) (*gqlmodels.Job, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	jobID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, apperror.NewValidation("invalid job id")
+	}
+	job, err := daos.RetryJob(jobID, ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NewNotFound("no dead job found for this id")
+	}
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "job")
+	}
+	return cnvrttogql.JobToGraphQlJob(job), nil
+}
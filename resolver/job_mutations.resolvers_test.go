@@ -0,0 +1,80 @@
+package resolver_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"go-template/daos"
+	fm "go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+	"go-template/resolver"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryJob(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		daoErr   error
+		wantCode apperror.Code
+	}{
+		{
+			name: SuccessCase,
+			id:   "4",
+		},
+		{
+			name:     "Invalid id",
+			id:       "four",
+			wantCode: apperror.Validation,
+		},
+		{
+			name:     "Job isn't dead",
+			id:       "4",
+			daoErr:   sql.ErrNoRows,
+			wantCode: apperror.NotFound,
+		},
+		{
+			name:     "Error from the database",
+			id:       "4",
+			daoErr:   errors.New("connection refused"),
+			wantCode: apperror.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFunc(daos.RetryJob, func(id int64, ctx context.Context) (*daos.Job, error) {
+				assert.Equal(t, int64(4), id)
+				if tt.daoErr != nil {
+					return nil, tt.daoErr
+				}
+				return &daos.Job{ID: id, Kind: "email", Status: daos.JobPending}, nil
+			})
+			defer patches.Reset()
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Mutation().RetryJob(adminContext(), tt.id)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, &fm.Job{ID: "4", Kind: "email", Status: fm.JobStatusPending, RunAt: got.RunAt,
+				CreatedAt: got.CreatedAt}, got)
+		})
+	}
+}
+
+func TestJobsRequireSuperAdmin(t *testing.T) {
+	// the daos aren't patched, the resolvers fail before reaching them
+	resolver1 := resolver.Resolver{}
+	ctx := context.WithValue(userContext(RegularUserID), auth.RoleCtxKey, UserRoleName)
+	_, err := resolver1.Query().Jobs(ctx, nil, nil)
+	assert.Equal(t, apperror.Forbidden, apperror.CodeOf(err))
+	_, err = resolver1.Mutation().RetryJob(ctx, "1")
+	assert.Equal(t, apperror.Forbidden, apperror.CodeOf(err))
+}
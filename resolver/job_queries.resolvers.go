@@ -0,0 +1,40 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/daos"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/cnvrttogql"
+	"go-template/pkg/utl/resultwrapper"
+	"strings"
+)
+
+// Jobs is the resolver for the jobs field.
+func (r *queryResolver) Jobs(ctx context.Context, filter *gqlmodels.JobFilter, pagination *gqlmodels.JobPagination) (*gqlmodels.JobsPayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	var jobFilter daos.JobFilter
+	if filter != nil {
+		if filter.Status != nil {
+			jobFilter.Status = strings.ToLower(filter.Status.String())
+		}
+		if filter.Kind != nil {
+			jobFilter.Kind = *filter.Kind
+		}
+	}
+	if pagination != nil && pagination.Limit != 0 {
+		jobFilter.Limit = pagination.Limit
+		jobFilter.Offset = pagination.Page * pagination.Limit
+	}
+
+	jobs, count, err := daos.FindAllJobsWithCount(jobFilter, ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return &gqlmodels.JobsPayload{Total: int(count), Jobs: cnvrttogql.JobsToGraphQlJobs(jobs)}, nil
+}
@@ -0,0 +1,61 @@
+package resolver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-template/daos"
+	fm "go-template/gqlmodels"
+	"go-template/resolver"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobs(t *testing.T) {
+	status := fm.JobStatusDead
+	kind := "email"
+	tests := []struct {
+		name       string
+		filter     *fm.JobFilter
+		pagination *fm.JobPagination
+		wantFilter daos.JobFilter
+		daoErr     error
+		wantErr    bool
+	}{
+		{
+			name:       SuccessCase,
+			filter:     &fm.JobFilter{Status: &status, Kind: &kind},
+			pagination: &fm.JobPagination{Limit: 10, Page: 2},
+			wantFilter: daos.JobFilter{Status: daos.JobDead, Kind: kind, Limit: 10, Offset: 20},
+		},
+		{
+			name:    "Error from the database",
+			daoErr:  errors.New("connection refused"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFunc(daos.FindAllJobsWithCount,
+				func(filter daos.JobFilter, ctx context.Context) ([]daos.Job, int64, error) {
+					assert.Equal(t, tt.wantFilter, filter)
+					if tt.daoErr != nil {
+						return nil, 0, tt.daoErr
+					}
+					return []daos.Job{{ID: 1, Kind: kind, Status: daos.JobDead}}, 21, nil
+				})
+			defer patches.Reset()
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Query().Jobs(adminContext(), tt.filter, tt.pagination)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if !tt.wantErr {
+				assert.Equal(t, 21, got.Total)
+				assert.Equal(t, "1", got.Jobs[0].ID)
+				assert.Equal(t, fm.JobStatusDead, got.Jobs[0].Status)
+			}
+		})
+	}
+}
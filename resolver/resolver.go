@@ -1,9 +1,30 @@
 package resolver
 
 import (
-	"sync"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	fm "go-template/gqlmodels"
+	"go-template/internal/constants"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service"
+	"go-template/internal/service/features"
+	"go-template/internal/service/outbox"
+	"go-template/internal/service/pubsub"
+	"go-template/internal/service/roles"
+	"go-template/internal/service/settings"
+	"go-template/internal/service/users"
+	"go-template/internal/service/webhooks"
+	"go-template/models"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/cnvrttogql"
+	"go-template/pkg/utl/convert"
+	"go-template/pkg/utl/zaplog"
 )
 
 // This file will
@@ -18,8 +39,181 @@ import (
 // dependencies you
 // require here.
 
+const (
+	// UserTopic is the topic UserEvents are published to
+	UserTopic = "user"
+	// RoleTopic is the topic RoleEvents are published to
+	RoleTopic = "role"
+)
+
 // Resolver ...
 type Resolver struct {
-	sync.Mutex
-	Observers map[string]chan *fm.User
+	// AuthService logs the users in and manages their credentials
+	AuthService *service.Auth
+	// UserService manages the users
+	UserService *users.Service
+	// RoleService manages the roles
+	RoleService *roles.Service
+	// PubSub streams the events relayed from the outbox to the subscriptions
+	PubSub pubsub.PubSub
+	// Webhooks redelivers webhook deliveries
+	Webhooks webhooks.Sink
+	// SettingsStore holds the runtime settings
+	SettingsStore *settings.Store
+	// Features evaluates the feature flags
+	Features *features.Service
+
+	subscriptions int64
+}
+
+// ActiveSubscriptions returns the number of subscribers currently listening for events
+func (r *Resolver) ActiveSubscriptions() int {
+	return int(atomic.LoadInt64(&r.subscriptions))
+}
+
+// Events records the user and role events of the services in the outbox, they reach the
+// subscribers of UserTopic and RoleTopic once the transaction commits
+type Events struct{}
+
+// RecordUser adds to the outbox of tx an event telling that user was created, updated or
+// deleted by the user of ctx
+func (Events) RecordUser(ctx context.Context, tx *sql.Tx, eventType fm.EventType, user *models.User) error {
+	depth := 1
+	if eventType == fm.EventTypeDeleted {
+		// the role isn't loaded since the user is gone
+		depth = constants.MaxDepth
+	}
+	return outbox.Record(ctx, tx, UserTopic, fm.UserEvent{
+		Type:      eventType,
+		User:      cnvrttogql.UserToGraphQlUser(user, depth),
+		Actor:     actor(ctx),
+		Timestamp: int(time.Now().UnixMilli()),
+	})
+}
+
+// RecordRole adds to the outbox of tx an event telling that role was created, updated or
+// deleted by the user of ctx
+func (Events) RecordRole(ctx context.Context, tx *sql.Tx, eventType fm.EventType, role *models.Role) error {
+	return outbox.Record(ctx, tx, RoleTopic, fm.RoleEvent{
+		Type:      eventType,
+		Role:      roleToGraphQL(role),
+		Actor:     actor(ctx),
+		Timestamp: int(time.Now().UnixMilli()),
+	})
+}
+
+// roleToGraphQL converts role without loading its users
+func roleToGraphQL(role *models.Role) *fm.Role {
+	return &fm.Role{
+		ID:          strconv.Itoa(role.ID),
+		AccessLevel: role.AccessLevel,
+		Name:        role.Name,
+	}
+}
+
+// actor identifies the user of ctx on an event, contact details are left out since events
+// reach other users
+func actor(ctx context.Context) *fm.User {
+	user := auth.FromContext(ctx)
+	if user == nil {
+		return nil
+	}
+	return &fm.User{
+		ID:        strconv.Itoa(user.ID),
+		FirstName: convert.NullDotStringToPointerString(user.FirstName),
+		LastName:  convert.NullDotStringToPointerString(user.LastName),
+		Username:  convert.NullDotStringToPointerString(user.Username),
+	}
+}
+
+// subscriber is the authenticated user of a subscription
+type subscriber struct {
+	id     string
+	roleID string
+	admin  bool
+}
+
+// subscriberFromContext loads the user of ctx and whether it is a super admin, which
+// decides the events it is allowed to receive
+func (r *Resolver) subscriberFromContext(ctx context.Context) (subscriber, error) {
+	user := auth.FromContext(ctx)
+	if user == nil {
+		return subscriber{}, apperror.NewUnauthenticated("subscriptions require an authenticated connection")
+	}
+	role, err := r.RoleService.Get(ctx, convert.NullDotIntToInt(user.RoleID))
+	if err != nil {
+		return subscriber{}, err
+	}
+	return subscriber{
+		id:     strconv.Itoa(user.ID),
+		roleID: strconv.Itoa(role.ID),
+		admin:  role.AccessLevel == int(constants.SuperAdminRole),
+	}, nil
+}
+
+// canSeeUser reports whether s is allowed to receive an event about user: super admins see
+// every user, other users only themselves
+func (s subscriber) canSeeUser(user *fm.User) bool {
+	return s.admin || (user != nil && user.ID == s.id)
+}
+
+// canSeeRole reports whether s is allowed to receive an event about role: super admins see
+// every role, other users only their own
+func (s subscriber) canSeeRole(role *fm.Role) bool {
+	return s.admin || (role != nil && role.ID == s.roleID)
+}
+
+// hasType reports whether t was requested, no types meaning all of them
+func hasType(types []fm.EventType, t fm.EventType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, requested := range types {
+		if requested == t {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe streams the events of topic to the returned channel, decoding them as E and
+// forwarding what accept maps them to. Rejected events are dropped. The channel is closed
+// once ctx is done or the subscription is ended by the PubSub, e.g. when the subscriber
+// fell behind and was disconnected.
+func subscribe[E any, T any](
+	ctx context.Context,
+	r *Resolver,
+	topic string,
+	accept func(*E) (*T, bool),
+) (<-chan *T, error) {
+	if r.PubSub == nil {
+		return nil, apperror.NewInternal(fmt.Errorf("subscriptions are not configured"))
+	}
+	messages, err := r.PubSub.Subscribe(ctx, topic)
+	if err != nil {
+		return nil, apperror.NewInternal(err)
+	}
+	atomic.AddInt64(&r.subscriptions, 1)
+	out := make(chan *T, 1)
+	go func() {
+		defer atomic.AddInt64(&r.subscriptions, -1)
+		defer close(out)
+		for message := range messages {
+			var event E
+			if err := json.Unmarshal(message, &event); err != nil {
+				zaplog.Error(ctx, "invalid "+topic+" event: ", err)
+				continue
+			}
+			v, ok := accept(&event)
+			if !ok {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
 }
@@ -0,0 +1,149 @@
+package resolver_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/config"
+	"go-template/internal/constants"
+	"go-template/internal/jwt"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/repository"
+	"go-template/internal/service"
+	"go-template/internal/service/features"
+	"go-template/internal/service/outbox"
+	"go-template/internal/service/roles"
+	"go-template/internal/service/settings"
+	"go-template/internal/service/users"
+	"go-template/models"
+	"go-template/pkg/utl/secure"
+	"go-template/resolver"
+	"go-template/testutls"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// SuperAdminID is the mock user, a super admin
+	SuperAdminID = 1
+	// RegularUserID is a user having the user role
+	RegularUserID = 2
+)
+
+// nopEvents records nothing, the events recorded in the outbox are covered by TestEvents
+type nopEvents struct{}
+
+func (nopEvents) RecordUser(context.Context, *sql.Tx, fm.EventType, *models.User) error { return nil }
+func (nopEvents) RecordRole(context.Context, *sql.Tx, fm.EventType, *models.Role) error { return nil }
+
+// newResolver returns a resolver whose services run on in-memory repositories holding the
+// mock user, an active super admin whose password is TestPassword, and a regular user
+func newResolver(t *testing.T) (*resolver.Resolver, *repository.MemoryUsers) {
+	sec := secure.New(1, sha1.New())
+	tokens, err := jwt.New("HS256", testutls.MockJWTSecret, 60, 64)
+	assert.NoError(t, err)
+
+	admin := testutls.MockUser()
+	admin.ID = SuperAdminID
+	admin.Password = null.StringFrom(sec.Hash(TestPassword))
+	admin.Active = null.BoolFrom(true)
+	admin.Token = null.StringFrom(TestToken)
+	admin.RoleID = null.IntFrom(1)
+	userRepo := repository.NewMemoryUsers(*admin, models.User{
+		ID:       RegularUserID,
+		Username: null.StringFrom(TestUsername),
+		Password: null.StringFrom(sec.Hash(TestPassword)),
+		Active:   null.BoolFrom(true),
+		RoleID:   null.IntFrom(2),
+	})
+	roleRepo := repository.NewMemoryRoles(
+		models.Role{ID: 1, Name: SuperAdminRoleName, AccessLevel: int(constants.SuperAdminRole)},
+		models.Role{ID: 2, Name: UserRoleName, AccessLevel: int(constants.UserRole)},
+	)
+	tx := repository.MemoryTransactor{}
+	cfg := &config.Configuration{App: &config.Application{MinPasswordStr: 1}}
+	return &resolver.Resolver{
+		AuthService:   service.NewAuth(userRepo, roleRepo, sec, tokens),
+		UserService:   users.New(userRepo, tx, sec, nopEvents{}),
+		RoleService:   roles.New(roleRepo, userRepo, tx, nopEvents{}),
+		SettingsStore: settings.NewStore(settings.Builtins(cfg), settings.NewMemory(), settings.Options{}),
+		Features:      features.New(features.NewMemory(), 0),
+	}, userRepo
+}
+
+// userContext authenticates the user userID
+func userContext(userID int) context.Context {
+	return context.WithValue(context.Background(), auth.UserCtxKey, &models.User{ID: userID})
+}
+
+// adminContext authenticates the mock user with the role of a super admin
+func adminContext() context.Context {
+	return context.WithValue(userContext(SuperAdminID), auth.RoleCtxKey, auth.SuperAdminRole)
+}
+
+func TestEvents(t *testing.T) {
+	_, cleanup, _ := testutls.SetupMockDB(t)
+	defer cleanup()
+	tests := []struct {
+		name      string
+		record    func(ctx context.Context) error
+		wantTopic string
+		want      interface{}
+	}{
+		{
+			name: "UserCreated",
+			record: func(ctx context.Context) error {
+				return resolver.Events{}.RecordUser(ctx, nil, fm.EventTypeCreated, &models.User{ID: 3})
+			},
+			wantTopic: resolver.UserTopic,
+			want:      fm.UserEvent{Type: fm.EventTypeCreated, User: &fm.User{ID: "3"}},
+		},
+		{
+			name: "UserDeleted",
+			record: func(ctx context.Context) error {
+				return resolver.Events{}.RecordUser(ctx, nil, fm.EventTypeDeleted, &models.User{ID: 3})
+			},
+			wantTopic: resolver.UserTopic,
+			want:      fm.UserEvent{Type: fm.EventTypeDeleted, User: &fm.User{ID: "3"}},
+		},
+		{
+			name: "RoleCreated",
+			record: func(ctx context.Context) error {
+				return resolver.Events{}.RecordRole(ctx, nil, fm.EventTypeCreated,
+					&models.Role{ID: 4, Name: "EDITOR", AccessLevel: 150})
+			},
+			wantTopic: resolver.RoleTopic,
+			want: fm.RoleEvent{Type: fm.EventTypeCreated,
+				Role: &fm.Role{ID: "4", Name: "EDITOR", AccessLevel: 150}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var topic string
+			var recorded interface{}
+			patches := gomonkey.ApplyFunc(outbox.Record,
+				func(_ context.Context, _ *sql.Tx, t string, v interface{}) error {
+					topic, recorded = t, v
+					return nil
+				})
+			defer patches.Reset()
+
+			assert.NoError(t, tt.record(context.Background()))
+			assert.Equal(t, tt.wantTopic, topic)
+			switch event := recorded.(type) {
+			case fm.UserEvent:
+				event.Timestamp = 0
+				recorded = event
+			case fm.RoleEvent:
+				event.Timestamp = 0
+				recorded = event
+			}
+			assert.Equal(t, tt.want, recorded)
+		})
+	}
+}
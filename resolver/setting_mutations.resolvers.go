@@ -0,0 +1,35 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/cnvrttogql"
+)
+
+// UpdateSetting is the resolver for the updateSetting field.
+func (r *mutationResolver) UpdateSetting(ctx context.Context, input gqlmodels.SettingUpdateInput) (*gqlmodels.Setting, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	setting, err := r.SettingsStore.Set(ctx, auth.UserIDFromContext(ctx), input.Key, input.Value)
+	if err != nil {
+		return nil, err
+	}
+	return cnvrttogql.SettingToGraphQlSetting(setting), nil
+}
+
+// ResetSetting is the resolver for the resetSetting field.
+func (r *mutationResolver) ResetSetting(ctx context.Context, key string) (*gqlmodels.Setting, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	setting, err := r.SettingsStore.Reset(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return cnvrttogql.SettingToGraphQlSetting(setting), nil
+}
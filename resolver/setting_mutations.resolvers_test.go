@@ -0,0 +1,80 @@
+package resolver_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service/settings"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateSetting(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    fm.SettingUpdateInput
+		want     string
+		wantCode apperror.Code
+	}{
+		{name: "Success", input: fm.SettingUpdateInput{Key: settings.PlaygroundEnabled, Value: "FALSE"}, want: "false"},
+		{name: "Invalid", input: fm.SettingUpdateInput{Key: settings.ThrottleLimit, Value: "0"},
+			wantCode: apperror.Validation},
+		{name: "Unknown", input: fm.SettingUpdateInput{Key: "unknown", Value: "0"}, wantCode: apperror.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver1, _ := newResolver(t)
+			got, err := resolver1.Mutation().UpdateSetting(adminContext(), tt.input)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got.Value)
+			assert.Equal(t, fm.SettingSourceDatabase, got.Source)
+			assert.Equal(t, strconv.Itoa(SuperAdminID), *got.UpdatedBy)
+			assert.NotNil(t, got.UpdatedAt)
+			assert.False(t, resolver1.SettingsStore.Bool(settings.PlaygroundEnabled))
+		})
+	}
+}
+
+func TestResetSetting(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	_, err := resolver1.Mutation().UpdateSetting(adminContext(),
+		fm.SettingUpdateInput{Key: settings.ThrottleLimit, Value: "50"})
+	assert.Nil(t, err)
+
+	got, err := resolver1.Mutation().ResetSetting(adminContext(), settings.ThrottleLimit)
+	assert.Nil(t, err)
+	assert.Equal(t, "5", got.Value)
+	assert.Equal(t, fm.SettingSourceDefault, got.Source)
+	assert.Nil(t, got.UpdatedBy)
+
+	_, err = resolver1.Mutation().ResetSetting(adminContext(), "unknown")
+	assert.Equal(t, apperror.NotFound, apperror.CodeOf(err))
+}
+
+func TestSettingsRequireSuperAdmin(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	ctx := context.WithValue(userContext(RegularUserID), auth.RoleCtxKey, UserRoleName)
+	operations := map[string]func() error{
+		"Settings": func() error { _, err := resolver1.Query().Settings(ctx); return err },
+		"Setting":  func() error { _, err := resolver1.Query().Setting(ctx, settings.ThrottleLimit); return err },
+		"UpdateSetting": func() error {
+			_, err := resolver1.Mutation().UpdateSetting(ctx, fm.SettingUpdateInput{Key: settings.ThrottleLimit, Value: "1"})
+			return err
+		},
+		"ResetSetting": func() error { _, err := resolver1.Mutation().ResetSetting(ctx, settings.ThrottleLimit); return err },
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, apperror.Forbidden, apperror.CodeOf(operation()))
+		})
+	}
+	assert.Equal(t, 5, resolver1.SettingsStore.Int(settings.ThrottleLimit))
+}
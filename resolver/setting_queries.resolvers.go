@@ -0,0 +1,31 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/cnvrttogql"
+)
+
+// Settings is the resolver for the settings field.
+func (r *queryResolver) Settings(ctx context.Context) ([]*gqlmodels.Setting, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return cnvrttogql.SettingsToGraphQlSettings(r.SettingsStore.List()), nil
+}
+
+// Setting is the resolver for the setting field.
+func (r *queryResolver) Setting(ctx context.Context, key string) (*gqlmodels.Setting, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	setting, err := r.SettingsStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return cnvrttogql.SettingToGraphQlSetting(setting), nil
+}
@@ -0,0 +1,63 @@
+package resolver_test
+
+import (
+	"testing"
+
+	fm "go-template/gqlmodels"
+	"go-template/internal/service/settings"
+	"go-template/pkg/utl/apperror"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings(t *testing.T) {
+	resolver1, _ := newResolver(t)
+	got, err := resolver1.Query().Settings(adminContext())
+	assert.Nil(t, err)
+	assert.Len(t, got, 5)
+	assert.Equal(t, settings.LogLevel, got[0].Key)
+	assert.Equal(t, fm.SettingTypeEnum, got[0].Type)
+	assert.Equal(t, []string{"debug", "info", "warn", "error"}, got[0].AllowedValues)
+}
+
+func TestSetting(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		want     *fm.Setting
+		wantCode apperror.Code
+	}{
+		{
+			name: "Success",
+			key:  settings.PasswordMinStrength,
+			want: &fm.Setting{
+				Key:           settings.PasswordMinStrength,
+				Type:          fm.SettingTypeInt,
+				Value:         "1",
+				Default:       "1",
+				Description:   "Minimum zxcvbn score, from 0 to 4, of the new passwords.",
+				AllowedValues: []string{},
+				Min:           intPtr(0),
+				Max:           intPtr(4),
+				Source:        fm.SettingSourceDefault,
+			},
+		},
+		{name: "Unknown", key: "unknown", wantCode: apperror.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver1, _ := newResolver(t)
+			got, err := resolver1.Query().Setting(adminContext(), tt.key)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
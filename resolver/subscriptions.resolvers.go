@@ -5,26 +5,47 @@ package resolver
 
 import (
 	"context"
-	"fmt"
 	"go-template/gqlmodels"
-	"go-template/pkg/utl"
+	"go-template/pkg/utl/gqlfilter"
+	"go-template/pkg/utl/zaplog"
 )
 
 // UserNotification is the resolver for the userNotification field.
 func (r *subscriptionResolver) UserNotification(ctx context.Context) (<-chan *gqlmodels.User, error) {
-	id := utl.RandomSequence(5)
-	event := make(chan *gqlmodels.User, 1)
-	go func() {
-		<-ctx.Done()
-		r.Lock()
-		delete(r.Observers, id)
-		r.Unlock()
-	}()
-	r.Lock()
-	r.Observers[id] = event
-	r.Unlock()
-	fmt.Print("Subscribed to user creation updates!")
-	return event, nil
+	s, err := r.subscriberFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := subscribe(ctx, r.Resolver, UserTopic, func(event *gqlmodels.UserEvent) (*gqlmodels.User, bool) {
+		return event.User, event.Type != gqlmodels.EventTypeDeleted && s.canSeeUser(event.User)
+	})
+	if err != nil {
+		return nil, err
+	}
+	zaplog.Info(ctx, "Subscribed to user creation updates!")
+	return users, nil
+}
+
+// UserEvents is the resolver for the userEvents field.
+func (r *subscriptionResolver) UserEvents(ctx context.Context, filter *gqlmodels.UserWhere, types []gqlmodels.EventType) (<-chan *gqlmodels.UserEvent, error) {
+	s, err := r.subscriberFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(ctx, r.Resolver, UserTopic, func(event *gqlmodels.UserEvent) (*gqlmodels.UserEvent, bool) {
+		return event, hasType(types, event.Type) && s.canSeeUser(event.User) && gqlfilter.MatchUser(filter, event.User)
+	})
+}
+
+// RoleEvents is the resolver for the roleEvents field.
+func (r *subscriptionResolver) RoleEvents(ctx context.Context, filter *gqlmodels.RoleWhere, types []gqlmodels.EventType) (<-chan *gqlmodels.RoleEvent, error) {
+	s, err := r.subscriberFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return subscribe(ctx, r.Resolver, RoleTopic, func(event *gqlmodels.RoleEvent) (*gqlmodels.RoleEvent, bool) {
+		return event, hasType(types, event.Type) && s.canSeeRole(event.Role) && gqlfilter.MatchRole(filter, event.Role)
+	})
 }
 
 // Subscription returns gqlmodels.SubscriptionResolver implementation.
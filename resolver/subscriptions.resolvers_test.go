@@ -2,39 +2,91 @@ package resolver_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	fm "go-template/gqlmodels"
 	"go-template/internal/config"
+	"go-template/internal/constants"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/repository"
+	"go-template/internal/service/pubsub"
+	"go-template/internal/service/roles"
+	"go-template/models"
 	"go-template/resolver"
 	"go-template/testutls"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// subscriberContext authenticates the mock user, the returned service gives its role the
+// access level accessLevel
+func subscriberContext(accessLevel constants.AccessRole) (context.Context, context.CancelFunc, *roles.Service) {
+	roleRepo := repository.NewMemoryRoles(models.Role{ID: testutls.MockUser().RoleID.Int, AccessLevel: int(accessLevel)})
+	svc := roles.New(roleRepo, repository.NewMemoryUsers(), repository.MemoryTransactor{}, nopEvents{})
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), auth.UserCtxKey, testutls.MockUser()))
+	return ctx, cancel, svc
+}
+
+func publish(t *testing.T, ps pubsub.PubSub, topic string, event interface{}) {
+	b, _ := json.Marshal(event)
+	assert.Nil(t, ps.Publish(context.Background(), topic, b))
+}
+
+// receive returns the events sent on ch until no event arrived for 100ms
+func receive[T any](ch <-chan *T) []*T {
+	var got []*T
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, v)
+		case <-time.After(100 * time.Millisecond):
+			return got
+		}
+	}
+}
+
 func TestUserNotification(
 	t *testing.T,
 ) {
 	cases := []struct {
-		name     string
-		wantResp <-chan *fm.User
-		wantErr  bool
+		name    string
+		pubsub  pubsub.PubSub
+		ctx     func() context.Context
+		want    *fm.User
+		wantErr bool
 	}{
 		{
-			name: SuccessCase,
-			wantResp: make(
-				chan *fm.User,
-				1,
-			),
-			wantErr: false,
+			name:   SuccessCase,
+			pubsub: pubsub.NewMemory(pubsub.Options{}),
+			want:   &fm.User{ID: "1", Email: &testutls.MockEmail},
+		},
+		{
+			name:    "Fail on missing pubsub",
+			wantErr: true,
+		},
+		{
+			name: "Fail on closed pubsub",
+			pubsub: func() pubsub.PubSub {
+				ps := pubsub.NewMemory(pubsub.Options{})
+				_ = ps.Close()
+				return ps
+			}(),
+			wantErr: true,
+		},
+		{
+			name:    "Fail on unauthenticated subscriber",
+			pubsub:  pubsub.NewMemory(pubsub.Options{}),
+			ctx:     context.Background,
+			wantErr: true,
 		},
 	}
 
-	observers := map[string]chan *fm.User{}
-	resolver1 := resolver.Resolver{
-		Observers: observers,
-	}
 	for _, tt := range cases {
 		t.Run(
 			tt.name,
@@ -44,15 +96,163 @@ func TestUserNotification(
 					fmt.Print("error loading .env file")
 				}
 
-				c := context.Background()
-				ctx := context.WithValue(c, testutls.UserKey, testutls.MockUser())
-				response, err := resolver1.Subscription().UserNotification(ctx)
-				if tt.wantResp != nil {
-					tt.wantResp = response
-					assert.Equal(t, tt.wantResp, response)
+				ctx, cancel, roleService := subscriberContext(constants.UserRole)
+				resolver1 := resolver.Resolver{PubSub: tt.pubsub, RoleService: roleService}
+				defer cancel()
+				if tt.ctx != nil {
+					ctx = tt.ctx()
 				}
+
+				response, err := resolver1.Subscription().UserNotification(ctx)
 				assert.Equal(t, tt.wantErr, err != nil)
+				if tt.wantErr {
+					return
+				}
+				assert.Equal(t, 1, resolver1.ActiveSubscriptions())
+
+				publish(t, tt.pubsub, resolver.UserTopic, fm.UserEvent{Type: fm.EventTypeDeleted, User: tt.want})
+				publish(t, tt.pubsub, resolver.UserTopic, fm.UserEvent{Type: fm.EventTypeCreated, User: &fm.User{ID: "2"}})
+				publish(t, tt.pubsub, resolver.UserTopic, fm.UserEvent{Type: fm.EventTypeUpdated, User: tt.want})
+				select {
+				case got := <-response:
+					assert.Equal(t, tt.want, got)
+				case <-time.After(time.Second):
+					t.Fatal("no user event received")
+				}
+
+				cancel()
+				_, open := <-response
+				assert.False(t, open)
+				assert.Eventually(t, func() bool { return resolver1.ActiveSubscriptions() == 0 }, time.Second, 10*time.Millisecond)
 			},
 		)
 	}
 }
+
+func TestUserEvents(t *testing.T) {
+	username := "other"
+	mine := &fm.User{ID: "1", Username: &username}
+	other := &fm.User{ID: "2", Username: &username}
+	events := []fm.UserEvent{
+		{Type: fm.EventTypeCreated, User: mine},
+		{Type: fm.EventTypeUpdated, User: other},
+		{Type: fm.EventTypeDeleted, User: mine},
+		{Type: fm.EventTypeDeleted, User: other},
+	}
+	cases := []struct {
+		name        string
+		accessLevel constants.AccessRole
+		filter      *fm.UserWhere
+		types       []fm.EventType
+		want        []fm.UserEvent
+	}{
+		{
+			name:        "Users only receive their own events",
+			accessLevel: constants.UserRole,
+			want:        []fm.UserEvent{events[0], events[2]},
+		},
+		{
+			name:        "Super admins receive every event",
+			accessLevel: constants.SuperAdminRole,
+			want:        events,
+		},
+		{
+			name:        "Filter on types",
+			accessLevel: constants.SuperAdminRole,
+			types:       []fm.EventType{fm.EventTypeDeleted},
+			want:        []fm.UserEvent{events[2], events[3]},
+		},
+		{
+			name:        "Filter on where",
+			accessLevel: constants.SuperAdminRole,
+			filter:      &fm.UserWhere{ID: &fm.IDFilter{EqualTo: &other.ID}},
+			types:       []fm.EventType{fm.EventTypeCreated, fm.EventTypeUpdated},
+			want:        []fm.UserEvent{events[1]},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := pubsub.NewMemory(pubsub.Options{})
+			defer ps.Close()
+			ctx, cancel, roleService := subscriberContext(tt.accessLevel)
+			r := resolver.Resolver{PubSub: ps, RoleService: roleService}
+			defer cancel()
+
+			response, err := r.Subscription().UserEvents(ctx, tt.filter, tt.types)
+			assert.Nil(t, err)
+			for _, event := range events {
+				publish(t, ps, resolver.UserTopic, event)
+			}
+			var want []*fm.UserEvent
+			for i := range tt.want {
+				want = append(want, &tt.want[i])
+			}
+			assert.Equal(t, want, receive(response))
+		})
+	}
+}
+
+func TestRoleEvents(t *testing.T) {
+	admin := "admin"
+	events := []fm.RoleEvent{
+		{Type: fm.EventTypeCreated, Role: &fm.Role{ID: "1", Name: UserRoleName}},
+		{Type: fm.EventTypeCreated, Role: &fm.Role{ID: "2", Name: SuperAdminRoleName}},
+	}
+	cases := []struct {
+		name        string
+		accessLevel constants.AccessRole
+		filter      *fm.RoleWhere
+		missingRole bool
+		want        []fm.RoleEvent
+		wantErr     bool
+	}{
+		{
+			name:        "Users only receive events about their role",
+			accessLevel: constants.UserRole,
+			want:        events[:1],
+		},
+		{
+			name:        "Super admins receive every event",
+			accessLevel: constants.SuperAdminRole,
+			want:        events,
+		},
+		{
+			name:        "Filter on where",
+			accessLevel: constants.SuperAdminRole,
+			filter:      &fm.RoleWhere{Name: &fm.StringFilter{Contain: &admin}},
+			want:        events[1:],
+		},
+		{
+			name:        ErrorFromGetRole,
+			missingRole: true,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := pubsub.NewMemory(pubsub.Options{})
+			defer ps.Close()
+			ctx, cancel, roleService := subscriberContext(tt.accessLevel)
+			if tt.missingRole {
+				roleService = roles.New(repository.NewMemoryRoles(), repository.NewMemoryUsers(),
+					repository.MemoryTransactor{}, nopEvents{})
+			}
+			r := resolver.Resolver{PubSub: ps, RoleService: roleService}
+			defer cancel()
+
+			response, err := r.Subscription().RoleEvents(ctx, tt.filter, nil)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if tt.wantErr {
+				return
+			}
+			for _, event := range events {
+				publish(t, ps, resolver.RoleTopic, event)
+			}
+			var want []*fm.RoleEvent
+			for i := range tt.want {
+				want = append(want, &tt.want[i])
+			}
+			assert.Equal(t, want, receive(response))
+		})
+	}
+}
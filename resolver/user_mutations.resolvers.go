@@ -6,14 +6,12 @@ package resolver
 import (
 	"context"
 	"fmt"
-	"go-template/daos"
 	"go-template/gqlmodels"
-	"go-template/internal/config"
 	"go-template/internal/middleware/auth"
-	"go-template/internal/service"
+	"go-template/internal/service/settings"
+	"go-template/internal/service/users"
 	"go-template/models"
 	"go-template/pkg/utl/cnvrttogql"
-	"go-template/pkg/utl/resultwrapper"
 	"go-template/pkg/utl/throttle"
 	"strconv"
 	"time"
@@ -23,7 +21,8 @@ import (
 
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, input gqlmodels.UserCreateInput) (*gqlmodels.User, error) {
-	err := throttle.Check(ctx, 5, 10*time.Second)
+	err := throttle.Check(ctx, r.SettingsStore.Int(settings.ThrottleLimit),
+		time.Duration(r.SettingsStore.Int(settings.ThrottleWindow))*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -33,7 +32,7 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input gqlmodels.UserC
 	if input.Active != nil {
 		active = null.BoolFrom(*input.Active)
 	}
-	user := models.User{
+	user, err := r.UserService.Create(ctx, models.User{
 		Username:  null.StringFrom(input.Username),
 		Password:  null.StringFrom(input.Password),
 		Email:     null.StringFrom(input.Email),
@@ -41,77 +40,36 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input gqlmodels.UserC
 		LastName:  null.StringFrom(input.LastName),
 		RoleID:    null.IntFrom(roleId),
 		Active:    active,
-	}
-	// loading configurations
-	cfg, err := config.Load()
-	if err != nil {
-		return nil, fmt.Errorf("error in loading config ")
-	}
-	// creating new secure service
-	sec := service.Secure(cfg)
-	user.Password = null.StringFrom(sec.Hash(user.Password.String))
-	newUser, err := daos.CreateUser(user, ctx)
+	})
 	if err != nil {
-		return nil, resultwrapper.ResolverSQLError(err, "user information")
-	}
-	graphUser := cnvrttogql.UserToGraphQlUser(&newUser, 1)
-
-	r.Lock()
-	for _, observer := range r.Observers {
-		observer <- graphUser
+		return nil, err
 	}
-	r.Unlock()
-
-	return graphUser, err
+	return cnvrttogql.UserToGraphQlUser(user, 1), nil
 }
 
 // UpdateUser is the resolver for the updateUser field.
 func (r *mutationResolver) UpdateUser(ctx context.Context, input *gqlmodels.UserUpdateInput) (*gqlmodels.User, error) {
-	userID := auth.UserIDFromContext(ctx)
-	user, _ := daos.FindUserByID(userID, ctx)
-	var u models.User
-	if user != nil {
-		u = *user
-	} else {
-		return nil, resultwrapper.ResolverWrapperFromMessage(404, "user not found")
-	}
-	if input.FirstName != nil {
-		u.FirstName = null.StringFromPtr(input.FirstName)
-	}
-	if input.LastName != nil {
-		u.LastName = null.StringFromPtr(input.LastName)
+	var changes users.Changes
+	if input != nil {
+		changes = users.Changes{
+			FirstName: input.FirstName,
+			LastName:  input.LastName,
+			Mobile:    input.Mobile,
+			Address:   input.Address,
+		}
 	}
-	if input.Mobile != nil {
-		u.Mobile = null.StringFromPtr(input.Mobile)
-	}
-	if input.Address != nil {
-		u.Address = null.StringFromPtr(input.Address)
-	}
-	_, err := daos.UpdateUser(u, ctx)
+	user, err := r.UserService.Update(ctx, auth.UserIDFromContext(ctx), changes)
 	if err != nil {
-		return nil, resultwrapper.ResolverSQLError(err, "new information")
-	}
-
-	graphUser := cnvrttogql.UserToGraphQlUser(&u, 1)
-	r.Lock()
-	for _, observer := range r.Observers {
-		observer <- graphUser
+		return nil, err
 	}
-	r.Unlock()
-
-	return graphUser, nil
+	return cnvrttogql.UserToGraphQlUser(user, 1), nil
 }
 
 // DeleteUser is the resolver for the deleteUser field.
 func (r *mutationResolver) DeleteUser(ctx context.Context) (*gqlmodels.UserDeletePayload, error) {
-	userID := auth.UserIDFromContext(ctx)
-	u, err := daos.FindUserByID(userID, ctx)
-	if err != nil {
-		return nil, resultwrapper.ResolverSQLError(err, "data")
-	}
-	_, err = daos.DeleteUser(*u, ctx)
+	user, err := r.UserService.Delete(ctx, auth.UserIDFromContext(ctx))
 	if err != nil {
-		return nil, resultwrapper.ResolverSQLError(err, "user")
+		return nil, err
 	}
-	return &gqlmodels.UserDeletePayload{ID: fmt.Sprint(userID)}, nil
+	return &gqlmodels.UserDeletePayload{ID: fmt.Sprint(user.ID)}, nil
 }
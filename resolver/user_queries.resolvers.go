@@ -5,44 +5,30 @@ package resolver
 
 import (
 	"context"
-	"go-template/daos"
 	"go-template/gqlmodels"
 	"go-template/internal/middleware/auth"
+	"go-template/internal/repository"
 	"go-template/pkg/utl/cnvrttogql"
-	"go-template/pkg/utl/rediscache"
-	"go-template/pkg/utl/resultwrapper"
-
-	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 // Me is the resolver for the me field.
 func (r *queryResolver) Me(ctx context.Context) (*gqlmodels.User, error) {
-	userID := auth.UserIDFromContext(ctx)
-	user, err := rediscache.GetUser(userID, ctx)
+	user, err := r.UserService.Get(ctx, auth.UserIDFromContext(ctx))
 	if err != nil {
-		return &gqlmodels.User{}, resultwrapper.ResolverSQLError(err, "data")
+		return &gqlmodels.User{}, err
 	}
-
-	return cnvrttogql.UserToGraphQlUser(user, 1), err
+	return cnvrttogql.UserToGraphQlUser(user, 1), nil
 }
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context, pagination *gqlmodels.UserPagination) (*gqlmodels.UsersPayload, error) {
-	var queryMods []qm.QueryMod
-	if pagination != nil {
-		if pagination.Limit != 0 {
-			queryMods = append(queryMods, qm.Limit(pagination.Limit), qm.Offset(pagination.Page*pagination.Limit))
-		}
+	var page repository.Page
+	if pagination != nil && pagination.Limit != 0 {
+		page = repository.Page{Limit: pagination.Limit, Offset: pagination.Page * pagination.Limit}
 	}
-
-	users, count, err := daos.FindAllUsersWithCount(queryMods, ctx)
+	users, count, err := r.Resolver.UserService.List(ctx, page)
 	if err != nil {
-		return nil, resultwrapper.ResolverSQLError(err, "data")
+		return nil, err
 	}
 	return &gqlmodels.UsersPayload{Total: int(count), Users: cnvrttogql.UsersToGraphQlUsers(users, 1)}, nil
 }
-
-// Query returns gqlmodels.QueryResolver implementation.
-func (r *Resolver) Query() gqlmodels.QueryResolver { return &queryResolver{r} }
-
-type queryResolver struct{ *Resolver }
@@ -0,0 +1,131 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-template/daos"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/internal/service/webhooks"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/cnvrttogql"
+	"go-template/pkg/utl/resultwrapper"
+	"strconv"
+)
+
+// CreateWebhook is the resolver for the createWebhook field.
+func (r *mutationResolver) CreateWebhook(ctx context.Context, input gqlmodels.WebhookCreateInput) (*gqlmodels.WebhookPayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(input.URL, input.EventTypes); err != nil {
+		return nil, err
+	}
+	var (
+		secret string
+		err    error
+	)
+	if input.Secret != nil {
+		secret = *input.Secret
+		if err := validateWebhookSecret(secret); err != nil {
+			return nil, err
+		}
+	} else if secret, err = webhooks.NewSecret(); err != nil {
+		return nil, apperror.NewInternal(err)
+	}
+	webhook, err := daos.CreateWebhook(daos.Webhook{
+		URL:        input.URL,
+		Secret:     secret,
+		EventTypes: webhookEventTypes(input.EventTypes),
+		Active:     input.Active == nil || *input.Active,
+	}, ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "webhook information")
+	}
+	return &gqlmodels.WebhookPayload{Webhook: cnvrttogql.WebhookToGraphQlWebhook(webhook), Secret: &secret}, nil
+}
+
+// UpdateWebhook is the resolver for the updateWebhook field.
+func (r *mutationResolver) UpdateWebhook(ctx context.Context, input gqlmodels.WebhookUpdateInput) (*gqlmodels.WebhookPayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(input.ID)
+	if err != nil {
+		return nil, apperror.NewValidation("invalid webhook id")
+	}
+	webhook, err := daos.FindWebhookByID(id, ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NewNotFound("webhook not found")
+	}
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "webhook")
+	}
+	eventTypes := input.EventTypes
+	if eventTypes == nil {
+		eventTypes = cnvrttogql.WebhookToGraphQlWebhook(webhook).EventTypes
+	}
+	if input.URL != nil {
+		webhook.URL = *input.URL
+	}
+	if err := validateWebhook(webhook.URL, eventTypes); err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = webhookEventTypes(eventTypes)
+	if input.Secret != nil {
+		if err := validateWebhookSecret(*input.Secret); err != nil {
+			return nil, err
+		}
+		webhook.Secret = *input.Secret
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	webhook, err = daos.UpdateWebhook(*webhook, ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "new webhook information")
+	}
+	return &gqlmodels.WebhookPayload{Webhook: cnvrttogql.WebhookToGraphQlWebhook(webhook), Secret: input.Secret}, nil
+}
+
+// DeleteWebhook is the resolver for the deleteWebhook field.
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, id string) (*gqlmodels.WebhookDeletePayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	webhookID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, apperror.NewValidation("invalid webhook id")
+	}
+	n, err := daos.DeleteWebhook(webhookID, ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "webhook")
+	}
+	if n == 0 {
+		return nil, apperror.NewNotFound("webhook not found")
+	}
+	return &gqlmodels.WebhookDeletePayload{ID: id}, nil
+}
+
+// Redeliver is the resolver for the redeliver field.
+func (r *mutationResolver) Redeliver(ctx context.Context, deliveryID string) (*gqlmodels.WebhookDelivery, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseInt(deliveryID, 10, 64)
+	if err != nil {
+		return nil, apperror.NewValidation("invalid delivery id")
+	}
+	delivery, err := r.Webhooks.Redeliver(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperror.NewNotFound("delivery not found")
+	}
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "delivery")
+	}
+	return cnvrttogql.WebhookDeliveryToGraphQlWebhookDelivery(delivery), nil
+}
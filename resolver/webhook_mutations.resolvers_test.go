@@ -0,0 +1,211 @@
+package resolver_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"go-template/daos"
+	fm "go-template/gqlmodels"
+	"go-template/internal/service/webhooks"
+	"go-template/pkg/utl/apperror"
+	"go-template/resolver"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+const webhookSecret = "0123456789abcdef"
+
+func TestCreateWebhook(t *testing.T) {
+	secret := webhookSecret
+	short := "short"
+	tests := []struct {
+		name       string
+		input      fm.WebhookCreateInput
+		wantSecret bool
+		wantCode   apperror.Code
+	}{
+		{
+			name: SuccessCase,
+			input: fm.WebhookCreateInput{URL: "https://example.com/hook", Secret: &secret,
+				EventTypes: []fm.WebhookEventType{fm.WebhookEventTypeUserCreated, fm.WebhookEventTypeRoleDeleted}},
+		},
+		{
+			name: "Generates a secret",
+			input: fm.WebhookCreateInput{URL: "https://example.com/hook",
+				EventTypes: []fm.WebhookEventType{fm.WebhookEventTypeUserCreated}},
+			wantSecret: true,
+		},
+		{
+			name: "Invalid url",
+			input: fm.WebhookCreateInput{URL: "ftp://example.com",
+				EventTypes: []fm.WebhookEventType{fm.WebhookEventTypeUserCreated}},
+			wantCode: apperror.Validation,
+		},
+		{
+			name:     "No event types",
+			input:    fm.WebhookCreateInput{URL: "https://example.com/hook"},
+			wantCode: apperror.Validation,
+		},
+		{
+			name: "Short secret",
+			input: fm.WebhookCreateInput{URL: "https://example.com/hook", Secret: &short,
+				EventTypes: []fm.WebhookEventType{fm.WebhookEventTypeUserCreated}},
+			wantCode: apperror.Validation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created daos.Webhook
+			patches := gomonkey.ApplyFunc(daos.CreateWebhook, func(w daos.Webhook, ctx context.Context) (*daos.Webhook, error) {
+				created = w
+				w.ID = 1
+				return &w, nil
+			})
+			defer patches.Reset()
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Mutation().CreateWebhook(adminContext(), tt.input)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "1", got.Webhook.ID)
+			assert.Equal(t, tt.input.EventTypes, got.Webhook.EventTypes)
+			assert.True(t, got.Webhook.Active)
+			assert.Equal(t, created.Secret, *got.Secret)
+			if tt.wantSecret {
+				assert.Len(t, created.Secret, 64)
+			} else {
+				assert.Equal(t, []string{"user.created", "role.deleted"}, created.EventTypes)
+			}
+		})
+	}
+}
+
+func TestUpdateWebhook(t *testing.T) {
+	active := false
+	tests := []struct {
+		name     string
+		input    fm.WebhookUpdateInput
+		findErr  error
+		want     daos.Webhook
+		wantCode apperror.Code
+	}{
+		{
+			name:  SuccessCase,
+			input: fm.WebhookUpdateInput{ID: "1", Active: &active},
+			want: daos.Webhook{ID: 1, URL: "https://example.com/hook", Secret: webhookSecret,
+				EventTypes: []string{"user.created"}},
+		},
+		{
+			name:     "Not found",
+			input:    fm.WebhookUpdateInput{ID: "1"},
+			findErr:  sql.ErrNoRows,
+			wantCode: apperror.NotFound,
+		},
+		{
+			name:     "Invalid id",
+			input:    fm.WebhookUpdateInput{ID: "one"},
+			wantCode: apperror.Validation,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFunc(daos.FindWebhookByID, func(id int, ctx context.Context) (*daos.Webhook, error) {
+				if tt.findErr != nil {
+					return nil, tt.findErr
+				}
+				return &daos.Webhook{ID: id, URL: "https://example.com/hook", Secret: webhookSecret,
+					EventTypes: []string{"user.created"}, Active: true}, nil
+			})
+			defer patches.Reset()
+			var updated daos.Webhook
+			patches.ApplyFunc(daos.UpdateWebhook, func(w daos.Webhook, ctx context.Context) (*daos.Webhook, error) {
+				updated = w
+				return &w, nil
+			})
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Mutation().UpdateWebhook(adminContext(), tt.input)
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, updated)
+			assert.False(t, got.Webhook.Active)
+			assert.Nil(t, got.Secret)
+		})
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		deleted  int64
+		wantCode apperror.Code
+	}{
+		{name: SuccessCase, deleted: 1},
+		{name: "Not found", deleted: 0, wantCode: apperror.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFunc(daos.DeleteWebhook, func(id int, ctx context.Context) (int64, error) {
+				return tt.deleted, nil
+			})
+			defer patches.Reset()
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Mutation().DeleteWebhook(adminContext(), "1")
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "1", got.ID)
+		})
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode apperror.Code
+	}{
+		{name: SuccessCase},
+		{name: "Not found", err: sql.ErrNoRows, wantCode: apperror.NotFound},
+		{name: "Error from the database", err: errors.New("connection refused"), wantCode: apperror.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyMethod(webhooks.Sink{}, "Redeliver",
+				func(_ webhooks.Sink, ctx context.Context, id int64) (*daos.WebhookDelivery, error) {
+					if tt.err != nil {
+						return nil, tt.err
+					}
+					return &daos.WebhookDelivery{ID: 4, WebhookID: 1, RedeliveryOf: null.Int64From(id),
+						EventType: "user.created", Status: daos.WebhookDeliveryPending, CreatedAt: time.Now()}, nil
+				})
+			defer patches.Reset()
+
+			resolver1 := resolver.Resolver{}
+			got, err := resolver1.Mutation().Redeliver(adminContext(), "3")
+			if tt.wantCode != "" {
+				assert.Equal(t, tt.wantCode, apperror.CodeOf(err))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "4", got.ID)
+			assert.Equal(t, "3", *got.RedeliveryOf)
+			assert.Equal(t, fm.WebhookEventTypeUserCreated, got.EventType)
+			assert.Equal(t, fm.WebhookDeliveryStatusPending, got.Status)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package resolver
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"go-template/daos"
+	"go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+	"go-template/pkg/utl/cnvrttogql"
+	"go-template/pkg/utl/resultwrapper"
+	"strconv"
+)
+
+// Webhooks is the resolver for the webhooks field.
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*gqlmodels.Webhook, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	webhooks, err := daos.FindAllWebhooks(ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return cnvrttogql.WebhooksToGraphQlWebhooks(webhooks), nil
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhookID string, pagination *gqlmodels.WebhookDeliveryPagination) (*gqlmodels.WebhookDeliveriesPayload, error) {
+	if err := auth.RequireSuperAdmin(ctx); err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(webhookID)
+	if err != nil {
+		return nil, apperror.NewValidation("invalid webhook id")
+	}
+	var limit, offset int
+	if pagination != nil && pagination.Limit != 0 {
+		limit = pagination.Limit
+		offset = pagination.Page * pagination.Limit
+	}
+	deliveries, count, err := daos.FindWebhookDeliveriesWithCount(id, limit, offset, ctx)
+	if err != nil {
+		return nil, resultwrapper.ResolverSQLError(err, "data")
+	}
+	return &gqlmodels.WebhookDeliveriesPayload{
+		Deliveries: cnvrttogql.WebhookDeliveriesToGraphQlWebhookDeliveries(deliveries),
+		Total:      int(count),
+	}, nil
+}
@@ -0,0 +1,80 @@
+package resolver_test
+
+import (
+	"context"
+	"testing"
+
+	"go-template/daos"
+	fm "go-template/gqlmodels"
+	"go-template/internal/middleware/auth"
+	"go-template/pkg/utl/apperror"
+	"go-template/resolver"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhooks(t *testing.T) {
+	patches := gomonkey.ApplyFunc(daos.FindAllWebhooks, func(ctx context.Context) ([]daos.Webhook, error) {
+		return []daos.Webhook{{ID: 1, URL: "https://example.com/hook", Secret: webhookSecret,
+			EventTypes: []string{"role.updated"}, Active: true}}, nil
+	})
+	defer patches.Reset()
+
+	resolver1 := resolver.Resolver{}
+	got, err := resolver1.Query().Webhooks(adminContext())
+	assert.Nil(t, err)
+	assert.Len(t, got, 1)
+	assert.Equal(t, []fm.WebhookEventType{fm.WebhookEventTypeRoleUpdated}, got[0].EventTypes)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	patches := gomonkey.ApplyFunc(daos.FindWebhookDeliveriesWithCount,
+		func(webhookID int, limit int, offset int, ctx context.Context) ([]daos.WebhookDelivery, int64, error) {
+			assert.Equal(t, 1, webhookID)
+			assert.Equal(t, 5, limit)
+			assert.Equal(t, 10, offset)
+			return []daos.WebhookDelivery{{ID: 3, WebhookID: 1, EventType: "user.deleted",
+				Status: daos.WebhookDeliveryFailed, Attempts: 2}}, 11, nil
+		})
+	defer patches.Reset()
+
+	resolver1 := resolver.Resolver{}
+	got, err := resolver1.Query().WebhookDeliveries(adminContext(), "1",
+		&fm.WebhookDeliveryPagination{Limit: 5, Page: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, 11, got.Total)
+	assert.Equal(t, fm.WebhookDeliveryStatusFailed, got.Deliveries[0].Status)
+	assert.Equal(t, fm.WebhookEventTypeUserDeleted, got.Deliveries[0].EventType)
+
+	_, err = resolver1.Query().WebhookDeliveries(adminContext(), "one", nil)
+	assert.NotNil(t, err)
+}
+
+func TestWebhooksRequireSuperAdmin(t *testing.T) {
+	// the daos aren't patched, the resolvers fail before reaching them
+	resolver1 := resolver.Resolver{}
+	ctx := context.WithValue(userContext(RegularUserID), auth.RoleCtxKey, UserRoleName)
+	operations := map[string]func() error{
+		"Webhooks": func() error { _, err := resolver1.Query().Webhooks(ctx); return err },
+		"WebhookDeliveries": func() error {
+			_, err := resolver1.Query().WebhookDeliveries(ctx, "1", nil)
+			return err
+		},
+		"CreateWebhook": func() error {
+			_, err := resolver1.Mutation().CreateWebhook(ctx, fm.WebhookCreateInput{URL: "https://example.com/hook"})
+			return err
+		},
+		"UpdateWebhook": func() error {
+			_, err := resolver1.Mutation().UpdateWebhook(ctx, fm.WebhookUpdateInput{ID: "1"})
+			return err
+		},
+		"DeleteWebhook": func() error { _, err := resolver1.Mutation().DeleteWebhook(ctx, "1"); return err },
+		"Redeliver":     func() error { _, err := resolver1.Mutation().Redeliver(ctx, "3"); return err },
+	}
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, apperror.Forbidden, apperror.CodeOf(operation()))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"net/url"
+	"strings"
+
+	fm "go-template/gqlmodels"
+	"go-template/pkg/utl/apperror"
+)
+
+// minWebhookSecretLength is the length of the shortest secret accepted from admins
+const minWebhookSecretLength = 16
+
+// validateWebhook checks the endpoint and event types of a webhook
+func validateWebhook(endpoint string, eventTypes []fm.WebhookEventType) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperror.NewValidation("url must be an absolute http or https url")
+	}
+	if len(eventTypes) == 0 {
+		return apperror.NewValidation("eventTypes must not be empty")
+	}
+	return nil
+}
+
+// validateWebhookSecret ...
+func validateWebhookSecret(secret string) error {
+	if len(secret) < minWebhookSecretLength {
+		return apperror.NewValidation("secret must be at least 16 characters long")
+	}
+	return nil
+}
+
+// webhookEventTypes converts event types such as USER_CREATED into user.created
+func webhookEventTypes(eventTypes []fm.WebhookEventType) []string {
+	r := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		r = append(r, strings.ToLower(strings.Replace(string(eventType), "_", ".", 1)))
+	}
+	return r
+}
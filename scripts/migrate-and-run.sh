@@ -2,11 +2,14 @@
 
 echo $ENVIRONMENT_NAME
 
-./migrations
+# with MIGRATE_ON_START the server applies the migrations itself, under an advisory lock
+if [ "$MIGRATE_ON_START" != "true" ]; then
+    ./migrations up || exit 1
+fi
 
 if [[ $ENVIRONMENT_NAME == "docker" ]]; then
     echo "seeding"
-    ./seeder
+    ./seeder apply || exit 1
 fi
 
 ./server
\ No newline at end of file
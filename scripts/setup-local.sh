@@ -4,13 +4,12 @@ set -a && source .env.local && set +a
 
 export PSQL_HOST=localhost
 # drop first
-go run ./cmd/migrations/main.go down
+go run ./cmd/migrations down all
 
 # run migrations
-go run ./cmd/migrations/main.go
+go run ./cmd/migrations up
 
 # seed data
-go run ./cmd/seeder/main.go
-go run ./cmd/seeder/exec/seed.go
+go run ./cmd/seeder apply
 
 go run ./cmd/server/main.go
\ No newline at end of file
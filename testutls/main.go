@@ -152,6 +152,7 @@ func MockConfig() *config.Configuration {
 			WriteTimeout: 5,
 		},
 		JWT: &config.JWT{
+			Secret:           MockJWTSecret,
 			MinSecretLength:  64,
 			DurationMinutes:  1440,
 			RefreshDuration:  3499200,